@@ -9,10 +9,13 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/nerkhin/internal/adapter/auth/paseto"
+	"github.com/nerkhin/internal/adapter/auth/totp"
 	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/adapter/handler/http"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/logger"
+	"github.com/nerkhin/internal/adapter/pricelist"
+	"github.com/nerkhin/internal/adapter/ratelimit"
 	"github.com/nerkhin/internal/adapter/storage/dbms"
 	"github.com/nerkhin/internal/adapter/storage/dbms/repository"
 	"github.com/nerkhin/internal/core/service"
@@ -55,12 +58,6 @@ func main() {
 	slog.Info("Successfully migrated up the database", "db", dbConfig.DBName)
 
 	// Dependency injection
-	tokenService, err := paseto.RegisterTokenService(tokenConfig)
-	if err != nil {
-		slog.Error("Error in registering token service", "error", err)
-		os.Exit(1)
-	}
-
 	// init repositories
 	cityRepo := &repository.CityRepository{}
 	productModelRepo := &repository.ProductModelRepository{}
@@ -70,6 +67,7 @@ func main() {
 	productRepo := &repository.ProductRepository{}
 	productRequestRepo := &repository.ProductRequestRepository{}
 	verificationCodeRepo := &repository.VerificationCodeRepository{}
+	refreshTokenRepo := &repository.RefreshTokenRepository{}
 	userRepo := &repository.UserRepository{}
 	userProductRepo := &repository.UserProductRepository{}
 	reportRepo := &repository.ReportRepository{}
@@ -78,6 +76,24 @@ func main() {
 	favoriteProductRepo := &repository.FavoriteProductRepository{}
 	favoriteAccountRepo := &repository.FavoriteAccountRepository{}
 	landingRepo := &repository.LandingRepository{}
+	roundingRuleRepo := &repository.RoundingRuleRepository{}
+	notificationRepo := &repository.NotificationRepository{}
+	stalenessPolicyRepo := &repository.StalenessPolicyRepository{}
+	priceListTemplateRepo := &repository.PriceListTemplateRepository{}
+	priceListShareRepo := &repository.PriceListShareRepository{}
+	loginEventRepo := &repository.LoginEventRepository{}
+	adminRoleRepo := &repository.AdminRoleRepository{}
+	auditRepo := &repository.AuditRepository{}
+	impersonationRepo := &repository.ImpersonationRepository{}
+	totpRepo := &repository.TOTPRepository{}
+
+	sessionService := service.RegisterSessionService(postgresDMBS, userRepo, refreshTokenRepo,
+		impersonationRepo)
+	tokenService, err := paseto.RegisterTokenService(tokenConfig, sessionService)
+	if err != nil {
+		slog.Error("Error in registering token service", "error", err)
+		os.Exit(1)
+	}
 
 	// init services
 	cityService := service.RegisterCityService(postgresDMBS, cityRepo)
@@ -95,15 +111,22 @@ func main() {
 
 	productService := service.RegisterProductService(postgresDMBS, productRepo, productCategoryRepo, productFilterRepo, productBrandRepo, productModelRepo, appConfig)
 	productRequestService := service.RegisterProductRequestService(postgresDMBS, productRequestRepo, userRepo, cityRepo)
-	verificationCodeService := service.RegisterVerificationCodeService(postgresDMBS,
-		verificationCodeRepo, userRepo, appConfig)
+	loginEventService := service.RegisterLoginEventService(postgresDMBS, loginEventRepo, userRepo,
+		notificationRepo, appConfig)
+	verificationCodeService, err := service.RegisterVerificationCodeService(postgresDMBS,
+		verificationCodeRepo, userRepo, loginEventService, appConfig)
+	if err != nil {
+		slog.Error("Error initializing verification code service", "error", err)
+		os.Exit(1)
+	}
 	userService := service.RegisterUserService(postgresDMBS, userRepo, verificationCodeService,
-		verificationCodeRepo, appConfig, tokenService)
+		verificationCodeRepo, appConfig, tokenService, adminRoleRepo, refreshTokenRepo)
 	authService := service.RegisterAuthService(postgresDMBS, userRepo, verificationCodeService,
-		verificationCodeRepo)
+		verificationCodeRepo, refreshTokenRepo, tokenService, loginEventService, appConfig)
 	userProductService := service.RegisterUserProductService(postgresDMBS, userProductRepo, userRepo,
 		productRepo, productFilterRepo, productBrandRepo, productModelRepo,
-		favoriteProductRepo, favoriteAccountRepo, userSubscriptionRepo)
+		favoriteProductRepo, favoriteAccountRepo, userSubscriptionRepo, roundingRuleRepo,
+		priceListTemplateRepo, appConfig)
 	reportService := service.RegisterReportService(postgresDMBS, reportRepo, userRepo)
 	subscriptionService := service.RegisterSubscriptionService(postgresDMBS, subscriptionRepo)
 	userSubscriptionService := service.RegisterUserSubscriptionService(postgresDMBS,
@@ -114,6 +137,30 @@ func main() {
 		favoriteAccountRepo)
 	landingService := service.RegisterLandingService(postgresDMBS,
 		landingRepo)
+	roundingRuleService := service.RegisterRoundingRuleService(postgresDMBS, roundingRuleRepo)
+	notificationService := service.RegisterNotificationService(postgresDMBS, notificationRepo)
+	stalenessPolicyService := service.RegisterStalenessPolicyService(postgresDMBS,
+		stalenessPolicyRepo, notificationRepo, appConfig)
+	priceListTemplateService := service.RegisterPriceListTemplateService(postgresDMBS,
+		priceListTemplateRepo)
+	priceListShareService, err := service.RegisterPriceListShareService(postgresDMBS,
+		priceListShareRepo, priceListTemplateRepo, userRepo, userSubscriptionRepo, userProductService,
+		appConfig)
+	if err != nil {
+		slog.Error("Error initializing price list share service", "error", err)
+		os.Exit(1)
+	}
+	adminRoleService := service.RegisterAdminRoleService(postgresDMBS, adminRoleRepo)
+	auditService := service.RegisterAuditService(postgresDMBS, auditRepo, appConfig)
+	impersonationService := service.RegisterImpersonationService(postgresDMBS, impersonationRepo,
+		userRepo, notificationRepo, tokenService, appConfig)
+	totpAuthenticator, err := totp.NewAuthenticator(appConfig)
+	if err != nil {
+		slog.Error("Error initializing TOTP authenticator", "error", err)
+		os.Exit(1)
+	}
+	totpService := service.RegisterTOTPService(postgresDMBS, totpRepo, userRepo, totpAuthenticator,
+		loginEventService, notificationRepo, appConfig)
 	productModelService := service.RegisterProductModelService(postgresDMBS, productModelRepo, productBrandRepo, productRepo, productCategoryRepo)
 
 	// init handlers
@@ -133,9 +180,21 @@ func main() {
 		tokenService, appConfig)
 	userHandler := handler.RegisterUserHandler(userService, tokenService, appConfig)
 	authHandler := handler.RegisterAuthHandler(authService, tokenService,
-		verificationCodeService, userSubscriptionService, appConfig)
+		verificationCodeService, userSubscriptionService, totpService, appConfig)
+	priceListRenderer, closePriceListRenderer, err := pricelist.NewPriceListRenderer(appConfig)
+	if err != nil {
+		slog.Error("Error initializing price list renderer", "error", err)
+		os.Exit(1)
+	}
+	defer closePriceListRenderer()
+	priceListExporter, err := pricelist.NewExporter(appConfig.PriceListRenderer, appConfig.ImageBasePath)
+	if err != nil {
+		slog.Error("Error initializing price list exporter", "error", err)
+		os.Exit(1)
+	}
+
 	userProductHandler := handler.RegisterUserProductHandler(userProductService, tokenService,
-		appConfig)
+		priceListRenderer, priceListExporter, priceListShareService, appConfig)
 	reportHandler := handler.RegisterReportHandler(reportService, tokenService, appConfig)
 	subscriptionHandler := handler.RegisterSubscriptionHandler(subscriptionService, tokenService,
 		appConfig)
@@ -147,6 +206,26 @@ func main() {
 		tokenService, appConfig)
 	landingHandler := handler.RegisterLandingHandler(landingService,
 		tokenService, appConfig)
+	roundingRuleHandler := handler.RegisterRoundingRuleHandler(roundingRuleService,
+		tokenService, appConfig)
+	notificationHandler := handler.RegisterNotificationHandler(notificationService,
+		tokenService, appConfig)
+	stalenessPolicyHandler := handler.RegisterStalenessPolicyHandler(stalenessPolicyService,
+		tokenService, appConfig)
+	priceListTemplateHandler := handler.RegisterPriceListTemplateHandler(priceListTemplateService,
+		tokenService, appConfig)
+	priceListShareHandler := handler.RegisterPriceListShareHandler(priceListShareService,
+		priceListRenderer, tokenService, appConfig)
+	loginEventHandler := handler.RegisterLoginEventHandler(loginEventService,
+		tokenService, appConfig)
+	adminRoleHandler := handler.RegisterAdminRoleHandler(adminRoleService,
+		tokenService, appConfig)
+	auditHandler := handler.RegisterAuditHandler(auditService,
+		tokenService, appConfig)
+	impersonationHandler := handler.RegisterImpersonationHandler(impersonationService,
+		tokenService, appConfig)
+	totpHandler := handler.RegisterTOTPHandler(totpService,
+		tokenService, appConfig)
 	dollarRepo := &repository.DollarLogRepository{}
 	dollarService := service.RegisterDollarService(postgresDMBS, dollarRepo, userRepo, productRepo)
 
@@ -160,11 +239,14 @@ func main() {
 
 		slog.Info("Cron Job: fetching latest dollar price...")
 
-		if err := dollarService.FetchAndUpdateDollar(ctx); err != nil {
+		run, err := dollarService.FetchAndUpdateDollar(ctx)
+		if err != nil {
 
 			slog.Error("Cron Job failed to update dollar", "error", err)
 		} else {
-			slog.Info("Cron Job: dollar updated successfully ✅")
+			slog.Info("Cron Job: dollar updated successfully ✅",
+				"rowsChanged", run.RowsChanged, "failedUsers", run.FailedUsers,
+				"durationMs", run.DurationMs)
 		}
 	})
 	if err != nil {
@@ -172,12 +254,79 @@ func main() {
 		slog.Error("Failed to register cron job", "error", err)
 	}
 
+	_, err = c.AddFunc("0 0 10 * * *", func() {
+		ctx := context.Background()
+
+		result, err := stalenessPolicyService.SendStalePriceReminders(ctx)
+		if err != nil {
+			slog.Error("Cron Job failed to send stale price reminders", "error", err)
+			return
+		}
+
+		slog.Info("Cron Job: stale price reminders sent",
+			"shops", result.ShopsCount, "notified", result.NotifiedCount,
+			"smsSent", result.SmsSentCount, "smsFailed", result.SmsFailCount)
+	})
+	if err != nil {
+		slog.Error("Failed to register stale price reminder cron job", "error", err)
+	}
+
+	_, err = c.AddFunc("0 30 3 * * *", func() {
+		ctx := context.Background()
+
+		count, err := userService.ExpireIdleDevices(ctx)
+		if err != nil {
+			slog.Error("Cron Job failed to expire idle devices", "error", err)
+			return
+		}
+
+		slog.Info("Cron Job: idle devices expired", "count", count)
+	})
+	if err != nil {
+		slog.Error("Failed to register idle device expiry cron job", "error", err)
+	}
+
+	_, err = c.AddFunc("0 0 4 * * *", func() {
+		ctx := context.Background()
+
+		count, err := auditService.PurgeAuditLogs(ctx)
+		if err != nil {
+			slog.Error("Cron Job failed to purge audit logs", "error", err)
+			return
+		}
+
+		slog.Info("Cron Job: old audit logs purged", "count", count)
+	})
+	if err != nil {
+		slog.Error("Failed to register audit log retention cron job", "error", err)
+	}
+
+	_, err = c.AddFunc("0 */5 * * * *", func() {
+		ctx := context.Background()
+
+		count, err := impersonationService.CloseExpiredImpersonations(ctx)
+		if err != nil {
+			slog.Error("Cron Job failed to close expired impersonation sessions", "error", err)
+			return
+		}
+
+		if count > 0 {
+			slog.Info("Cron Job: expired impersonation sessions closed", "count", count)
+		}
+	})
+	if err != nil {
+		slog.Error("Failed to register impersonation expiry cron job", "error", err)
+	}
+
 	c.Start()
 	defer c.Stop()
 
 	// init router
+	rateLimiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), appConfig.RateLimit)
 	router, err := http.NewRouter(
 		httpConfig,
+		rateLimiter,
+		auditService,
 		cityHandler,
 		productModelHandler,
 		productBrandHandler,
@@ -196,6 +345,16 @@ func main() {
 		productFilterImportHandler,
 
 		landingHandler,
+		roundingRuleHandler,
+		notificationHandler,
+		stalenessPolicyHandler,
+		priceListTemplateHandler,
+		priceListShareHandler,
+		loginEventHandler,
+		adminRoleHandler,
+		auditHandler,
+		impersonationHandler,
+		totpHandler,
 	)
 	if err != nil {
 		slog.Error("Error initializing router", "error", err)
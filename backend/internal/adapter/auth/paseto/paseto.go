@@ -2,6 +2,7 @@
 package paseto
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -24,13 +25,14 @@ type PasetoToken struct {
 	parser               paseto.Parser
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
+	sessions             port.SessionService
 }
 
 // این خط در زمان کامپایل بررسی می‌کند که آیا *PasetoToken تمام متدهای اینترفیس port.TokenService را پیاده‌سازی کرده است یا خیر.
 var _ port.TokenService = (*PasetoToken)(nil)
 
 // RegisterTokenService: تابع سازنده و مقداردهی اولیه سرویس توکن
-func RegisterTokenService(cfg config.TokenConfig) (port.TokenService, error) {
+func RegisterTokenService(cfg config.TokenConfig, sessions port.SessionService) (port.TokenService, error) {
 	// 1. Parse کردن مدت اعتبارها
 	accessTokenDur, err := time.ParseDuration(cfg.Duration)
 	if err != nil {
@@ -67,6 +69,7 @@ func RegisterTokenService(cfg config.TokenConfig) (port.TokenService, error) {
 		parser:               parser,
 		accessTokenDuration:  accessTokenDur,
 		refreshTokenDuration: refreshTokenDur,
+		sessions:             sessions,
 	}, nil
 }
 
@@ -89,7 +92,7 @@ func (pt *PasetoToken) safeParse(tokenString string) (*paseto.Token, error) {
 
 // --- پیاده‌سازی متدهای اینترفیس port.TokenService ---
 
-func (pt *PasetoToken) CreateAccessToken(user *domain.User, adminAccess *domain.AdminAccess) (string, *domain.TokenPayload, time.Time, error) {
+func (pt *PasetoToken) CreateAccessToken(user *domain.User, adminAccess *domain.AdminAccess, familyID uuid.UUID) (string, *domain.TokenPayload, time.Time, error) {
 	jti, err := uuid.NewRandom()
 	if err != nil {
 		return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
@@ -102,7 +105,11 @@ func (pt *PasetoToken) CreateAccessToken(user *domain.User, adminAccess *domain.
 		UserState:   user.State,
 		CityID:      user.CityID,
 		AdminAccess: adminAccess,
+		Permissions: domain.PermissionsFor(user.Role, adminAccess),
 		Type:        "access",
+
+		SessionVersion: user.SessionVersion,
+		FamilyID:       familyID,
 	}
 
 	token := paseto.NewToken()
@@ -143,7 +150,8 @@ func (pt *PasetoToken) VerifyAccessToken(tokenString string) (*domain.TokenPaylo
 	return &tokenPayload, nil
 }
 
-func (pt *PasetoToken) CreateRefreshToken(user *domain.User) (string, *domain.RefreshTokenPayload, time.Time, error) {
+// CreateRefreshToken توکن تازهٔ خانوادهٔ familyID را می‌سازد؛ ثبت jti آن با سرویس احراز هویت است
+func (pt *PasetoToken) CreateRefreshToken(user *domain.User, familyID uuid.UUID) (string, *domain.RefreshTokenPayload, time.Time, error) {
 	jti, err := uuid.NewRandom()
 	if err != nil {
 		return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
@@ -152,6 +160,7 @@ func (pt *PasetoToken) CreateRefreshToken(user *domain.User) (string, *domain.Re
 		JTI:      jti,
 		UserID:   user.ID,
 		UserRole: user.Role,
+		FamilyID: familyID,
 		Type:     "refresh",
 	}
 	token := paseto.NewToken()
@@ -190,38 +199,99 @@ func (pt *PasetoToken) VerifyRefreshToken(tokenString string) (*domain.RefreshTo
 }
 
 
-func (pt *PasetoToken) CreateImpersonationToken(targetUser *domain.User, adminID int64) (string, *domain.TokenPayload, time.Time, error) {
-    jti, err := uuid.NewRandom()
-    if err != nil {
-        return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
-    }
-
-    payload := &domain.TokenPayload{
-        JTI:                 jti,
-        UserID:              targetUser.ID,
-        UserRole:            targetUser.Role,
-        UserState:           targetUser.State,
-        CityID:              targetUser.CityID,
-        AdminAccess:         nil, // Admin access does not apply to the impersonated user
-        Type:                "access",
-        ImpersonatorAdminID: &adminID, // <-- شناسه ادمین را اینجا ذخیره می‌کنیم
-    }
-
-    token := paseto.NewToken()
-    if err := token.Set("payload", payload); err != nil {
-        return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
-    }
-
-    issuedAt := time.Now().UTC()
-    // توکن تقلیدی بهتر است زمان انقضای کوتاه‌تری داشته باشد
-    expiredAt := issuedAt.Add(pt.accessTokenDuration / 2) // مثلا نصف زمان عادی
-
-    token.SetIssuedAt(issuedAt)
-    token.SetNotBefore(issuedAt)
-    token.SetExpiration(expiredAt)
-
-    encryptedToken := token.V4Encrypt(pt.key, nil)
-    return encryptedToken, payload, expiredAt, nil
+// CreateImpersonationToken توکن ورود ادمین به جای کاربر را برای نشست session می‌سازد؛
+// انقضای توکن همان پایان نشست است و بستن زودتر نشست را ValidateSession اعمال می‌کند
+func (pt *PasetoToken) CreateImpersonationToken(targetUser *domain.User, session *domain.ImpersonationSession) (string, *domain.TokenPayload, time.Time, error) {
+	jti, err := uuid.NewRandom()
+	if err != nil {
+		return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
+	}
+
+	adminID := session.AdminID
+	sessionID := session.ID
+	payload := &domain.TokenPayload{
+		JTI:                    jti,
+		UserID:                 targetUser.ID,
+		UserRole:               targetUser.Role,
+		UserState:              targetUser.State,
+		CityID:                 targetUser.CityID,
+		AdminAccess:            nil, // Admin access does not apply to the impersonated user
+		Type:                   "access",
+		ImpersonatorAdminID:    &adminID,
+		SessionVersion:         targetUser.SessionVersion,
+		ImpersonationSessionID: &sessionID,
+		ImpersonationReadOnly:  session.ReadOnly,
+	}
+
+	token := paseto.NewToken()
+	if err := token.Set("payload", payload); err != nil {
+		return "", nil, time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
+	}
+
+	issuedAt := time.Now().UTC()
+	expiredAt := session.ExpiresAt.UTC()
+
+	token.SetIssuedAt(issuedAt)
+	token.SetNotBefore(issuedAt)
+	token.SetExpiration(expiredAt)
+
+	encryptedToken := token.V4Encrypt(pt.key, nil)
+	return encryptedToken, payload, expiredAt, nil
+}
+
+// CreateMFAToken توکن کوتاه‌مدت مرحلهٔ دوم ورود را می‌سازد؛ این توکن به هیچ مسیری جز بررسی کد TOTP دسترسی نمی‌دهد
+func (pt *PasetoToken) CreateMFAToken(userID int64, deviceID string, duration time.Duration) (string, time.Time, error) {
+	jti, err := uuid.NewRandom()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
+	}
+	payload := &domain.MFATokenPayload{
+		JTI:      jti,
+		UserID:   userID,
+		DeviceID: deviceID,
+		Type:     "mfa",
+	}
+	token := paseto.NewToken()
+	if err := token.Set("payload", payload); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", msg.ErrTokenCreation, err)
+	}
+
+	issuedAt := time.Now().UTC()
+	expiredAt := issuedAt.Add(duration)
+
+	token.SetIssuedAt(issuedAt)
+	token.SetNotBefore(issuedAt)
+	token.SetExpiration(expiredAt)
+
+	encryptedToken := token.V4Encrypt(pt.key, nil)
+	return encryptedToken, expiredAt, nil
+}
+
+func (pt *PasetoToken) VerifyMFAToken(tokenString string) (*domain.MFATokenPayload, error) {
+	var tokenPayload domain.MFATokenPayload
+
+	parsedToken, err := pt.parser.ParseV4Local(pt.key, tokenString, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s (mfa token): %w", msg.ErrInvalidToken, err)
+	}
+
+	if err := parsedToken.Get("payload", &tokenPayload); err != nil {
+		return nil, fmt.Errorf("%s: failed to get 'payload' claim from mfa token: %w", msg.ErrInvalidToken, err)
+	}
+
+	if tokenPayload.Type != "mfa" {
+		return nil, fmt.Errorf("%s: token type is not 'mfa'", msg.ErrInvalidToken)
+	}
+	return &tokenPayload, nil
+}
+
+// ValidateSession نسخهٔ نشست توکن را با نسخهٔ فعلی کاربر مقایسه می‌کند؛
+// توکن با امضای معتبر بعد از تغییر نقش، وضعیت یا دسترسی کاربر، یا خروج دستگاه صادرکننده‌اش دیگر پذیرفته نمی‌شود
+func (pt *PasetoToken) ValidateSession(ctx context.Context, payload *domain.TokenPayload) error {
+	if pt.sessions == nil {
+		return nil
+	}
+	return pt.sessions.ValidateSession(ctx, payload)
 }
 
 func (pt *PasetoToken) GetAccessTokenDuration() time.Duration {
@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+)
+
+const testKeyHex = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+// رمز بردارهای آزمون RFC 6238 برای SHA1، یعنی "12345678901234567890" به base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+
+	authenticator, err := NewAuthenticator(config.App{
+		TOTP: config.TOTPConfig{Issuer: "Nerkhin", EncryptionKeyHex: testKeyHex},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return authenticator
+}
+
+func TestNewAuthenticatorRequiresOwnKey(t *testing.T) {
+	_, err := NewAuthenticator(config.App{
+		Token: config.TokenConfig{SymmetricKeyHex: testKeyHex},
+	})
+	if err == nil {
+		t.Fatal("NewAuthenticator fell back to the Paseto key")
+	}
+
+	_, err = NewAuthenticator(config.App{
+		TOTP: config.TOTPConfig{EncryptionKeyHex: "0f1e2d3c"},
+	})
+	if err == nil {
+		t.Fatal("NewAuthenticator accepted a short key")
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	secret, err := authenticator.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+
+	encrypted, err := authenticator.EncryptSecret(secret)
+	if err != nil {
+		t.Fatalf("EncryptSecret: %v", err)
+	}
+	if encrypted == secret {
+		t.Fatal("secret is stored in plain text")
+	}
+
+	decrypted, err := authenticator.DecryptSecret(encrypted)
+	if err != nil {
+		t.Fatalf("DecryptSecret: %v", err)
+	}
+	if decrypted != secret {
+		t.Errorf("decrypted = %q, want %q", decrypted, secret)
+	}
+
+	// رمزی که با کلید دیگری رمزنگاری شده باز نمی‌شود
+	other, err := NewAuthenticator(config.App{TOTP: config.TOTPConfig{
+		EncryptionKeyHex: "a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1",
+	}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	if _, err := other.DecryptSecret(encrypted); err == nil {
+		t.Error("secret decrypted with a different key")
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	tests := []struct {
+		name      string
+		code      string
+		afterStep int64
+		now       int64
+		wantStep  int64
+		wantOK    bool
+	}{
+		{name: "rfc vector at 59", code: "287082", now: 59, wantStep: 1, wantOK: true},
+		{name: "rfc vector at 1111111109", code: "081804", now: 1111111109, wantStep: 37037036, wantOK: true},
+		{name: "rfc vector at 1234567890", code: "005924", now: 1234567890, wantStep: 41152263, wantOK: true},
+		{name: "previous step within skew", code: "287082", now: 89, wantStep: 1, wantOK: true},
+		{name: "step outside skew", code: "287082", now: 119},
+		{name: "replayed step", code: "287082", now: 59, afterStep: 1},
+		{name: "wrong code", code: "287083", now: 59},
+		{name: "wrong length", code: "28708", now: 59},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := authenticator.ValidateCode(rfcSecret, tt.code, tt.afterStep, time.Unix(tt.now, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateCode = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/hex"
+	"fmt"
 	"os"
 	"strconv" // برای تبدیل رشته به bool
 	// برای time.ParseDuration (هرچند اینجا مستقیماً استفاده نمی‌شود اما در سرویس توکن لازم است)
@@ -18,6 +20,112 @@ type App struct {
 	Cookie             CookieConfig // <--- اضافه شد
 	DB                 DBConfig     // <--- اضافه شد (اگر لازم است در سطح App باشد)
 	HTTP               HTTPConfig   // <--- اضافه شد (اگر لازم است در سطح App باشد)
+	PriceGuard         PriceGuardConfig
+	StalePrice         StalePriceConfig
+	PriceListRenderer  PriceListRendererConfig
+	PriceListShare     PriceListShareConfig
+	OTP                OTPConfig
+	RateLimit          RateLimitConfig
+	Device             DeviceConfig
+	LoginAlert         LoginAlertConfig
+	Audit              AuditConfig
+	Impersonation      ImpersonationConfig
+	TOTP               TOTPConfig
+}
+
+// PriceGuardConfig - بازهٔ مجاز انحراف قیمت واردشده از میانهٔ قیمت بازار
+type PriceGuardConfig struct {
+	BandPercent int // مثلا 50 یعنی ±۵۰٪ میانه
+	MinSamples  int // حداقل تعداد فروشنده برای معتبر بودن میانه
+}
+
+// StalePriceConfig - یادآوری به فروشگاه‌هایی که قیمت‌های قدیمی دارند
+type StalePriceConfig struct {
+	ReminderIntervalDays int // فاصلهٔ دو یادآوری پشت سر هم برای یک فروشگاه
+	SmsEnabled           bool
+	SmsTemplate          string // قالب Lookup کاوه‌نگار؛ token تعداد قیمت‌های قدیمی است
+}
+
+// PriceListRendererConfig - تنظیمات ساخت PDF لیست قیمت
+type PriceListRendererConfig struct {
+	Engine          string // chromium | native (بدون مرورگر، با gofpdf)
+	FontDir         string // فونت‌های Vazirmatn برای renderer بومی؛ مسیر نسبی از پوشهٔ کاری یا کنار فایل اجرایی
+	ChromePath      string
+	PoolSize        int // تعداد تب‌های هم‌زمان Chromium
+	QueueSize       int // درخواست‌های منتظر؛ بیشتر از این رد می‌شوند
+	TimeoutSeconds  int // سقف زمان ساخت هر PDF
+	CacheSize       int // تعداد PDFهای نگه‌داشته در حافظه
+	CacheTTLMinutes int
+}
+
+// PriceListShareConfig - لینک عمومی و امضاشدهٔ لیست قیمت فروشگاه
+type PriceListShareConfig struct {
+	BaseURL         string // صفحهٔ عمومی سایت؛ توکن به انتهای آن اضافه می‌شود
+	SigningKeyHex   string // الزامی؛ کلید HMAC اختصاصی توکن لینک‌ها
+	DefaultTTLHours int
+	MaxTTLHours     int
+}
+
+// OTPConfig - انقضا، سقف تلاش و محدودیت ارسال کد تایید ورود
+type OTPConfig struct {
+	TTLSeconds            int
+	MaxAttempts           int // بعد از این تعداد کد اشتباه، کد باطل و شماره قفل می‌شود
+	LockMinutes           int
+	ResendCooldownSeconds int    // فاصلهٔ دو ارسال برای یک شماره
+	MaxSendsPerPhone      int    // سقف پیامک در یک ساعت برای هر شماره
+	MaxSendsPerIP         int    // سقف پیامک در یک ساعت از هر IP
+	HashKeyHex            string // الزامی؛ pepper اختصاصی hash کدها
+}
+
+// DeviceConfig - دستگاه‌های واردشدهٔ کاربران
+type DeviceConfig struct {
+	IdleExpiryDays int // دستگاهی که این مدت استفاده نشده خارج می‌شود؛ صفر یعنی هرگز
+}
+
+// LoginAlertConfig - هشدار ورود از دستگاه یا IP ناآشنا به صاحب حساب
+type LoginAlertConfig struct {
+	LookbackDays int // ورودهای موفق این بازه دستگاه و IP آشنا حساب می‌شوند
+	SmsEnabled   bool
+	SmsTemplate  string // قالب Lookup کاوه‌نگار؛ token آدرس IP ورود است
+}
+
+// AuditConfig - ثبت کارهای ادمین‌ها و درخواست‌های جعل هویت
+type AuditConfig struct {
+	Enabled       bool
+	RetentionDays int // سوابق قدیمی‌تر پاک می‌شوند؛ صفر یعنی هرگز
+	MaxBodyBytes  int // بدنهٔ بزرگ‌تر از این ذخیره نمی‌شود
+}
+
+// ImpersonationConfig - مدت نشست ورود ادمین به جای کاربر
+type ImpersonationConfig struct {
+	DefaultMinutes int
+	MaxMinutes     int // مدت درخواستی بیشتر از این کوتاه می‌شود
+}
+
+// TOTPConfig - ورود دومرحله‌ای با برنامهٔ احراز هویت
+type TOTPConfig struct {
+	Issuer            string // نامی که در برنامهٔ احراز هویت دیده می‌شود
+	EncryptionKeyHex  string // الزامی؛ کلید اختصاصی رمزنگاری رمزهای TOTP
+	ChallengeMinutes  int    // مهلت وارد کردن کد TOTP بعد از کد پیامکی
+	MaxAttempts       int    // بعد از این تعداد کد اشتباه، ورود دومرحله‌ای قفل می‌شود
+	LockMinutes       int
+	RecoveryCodeCount int
+}
+
+// RateLimitConfig - محدودیت تعداد درخواست (token bucket) برای هر گروه از مسیرها
+type RateLimitConfig struct {
+	Enabled bool
+	API     RateLimitPolicyConfig // همهٔ مسیرها به ازای IP
+	Auth    RateLimitPolicyConfig // ورود، ثبت‌نام و تمدید توکن به ازای IP
+	OTP     RateLimitPolicyConfig // ارسال و بررسی کد تایید به ازای شماره
+	Search  RateLimitPolicyConfig // جست‌وجو و فیلتر محصولات به ازای کاربر
+	Public  RateLimitPolicyConfig // لیست قیمت عمومی به ازای IP
+}
+
+// RateLimitPolicyConfig - PerMinute نرخ پر شدن و Burst ظرفیت سطل؛ صفر یعنی بدون محدودیت
+type RateLimitPolicyConfig struct {
+	PerMinute int
+	Burst     int
 }
 
 // CookieConfig - برای تنظیمات کوکی Refresh Token
@@ -54,6 +162,7 @@ type HTTPConfig struct {
 	URL            string `env:"HTTP_URL"`
 	Port           string `env:"HTTP_PORT"`
 	AllowedOrigins string `env:"HTTP_ALLOWED_ORIGINS"`
+	TrustedProxies string // IP یا CIDRهای reverse proxy؛ خالی → هیچ‌کدام (X-Forwarded-For نادیده گرفته می‌شود)
 }
 
 // تابع کمکی برای خواندن متغیر محیطی با مقدار پیش‌فرض (برای bool)
@@ -69,6 +178,19 @@ func getEnvAsBool(name string, defaultVal bool) bool {
 	return valBool
 }
 
+// تابع کمکی برای خواندن متغیر محیطی با مقدار پیش‌فرض (برای int)
+func getEnvAsInt(name string, defaultVal int) int {
+	valStr := os.Getenv(name)
+	if valStr == "" {
+		return defaultVal
+	}
+	valInt, err := strconv.Atoi(valStr)
+	if err != nil {
+		return defaultVal
+	}
+	return valInt
+}
+
 // تابع کمکی برای خواندن متغیر محیطی با مقدار پیش‌فرض (برای string)
 func getEnv(name string, defaultVal string) string {
 	val := os.Getenv(name)
@@ -78,6 +200,24 @@ func getEnv(name string, defaultVal string) string {
 	return val
 }
 
+// DecodeKeyHex کلید اختصاصی یک کاربرد را از hex می‌خواند؛ دست‌کم ۳۲ بایت.
+// هر کاربرد (امضای لینک، hash کد تایید، رمزنگاری TOTP) کلید خودش را دارد و به کلید Paseto برنمی‌گردد.
+func DecodeKeyHex(envName, keyHex string) ([]byte, error) {
+	if keyHex == "" {
+		return nil, fmt.Errorf("%s is not provided in config", envName)
+	}
+
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be hex encoded: %w", envName, err)
+	}
+	if len(key) < 32 {
+		return nil, fmt.Errorf("%s must be at least 64 hex characters, got %d", envName, len(keyHex))
+	}
+
+	return key, nil
+}
+
 // LoadAppConfig - تابع اصلی برای بارگذاری تمام تنظیمات برنامه
 func LoadAppConfig() App {
 	return App{
@@ -91,6 +231,17 @@ func LoadAppConfig() App {
 		Cookie:             LoadCookieConfig(), // <--- فراخوانی تابع بارگذاری تنظیمات کوکی
 		DB:                 LoadDBConfig(),     // <--- فراخوانی تابع بارگذاری تنظیمات دیتابیس
 		HTTP:               LoadHTTPConfig(),   // <--- فراخوانی تابع بارگذاری تنظیمات HTTP
+		PriceGuard:         LoadPriceGuardConfig(),
+		StalePrice:         LoadStalePriceConfig(),
+		PriceListRenderer:  LoadPriceListRendererConfig(),
+		PriceListShare:     LoadPriceListShareConfig(),
+		OTP:                LoadOTPConfig(),
+		RateLimit:          LoadRateLimitConfig(),
+		Device:             LoadDeviceConfig(),
+		LoginAlert:         LoadLoginAlertConfig(),
+		Audit:              LoadAuditConfig(),
+		Impersonation:      LoadImpersonationConfig(),
+		TOTP:               LoadTOTPConfig(),
 	}
 }
 
@@ -135,5 +286,124 @@ func LoadHTTPConfig() HTTPConfig {
 		URL:            os.Getenv("HTTP_URL"),
 		Port:           getEnv("HTTP_PORT", "8080"),
 		AllowedOrigins: os.Getenv("HTTP_ALLOWED_ORIGINS"),
+		TrustedProxies: os.Getenv("HTTP_TRUSTED_PROXIES"),
+	}
+}
+
+// LoadPriceGuardConfig - بارگذاری تنظیمات کنترل انحراف قیمت از بازار
+func LoadPriceGuardConfig() PriceGuardConfig {
+	return PriceGuardConfig{
+		BandPercent: getEnvAsInt("PRICE_GUARD_BAND_PERCENT", 50),
+		MinSamples:  getEnvAsInt("PRICE_GUARD_MIN_SAMPLES", 3),
+	}
+}
+
+// LoadStalePriceConfig - بارگذاری تنظیمات یادآوری قیمت‌های قدیمی
+func LoadStalePriceConfig() StalePriceConfig {
+	return StalePriceConfig{
+		ReminderIntervalDays: getEnvAsInt("STALE_PRICE_REMINDER_INTERVAL_DAYS", 7),
+		SmsEnabled:           getEnvAsBool("STALE_PRICE_SMS_ENABLED", false),
+		SmsTemplate:          getEnv("STALE_PRICE_SMS_TEMPLATE", "stale-prices"),
+	}
+}
+
+// LoadPriceListRendererConfig - بارگذاری تنظیمات ساخت PDF لیست قیمت
+func LoadPriceListRendererConfig() PriceListRendererConfig {
+	return PriceListRendererConfig{
+		Engine:          getEnv("PRICE_LIST_RENDERER", "chromium"),
+		FontDir:         getEnv("PRICE_LIST_FONT_DIR", "assets/fonts"),
+		ChromePath:      getEnv("CHROMEDP_EXEC_PATH", "/usr/bin/chromium-browser"),
+		PoolSize:        getEnvAsInt("PRICE_LIST_RENDER_POOL_SIZE", 2),
+		QueueSize:       getEnvAsInt("PRICE_LIST_RENDER_QUEUE_SIZE", 8),
+		TimeoutSeconds:  getEnvAsInt("PRICE_LIST_RENDER_TIMEOUT_SECONDS", 60),
+		CacheSize:       getEnvAsInt("PRICE_LIST_CACHE_SIZE", 64),
+		CacheTTLMinutes: getEnvAsInt("PRICE_LIST_CACHE_TTL_MINUTES", 30),
+	}
+}
+
+// LoadPriceListShareConfig - بارگذاری تنظیمات لینک عمومی لیست قیمت
+func LoadPriceListShareConfig() PriceListShareConfig {
+	return PriceListShareConfig{
+		BaseURL:         getEnv("PRICE_LIST_SHARE_BASE_URL", "https://nerrkhin.com/price-list/"),
+		SigningKeyHex:   os.Getenv("PRICE_LIST_SHARE_SIGNING_KEY"),
+		DefaultTTLHours: getEnvAsInt("PRICE_LIST_SHARE_TTL_HOURS", 24*30),
+		MaxTTLHours:     getEnvAsInt("PRICE_LIST_SHARE_MAX_TTL_HOURS", 24*365),
+	}
+}
+
+// LoadOTPConfig - بارگذاری تنظیمات کد تایید ورود
+func LoadOTPConfig() OTPConfig {
+	return OTPConfig{
+		TTLSeconds:            getEnvAsInt("OTP_TTL_SECONDS", 120),
+		MaxAttempts:           getEnvAsInt("OTP_MAX_ATTEMPTS", 5),
+		LockMinutes:           getEnvAsInt("OTP_LOCK_MINUTES", 15),
+		ResendCooldownSeconds: getEnvAsInt("OTP_RESEND_COOLDOWN_SECONDS", 60),
+		MaxSendsPerPhone:      getEnvAsInt("OTP_MAX_SENDS_PER_PHONE_HOUR", 5),
+		MaxSendsPerIP:         getEnvAsInt("OTP_MAX_SENDS_PER_IP_HOUR", 20),
+		HashKeyHex:            os.Getenv("OTP_HASH_KEY"),
+	}
+}
+
+// LoadRateLimitConfig - بارگذاری سیاست‌های محدودیت درخواست؛ مثلا RATE_LIMIT_AUTH_PER_MINUTE و RATE_LIMIT_AUTH_BURST
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		API:     loadRateLimitPolicyConfig("RATE_LIMIT_API", 600, 120),
+		Auth:    loadRateLimitPolicyConfig("RATE_LIMIT_AUTH", 30, 10),
+		OTP:     loadRateLimitPolicyConfig("RATE_LIMIT_OTP", 6, 3),
+		Search:  loadRateLimitPolicyConfig("RATE_LIMIT_SEARCH", 120, 30),
+		Public:  loadRateLimitPolicyConfig("RATE_LIMIT_PUBLIC", 60, 20),
+	}
+}
+
+func loadRateLimitPolicyConfig(prefix string, perMinute, burst int) RateLimitPolicyConfig {
+	return RateLimitPolicyConfig{
+		PerMinute: getEnvAsInt(prefix+"_PER_MINUTE", perMinute),
+		Burst:     getEnvAsInt(prefix+"_BURST", burst),
+	}
+}
+
+// LoadDeviceConfig - بارگذاری تنظیمات دستگاه‌های واردشده
+func LoadDeviceConfig() DeviceConfig {
+	return DeviceConfig{
+		IdleExpiryDays: getEnvAsInt("DEVICE_IDLE_EXPIRY_DAYS", 60),
+	}
+}
+
+// LoadLoginAlertConfig - بارگذاری تنظیمات هشدار ورود مشکوک
+func LoadLoginAlertConfig() LoginAlertConfig {
+	return LoginAlertConfig{
+		LookbackDays: getEnvAsInt("LOGIN_ALERT_LOOKBACK_DAYS", 90),
+		SmsEnabled:   getEnvAsBool("LOGIN_ALERT_SMS_ENABLED", false),
+		SmsTemplate:  getEnv("LOGIN_ALERT_SMS_TEMPLATE", "suspicious-login"),
+	}
+}
+
+// LoadAuditConfig - بارگذاری تنظیمات سوابق ادمین
+func LoadAuditConfig() AuditConfig {
+	return AuditConfig{
+		Enabled:       getEnvAsBool("AUDIT_ENABLED", true),
+		RetentionDays: getEnvAsInt("AUDIT_RETENTION_DAYS", 365),
+		MaxBodyBytes:  getEnvAsInt("AUDIT_MAX_BODY_BYTES", 16<<10),
+	}
+}
+
+// LoadImpersonationConfig - بارگذاری تنظیمات نشست ورود به جای کاربر
+func LoadImpersonationConfig() ImpersonationConfig {
+	return ImpersonationConfig{
+		DefaultMinutes: getEnvAsInt("IMPERSONATION_DEFAULT_MINUTES", 30),
+		MaxMinutes:     getEnvAsInt("IMPERSONATION_MAX_MINUTES", 120),
+	}
+}
+
+// LoadTOTPConfig - بارگذاری تنظیمات ورود دومرحله‌ای
+func LoadTOTPConfig() TOTPConfig {
+	return TOTPConfig{
+		Issuer:            getEnv("TOTP_ISSUER", "Nerkhin"),
+		EncryptionKeyHex:  os.Getenv("TOTP_ENCRYPTION_KEY"),
+		ChallengeMinutes:  getEnvAsInt("TOTP_CHALLENGE_MINUTES", 5),
+		MaxAttempts:       getEnvAsInt("TOTP_MAX_ATTEMPTS", 5),
+		LockMinutes:       getEnvAsInt("TOTP_LOCK_MINUTES", 15),
+		RecoveryCodeCount: getEnvAsInt("TOTP_RECOVERY_CODE_COUNT", 10),
 	}
 }
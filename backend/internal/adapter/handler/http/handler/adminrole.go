@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type AdminRoleHandler struct {
+	service      port.AdminRoleService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterAdminRoleHandler(service port.AdminRoleService, tokenService port.TokenService,
+	appConfig config.App) *AdminRoleHandler {
+	return &AdminRoleHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (arh *AdminRoleHandler) GetAdminRoles(c *gin.Context) {
+	ctx := c.Request.Context()
+	roles, err := arh.service.GetAdminRoles(ctx)
+	if err != nil {
+		HandleError(c, err, arh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, roles)
+}
+
+// GetPermissions همهٔ دسترسی‌های قابل تخصیص به نقش‌ها و ادمین‌ها
+func (arh *AdminRoleHandler) GetPermissions(c *gin.Context) {
+	handleSuccess(c, domain.AllPermissions)
+}
+
+type saveAdminRoleRequest struct {
+	ID          int64               `json:"id"` // خالی → نقش جدید
+	Name        string              `json:"name" example:"پشتیبانی"`
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+type saveAdminRoleResponse struct {
+	ID int64 `json:"id" example:"1"`
+}
+
+func (arh *AdminRoleHandler) Save(c *gin.Context) {
+	var req saveAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, arh.AppConfig.Lang)
+		return
+	}
+
+	role := &domain.AdminRole{
+		ID:          req.ID,
+		Name:        req.Name,
+		Permissions: req.Permissions,
+	}
+
+	ctx := c.Request.Context()
+	id, err := arh.service.SaveAdminRole(ctx, role)
+	if err != nil {
+		HandleError(c, err, arh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, saveAdminRoleResponse{ID: id})
+}
+
+type deleteAdminRoleRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (arh *AdminRoleHandler) Delete(c *gin.Context) {
+	var req deleteAdminRoleRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, arh.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := arh.service.DeleteAdminRole(ctx, req.ID)
+	if err != nil {
+		HandleError(c, err, arh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
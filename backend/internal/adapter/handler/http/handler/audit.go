@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type AuditHandler struct {
+	service      port.AuditService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterAuditHandler(service port.AuditService, tokenService port.TokenService,
+	appConfig config.App) *AuditHandler {
+	return &AuditHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+type fetchAuditLogsRequest struct {
+	ActorID            int64      `json:"actorId"`
+	ImpersonatedUserID int64      `json:"impersonatedUserId"`
+	EntityType         string     `json:"entityType"`
+	EntityID           string     `json:"entityId"`
+	Method             string     `json:"method"`
+	From               *time.Time `json:"from"`
+	To                 *time.Time `json:"to"`
+	Page               int        `json:"page"`
+	Limit              int        `json:"limit"`
+}
+
+type fetchAuditLogsResponse struct {
+	Logs       []*domain.AuditLog `json:"logs"`
+	TotalCount int64              `json:"totalCount"`
+}
+
+func (ah *AuditHandler) FetchAuditLogs(c *gin.Context) {
+	var req fetchAuditLogsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, ah.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	logs, totalCount, err := ah.service.GetAuditLogs(ctx, domain.AuditLogFilter{
+		ActorID:            req.ActorID,
+		ImpersonatedUserID: req.ImpersonatedUserID,
+		EntityType:         req.EntityType,
+		EntityID:           req.EntityID,
+		Method:             strings.ToUpper(req.Method),
+		From:               req.From,
+		To:                 req.To,
+	}, req.Page, req.Limit)
+	if err != nil {
+		HandleError(c, err, ah.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, fetchAuditLogsResponse{
+		Logs:       logs,
+		TotalCount: totalCount,
+	})
+}
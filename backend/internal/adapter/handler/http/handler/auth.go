@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
 	"github.com/nerkhin/internal/core/domain/msg"
 	"github.com/nerkhin/internal/core/port"
 )
@@ -16,6 +17,7 @@ type AuthHandler struct {
 	verificationCodeService port.VerificationCodeService
 	config                  config.App
 	userSubRepo             port.UserSubscriptionService
+	totpService             port.TOTPService
 }
 
 func RegisterAuthHandler(
@@ -23,6 +25,7 @@ func RegisterAuthHandler(
 	tokenService port.TokenService,
 	verificationCodeService port.VerificationCodeService,
 	userSubRepo port.UserSubscriptionService,
+	totpService port.TOTPService,
 	appConfig config.App) *AuthHandler {
 	return &AuthHandler{
 		tokenService:            tokenService,
@@ -30,6 +33,7 @@ func RegisterAuthHandler(
 		verificationCodeService: verificationCodeService,
 		config:                  appConfig,
 		userSubRepo:             userSubRepo,
+		totpService:             totpService,
 	}
 }
 
@@ -48,9 +52,9 @@ func (ah *AuthHandler) Login(ctx *gin.Context) {
 		validationError(ctx, err, ah.config.Lang)
 		return
 	}
-	_, err := ah.authService.Login(ctx, req.Phone)
+	_, err := ah.authService.Login(ctx, req.Phone, ctx.ClientIP())
 	if err != nil {
-		HandleError(ctx, err, ah.config.Lang)
+		handleVerificationCodeError(ctx, err, ah.config.Lang)
 		return
 	}
 	rsp := &loginResponse{
@@ -59,6 +63,17 @@ func (ah *AuthHandler) Login(ctx *gin.Context) {
 	handleSuccess(ctx, rsp)
 }
 
+// handleVerificationCodeError محدودیت ارسال و قفل کد را با 429 و Retry-After برمی‌گرداند
+func handleVerificationCodeError(ctx *gin.Context, err error, lang string) {
+	var throttleErr *domain.VerificationCodeThrottleError
+	if !errors.As(err, &throttleErr) {
+		HandleError(ctx, err, lang)
+		return
+	}
+
+	HandleTooManyRequests(ctx, err, throttleErr.RetryAfter, lang)
+}
+
 type vcRequest struct {
 	Phone    string `json:"phone" binding:"required"`
 	Code     string `json:"code" binding:"required"`
@@ -76,6 +91,21 @@ type tokenResponse struct {
 	User                  *UserResponse `json:"user"`
 	SubscriptionStatus    string        `json:"subscriptionStatus,omitempty"`
 	SubscriptionExpiresAt *string       `json:"subscriptionExpiresAt,omitempty"`
+	RecoveryCodes         []string      `json:"recoveryCodes,omitempty"` // فقط وقتی TOTP در همین ورود فعال شده باشد
+}
+
+// mfaResponse یعنی کد پیامکی درست بود و ورود با کد TOTP در /auth/verify-totp کامل می‌شود
+type mfaResponse struct {
+	MFARequired       bool                   `json:"mfaRequired"`
+	MFAToken          string                 `json:"mfaToken"`
+	MFATokenExpiresAt int64                  `json:"mfaTokenExpiresAt"`
+	EnrollmentPending bool                   `json:"enrollmentPending"`    // اولین کد درست، TOTP ساخته‌شده را فعال و کدهای بازیابی را برمی‌گرداند
+	Enrollment        *domain.TOTPEnrollment `json:"enrollment,omitempty"` // رمزی که ادمین ساخته؛ فقط همین یک بار
+}
+
+type verifyTOTPRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"` // کد شش‌رقمی برنامه یا یک کد بازیابی
 }
 
 type UserResponse struct {
@@ -102,7 +132,7 @@ func (ah *AuthHandler) VerifyCode(ctx *gin.Context) {
 	// CHANGED: Pass device info to the service
 	user, adminAccess, err := ah.verificationCodeService.VerifyCode(ctx, req.Phone, req.Code, req.DeviceID, userAgent, ipAddress)
 	if err != nil {
-		HandleError(ctx, err, ah.config.Lang)
+		handleVerificationCodeError(ctx, err, ah.config.Lang)
 		return
 	}
 	if user == nil {
@@ -110,13 +140,73 @@ func (ah *AuthHandler) VerifyCode(ctx *gin.Context) {
 		return
 	}
 
-	accessTokenString, _, accessTokenExpiration, err := ah.tokenService.CreateAccessToken(user, adminAccess)
+	challenge, err := ah.totpService.GetLoginChallenge(ctx, user)
+	if err != nil {
+		HandleError(ctx, err, ah.config.Lang)
+		return
+	}
+	if challenge != nil {
+		duration := time.Duration(ah.config.TOTP.ChallengeMinutes) * time.Minute
+		mfaToken, expiresAt, err := ah.tokenService.CreateMFAToken(user.ID, req.DeviceID, duration)
+		if err != nil {
+			HandleError(ctx, err, ah.config.Lang)
+			return
+		}
+
+		handleSuccess(ctx, &mfaResponse{
+			MFARequired:       true,
+			MFAToken:          mfaToken,
+			MFATokenExpiresAt: expiresAt.Unix(),
+			EnrollmentPending: challenge.PendingEnrollment,
+			Enrollment:        challenge.Enrollment,
+		})
+		return
+	}
+
+	ah.completeLogin(ctx, user, adminAccess, req.DeviceID, nil)
+}
+
+// VerifyTOTP مرحلهٔ دوم ورود است؛ با کد برنامهٔ احراز هویت یا یک کد بازیابی توکن‌ها صادر می‌شوند
+func (ah *AuthHandler) VerifyTOTP(ctx *gin.Context) {
+	var req verifyTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validationError(ctx, err, ah.config.Lang)
+		return
+	}
+
+	payload, err := ah.tokenService.VerifyMFAToken(req.MFAToken)
+	if err != nil {
+		HandleError(ctx, err, ah.config.Lang)
+		return
+	}
+
+	user, adminAccess, recoveryCodes, err := ah.totpService.VerifyLoginCode(ctx, payload.UserID, req.Code,
+		payload.DeviceID, ctx.GetHeader("User-Agent"), ctx.ClientIP())
+	if err != nil {
+		handleVerificationCodeError(ctx, err, ah.config.Lang)
+		return
+	}
+
+	ah.completeLogin(ctx, user, adminAccess, payload.DeviceID, recoveryCodes)
+}
+
+// completeLogin بعد از همهٔ مرحله‌های ورود دستگاه را ثبت، توکن‌ها را صادر و پاسخ ورود را می‌فرستد
+func (ah *AuthHandler) completeLogin(ctx *gin.Context, user *domain.User,
+	adminAccess *domain.AdminAccess, deviceID string, recoveryCodes []string) {
+	device := &domain.ActiveDevice{
+		UserID:    user.ID,
+		DeviceID:  deviceID,
+		UserAgent: ctx.GetHeader("User-Agent"),
+		IPAddress: ctx.ClientIP(),
+	}
+
+	refreshTokenString, familyID, err := ah.authService.CreateSession(ctx, user, device)
 	if err != nil {
 		HandleError(ctx, err, ah.config.Lang)
 		return
 	}
 
-	refreshTokenString, _, _, err := ah.tokenService.CreateRefreshToken(user)
+	accessTokenString, _, accessTokenExpiration, err := ah.tokenService.CreateAccessToken(user, adminAccess, familyID)
 	if err != nil {
 		HandleError(ctx, err, ah.config.Lang)
 		return
@@ -146,6 +236,7 @@ func (ah *AuthHandler) VerifyCode(ctx *gin.Context) {
 		User:                  clientUserResponse,
 		SubscriptionStatus:    subStatus,
 		SubscriptionExpiresAt: subExpStr,
+		RecoveryCodes:         recoveryCodes,
 	}
 	handleSuccess(ctx, responsePayload)
 }
@@ -158,29 +249,15 @@ func (ah *AuthHandler) RefreshAccessToken(ctx *gin.Context) {
         return
     }
 
-    refreshTokenPayload, err := ah.tokenService.VerifyRefreshToken(req.RefreshToken)
-    if err != nil {
-        ctx.SetCookie(ah.config.Cookie.Name, "", -1, ah.config.Cookie.Path, ah.config.Cookie.Domain, ah.config.Cookie.Secure, ah.config.Cookie.HTTPOnly)
-        HandleError(ctx, err, ah.config.Lang)
-        return
-    }
-
-    user, err := ah.authService.GetUserByID(ctx, refreshTokenPayload.UserID)
+    // توکن مصرف می‌شود و کلاینت باید توکن تازهٔ پاسخ را نگه دارد
+    user, adminAccess, newRefreshToken, familyID, err := ah.authService.RefreshSession(ctx, req.RefreshToken)
     if err != nil {
+        ah.clearRefreshCookie(ctx)
         HandleError(ctx, err, ah.config.Lang)
         return
     }
-    if user == nil {
-        HandleError(ctx, errors.New(msg.ErrUserDoesNotExist), ah.config.Lang)
-        return
-    }
-
-    if user.Role != refreshTokenPayload.UserRole {
-        HandleError(ctx, errors.New("user role mismatch during token refresh"), ah.config.Lang)
-        return
-    }
 
-    newAccessTokenString, _, Expiration, err := ah.tokenService.CreateAccessToken(user, nil)
+    newAccessTokenString, _, Expiration, err := ah.tokenService.CreateAccessToken(user, adminAccess, familyID)
     if err != nil {
         HandleError(ctx, err, ah.config.Lang)
         return
@@ -205,7 +282,7 @@ func (ah *AuthHandler) RefreshAccessToken(ctx *gin.Context) {
 
     responsePayload := &tokenResponse{
         AccessToken:           newAccessTokenString,
-        RefreshToken:          req.RefreshToken,
+        RefreshToken:          newRefreshToken,
         AccessTokenExpiresAt:  Expiration.Unix(),
         User:                  clientUserResponse,
         SubscriptionStatus:    subStatus,
@@ -214,6 +291,31 @@ func (ah *AuthHandler) RefreshAccessToken(ctx *gin.Context) {
     handleSuccess(ctx, responsePayload)
 }
 
+// Logout دستگاه صاحب refresh token را خارج و همهٔ توکن‌های آن را باطل می‌کند
+func (ah *AuthHandler) Logout(ctx *gin.Context) {
+	var req refreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		validationError(ctx, err, ah.config.Lang)
+		return
+	}
+
+	err := ah.authService.Logout(ctx, req.RefreshToken)
+	ah.clearRefreshCookie(ctx)
+	if err != nil {
+		HandleError(ctx, err, ah.config.Lang)
+		return
+	}
+
+	handleSuccess(ctx, &loginResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+func (ah *AuthHandler) clearRefreshCookie(ctx *gin.Context) {
+	ctx.SetCookie(ah.config.Cookie.Name, "", -1, ah.config.Cookie.Path, ah.config.Cookie.Domain, ah.config.Cookie.Secure, ah.config.Cookie.HTTPOnly)
+}
+
 func (ah *AuthHandler) fetchSubscriptionInfo(
     ctx *gin.Context,
     userID int64,
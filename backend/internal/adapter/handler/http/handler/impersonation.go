@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type ImpersonationHandler struct {
+	service      port.ImpersonationService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterImpersonationHandler(service port.ImpersonationService, tokenService port.TokenService,
+	appConfig config.App) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+type startImpersonationUriRequest struct {
+	UserID int64 `uri:"userId" binding:"required,min=1" example:"1"`
+}
+
+type startImpersonationRequest struct {
+	Reason          string `json:"reason"`
+	ReadOnly        bool   `json:"readOnly"`
+	DurationMinutes int    `json:"durationMinutes"`
+}
+
+type startImpersonationResponse struct {
+	ImpersonationToken string                       `json:"impersonationToken"`
+	Session            *domain.ImpersonationSession `json:"session"`
+	User               *domain.User                 `json:"user"`
+}
+
+// StartImpersonation ادمین را برای مدت محدود و با ذکر دلیل به جای کاربر وارد می‌کند
+func (ih *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	var uriReq startImpersonationUriRequest
+	if err := c.ShouldBindUri(&uriReq); err != nil {
+		validationError(c, err, ih.AppConfig.Lang)
+		return
+	}
+
+	var req startImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, ih.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	token, session, user, err := ih.service.StartImpersonation(ctx, authPayload.UserID,
+		&domain.ImpersonationRequest{
+			UserID:          uriReq.UserID,
+			Reason:          req.Reason,
+			ReadOnly:        req.ReadOnly,
+			DurationMinutes: req.DurationMinutes,
+		})
+	if err != nil {
+		HandleError(c, err, ih.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, startImpersonationResponse{
+		ImpersonationToken: token,
+		Session:            session,
+		User:               user,
+	})
+}
+
+// EndImpersonation نشست جعل هویتی را که درخواست با توکن آن آمده می‌بندد
+func (ih *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := ih.service.EndImpersonation(ctx, authPayload)
+	if err != nil {
+		HandleError(c, err, ih.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type LoginEventHandler struct {
+	service      port.LoginEventService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterLoginEventHandler(service port.LoginEventService, tokenService port.TokenService,
+	appConfig config.App) *LoginEventHandler {
+	return &LoginEventHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+// GetLoginEvents سابقهٔ ورود کاربر واردشده
+func (leh *LoginEventHandler) GetLoginEvents(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	events, err := leh.service.GetLoginEvents(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, leh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, events)
+}
+
+type getUserLoginEventsRequest struct {
+	UserID int64 `uri:"userId" binding:"required,min=1" example:"1"`
+}
+
+// GetUserLoginEvents سابقهٔ ورود یک کاربر برای ادمین
+func (leh *LoginEventHandler) GetUserLoginEvents(c *gin.Context) {
+	var req getUserLoginEventsRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, leh.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := leh.service.GetLoginEvents(ctx, req.UserID)
+	if err != nil {
+		HandleError(c, err, leh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, events)
+}
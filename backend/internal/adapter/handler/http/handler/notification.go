@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type NotificationHandler struct {
+	service      port.NotificationService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterNotificationHandler(service port.NotificationService, tokenService port.TokenService,
+	appConfig config.App) *NotificationHandler {
+	return &NotificationHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (nh *NotificationHandler) GetNotifications(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	notifications, err := nh.service.GetNotifications(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, nh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, notifications)
+}
+
+type markNotificationReadRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (nh *NotificationHandler) MarkRead(c *gin.Context) {
+	var req markNotificationReadRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, nh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := nh.service.MarkNotificationRead(ctx, authPayload.UserID, req.ID)
+	if err != nil {
+		HandleError(c, err, nh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
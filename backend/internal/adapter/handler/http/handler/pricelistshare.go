@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// priceListSharePinHeader رمز لینک؛ برای باز کردن مستقیم PDF در مرورگر فیلد «pin» بدنهٔ POST هم پذیرفته می‌شود.
+// رمز از query خوانده نمی‌شود تا در لاگ‌ها، تاریخچهٔ مرورگر و Referer نماند.
+const priceListSharePinHeader = "X-Price-List-Pin"
+
+type PriceListShareHandler struct {
+	service           port.PriceListShareService
+	priceListRenderer port.PriceListRenderer
+	TokenService      port.TokenService
+	AppConfig         config.App
+}
+
+func RegisterPriceListShareHandler(service port.PriceListShareService,
+	priceListRenderer port.PriceListRenderer, tokenService port.TokenService,
+	appConfig config.App) *PriceListShareHandler {
+	return &PriceListShareHandler{
+		service,
+		priceListRenderer,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (psh *PriceListShareHandler) FetchAll(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	links, err := psh.service.GetPriceListShareLinks(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, links)
+}
+
+type createPriceListShareLinkRequest struct {
+	Title          string `json:"title" example:"لیست مشتریان عمده"`
+	TemplateID     int64  `json:"templateId" example:"1"`      // صفر → قالب پیش‌فرض فروشگاه
+	ExpiresInHours int    `json:"expiresInHours" example:"72"` // صفر → مدت پیش‌فرض
+	Pin            string `json:"pin" example:"1234"`          // اختیاری، ۴ تا ۸ رقم
+}
+
+func (psh *PriceListShareHandler) Create(c *gin.Context) {
+	var req createPriceListShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	link := &domain.PriceListShareLink{
+		UserID: authPayload.UserID,
+		Title:  req.Title,
+	}
+	if req.TemplateID > 0 {
+		link.TemplateID = &req.TemplateID
+	}
+
+	ctx := c.Request.Context()
+	created, err := psh.service.CreatePriceListShareLink(ctx, link, req.ExpiresInHours, req.Pin)
+	if err != nil {
+		HandleError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, created)
+}
+
+type priceListShareLinkIDRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (psh *PriceListShareHandler) Revoke(c *gin.Context) {
+	var req priceListShareLinkIDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := psh.service.RevokePriceListShareLink(ctx, authPayload.UserID, req.ID)
+	if err != nil {
+		HandleError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+type publicPriceListRequest struct {
+	Token string `uri:"token"`
+}
+
+// FetchPublic لیست قیمت فقط‌خواندنی برای بازدیدکنندهٔ بدون ورود
+func (psh *PriceListShareHandler) FetchPublic(c *gin.Context) {
+	var req publicPriceListRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	priceList, err := psh.service.OpenSharedPriceList(ctx, req.Token, priceListSharePin(c), c.ClientIP())
+	if err != nil {
+		handlePriceListShareError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	handleSuccess(c, priceList)
+}
+
+func (psh *PriceListShareHandler) FetchPublicPDF(c *gin.Context) {
+	var req publicPriceListRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	priceList, err := psh.service.OpenSharedPriceList(ctx, req.Token, priceListSharePin(c), c.ClientIP())
+	if err != nil {
+		handlePriceListShareError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	pdfBuf, err := psh.priceListRenderer.RenderPriceListPDF(ctx, priceList)
+	if err != nil {
+		handlePriceListRenderError(c, err, psh.AppConfig.Lang)
+		return
+	}
+
+	fileName := priceListFileName(priceList.ShopInfo, domain.PriceListFormatPDF)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/pdf", pdfBuf)
+}
+
+type priceListSharePinRequest struct {
+	Pin string `json:"pin" example:"1234"`
+}
+
+func priceListSharePin(c *gin.Context) string {
+	if pin := strings.TrimSpace(c.GetHeader(priceListSharePinHeader)); pin != "" {
+		return pin
+	}
+	if c.Request.Method != http.MethodPost {
+		return ""
+	}
+
+	if c.ContentType() == binding.MIMEJSON {
+		var req priceListSharePinRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(req.Pin)
+	}
+	// PostForm فقط بدنهٔ فرم را می‌خواند، نه query
+	return strings.TrimSpace(c.PostForm("pin"))
+}
+
+// handlePriceListShareError لینک نامعتبر 404، رمز لازم یا اشتباه 403 و قفل رمز 429 برمی‌گرداند
+func handlePriceListShareError(c *gin.Context, err error, lang string) {
+	var statusCode int
+	switch err.Error() {
+	case msg.ErrPriceListShareLinkIsNotValid:
+		statusCode = http.StatusNotFound
+	case msg.ErrPriceListSharePinRequired, msg.ErrPriceListSharePinIsWrong:
+		statusCode = http.StatusForbidden
+	case msg.ErrPriceListSharePinLocked:
+		statusCode = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.Itoa(15*60))
+	default:
+		HandleError(c, err, lang)
+		return
+	}
+
+	errMsg, isTranslated := parseError(err, lang)
+	c.JSON(statusCode, newErrorResponse(errMsg, isTranslated))
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPriceListSharePin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		header      string
+		want        string
+	}{
+		{name: "header", method: http.MethodGet, target: "/x", header: "1234", want: "1234"},
+		{name: "query is ignored", method: http.MethodGet, target: "/x?pin=1234", want: ""},
+		{
+			name: "form body", method: http.MethodPost, target: "/x",
+			contentType: "application/x-www-form-urlencoded", body: "pin=5678", want: "5678",
+		},
+		{
+			name: "json body", method: http.MethodPost, target: "/x",
+			contentType: "application/json", body: `{"pin":" 9012 "}`, want: "9012",
+		},
+		{
+			name: "query is ignored on post", method: http.MethodPost, target: "/x?pin=1234",
+			contentType: "application/x-www-form-urlencoded", want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				c.Request.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.header != "" {
+				c.Request.Header.Set(priceListSharePinHeader, tt.header)
+			}
+
+			if got := priceListSharePin(c); got != tt.want {
+				t.Fatalf("priceListSharePin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
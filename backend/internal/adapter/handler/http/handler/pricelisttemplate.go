@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+)
+
+type PriceListTemplateHandler struct {
+	service      port.PriceListTemplateService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterPriceListTemplateHandler(service port.PriceListTemplateService,
+	tokenService port.TokenService, appConfig config.App) *PriceListTemplateHandler {
+	return &PriceListTemplateHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (pth *PriceListTemplateHandler) FetchAll(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	templates, err := pth.service.GetPriceListTemplates(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, templates)
+}
+
+type priceListTemplateIDRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (pth *PriceListTemplateHandler) Fetch(c *gin.Context) {
+	var req priceListTemplateIDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	template, err := pth.service.GetPriceListTemplate(ctx, authPayload.UserID, req.ID)
+	if err != nil {
+		HandleError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, template)
+}
+
+type savePriceListTemplateRequest struct {
+	Title           string                  `json:"title" example:"لیست همکار"`
+	IsDefault       bool                    `json:"isDefault"`
+	ShowDollarPrice bool                    `json:"showDollarPrice"`
+	ShowImage       bool                    `json:"showImage"`
+	ShowDescription bool                    `json:"showDescription"`
+	ShowSKU         *bool                   `json:"showSku"`                    // خالی → نمایش کد کالا
+	GroupBy         domain.PriceListGroupBy `json:"groupBy" example:"category"` // none|category|brand
+	SortBy          domain.PriceListSort    `json:"sortBy" example:"price"`     // default|model|price|updated
+	SortDir         domain.SortDir          `json:"sortDir" example:"asc"`
+	HeaderText      string                  `json:"headerText"`
+	FooterText      string                  `json:"footerText"`
+	MarkupPercent   decimal.NullDecimal     `json:"markupPercent" example:"12"` // خالی → بدون قیمت مصرف‌کننده
+	PaperSize       domain.PaperSize        `json:"paperSize" example:"A4"`     // A4|A5|Letter
+}
+
+func (r savePriceListTemplateRequest) toTemplate(userID int64) *domain.PriceListTemplate {
+	return &domain.PriceListTemplate{
+		UserID:          userID,
+		Title:           r.Title,
+		IsDefault:       r.IsDefault,
+		ShowDollarPrice: r.ShowDollarPrice,
+		ShowImage:       r.ShowImage,
+		ShowDescription: r.ShowDescription,
+		ShowSKU:         r.ShowSKU == nil || *r.ShowSKU,
+		GroupBy:         r.GroupBy,
+		SortBy:          r.SortBy,
+		SortDir:         r.SortDir,
+		HeaderText:      r.HeaderText,
+		FooterText:      r.FooterText,
+		MarkupPercent:   r.MarkupPercent,
+		PaperSize:       r.PaperSize,
+	}
+}
+
+type createPriceListTemplateResponse struct {
+	ID int64 `json:"id" example:"1"`
+}
+
+func (pth *PriceListTemplateHandler) Create(c *gin.Context) {
+	var req savePriceListTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	id, err := pth.service.CreatePriceListTemplate(ctx, req.toTemplate(authPayload.UserID))
+	if err != nil {
+		HandleError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, createPriceListTemplateResponse{ID: id})
+}
+
+type updatePriceListTemplateRequest struct {
+	ID int64 `json:"id" example:"1"`
+	savePriceListTemplateRequest
+}
+
+func (pth *PriceListTemplateHandler) Update(c *gin.Context) {
+	var req updatePriceListTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	template := req.toTemplate(authPayload.UserID)
+	template.ID = req.ID
+
+	ctx := c.Request.Context()
+	err := pth.service.UpdatePriceListTemplate(ctx, template)
+	if err != nil {
+		HandleError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+func (pth *PriceListTemplateHandler) Delete(c *gin.Context) {
+	var req priceListTemplateIDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := pth.service.DeletePriceListTemplate(ctx, authPayload.UserID, req.ID)
+	if err != nil {
+		HandleError(c, err, pth.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+)
+
+type RoundingRuleHandler struct {
+	service      port.RoundingRuleService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterRoundingRuleHandler(service port.RoundingRuleService, tokenService port.TokenService,
+	appConfig config.App) *RoundingRuleHandler {
+	return &RoundingRuleHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (rrh *RoundingRuleHandler) GetRoundingRules(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+
+	ctx := c.Request.Context()
+	rules, err := rrh.service.GetRoundingRules(ctx, currentUserID)
+	if err != nil {
+		HandleError(c, err, rrh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, rules)
+}
+
+type saveRoundingRuleRequest struct {
+	CategoryID *int64                   `json:"categoryId"` // خالی → قانون پیش‌فرض فروشگاه
+	Step       decimal.Decimal          `json:"step" example:"10000"`
+	Direction  domain.RoundingDirection `json:"direction" example:"up"`
+	Ending     decimal.Decimal          `json:"ending" example:"9000"`
+}
+
+type saveRoundingRuleResponse struct {
+	ID int64 `json:"id" example:"1"`
+}
+
+func (rrh *RoundingRuleHandler) Save(c *gin.Context) {
+	var req saveRoundingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, rrh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	rule := &domain.RoundingRule{
+		UserID:     authPayload.UserID,
+		CategoryID: req.CategoryID,
+		Step:       req.Step,
+		Direction:  req.Direction,
+		Ending:     req.Ending,
+	}
+
+	ctx := c.Request.Context()
+	id, err := rrh.service.SaveRoundingRule(ctx, rule)
+	if err != nil {
+		HandleError(c, err, rrh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, saveRoundingRuleResponse{ID: id})
+}
+
+type deleteRoundingRuleRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (rrh *RoundingRuleHandler) Delete(c *gin.Context) {
+	var req deleteRoundingRuleRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, rrh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := rrh.service.DeleteRoundingRule(ctx, authPayload.UserID, req.ID)
+	if err != nil {
+		HandleError(c, err, rrh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type StalenessPolicyHandler struct {
+	service      port.StalenessPolicyService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterStalenessPolicyHandler(service port.StalenessPolicyService,
+	tokenService port.TokenService, appConfig config.App) *StalenessPolicyHandler {
+	return &StalenessPolicyHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+func (sph *StalenessPolicyHandler) GetStalenessPolicies(c *gin.Context) {
+	ctx := c.Request.Context()
+	policies, err := sph.service.GetStalenessPolicies(ctx)
+	if err != nil {
+		HandleError(c, err, sph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, policies)
+}
+
+type saveStalenessPolicyRequest struct {
+	CategoryID *int64 `json:"categoryId"` // خالی → سیاست پیش‌فرض
+	MaxAgeDays int    `json:"maxAgeDays" example:"30"`
+	AutoHide   bool   `json:"autoHide" example:"false"`
+}
+
+type saveStalenessPolicyResponse struct {
+	ID int64 `json:"id" example:"1"`
+}
+
+func (sph *StalenessPolicyHandler) Save(c *gin.Context) {
+	var req saveStalenessPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, sph.AppConfig.Lang)
+		return
+	}
+
+	policy := &domain.StalenessPolicy{
+		CategoryID: req.CategoryID,
+		MaxAgeDays: req.MaxAgeDays,
+		AutoHide:   req.AutoHide,
+	}
+
+	ctx := c.Request.Context()
+	id, err := sph.service.SaveStalenessPolicy(ctx, policy)
+	if err != nil {
+		HandleError(c, err, sph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, saveStalenessPolicyResponse{ID: id})
+}
+
+type deleteStalenessPolicyRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (sph *StalenessPolicyHandler) Delete(c *gin.Context) {
+	var req deleteStalenessPolicyRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, sph.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := sph.service.DeleteStalenessPolicy(ctx, req.ID)
+	if err != nil {
+		HandleError(c, err, sph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
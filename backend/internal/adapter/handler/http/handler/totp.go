@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type TOTPHandler struct {
+	service      port.TOTPService
+	TokenService port.TokenService
+	AppConfig    config.App
+}
+
+func RegisterTOTPHandler(service port.TOTPService, tokenService port.TokenService,
+	appConfig config.App) *TOTPHandler {
+	return &TOTPHandler{
+		service,
+		tokenService,
+		appConfig,
+	}
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type updateTOTPPolicyRequest struct {
+	Enforcement domain.TOTPEnforcement `json:"enforcement" binding:"required"`
+}
+
+type resetUserTOTPUriRequest struct {
+	UserID int64 `uri:"userId" binding:"required,min=1" example:"1"`
+}
+
+func (th *TOTPHandler) GetStatus(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	status, err := th.service.GetStatus(ctx, authPayload.UserID, authPayload.UserRole)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, status)
+}
+
+// BeginEnrollment رمز و QR برنامهٔ احراز هویت را می‌دهد؛ TOTP تا تایید اولین کد فعال نمی‌شود
+func (th *TOTPHandler) BeginEnrollment(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	enrollment, err := th.service.BeginEnrollment(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, enrollment)
+}
+
+// ConfirmEnrollment با اولین کد درست TOTP را فعال می‌کند؛ کدهای بازیابی فقط در همین پاسخ دیده می‌شوند
+func (th *TOTPHandler) ConfirmEnrollment(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	recoveryCodes, err := th.service.ConfirmEnrollment(ctx, authPayload.UserID, req.Code)
+	if err != nil {
+		handleVerificationCodeError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (th *TOTPHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	recoveryCodes, err := th.service.RegenerateRecoveryCodes(ctx, authPayload.UserID, req.Code)
+	if err != nil {
+		handleVerificationCodeError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (th *TOTPHandler) DisableTOTP(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := th.service.DisableTOTP(ctx, authPayload.UserID, authPayload.UserRole, req.Code)
+	if err != nil {
+		handleVerificationCodeError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+func (th *TOTPHandler) GetTOTPPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	policy, err := th.service.GetTOTPPolicy(ctx)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, policy)
+}
+
+// UpdateTOTPPolicy الزام TOTP را تغییر می‌دهد؛ از ورود بعدی کاربران اعمال می‌شود
+func (th *TOTPHandler) UpdateTOTPPolicy(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var req updateTOTPPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := th.service.UpdateTOTPPolicy(ctx, authPayload.UserID, req.Enforcement)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+// IssueUserEnrollment برای کاربری با نقش پایین‌تر رمز TOTP می‌سازد؛ رمز فقط در ورود بعدی خود کاربر نشان داده می‌شود
+func (th *TOTPHandler) IssueUserEnrollment(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var uriReq resetUserTOTPUriRequest
+	if err := c.ShouldBindUri(&uriReq); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := th.service.IssueEnrollment(ctx, authPayload.UserID, uriReq.UserID)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+// ResetUserTOTP ورود دومرحله‌ای کاربری را که برنامه و کدهای بازیابی‌اش را از دست داده پاک می‌کند
+func (th *TOTPHandler) ResetUserTOTP(c *gin.Context) {
+	authPayload, ok := th.ownAuthPayload(c)
+	if !ok {
+		return
+	}
+
+	var uriReq resetUserTOTPUriRequest
+	if err := c.ShouldBindUri(&uriReq); err != nil {
+		validationError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := th.service.ResetUserTOTP(ctx, authPayload.UserID, uriReq.UserID)
+	if err != nil {
+		HandleError(c, err, th.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+// ownAuthPayload تنظیمات ورود دومرحله‌ای فقط با توکن خود کاربر تغییر می‌کند، نه با توکن جعل هویت
+func (th *TOTPHandler) ownAuthPayload(c *gin.Context) (*domain.TokenPayload, bool) {
+	authPayload := httputil.GetAuthPayload(c)
+	if authPayload.ImpersonatorAdminID != nil {
+		HandleError(c, errors.New(msg.ErrOperationNotAllowedForThisUser), th.AppConfig.Lang)
+		return nil, false
+	}
+	return authPayload, true
+}
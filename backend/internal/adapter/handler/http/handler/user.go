@@ -3,7 +3,6 @@ package handler
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"math"
 
 	"strconv"
@@ -604,10 +603,8 @@ type updateAdminUriRequest struct {
 }
 
 type updateAdminRequest struct {
-	SaveProduct        bool `json:"saveProduct"`
-	ChangeUserState    bool `json:"changeUserState"`
-	ChangeShopState    bool `json:"changeShopState"`
-	ChangeAccountState bool `json:"changeAccountState"`
+	RoleID      *int64              `json:"roleId"`
+	Permissions []domain.Permission `json:"permissions"`
 }
 
 // این struct و تابع جدید را به فایل handler/user.go اضافه کنید
@@ -650,11 +647,9 @@ func (uh *UserHandler) UpdateAdminAccess(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	err := uh.service.UpdateAdminAccess(ctx, &domain.AdminAccess{
-		UserID:             uriReq.AdminID,
-		SaveProduct:        reqPayload.SaveProduct,
-		ChangeUserState:    reqPayload.ChangeUserState,
-		ChangeShopState:    reqPayload.ChangeShopState,
-		ChangeAccountState: reqPayload.ChangeAccountState,
+		UserID:      uriReq.AdminID,
+		RoleID:      reqPayload.RoleID,
+		Permissions: reqPayload.Permissions,
 	})
 	if err != nil {
 		HandleError(c, err, uh.AppConfig.Lang)
@@ -764,32 +759,3 @@ func (uh *UserHandler) FetchAdminUserList(c *gin.Context) {
 
 	handleSuccess(c, users)
 }
-
-// in handler/user_handler.go
-
-func (uh *UserHandler) ImpersonateUser(c *gin.Context) {
-	// هویت ادمین از توکن فعلی او که در Middleware بررسی شده، استخراج می‌شود
-	fmt.Println("--- ImpersonateUser handler reached! ---")
-	authPayload := httputil.GetAuthPayload(c)
-	adminID := authPayload.UserID
-
-	targetUserID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
-	if err != nil {
-		HandleError(c, errors.New("Invalid user ID"), uh.AppConfig.Lang)
-		return
-	}
-	fmt.Println("2222222222222222222222222222222222222")
-
-	ctx := c.Request.Context()
-	impersonationToken, targetUser, err := uh.service.ImpersonateUser(ctx, targetUserID, adminID)
-	if err != nil {
-		HandleError(c, err, uh.AppConfig.Lang)
-		return
-	}
-   fmt.Println("333333333333333333333333333333333333333333")
-	// پاسخ شامل توکن جدید و اطلاعات کاربر است
-	handleSuccess(c, gin.H{
-		"impersonationToken": impersonationToken,
-		"user":               targetUser,
-	})
-}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+)
+
+type ownDeviceUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listOwnDevicesRequest struct {
+	DeviceID string `form:"deviceId"`
+}
+
+type renameOwnDeviceRequest struct {
+	Name string `json:"name"`
+}
+
+type signOutOtherDevicesRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ListOwnDevices دستگاه‌های کاربر واردشده؛ با deviceId دستگاه فعلی مشخص می‌شود
+func (uh *UserHandler) ListOwnDevices(c *gin.Context) {
+	var req listOwnDevicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	devices, err := uh.service.GetOwnDevices(c.Request.Context(), authPayload.UserID, req.DeviceID)
+	if err != nil {
+		HandleError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, devices)
+}
+
+func (uh *UserHandler) RenameOwnDevice(c *gin.Context) {
+	var uriReq ownDeviceUriRequest
+	if err := c.ShouldBindUri(&uriReq); err != nil {
+		validationError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	var req renameOwnDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	err := uh.service.RenameOwnDevice(c.Request.Context(), authPayload.UserID, uriReq.ID, req.Name)
+	if err != nil {
+		HandleError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+func (uh *UserHandler) SignOutOwnDevice(c *gin.Context) {
+	var uriReq ownDeviceUriRequest
+	if err := c.ShouldBindUri(&uriReq); err != nil {
+		validationError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	err := uh.service.SignOutOwnDevice(c.Request.Context(), authPayload.UserID, uriReq.ID)
+	if err != nil {
+		HandleError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+// SendSignOutOtherDevicesCode کد تایید خروج از بقیهٔ دستگاه‌ها را پیامک می‌کند
+func (uh *UserHandler) SendSignOutOtherDevicesCode(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+	err := uh.service.SendSignOutOtherDevicesCode(c.Request.Context(), authPayload.UserID, c.ClientIP())
+	if err != nil {
+		handleVerificationCodeError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
+
+func (uh *UserHandler) SignOutOtherDevices(c *gin.Context) {
+	var req signOutOtherDevicesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	// دستگاه فعلی همان دستگاهی است که توکن درخواست برای آن صادر شده
+	err := uh.service.SignOutOtherDevices(c.Request.Context(), authPayload.UserID, authPayload.FamilyID, req.Code)
+	if err != nil {
+		handleVerificationCodeError(c, err, uh.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, nil)
+}
@@ -1,41 +1,46 @@
 package handler
 
 import (
-	"context"
-	"encoding/base64"
+	"bytes"
+	"encoding/csv"
+	"errors"
 	"fmt"
-	"html"
-	"os"
-	"path/filepath"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/chromedp/cdproto/emulation"
-	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/chromedp"
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/config"
 	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
 	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
 	"github.com/nerkhin/internal/core/port"
+	"github.com/nerkhin/internal/pkg/pagination"
 	"github.com/shopspring/decimal"
-	"github.com/skip2/go-qrcode"
 	ptime "github.com/yaa110/go-persian-calendar"
 )
 
 type UserProductHandler struct {
-	service      port.UserProductService
-	TokenService port.TokenService
-	AppConfig    config.App
+	service           port.UserProductService
+	TokenService      port.TokenService
+	AppConfig         config.App
+	priceListRenderer port.PriceListRenderer
+	priceListExporter port.PriceListExporter
+	priceListShare    port.PriceListShareService
 }
 
 func RegisterUserProductHandler(service port.UserProductService, tokenService port.TokenService,
-	appConfig config.App) *UserProductHandler {
+	priceListRenderer port.PriceListRenderer, priceListExporter port.PriceListExporter,
+	priceListShare port.PriceListShareService, appConfig config.App) *UserProductHandler {
 	return &UserProductHandler{
 		service,
 		tokenService,
 		appConfig,
+		priceListRenderer,
+		priceListExporter,
+		priceListShare,
 	}
 }
 
@@ -47,6 +52,9 @@ type createUserProductRequest struct {
 	DollarPrice string `json:"dollarPrice"`
 	OtherCosts  string `json:"otherCosts"`
 	FinalPrice  string `json:"finalPrice"`
+	SKU         string `json:"sku"`
+
+	ConfirmPriceDeviation bool `json:"confirmPriceDeviation"`
 }
 
 type createUserProductResponse struct {
@@ -259,12 +267,14 @@ func (uph *UserProductHandler) Create(c *gin.Context) {
 			Decimal: otherCostsDecimal,
 			Valid:   !otherCostsDecimal.IsZero(),
 		},
-		FinalPrice: finalPrice,
+		FinalPrice:            finalPrice,
+		SKU:                   &req.SKU,
+		ConfirmPriceDeviation: req.ConfirmPriceDeviation,
 	}
 
 	id, err := uph.service.CreateUserProduct(ctx, category)
 	if err != nil {
-		HandleError(c, err, uph.AppConfig.Lang)
+		handleUserProductError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
@@ -366,12 +376,23 @@ func (uph *UserProductHandler) FetchShops(c *gin.Context) {
 	handleSuccess(c, shops)
 }
 
+// priceListRequest قالب لیست قیمت؛ بدون templateId قالب پیش‌فرض فروشگاه استفاده می‌شود
+type priceListRequest struct {
+	TemplateID int64 `form:"templateId" example:"1"`
+}
+
 func (uph *UserProductHandler) FetchPriceList(c *gin.Context) {
+	var req priceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
 	authPayload := httputil.GetAuthPayload(c)
 	currentUserID := authPayload.UserID
 
 	ctx := c.Request.Context()
-	priceList, err := uph.service.GetPriceList(ctx, currentUserID)
+	priceList, err := uph.service.GetPriceList(ctx, currentUserID, req.TemplateID)
 	if err != nil {
 		HandleError(c, err, uph.AppConfig.Lang)
 		return
@@ -404,12 +425,109 @@ func (uph *UserProductHandler) FetchShopByUserId(c *gin.Context) {
 	handleSuccess(c, priceList)
 }
 
+type shopPriceListRequest struct {
+	UserID int64 `uri:"uid"`
+}
+
+// FetchShopPriceListPDF لیست قیمت کامل فروشگاه دیگر؛ فیلترهای دسته و برند مثل fetch-shop اعمال می‌شوند
+func (uph *UserProductHandler) FetchShopPriceListPDF(c *gin.Context) {
+	var req shopPriceListRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+
+	ctx := c.Request.Context()
+	raw, err := uph.service.GetShopPriceList(ctx, currentUserID, req.UserID, shopProductQuery(c))
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	pdfBuf, err := uph.priceListRenderer.RenderPriceListPDF(ctx, raw)
+	if err != nil {
+		handlePriceListRenderError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	fileName := priceListFileName(raw.ShopInfo, domain.PriceListFormatPDF)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/pdf", pdfBuf)
+}
+
+// ExportShopPriceList لیست قیمت فروشگاه دیگر به صورت xlsx، csv، png یا jpeg
+func (uph *UserProductHandler) ExportShopPriceList(c *gin.Context) {
+	var req shopPriceListRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	format := domain.PriceListFormat(c.Query("format"))
+	if !domain.IsPriceListExportFormatValid(format) {
+		HandleError(c, errors.New(msg.ErrPriceListFormatIsNotValid), uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+
+	ctx := c.Request.Context()
+	raw, err := uph.service.GetShopPriceList(ctx, currentUserID, req.UserID, shopProductQuery(c))
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	file, err := uph.priceListExporter.ExportPriceList(ctx, raw, format)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	fileName := priceListFileName(raw.ShopInfo, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, format.ContentType(), file)
+}
+
 type updateUserProductRequest struct {
 	ID          int64  `json:"id"`
 	IsDollar    bool   `json:"isDollar"`
 	DollarPrice string `json:"dollarPrice"`
 	OtherCosts  string `json:"otherCosts"`
 	FinalPrice  string `json:"finalPrice"`
+	// نفرستادن sku کد فعلی را نگه می‌دارد و رشتهٔ خالی آن را پاک می‌کند
+	SKU *string `json:"sku"`
+
+	ConfirmPriceDeviation bool `json:"confirmPriceDeviation"`
+}
+
+type priceDeviationResponse struct {
+	Message              string                `json:"message"`
+	IsTranslated         bool                  `json:"isTranslated"`
+	RequiresConfirmation bool                  `json:"requiresConfirmation"`
+	Deviation            domain.PriceDeviation `json:"deviation"`
+}
+
+// handleUserProductError هشدار انحراف قیمت را با کد 409 و جزئیات برمی‌گرداند تا کلاینت
+// بتواند از کاربر تایید بگیرد و با confirmPriceDeviation دوباره ارسال کند.
+func handleUserProductError(c *gin.Context, err error, lang string) {
+	var deviationErr *domain.PriceDeviationError
+	if !errors.As(err, &deviationErr) {
+		HandleError(c, err, lang)
+		return
+	}
+
+	errMsg, isTranslated := parseError(err, lang)
+	c.JSON(http.StatusConflict, priceDeviationResponse{
+		Message:              errMsg,
+		IsTranslated:         isTranslated,
+		RequiresConfirmation: true,
+		Deviation:            deviationErr.PriceDeviation,
+	})
 }
 
 // internal/adapter/http/handler/user_product_handler.go
@@ -421,6 +539,20 @@ func (psh *UserProductHandler) FetchShopProducts(c *gin.Context) {
 	userID := currentUserID
 	shopID, _ := strconv.ParseInt(c.Query("shopId"), 10, 64)
 
+	q := shopProductQuery(c)
+	q.ShopID = shopID
+
+	vm, err := psh.service.FetchShopProductsFiltered(c, currentUserID, shopID, userID, q)
+	if err != nil {
+		HandleError(c, err, psh.AppConfig.Lang)
+		return
+	}
+	handleSuccess(c, vm)
+}
+
+// shopProductQuery فیلترهای query string محصولات فروشگاه:
+// brandIds=1,2&categoryId=...&subCategoryId=...&isDollar=1|0&sortUpdated=asc|desc&search=...&limit=...&offset=...
+func shopProductQuery(c *gin.Context) *domain.UserProductQuery {
 	parseIDs := func(s string) []int64 {
 		if s = strings.TrimSpace(s); s == "" {
 			return nil
@@ -454,8 +586,7 @@ func (psh *UserProductHandler) FetchShopProducts(c *gin.Context) {
 	sort := domain.SortDir(strings.ToLower(c.Query("sortUpdated")))
 	search := c.Query("search")
 
-	q := &domain.UserProductQuery{
-		ShopID:        shopID,
+	return &domain.UserProductQuery{
 		BrandIDs:      parseIDs(c.Query("brandIds")),
 		CategoryID:    categoryID,
 		SubCategoryID: subCatID,
@@ -465,13 +596,6 @@ func (psh *UserProductHandler) FetchShopProducts(c *gin.Context) {
 		Limit:         limit,
 		Offset:        offset,
 	}
-
-	vm, err := psh.service.FetchShopProductsFiltered(c, currentUserID, shopID, userID, q)
-	if err != nil {
-		HandleError(c, err, psh.AppConfig.Lang)
-		return
-	}
-	handleSuccess(c, vm)
 }
 func (uph *UserProductHandler) Update(c *gin.Context) {
 	var req updateUserProductRequest
@@ -483,9 +607,11 @@ func (uph *UserProductHandler) Update(c *gin.Context) {
 	authPayload := httputil.GetAuthPayload(c)
 
 	userProduct := &domain.UserProduct{
-		ID:       req.ID,
-		UserID:   authPayload.UserID,
-		IsDollar: req.IsDollar,
+		ID:                    req.ID,
+		UserID:                authPayload.UserID,
+		IsDollar:              req.IsDollar,
+		SKU:                   req.SKU,
+		ConfirmPriceDeviation: req.ConfirmPriceDeviation,
 	}
 
 	dollarPrice := decimal.NullDecimal{Valid: false}
@@ -540,7 +666,7 @@ func (uph *UserProductHandler) Update(c *gin.Context) {
 
 	err := uph.service.UpdateUserProduct(ctx, userProduct)
 	if err != nil {
-		HandleError(c, err, uph.AppConfig.Lang)
+		handleUserProductError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
@@ -569,6 +695,30 @@ func (uph *UserProductHandler) Fetch(c *gin.Context) {
 	handleSuccess(c, userProduct)
 }
 
+type fetchUserProductBySKURequest struct {
+	SKU string `uri:"sku" binding:"required" example:"A-1024"`
+}
+
+// FetchBySKU محصول فروشگاه جاری را با کد کالای خود فروشگاه برمی‌گرداند (برای نرم‌افزار حسابداری)
+func (uph *UserProductHandler) FetchBySKU(c *gin.Context) {
+	var req fetchUserProductBySKURequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	userProduct, err := uph.service.FetchUserProductBySKU(ctx, authPayload.UserID, req.SKU)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, userProduct)
+}
+
 type deleteUserProductByIdRequest struct {
 	Id int64 `uri:"id" binding:"required"`
 }
@@ -631,81 +781,6 @@ func tryParseTimeString(s string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
-/* ───────── Small helpers ───────── */
-
-func moneyIRR(n decimal.Decimal) string {
-
-	// مقدار اصلی
-	d := n
-
-	// تبدیل به عدد صحیح ریالی (بدون اعشار)
-	// اگر اعشار داری و میخوای حذف بشه، IntPart استفاده کن
-	num := d.IntPart()
-	s := fmt.Sprintf("%d", num)
-
-	neg := ""
-	if num < 0 {
-		neg = "-"
-		s = s[1:] // علامت منفی رو حذف می‌کنیم
-	}
-
-	// اضافه کردن ویرگول به هر سه رقم
-	var out []byte
-	c := 0
-	for i := len(s) - 1; i >= 0; i-- {
-		out = append(out, s[i])
-		c++
-		if c%3 == 0 && i != 0 {
-			out = append(out, ',')
-		}
-	}
-
-	// برگردوندن آرایه (چون برعکس ساخته شده)
-	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
-		out[i], out[j] = out[j], out[i]
-	}
-
-	return neg + string(out)
-}
-
-const lrm = "\u200E"
-
-func moneyIRR_LTR(n decimal.Decimal) string {
-	return lrm + moneyIRR(n) + lrm
-}
-
-func ymdJalali_LTR(t time.Time) string {
-	s := fmt.Sprintf("%d/%s/%s", t.Year(), pad2(int(t.Month())), pad2(t.Day()))
-	return lrm + s + lrm
-}
-
-var jalaliMonths = [...]string{
-	"", "فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور",
-	"مهر", "آبان", "آذر", "دی", "بهمن", "اسفند",
-}
-
-func jalaliDateLong(t ptime.Time) string {
-	return fmt.Sprintf("%d %s %d", t.Day(), jalaliMonths[int(t.Month())], t.Year())
-}
-
-func pad2(n int) string {
-	if n < 10 {
-		return fmt.Sprintf("0%d", n)
-	}
-	return fmt.Sprintf("%d", n)
-}
-
-func joinNonEmpty(parts ...string) string {
-	var out []string
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			out = append(out, p)
-		}
-	}
-	return strings.Join(out, " ")
-}
-
 func firstNonEmpty(a, b string) string {
 	if strings.TrimSpace(a) != "" {
 		return a
@@ -736,405 +811,6 @@ func uniqueStr(ss []string) []string {
 	return out
 }
 
-func htmlEsc(s string) string { return html.EscapeString(s) }
-
-// مسیر فونت‌ها (طبق Dockerfile شما)
-const localFontDir = "/assets/fonts"
-
-// نام‌های احتمالی فونت‌ها (هر کدام موجود بود برداشته می‌شود)
-var vazirRegCandidates = []string{
-	"Vazirmatn-Regular.woff2",
-	"Vazirmatn-FD-Regular.woff2",
-	"Vazirmatn-Regular.ttf",
-	"Vazirmatn-FD-Regular.ttf",
-	"VazirFD.ttf",
-}
-var vazirBoldCandidates = []string{
-	"Vazirmatn-Bold.woff2",
-	"Vazirmatn-FD-Bold.woff2",
-	"Vazirmatn-Bold.ttf",
-	"Vazirmatn-FD-Bold.ttf",
-	"Vazirmatn-FD-Bold.ttf",
-}
-
-// تاریخ ایتم‌ها به صورت yyyy/mm/dd جلالی (LTR)
-func jalaliYMDFromAny(v any) string {
-	jt := ptime.New(v.(time.Time))
-	if jt.Time().IsZero() {
-		return "—"
-	}
-	return ymdJalali_LTR(jt.Time()) // خروجی مثل: 1404/06/18
-}
-
-func pickExistingFont(fontDir string, names []string) (fullPath, mime, format string, ok bool) {
-	for _, n := range names {
-		p := filepath.Join(fontDir, n)
-		if st, err := os.Stat(p); err == nil && !st.IsDir() {
-			ext := strings.ToLower(filepath.Ext(n))
-			switch ext {
-			case ".woff2":
-				return p, "font/woff2", "woff2", true
-			case ".ttf":
-				return p, "font/ttf", "truetype", true
-			default:
-				return p, "font/ttf", "truetype", true
-			}
-		}
-	}
-	return "", "", "", false
-}
-
-func fontDataURI(path, mime string) (string, error) {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(b), nil
-}
-
-const siteBaseURL = "https://nerrkhin.com"
-
-// CSS @font-face با فونت لوکال به صورت data:URI
-func buildLocalVazirmatnCSS() string {
-	regPath, regMime, regFmt, ok1 := pickExistingFont(localFontDir, vazirRegCandidates)
-	boldPath, boldMime, boldFmt, ok2 := pickExistingFont(localFontDir, vazirBoldCandidates)
-	if !ok1 || !ok2 {
-		// اگر فونت پیدا نشد، CSS خالی برگردان (fallback به سیستم)
-		return ""
-	}
-	regURI, err1 := fontDataURI(regPath, regMime)
-	boldURI, err2 := fontDataURI(boldPath, boldMime)
-	if err1 != nil || err2 != nil {
-		return ""
-	}
-
-	family := "Vazirmatn Local"
-	css := fmt.Sprintf(`
-@font-face {
-  font-family: '%s';
-  src: url('%s') format('%s');
-  font-weight: 400;
-  font-style: normal;
-  font-display: swap;
-}
-@font-face {
-  font-family: '%s';
-  src: url('%s') format('%s');
-  font-weight: 700;
-  font-style: normal;
-  font-display: swap;
-}
-`, family, regURI, regFmt, family, boldURI, boldFmt)
-
-	return css
-}
-
-// ===================== ساخت HTML (RTL+Vazirmatn) =====================
-// نیاز به این پکیج دارید:
-// go get github.com/skip2/go-qrcode
-// اگر قبلاً دارید، همین را نگه دارید.
-
-// buildQRDataURI: متن را به PNG QR تبدیل می‌کند و result را به‌صورت data:URI برمی‌گرداند.
-func buildQRDataURI(text string, size int) string {
-	if strings.TrimSpace(text) == "" {
-		return ""
-	}
-	if size <= 0 {
-		size = 128
-	}
-	png, err := qrcode.Encode(text, qrcode.Medium, size)
-	if err != nil || len(png) == 0 {
-		return ""
-	}
-	b64 := base64.StdEncoding.EncodeToString(png)
-	return "data:image/png;base64," + b64
-}
-
-func shopLogoURL(u string) string {
-	u = strings.TrimSpace(u)
-	if u == "" {
-		return ""
-	}
-	// اگر خودش کامل بود، دست نزن
-	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
-		return u
-	}
-	// اگر هرجای رشته "/uploads/" داشت → همون‌جارو به مسیر وب تبدیل کن
-	if i := strings.Index(u, "/uploads/"); i >= 0 {
-		return siteBaseURL + u[i:] // از /uploads/... به بعد
-	}
-	// اگر خودش از /uploads شروع می‌شود
-	if strings.HasPrefix(u, "/uploads/") {
-		return siteBaseURL + u
-	}
-	// در بدترین حالت: فرض کن فقط نام فایل است
-	return siteBaseURL + "/uploads/" + u
-}
-
-// now از نوع ptime.Time در کد شماست؛ اینجا signature شما را دست‌نخورده نگه می‌دارم.
-func buildPriceListHTML(vm domain.ShopViewModel, now interface{}) string {
-	shopName := strings.TrimSpace(vm.ShopInfo.ShopName)
-	if shopName == "" {
-		shopName = "—"
-	}
-	phones := strings.Join([]string{vm.ShopInfo.ShopPhone1, vm.ShopInfo.ShopPhone2, vm.ShopInfo.ShopPhone3}, " , ")
-	addr := strings.TrimSpace(vm.ShopInfo.ShopAddress)
-
-	// URL سایت (برای QR بالای تاریخ)
-	siteURL := strings.TrimSpace(vm.ShopInfo.WebsiteUrl)
-	if siteURL == "" {
-		siteURL = siteBaseURL
-	}
-	siteQR := buildQRDataURI(siteURL, 96)
-
-	// لوگو (از /uploads/... به URL کامل)
-
-	// Social QR (اختیاری)
-	socials := []struct {
-		label string
-		url   string
-	}{
-		{"Instagram", strings.TrimSpace(vm.ShopInfo.InstagramUrl)},
-		{"Telegram", strings.TrimSpace(vm.ShopInfo.TelegramUrl)},
-		{"WhatsApp", strings.TrimSpace(vm.ShopInfo.WhatsappUrl)},
-	}
-	var socialsQR strings.Builder
-	for _, s := range socials {
-		if s.url == "" {
-			continue
-		}
-		qr := buildQRDataURI(s.url, 110)
-		if qr == "" {
-			continue
-		}
-		socialsQR.WriteString(fmt.Sprintf(`
-			<div class="qr-card">
-				<img src="%s" alt="%s QR"/>
-				<div class="qr-label">%s</div>
-			</div>
-		`, htmlEsc(qr), htmlEsc(s.label), htmlEsc(s.label)))
-	}
-
-	// ردیف‌های جدول
-	var rows strings.Builder
-	for i, it := range vm.Products {
-		title := joinNonEmpty(it.ProductCategory, it.ProductBrand, it.ModelName)
-		if strings.TrimSpace(title) == "" {
-			title = " "
-		}
-		updated := jalaliDateLong(ptime.New(it.UpdatedAt.Time))
-		price := moneyIRR_LTR(it.FinalPrice)
-		rows.WriteString(fmt.Sprintf(`
-			<tr>
-				<td class="c">%d</td>
-				<td class="r">%s</td>
-				<td class="c">%s</td>
-				<td class="c">%s</td>
-			</tr>`,
-			i+1, htmlEsc(title), htmlEsc(price), htmlEsc(updated),
-		))
-	}
-
-	fontCSS := buildLocalVazirmatnCSS()
-
-	// لوگو HTML (اگر نبود، جای‌گیر ظریف)
-	var logoHTML string
-	if u := shopLogoURL(vm.ShopInfo.ImageUrl); u != "" {
-		logoHTML = fmt.Sprintf(`<img class="shop-logo" src="%s" alt="shop logo"/>`, htmlEsc(u))
-	} else {
-		logoHTML = `<div class="shop-logo placeholder"></div>`
-	}
-	// QR سایت (اگر نبود، فاصله‌ی هم‌تراز)
-	siteQRHTML := `<div class="site-qr placeholder"></div>`
-	if siteQR != "" {
-		siteQRHTML = fmt.Sprintf(`
-			<div class="site-qr">
-				<img src="%s" alt="site QR"/>
-				<div class="small">%s</div>
-			</div>`, htmlEsc(siteQR), htmlEsc(siteURL))
-	}
-
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html lang="fa" dir="rtl">
-<head>
-<meta charset="utf-8"/>
-<meta name="viewport" content="width=device-width, initial-scale=1" />
-<title>%s</title>
-<style>
-%s
-
-* { box-sizing: border-box; }
-body {
-  font-family: "Vazirmatn Local", system-ui, -apple-system, Segoe UI, Roboto, Arial, sans-serif;
-  direction: rtl; unicode-bidi: embed;
-  margin: 24px; color: #111;
-}
-
-/* ===== Header (هم‌تراز و حرفه‌ای) ===== */
-.header {
-  display: grid;
-  grid-template-columns: 1.2fr 1fr 1fr;
-  align-items: center;  /* ← تمام آیتم‌ها عموداً وسط */
-  gap: 16px;
-  margin-bottom: 14px;
-  padding: 10px 12px;
-  border: 1px solid #eee;
-  border-radius: 12px;
-  background: #fafafa;
-}
-
-.header .right {
-  display: flex;
-  align-items: center;
-  gap: 12px;
-}
-.shop-logo {
-  width: 74px; height: 74px;
-  border-radius: 50%%; object-fit: cover; object-position: center;
-  border: 1px solid #e9e9e9; background: #fff;
-}
-.shop-logo.placeholder { background: #f3f3f3; }
-
-.shop-title {
-  display: flex; flex-direction: column; gap: 2px;
-}
-.shop-title .name {
-  font-size: 26px; font-weight: 800; line-height: 1.1;
-}
-.shop-title .sub {
-  font-size: 12px; color: #666;
-}
-
-/* ستون وسط: QR سایت */
-.header .center {
-  display: flex; justify-content: center;
-}
-.site-qr { text-align: center; }
-.site-qr img { width: 86px; height: 86px; display: block; margin: 0 auto 6px; }
-.site-qr .small { font-size: 11px; color: #666; direction: ltr; }
-.site-qr.placeholder { width: 86px; height: 86px; border-radius: 8px; background: #f3f3f3; }
-
-/* ستون چپ: تاریخ */
-.header .left {
-  display: flex; justify-content: flex-start; /* چون RTL است، چپِ بصری */
-  align-items: center; gap: 8px;
-}
-.date {
-  font-size: 14px; color: #333; font-weight: 700;
-  white-space: nowrap;
-}
-
-/* متا: آدرس/تلفن‌ها */
-.meta {
-  display: grid; grid-template-columns: 1fr; gap: 6px;
-  font-size: 13px; margin: 10px 0 16px;
-}
-.meta .row { display: flex; align-items: baseline; gap: 8px; }
-.meta .label { font-weight: 800; color: #222; min-width: 64px; }
-.meta .value { color: #111; }
-
-/* Divider */
-.hr { height: 1px; background: #e5e5e5; margin: 10px 0 16px; }
-
-/* جدول اقلام */
-table { width: 100%%; border-collapse: collapse; }
-th, td { border: 1px solid #cfcfcf; padding: 8px 10px; font-size: 13px; }
-th { background: #f5f5f5; font-weight: 700; }
-td.r { text-align: right; }
-td.c { text-align: center; }
-
-/* فوتر QR شبکه‌های اجتماعی (اختیاری) */
-.footer { margin-top: 16px; }
-.qr-list { display: flex; flex-wrap: wrap; gap: 12px; }
-.qr-card { width: 110px; text-align: center; }
-.qr-card img {
-  width: 110px; height: 110px; display: block;
-  border: 1px solid #eee; background: #fff; border-radius: 8px;
-}
-.qr-label { margin-top: 6px; font-size: 12px; color: #444; font-weight: 600; }
-</style>
-</head>
-<body>
-
-<!-- Header -->
-<div class="header">
-  <!-- Right: Logo + Name (هم‌ردیف و وسط‌چین عمودی) -->
-  <div class="right">
-    %s
-    <div class="shop-title">
-      <div class="name">%s</div>
-      <div class="sub">لیست قیمت فروشگاه</div>
-    </div>
-  </div>
-
-  <!-- Center: Site QR -->
-  <div class="center">
-    %s
-  </div>
-
-  <!-- Left: Date -->
-  <div class="left">
-    <!-- اگر خواستی یک آیکون تقویم SVG کوچک اینجا اضافه کن -->
-    <div class="date">%s</div>
-  </div>
-</div>
-
-<!-- Address & Phones -->
-<div class="meta">
-  <div class="row"><div class="label">آدرس:</div><div class="value">%s</div></div>
-  <div class="row"><div class="label">تلفن‌ها:</div><div class="value">%s</div></div>
-</div>
-
-<div class="hr"></div>
-
-<!-- Table -->
-<table>
-  <thead>
-    <tr>
-      <th class="c" style="width:12%%">ردیف</th>
-      <th class="r" style="width:48%%">نام محصول</th>
-      <th class="c" style="width:20%%">قیمت</th>
-      <th class="c" style="width:20%%">آخرین بروزرسانی</th>
-    </tr>
-  </thead>
-  <tbody>
-    %s
-  </tbody>
-</table>
-
-<!-- Footer (Social QR, only if URLs exist) -->
-<div class="footer">
-  <div class="qr-list">%s</div>
-</div>
-
-</body>
-</html>`,
-		// <title>
-		htmlEsc(shopName),
-		fontCSS,
-
-		// header.right → logo + title
-		logoHTML,
-		htmlEsc(shopName),
-
-		// header.center → site QR
-		siteQRHTML,
-
-		// header.left → date
-		htmlEsc(jalaliDateLong(now.(ptime.Time))),
-
-		// meta
-		htmlEsc(addr),
-		htmlEsc(phones),
-
-		// rows
-		rows.String(),
-
-		// socials
-		socialsQR.String(),
-	)
-}
-
 // ===================== هندلر اصلی: HTML → PDF با chromedp =====================
 
 // func (uph *UserProductHandler) FetchPriceListPDF(c *gin.Context) {
@@ -1248,136 +924,149 @@ td.c { text-align: center; }
 // }
 
 type adjustUserPricesRequest struct {
-	Percent decimal.Decimal `json:"percent" binding:"required"` // مثال: +10 یا -5
+	// برای سازگاری با نسخهٔ قبلی: اگر mode خالی باشد، percent روی همهٔ محصولات اعمال می‌شود
+	Percent decimal.NullDecimal         `json:"percent"` // مثال: +10 یا -5
+	Mode    domain.PriceAdjustmentMode  `json:"mode"`    // percent | amount
+	Value   decimal.NullDecimal         `json:"value"`
+	Scope   domain.PriceAdjustmentScope `json:"scope"`
+}
+
+func (r *adjustUserPricesRequest) toPriceAdjustment(userID int64) *domain.PriceAdjustment {
+	adjustment := &domain.PriceAdjustment{
+		UserID: userID,
+		Mode:   r.Mode,
+		Value:  r.Value.Decimal,
+		Scope:  r.Scope,
+	}
+
+	if adjustment.Mode == "" && r.Percent.Valid {
+		adjustment.Mode = domain.PriceAdjustmentPercent
+		adjustment.Value = r.Percent.Decimal
+	}
+
+	return adjustment
 }
 
 // internal/adapter/http/handler/user_product_handler.go
 
+// priceListFileRequest بدون shareLinkId، QR فایل به لینک عمومی خودکار همان قالب اشاره می‌کند
+type priceListFileRequest struct {
+	TemplateID  int64 `form:"templateId" example:"1"`
+	ShareLinkID int64 `form:"shareLinkId" example:"1"`
+}
+
 func (uph *UserProductHandler) FetchPriceListPDF(c *gin.Context) {
+	var req priceListFileRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
 	authPayload := httputil.GetAuthPayload(c)
 	currentUserID := authPayload.UserID
 
 	ctx := c.Request.Context()
-	raw, err := uph.service.GetPriceList(ctx, currentUserID)
+	raw, err := uph.service.GetPriceList(ctx, currentUserID, req.TemplateID)
 	if err != nil {
 		HandleError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
-	now := ptime.Now()
-	htmlStr := buildPriceListHTML(*raw, now)
-
-	// --- تضمین محیط‌های نوشتن‌پذیر برای Chrome/Crashpad ---
-	ensureDir := func(p string) { _ = os.MkdirAll(p, 0o777) }
-	home := os.Getenv("HOME")
-	if home == "" {
-		home = "/home/appuser" // مطابق Dockerfile
-	}
-	os.Setenv("HOME", home)
-	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
-	os.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
-	os.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
-
-	ensureDir(os.Getenv("XDG_CONFIG_HOME"))
-	ensureDir(os.Getenv("XDG_CACHE_HOME"))
-	ensureDir(os.Getenv("XDG_DATA_HOME"))
-
-	profileDir := "/tmp/chromedp-profile"
-	crashDir := "/tmp/chrome-crashpad"
-	ensureDir(profileDir)
-	ensureDir(crashDir)
-
-	execPath := os.Getenv("CHROMEDP_EXEC_PATH")
-	if execPath == "" {
-		execPath = "/usr/bin/chromium-browser"
-	}
-
-	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath(execPath),
-		// Headless: اگر لازم شد، "new" را به true تغییر بده
-		chromedp.Flag("headless", "new"),
-		// برای کانتینر
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-setuid-sandbox", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		// مسیر crash dumps (برخی بیلدها نیاز دارند)
-		chromedp.Flag("crash-dumps-dir", crashDir),
-		// خاموشی گزارشگر کرش (برای برخی نسخه‌ها کمک می‌کند)
-		chromedp.Flag("disable-crash-reporter", true),
-		chromedp.Flag("disable-breakpad", true),
-		// پروفایل قابل‌نوشتن
-		chromedp.UserDataDir(profileDir),
-		// اولین اجرا بی‌سروصدا
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("no-default-browser-check", true),
-	)
+	raw.ShareURL, err = uph.priceListShare.GetPriceListShareURL(ctx, currentUserID,
+		req.TemplateID, req.ShareLinkID)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	pdfBuf, err := uph.priceListRenderer.RenderPriceListPDF(ctx, raw)
+	if err != nil {
+		handlePriceListRenderError(c, err, uph.AppConfig.Lang)
+		return
+	}
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
-	defer allocCancel()
+	fileName := priceListFileName(raw.ShopInfo, domain.PriceListFormatPDF)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(200, "application/pdf", pdfBuf)
+}
 
-	chromeCtx, cancel := chromedp.NewContext(allocCtx)
-	defer cancel()
+type exportPriceListRequest struct {
+	Format      domain.PriceListFormat `form:"format"`
+	TemplateID  int64                  `form:"templateId" example:"1"`
+	ShareLinkID int64                  `form:"shareLinkId" example:"1"` // فقط برای QR تصویر
+}
 
-	// فقط یک بار
-	if err := chromedp.Run(chromeCtx); err != nil {
-		HandleError(c, fmt.Errorf("chrome boot error: %w", err), uph.AppConfig.Lang)
+// ExportPriceList لیست قیمت فروشگاه را به صورت xlsx، csv، png یا jpeg برمی‌گرداند
+func (uph *UserProductHandler) ExportPriceList(c *gin.Context) {
+	var req exportPriceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
-	timeoutCtx, timeoutCancel := context.WithTimeout(chromeCtx, 120*time.Second)
-	defer timeoutCancel()
+	if !domain.IsPriceListExportFormatValid(req.Format) {
+		HandleError(c, errors.New(msg.ErrPriceListFormatIsNotValid), uph.AppConfig.Lang)
+		return
+	}
 
-	var pdfBuf []byte
-	err = chromedp.Run(
-		timeoutCtx,
-		chromedp.Navigate("about:blank"),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			ft, err := page.GetFrameTree().Do(ctx)
-			if err != nil {
-				return err
-			}
-			return page.SetDocumentContent(ft.Frame.ID, htmlStr).Do(ctx)
-		}),
-		chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			return emulation.SetEmulatedMedia().WithMedia("screen").Do(ctx)
-		}),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			data, _, err := page.PrintToPDF().
-				WithPrintBackground(true).
-				WithPaperWidth(8.27).   // A4
-				WithPaperHeight(11.69). // A4
-				WithMarginTop(0.39).
-				WithMarginBottom(0.39).
-				WithMarginLeft(0.39).
-				WithMarginRight(0.39).
-				Do(ctx)
-			if err != nil {
-				return err
-			}
-			pdfBuf = data
-			return nil
-		}),
-	)
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+
+	ctx := c.Request.Context()
+	raw, err := uph.service.GetPriceList(ctx, currentUserID, req.TemplateID)
 	if err != nil {
-		HandleError(c, fmt.Errorf("pdf render error: %w", err), uph.AppConfig.Lang)
+		HandleError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
-	shopName := strings.TrimSpace(raw.ShopInfo.ShopPhone1)
+	if req.Format == domain.PriceListFormatPNG || req.Format == domain.PriceListFormatJPEG {
+		raw.ShareURL, err = uph.priceListShare.GetPriceListShareURL(ctx, currentUserID,
+			req.TemplateID, req.ShareLinkID)
+		if err != nil {
+			HandleError(c, err, uph.AppConfig.Lang)
+			return
+		}
+	}
+
+	file, err := uph.priceListExporter.ExportPriceList(ctx, raw, req.Format)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	fileName := priceListFileName(raw.ShopInfo, req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, req.Format.ContentType(), file)
+}
+
+func priceListFileName(shop *domain.User, format domain.PriceListFormat) string {
+	now := ptime.Now()
+	shopName := ""
+	if shop != nil {
+		shopName = strings.TrimSpace(shop.ShopPhone1)
+	}
 	if shopName == "" {
 		shopName = "shop"
 	}
-	fileName := fmt.Sprintf(
-		"price-list-%s-%04d%02d%02d.pdf",
+
+	return fmt.Sprintf(
+		"price-list-%s-%04d%02d%02d.%s",
 		strings.ReplaceAll(shopName, " ", "-"),
-		now.Year(), int(now.Month()), now.Day(),
+		now.Year(), int(now.Month()), now.Day(), format.Extension(),
 	)
+}
 
-	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
-	c.Data(200, "application/pdf", pdfBuf)
+// handlePriceListRenderError وقتی صف ساخت PDF پر است 503 با Retry-After برمی‌گرداند
+func handlePriceListRenderError(c *gin.Context, err error, lang string) {
+	if err.Error() != msg.ErrPriceListRendererBusy {
+		HandleError(c, err, lang)
+		return
+	}
+
+	errMsg, isTranslated := parseError(err, lang)
+	c.Header("Retry-After", "5")
+	c.JSON(http.StatusServiceUnavailable, newErrorResponse(errMsg, isTranslated))
 }
 
 func (uph *UserProductHandler) AdjustUserFinalPricesByPercent(c *gin.Context) {
@@ -1391,10 +1080,231 @@ func (uph *UserProductHandler) AdjustUserFinalPricesByPercent(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	if err := uph.service.AdjustUserFinalPricesByPercent(ctx, currentUserID, req.Percent); err != nil {
+	result, err := uph.service.AdjustPrices(ctx, req.toPriceAdjustment(currentUserID))
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, result)
+}
+
+func (uph *UserProductHandler) PreviewPriceAdjustment(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+	var req adjustUserPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	preview, err := uph.service.PreviewPriceAdjustment(ctx, req.toPriceAdjustment(currentUserID))
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, preview)
+}
+
+func (uph *UserProductHandler) UndoLastPriceAdjustment(c *gin.Context) {
+	authPayload := httputil.GetAuthPayload(c)
+	currentUserID := authPayload.UserID
+
+	ctx := c.Request.Context()
+
+	result, err := uph.service.UndoLastPriceAdjustment(ctx, currentUserID)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, result)
+}
+
+type fetchPriceDeviationFlagsRequest struct {
+	Reviewed *bool `form:"reviewed"`
+	Page     int   `form:"page"`
+	PageSize int   `form:"page_size"`
+}
+
+func (uph *UserProductHandler) FetchPriceDeviationFlags(c *gin.Context) {
+	var req fetchPriceDeviationFlagsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := uph.service.GetPriceDeviationFlags(ctx,
+		domain.PriceDeviationFlagFilter{Reviewed: req.Reviewed},
+		pagination.Pagination{Page: req.Page, PageSize: req.PageSize})
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, result)
+}
+
+type reviewPriceDeviationFlagRequest struct {
+	ID int64 `uri:"id" example:"1"`
+}
+
+func (uph *UserProductHandler) ReviewPriceDeviationFlag(c *gin.Context) {
+	var req reviewPriceDeviationFlagRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	err := uph.service.ReviewPriceDeviationFlag(ctx, req.ID, authPayload.UserID)
+	if err != nil {
 		HandleError(c, err, uph.AppConfig.Lang)
 		return
 	}
 
 	handleSuccess(c, nil)
 }
+
+type confirmPricesStillValidRequest struct {
+	IDs []int64 `json:"ids"` // خالی → همهٔ محصولات فروشگاه
+}
+
+type confirmPricesStillValidResponse struct {
+	ConfirmedCount int64 `json:"confirmedCount" example:"12"`
+}
+
+func (uph *UserProductHandler) ConfirmPricesStillValid(c *gin.Context) {
+	var req confirmPricesStillValidRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	count, err := uph.service.ConfirmPricesStillValid(ctx, authPayload.UserID, req.IDs)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, confirmPricesStillValidResponse{ConfirmedCount: count})
+}
+
+type competitivePositionRequest struct {
+	CategoryID int64                          `form:"category_id"`
+	BrandIDs   []int64                        `form:"brand_ids"`
+	SortBy     domain.CompetitivePositionSort `form:"sort_by" example:"city_rank"`
+	SortDir    domain.SortDir                 `form:"sort_dir" example:"desc"`
+	Page       int                            `form:"page"`
+	PageSize   int                            `form:"page_size"`
+}
+
+func (req competitivePositionRequest) toFilter(userID int64) *domain.CompetitivePositionFilter {
+	return &domain.CompetitivePositionFilter{
+		UserID:     userID,
+		CategoryID: req.CategoryID,
+		BrandIDs:   req.BrandIDs,
+		SortBy:     req.SortBy,
+		SortDir:    req.SortDir,
+	}
+}
+
+func (uph *UserProductHandler) FetchCompetitivePositions(c *gin.Context) {
+	var req competitivePositionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	result, err := uph.service.GetCompetitivePositions(ctx, req.toFilter(authPayload.UserID),
+		pagination.Pagination{Page: req.Page, PageSize: req.PageSize})
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, result)
+}
+
+// ExportCompetitivePositions خروجی CSV گزارش جایگاه رقابتی
+func (uph *UserProductHandler) ExportCompetitivePositions(c *gin.Context) {
+	var req competitivePositionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	positions, err := uph.service.ExportCompetitivePositions(ctx, req.toFilter(authPayload.UserID))
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeCompetitivePositionsCSV(&buf, positions); err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	now := ptime.Now()
+	fileName := fmt.Sprintf("competitive-position-%04d%02d%02d.csv",
+		now.Year(), int(now.Month()), now.Day())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// writeCompetitivePositionsCSV ردیف‌های گزارش را با BOM برای نمایش درست فارسی در اکسل می‌نویسد
+func writeCompetitivePositionsCSV(out io.Writer, positions []*domain.CompetitivePosition) error {
+	if _, err := io.WriteString(out, "\uFEFF"); err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(out)
+	err := w.Write([]string{
+		"مدل", "دسته", "برند", "قیمت من",
+		"کمترین قیمت شهر", "میانه قیمت شهر", "تعداد فروشگاه شهر", "رتبه در شهر", "ارزان‌تر از من در شهر",
+		"کمترین قیمت کشور", "میانه قیمت کشور", "تعداد فروشگاه کشور", "رتبه در کشور", "ارزان‌تر از من در کشور",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range positions {
+		err = w.Write([]string{
+			p.ModelName, p.CategoryTitle, p.BrandTitle, p.MyPrice.StringFixed(0),
+			nullDecimalString(p.CityMinPrice), nullDecimalString(p.CityMedianPrice),
+			strconv.Itoa(p.CityShopsCount), strconv.Itoa(p.CityRank), strconv.Itoa(p.CityUndercutCount),
+			nullDecimalString(p.NationalMinPrice), nullDecimalString(p.NationalMedianPrice),
+			strconv.Itoa(p.NationalShopsCount), strconv.Itoa(p.NationalRank),
+			strconv.Itoa(p.NationalUndercutCount),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func nullDecimalString(d decimal.NullDecimal) string {
+	if !d.Valid {
+		return ""
+	}
+	return d.Decimal.StringFixed(0)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/shopspring/decimal"
+)
+
+type failingWriter struct {
+	after int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.after <= 0 {
+		return 0, errors.New("disk full")
+	}
+	w.after--
+	return len(p), nil
+}
+
+func TestWriteCompetitivePositionsCSV(t *testing.T) {
+	positions := []*domain.CompetitivePosition{{
+		ModelName:      "A15",
+		MyPrice:        decimal.NewFromInt(1200000),
+		CityMinPrice:   decimal.NullDecimal{Decimal: decimal.NewFromInt(1100000), Valid: true},
+		CityShopsCount: 3,
+		CityRank:       2,
+	}}
+
+	var buf bytes.Buffer
+	if err := writeCompetitivePositionsCSV(&buf, positions); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "\uFEFF") {
+		t.Fatal("missing BOM")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\uFEFF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "A15" || records[1][3] != "1200000" || records[1][5] != "" {
+		t.Fatalf("unexpected records: %q", records)
+	}
+}
+
+func TestWriteCompetitivePositionsCSVReturnsWriteErrors(t *testing.T) {
+	for after := 0; after < 2; after++ {
+		err := writeCompetitivePositionsCSV(&failingWriter{after: after}, []*domain.CompetitivePosition{{}})
+		if err == nil {
+			t.Fatalf("write error after %d writes was swallowed", after)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	httputil "github.com/nerkhin/internal/adapter/handler/http/helper"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/domain/translate"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	ptime "github.com/yaa110/go-persian-calendar"
+)
+
+// ستون‌های فایل قیمت فروشگاه؛ عنوان‌ها بدون حساسیت به حروف، فاصله و نیم‌فاصله تطبیق داده می‌شوند
+const (
+	importColID          = "id"
+	importColProductID   = "productId"
+	importColBrand       = "brand"
+	importColModel       = "model"
+	importColSKU         = "sku"
+	importColIsDollar    = "isDollar"
+	importColDollarPrice = "dollarPrice"
+	importColOtherCosts  = "otherCosts"
+	importColFinalPrice  = "finalPrice"
+	importColIsHidden    = "isHidden"
+	importColOrder       = "order"
+	importColVersion     = "version"
+)
+
+var userProductImportHeaders = map[string]string{
+	"شناسه":           importColID,
+	"id":              importColID,
+	"user product id": importColID,
+	"شناسه محصول":     importColProductID,
+	"کد محصول":        importColProductID,
+	"product id":      importColProductID,
+	"productid":       importColProductID,
+	"برند":            importColBrand,
+	"brand":           importColBrand,
+	"مدل":             importColModel,
+	"model":           importColModel,
+	"کد کالا":         importColSKU,
+	"بارکد":           importColSKU,
+	"sku":             importColSKU,
+	"barcode":         importColSKU,
+	"دلاری":           importColIsDollar,
+	"is dollar":       importColIsDollar,
+	"isdollar":        importColIsDollar,
+	"قیمت دلاری":      importColDollarPrice,
+	"dollar price":    importColDollarPrice,
+	"dollarprice":     importColDollarPrice,
+	"هزینه های جانبی": importColOtherCosts,
+	"سایر هزینه ها":   importColOtherCosts,
+	"other costs":     importColOtherCosts,
+	"othercosts":      importColOtherCosts,
+	"قیمت":            importColFinalPrice,
+	"قیمت نهایی":      importColFinalPrice,
+	"price":           importColFinalPrice,
+	"final price":     importColFinalPrice,
+	"finalprice":      importColFinalPrice,
+	"مخفی":            importColIsHidden,
+	"hidden":          importColIsHidden,
+	"is hidden":       importColIsHidden,
+	"ishidden":        importColIsHidden,
+	"ترتیب":           importColOrder,
+	"order":           importColOrder,
+	"نسخه":            importColVersion,
+	"version":         importColVersion,
+}
+
+// userProductExportHeaders عنوان ستون‌های خروجی که ورود دوباره همان‌ها را می‌شناسد؛
+// «دسته» فقط برای خواندن است و هنگام ورود نادیده گرفته می‌شود
+var userProductExportHeaders = []string{
+	"شناسه", "شناسه محصول", "کد کالا", "دسته", "برند", "مدل", "دلاری", "قیمت دلاری",
+	"هزینه‌های جانبی", "قیمت نهایی", "مخفی", "ترتیب", "نسخه",
+}
+
+// POST /user-product/import
+// multipart/form-data: file=<csv|xlsx>
+// اختیاری: dryRun، createMissing، createHidden و confirmPriceDeviation (true|false، پیش‌فرض false)
+func (uph *UserProductHandler) ImportUserProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+	authPayload := httputil.GetAuthPayload(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		validationError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	records, err := readImportSpreadsheet(fileHeader)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	rows, err := parseUserProductImportRows(records)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	options := domain.UserProductImportOptions{
+		DryRun:                importFlag(c, "dryRun"),
+		CreateMissing:         importFlag(c, "createMissing"),
+		CreateHidden:          importFlag(c, "createHidden"),
+		ConfirmPriceDeviation: importFlag(c, "confirmPriceDeviation"),
+	}
+
+	result, err := uph.service.ImportUserProducts(ctx, authPayload.UserID, rows, options)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	for _, row := range result.Rows {
+		if row.Error != "" {
+			row.Error, _ = translate.Translate(uph.AppConfig.Lang, row.Error)
+		}
+	}
+
+	handleSuccess(c, result)
+}
+
+// GET /user-product/export?format=xlsx|csv
+// همهٔ محصولات فروشگاه با شناسه و نسخهٔ ردیف؛ همین فایل پس از ویرایش به /user-product/import
+// فرستاده می‌شود و فقط خانه‌های تغییرکرده اعمال می‌شوند
+func (uph *UserProductHandler) ExportUserProducts(c *gin.Context) {
+	format := domain.PriceListFormat(strings.ToLower(c.DefaultQuery("format",
+		string(domain.PriceListFormatXLSX))))
+	if format != domain.PriceListFormatXLSX && format != domain.PriceListFormatCSV {
+		validationError(c, errors.New(msg.ErrPriceListFormatIsNotValid), uph.AppConfig.Lang)
+		return
+	}
+
+	authPayload := httputil.GetAuthPayload(c)
+
+	ctx := c.Request.Context()
+	products, err := uph.service.ExportUserProducts(ctx, authPayload.UserID)
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	records := make([][]any, 0, len(products))
+	for _, p := range products {
+		records = append(records, []any{
+			p.ID, p.ProductID, p.SKUValue(), p.ProductCategory, p.ProductBrand, p.ModelName,
+			importBoolText(p.IsDollar), exportPrice(p.DollarPrice),
+			exportPrice(p.OtherCosts), p.FinalPrice,
+			importBoolText(p.IsHidden), p.Order, p.Version,
+		})
+	}
+
+	var file []byte
+	if format == domain.PriceListFormatCSV {
+		file, err = writeExportCSV(userProductExportHeaders, records)
+	} else {
+		file, err = writeExportXLSX(userProductExportHeaders, records)
+	}
+	if err != nil {
+		HandleError(c, err, uph.AppConfig.Lang)
+		return
+	}
+
+	now := ptime.Now()
+	fileName := fmt.Sprintf("products-%04d%02d%02d.%s",
+		now.Year(), int(now.Month()), now.Day(), format.Extension())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, format.ContentType(), file)
+}
+
+func exportPrice(price decimal.NullDecimal) any {
+	if !price.Valid {
+		return ""
+	}
+	return price.Decimal
+}
+
+func importBoolText(value bool) string {
+	if value {
+		return "بله"
+	}
+	return "خیر"
+}
+
+// writeExportCSV با BOM برای نمایش درست فارسی در اکسل
+func writeExportCSV(headers []string, records [][]any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	_ = w.Write(headers)
+	for _, record := range records {
+		line := make([]string, len(record))
+		for i, value := range record {
+			line[i] = fmt.Sprint(value)
+		}
+		_ = w.Write(line)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// writeExportXLSX شیت راست‌به‌چپ؛ مبالغ به‌صورت عدد نوشته می‌شوند تا در اکسل قابل ویرایش باشند
+func writeExportXLSX(headers []string, records [][]any) (file []byte, err error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	rtl := true
+	if err = f.SetSheetView(sheet, 0, &excelize.ViewOptions{RightToLeft: &rtl}); err != nil {
+		return
+	}
+
+	header := make([]any, len(headers))
+	for i, h := range headers {
+		header[i] = h
+	}
+	if err = f.SetSheetRow(sheet, "A1", &header); err != nil {
+		return
+	}
+
+	for i, record := range records {
+		row := make([]any, len(record))
+		for j, value := range record {
+			row[j] = value
+			if price, ok := value.(decimal.Decimal); ok {
+				row[j], _ = price.Float64()
+			}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err = f.SetSheetRow(sheet, cell, &row); err != nil {
+			return
+		}
+	}
+
+	if err = f.SetPanes(sheet, &excelize.Panes{
+		Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft",
+	}); err != nil {
+		return
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return
+	}
+	return buf.Bytes(), nil
+}
+
+func importFlag(c *gin.Context, key string) bool {
+	value, _ := parseImportBool(c.DefaultPostForm(key, c.Query(key)))
+	return value != nil && *value
+}
+
+// readImportSpreadsheet ردیف‌های فایل CSV یا اولین شیت فایل XLSX را برمی‌گرداند
+func readImportSpreadsheet(fileHeader *multipart.FileHeader) (records [][]string, err error) {
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext != ".csv" && ext != ".xlsx" {
+		return nil, errors.New(msg.ErrUserProductImportFileIsNotValid)
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if ext == ".csv" {
+		reader := csv.NewReader(bomAwareReader{r: f})
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		records, err = reader.ReadAll()
+		if err != nil {
+			return nil, errors.New(msg.ErrUserProductImportFileIsNotValid)
+		}
+		return records, nil
+	}
+
+	book, err := excelize.OpenReader(f)
+	if err != nil {
+		return nil, errors.New(msg.ErrUserProductImportFileIsNotValid)
+	}
+	defer book.Close()
+
+	sheets := book.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New(msg.ErrUserProductImportIsEmpty)
+	}
+
+	return book.GetRows(sheets[0])
+}
+
+// parseUserProductImportRows ردیف اول را عنوان ستون‌ها در نظر می‌گیرد. خانه‌های نامعتبر خطای
+// ردیف می‌شوند تا بقیهٔ فایل پردازش شود؛ ردیف‌های کاملا خالی نادیده گرفته می‌شوند.
+func parseUserProductImportRows(records [][]string) (rows []*domain.UserProductImportRow, err error) {
+	if len(records) < 2 {
+		return nil, errors.New(msg.ErrUserProductImportIsEmpty)
+	}
+
+	idx := map[string]int{}
+	for i, title := range records[0] {
+		col, ok := userProductImportHeaders[normalizeImportHeader(title)]
+		if _, seen := idx[col]; ok && !seen {
+			idx[col] = i
+		}
+	}
+
+	column := func(record []string, col string) string {
+		i, ok := idx[col]
+		if !ok {
+			return ""
+		}
+		return safeGet(record, i)
+	}
+
+	_, hasID := idx[importColID]
+	_, hasSKU := idx[importColSKU]
+	_, hasProductID := idx[importColProductID]
+	_, hasBrand := idx[importColBrand]
+	_, hasModel := idx[importColModel]
+	if !hasID && !hasSKU && !hasProductID && !(hasBrand && hasModel) {
+		return nil, errors.New(msg.ErrUserProductImportColumnsAreMissing)
+	}
+
+	for i, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := &domain.UserProductImportRow{
+			Row:   i + 2,
+			Brand: column(record, importColBrand),
+			Model: column(record, importColModel),
+			SKU:   domain.NormalizeSKU(column(record, importColSKU)),
+		}
+		valid := true
+
+		var ok bool
+		row.UserProductID, ok = parseImportID(column(record, importColID))
+		valid = valid && ok
+		row.ProductID, ok = parseImportID(column(record, importColProductID))
+		valid = valid && ok
+		row.Version, ok = parseImportID(column(record, importColVersion))
+		valid = valid && ok
+		if raw := column(record, importColOrder); raw != "" {
+			order, ok := parseImportID(raw)
+			valid = valid && ok
+			row.Order = &order
+		}
+
+		row.DollarPrice, ok = parseImportPrice(column(record, importColDollarPrice))
+		valid = valid && ok
+		row.OtherCosts, ok = parseImportPrice(column(record, importColOtherCosts))
+		valid = valid && ok
+		row.FinalPrice, ok = parseImportPrice(column(record, importColFinalPrice))
+		valid = valid && ok
+		row.IsDollar, ok = parseImportBool(column(record, importColIsDollar))
+		valid = valid && ok
+		row.IsHidden, ok = parseImportBool(column(record, importColIsHidden))
+		valid = valid && ok
+
+		if !valid {
+			row.ParseError = msg.ErrUserProductImportValueIsNotValid
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseImportID عدد صحیح مثبت یا خانهٔ خالی (صفر) را می‌پذیرد
+func parseImportID(raw string) (id int64, ok bool) {
+	raw = normalizeDigits(raw)
+	if raw == "" {
+		return 0, true
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+	return id, true
+}
+
+func normalizeImportHeader(title string) string {
+	title = strings.NewReplacer(
+		"\u200c", " ", "_", " ", "-", " ", "ي", "ی", "ك", "ک",
+	).Replace(strings.ToLower(title))
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// parseImportPrice ارقام فارسی و جداکنندهٔ هزارگان را می‌پذیرد؛ خانهٔ خالی یعنی بدون مقدار
+func parseImportPrice(raw string) (price decimal.NullDecimal, ok bool) {
+	raw = strings.NewReplacer(",", "", "٬", "", "،", "", " ", "", "٫", ".").
+		Replace(normalizeDigits(raw))
+	if raw == "" {
+		return price, true
+	}
+
+	value, err := decimal.NewFromString(raw)
+	if err != nil || value.IsNegative() {
+		return price, false
+	}
+
+	return decimal.NullDecimal{Decimal: value, Valid: true}, true
+}
+
+func parseImportBool(raw string) (value *bool, ok bool) {
+	var b bool
+	switch strings.ToLower(normalizeDigits(raw)) {
+	case "":
+		return nil, true
+	case "1", "true", "yes", "y", "بله", "بلی", "آری", "✓":
+		b = true
+	case "0", "false", "no", "n", "خیر", "نه":
+		b = false
+	default:
+		return nil, false
+	}
+	return &b, true
+}
@@ -126,6 +126,27 @@ func (ush *UserSubscriptionHandler) FetchPaymentTransactionsHistory(c *gin.Conte
 	handleSuccess(c, paymentTransactions)
 }
 
+type userPaymentTransactionsRequest struct {
+	UserID int64 `uri:"userId" binding:"required,min=1"`
+}
+
+// FetchUserPaymentTransactions تاریخچهٔ تراکنش‌های پرداخت یک کاربر برای ادمین‌های دارای دسترسی payments
+func (ush *UserSubscriptionHandler) FetchUserPaymentTransactions(c *gin.Context) {
+	var req userPaymentTransactionsRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		validationError(c, err, ush.AppConfig.Lang)
+		return
+	}
+
+	paymentTransactions, err := ush.service.FetchUserPaymentTransactionsHistory(c.Request.Context(), req.UserID)
+	if err != nil {
+		HandleError(c, err, ush.AppConfig.Lang)
+		return
+	}
+
+	handleSuccess(c, paymentTransactions)
+}
+
 func (ush *UserSubscriptionHandler) FetchUserSubscription(c *gin.Context) {
 	authPayload := httputil.GetAuthPayload(c)
 	currentUserId := authPayload.UserID
@@ -2,6 +2,9 @@ package handler
 
 import (
 	"errors"
+	"math"
+	"strconv"
+	"time"
 
 	"mime/multipart"
 	"net/http"
@@ -26,6 +29,8 @@ var errorStatusMap = map[string]int{
 	msg.ErrInvalidAuthorizationHeader:            http.StatusUnauthorized,
 	msg.ErrInvalidToken:                          http.StatusUnauthorized,
 	msg.ErrExpiredToken:                          http.StatusUnauthorized,
+	msg.ErrRefreshTokenReused:                    http.StatusUnauthorized,
+	msg.ErrSessionIsRevoked:                      http.StatusUnauthorized,
 	msg.ErrOperationNotAllowedForThisUser:        http.StatusForbidden,
 	msg.ErrOperationNotAllowedForNonApprovedUser: http.StatusForbidden,
 }
@@ -66,6 +71,18 @@ func HandleAbort(c *gin.Context, err error, lang string) {
 	c.AbortWithStatusJSON(statusCode, errRsp)
 }
 
+// HandleTooManyRequests درخواست را با 429 و هدر Retry-After (به ثانیه، دست‌کم یک) متوقف می‌کند
+func HandleTooManyRequests(c *gin.Context, err error, retryAfter time.Duration, lang string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	errMsg, isTranslated := parseError(err, lang)
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, newErrorResponse(errMsg, isTranslated))
+}
+
 func parseError(err error, lang string) (errMessage string, isTranslated bool) {
 	errMessage, isTranslated = translate.Translate(lang, err.Error())
 	return errMessage, isTranslated
@@ -11,13 +11,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/adapter/ratelimit"
+	"github.com/nerkhin/internal/core/port"
 
 	// مسیرهای صحیح به پکیج‌های AddRoutes شما
+	"github.com/nerkhin/internal/adapter/handler/http/routes/adminrole"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/audit"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/auth"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/city"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/favoriteaccount"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/favoriteproduct"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/impersonation"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/landing"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/loginevent"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/notification"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/pricelistshare"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/pricelisttemplate"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/product"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/productbrand"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/productcategory"
@@ -26,7 +36,10 @@ import (
 	"github.com/nerkhin/internal/adapter/handler/http/routes/productmodel"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/productrequest"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/report"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/roundingrule"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/stalenesspolicy"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/subscription"
+	"github.com/nerkhin/internal/adapter/handler/http/routes/totp"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/user"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/userproduct"
 	"github.com/nerkhin/internal/adapter/handler/http/routes/usersubscription"
@@ -40,6 +53,8 @@ type Router struct {
 
 func NewRouter(
 	httpConfig config.HTTPConfig,
+	rateLimiter *ratelimit.Limiter,
+	auditService port.AuditService,
 	cityHandler *handler.CityHandler,
 	productModelHandler *handler.ProductModelHandler,
 	productBrandHandler *handler.ProductBrandHandler,
@@ -57,6 +72,16 @@ func NewRouter(
 	favoriteAccountHandler *handler.FavoriteAccountHandler,
 	productFilterImportHandler *handler.ProductFilterImportHandler,
 	landingHandler *handler.LandingHandler,
+	roundingRuleHandler *handler.RoundingRuleHandler,
+	notificationHandler *handler.NotificationHandler,
+	stalenessPolicyHandler *handler.StalenessPolicyHandler,
+	priceListTemplateHandler *handler.PriceListTemplateHandler,
+	priceListShareHandler *handler.PriceListShareHandler,
+	loginEventHandler *handler.LoginEventHandler,
+	adminRoleHandler *handler.AdminRoleHandler,
+	auditHandler *handler.AuditHandler,
+	impersonationHandler *handler.ImpersonationHandler,
+	totpHandler *handler.TOTPHandler,
 ) (*Router, error) {
 	if httpConfig.Env == "production" || httpConfig.Env == "staging" {
 		gin.SetMode(gin.ReleaseMode)
@@ -67,6 +92,16 @@ func NewRouter(
 	router.Use(gin.RecoveryWithWriter(os.Stderr))
 	router.MaxMultipartMemory = 8 << 20
 
+	// بدون این تنظیم gin به X-Forwarded-For هر کلاینتی اعتماد می‌کند و محدودیت IP دور زده می‌شود؛
+	// پیش‌فرض هیچ proxy مورد اعتماد نیست و IP اتصال ملاک است
+	var trustedProxies []string
+	if httpConfig.TrustedProxies != "" {
+		trustedProxies = strings.Split(httpConfig.TrustedProxies, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
 	corsConfig := cors.DefaultConfig()
 
 	if httpConfig.AllowedOrigins != "" {
@@ -78,28 +113,41 @@ func NewRouter(
 
 	corsConfig.AllowCredentials = true
 
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "Accept"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "Accept", "X-Price-List-Pin"}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"}
 
 	router.Use(cors.New(corsConfig))
+	rateLimit := middleware.NewRateLimit(rateLimiter, httpConfig.Lang)
 	api := router.Group("/api/go")
-	product.AddRoutes(api, productHandler)
+	api.Use(rateLimit.ByIP(ratelimit.PolicyAPI))
+	api.Use(middleware.Audit(auditService, auditHandler.AppConfig))
+	product.AddRoutes(api, productHandler, rateLimit)
 	productmodel.AddRoutes(api, productModelHandler)
 	productcategory.AddRoutes(api, productCategoryHandler)
 	productbrand.AddRoutes(api, productBrandHandler)
 	city.AddRoutes(api, cityHandler)
 	productrequest.AddRoutes(api, productRequestHandler)
-	userproduct.AddRoutes(api, userProduct)
+	userproduct.AddRoutes(api, userProduct, rateLimit)
 	subscription.AddRoutes(api, subscriptionHandler)
 	report.AddRoutes(api, reportHandler)
 	user.AddRoutes(api, userHandler)
-	auth.AddRoutes(api, authHandler, userHandler)
+	auth.AddRoutes(api, authHandler, userHandler, rateLimit)
 	productfilter.AddRoutes(api, productFilterHandler)
 	favoriteproduct.AddRoutes(api, favoriteProductHandler)
 	favoriteaccount.AddRoutes(api, favoriteAccountHandler)
 	usersubscription.AddRoutes(api, userSubscriptionHandler)
 	landing.AddRoutes(api, landingHandler)
 	productfilterroute.AddRoutes(api, productFilterImportHandler)
+	roundingrule.AddRoutes(api, roundingRuleHandler)
+	notification.AddRoutes(api, notificationHandler)
+	stalenesspolicy.AddRoutes(api, stalenessPolicyHandler)
+	pricelisttemplate.AddRoutes(api, priceListTemplateHandler)
+	pricelistshare.AddRoutes(api, priceListShareHandler, rateLimit)
+	loginevent.AddRoutes(api, loginEventHandler)
+	adminrole.AddRoutes(api, adminRoleHandler)
+	audit.AddRoutes(api, auditHandler)
+	impersonation.AddRoutes(api, impersonationHandler)
+	totp.AddRoutes(api, totpHandler)
 
 	return &Router{
 		Engine: router, // برگرداندن Router که gin.Engine را در خود دارد
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/audit"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type fakeAuditService struct {
+	port.AuditService
+	logs []*domain.AuditLog
+}
+
+func (s *fakeAuditService) RecordAuditLog(_ context.Context, log *domain.AuditLog) error {
+	s.logs = append(s.logs, log)
+	return nil
+}
+
+type fakeTokenService struct {
+	port.TokenService
+	payloads map[string]*domain.TokenPayload
+}
+
+func (ts *fakeTokenService) VerifyAccessToken(tokenString string) (*domain.TokenPayload, error) {
+	payload, ok := ts.payloads[tokenString]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return payload, nil
+}
+
+func (*fakeTokenService) ValidateSession(context.Context, *domain.TokenPayload) error {
+	return nil
+}
+
+func TestAuditRecordsByMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	appConfig := config.App{Audit: config.AuditConfig{Enabled: true, MaxBodyBytes: 1024}}
+	tokens := &fakeTokenService{payloads: map[string]*domain.TokenPayload{
+		"admin": {UserID: 1, UserRole: domain.Admin},
+		"shop":  {UserID: 2, UserRole: domain.Wholesaler},
+	}}
+
+	tests := []struct {
+		name    string
+		token   string
+		method  string
+		path    string
+		body    string
+		wantLog bool
+	}{
+		{name: "admin change", token: "admin", method: http.MethodPost, path: "/api/go/user/change-state",
+			body: `{"userId":5,"code":"123456"}`, wantLog: true},
+		// نام مسیر تصمیم نمی‌گیرد؛ POST با پیشوند fetch هم ثبت می‌شود
+		{name: "admin post named fetch", token: "admin", method: http.MethodPost,
+			path: "/api/go/user/fetch-users", body: `{}`, wantLog: true},
+		{name: "admin delete", token: "admin", method: http.MethodDelete, path: "/api/go/user/delete/5",
+			wantLog: true},
+		{name: "admin read", token: "admin", method: http.MethodGet, path: "/api/go/user/fetch-users"},
+		{name: "shop change", token: "shop", method: http.MethodPost, path: "/api/go/user/change-state",
+			body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditService := &fakeAuditService{}
+			var requestID string
+
+			router := gin.New()
+			router.Use(Audit(auditService, appConfig))
+			group := router.Group("/api/go/user", AuthMiddleware(tokens, appConfig))
+			record := func(c *gin.Context) {
+				requestID = audit.RequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			}
+			group.POST("/change-state", record)
+			group.POST("/fetch-users", record)
+			group.GET("/fetch-users", record)
+			group.DELETE("/delete/:userId", record)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.body != "" {
+				req.Header.Set("Content-Type", gin.MIMEJSON)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tt.wantLog {
+				if len(auditService.logs) != 0 || requestID != "" {
+					t.Fatalf("logs = %d, request id %q; want nothing recorded", len(auditService.logs), requestID)
+				}
+				return
+			}
+
+			if len(auditService.logs) != 1 {
+				t.Fatalf("logs = %d, want 1", len(auditService.logs))
+			}
+			log := auditService.logs[0]
+			if requestID == "" || log.RequestID.String() != requestID {
+				t.Errorf("request id in handler = %q, log = %s", requestID, log.RequestID)
+			}
+			if log.ActorID != 1 || log.Method != tt.method || log.EntityType != "user" {
+				t.Errorf("log = %+v", log)
+			}
+			if log.RequestBody != nil && strings.Contains(string(*log.RequestBody), "123456") {
+				t.Errorf("body kept a sensitive field: %s", *log.RequestBody)
+			}
+		})
+	}
+}
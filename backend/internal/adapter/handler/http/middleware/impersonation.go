@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+)
+
+const allowReadOnlyImpersonationKey = "allow_read_only_impersonation"
+
+// AllowReadOnlyImpersonation مسیرهای گروه را از محدودیت نشست فقط‌خواندنی معاف می‌کند؛
+// باید قبل از AuthMiddleware بیاید، مثلا برای پایان دادن به همان نشست
+func AllowReadOnlyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(allowReadOnlyImpersonationKey, true)
+		c.Next()
+	}
+}
+
+// blockReadOnlyImpersonation در نشست جعل هویت فقط‌خواندنی هر درخواستی جز GET را رد می‌کند
+func blockReadOnlyImpersonation(c *gin.Context, payload *domain.TokenPayload, lang string) bool {
+	if !payload.ImpersonationReadOnly || c.GetBool(allowReadOnlyImpersonationKey) {
+		return false
+	}
+
+	if c.Request.Method == http.MethodGet {
+		return false
+	}
+
+	handler.HandleAbort(c, errors.New(msg.ErrImpersonationIsReadOnly), lang)
+	return true
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func TestReadOnlyImpersonationAllowsOnlyGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	appConfig := config.App{}
+	tokens := &fakeTokenService{payloads: map[string]*domain.TokenPayload{
+		"read-only": {UserID: 2, UserRole: domain.Wholesaler, ImpersonationReadOnly: true},
+		"full":      {UserID: 2, UserRole: domain.Wholesaler},
+	}}
+
+	tests := []struct {
+		name    string
+		token   string
+		method  string
+		path    string
+		wantRan bool
+	}{
+		{name: "read-only get", token: "read-only", method: http.MethodGet, path: "/api/go/user/me", wantRan: true},
+		{name: "read-only head", token: "read-only", method: http.MethodHead, path: "/api/go/user/me"},
+		{name: "read-only options", token: "read-only", method: http.MethodOptions, path: "/api/go/user/me"},
+		{name: "read-only post", token: "read-only", method: http.MethodPost, path: "/api/go/user/update"},
+		{name: "read-only end session", token: "read-only", method: http.MethodPost,
+			path: "/api/go/impersonation/end", wantRan: true},
+		{name: "full post", token: "full", method: http.MethodPost, path: "/api/go/user/update", wantRan: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			record := func(c *gin.Context) {
+				ran = true
+				c.Status(http.StatusOK)
+			}
+
+			router := gin.New()
+			group := router.Group("/api/go/user", AuthMiddleware(tokens, appConfig))
+			group.GET("/me", record)
+			group.HEAD("/me", record)
+			group.OPTIONS("/me", record)
+			group.POST("/update", record)
+			router.Group("/api/go/impersonation", AllowReadOnlyImpersonation(), AuthMiddleware(tokens, appConfig)).
+				POST("/end", record)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if ran != tt.wantRan {
+				t.Errorf("handler ran = %v, want %v", ran, tt.wantRan)
+			}
+		})
+	}
+}
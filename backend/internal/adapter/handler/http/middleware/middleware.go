@@ -58,8 +58,18 @@ func AuthMiddleware(token port.TokenService, appConfig config.App) gin.HandlerFu
 			return
 		}
 
+		if err := token.ValidateSession(c, payload); err != nil {
+			handler.HandleAbort(c, err, appConfig.Lang)
+			return
+		}
+
 		c.Set(httputil.AuthPayloadKey, payload)
 		c.Set("user_id", payload.UserID)
+		beginAudit(c, payload, appConfig.Audit.MaxBodyBytes)
+
+		if blockReadOnlyImpersonation(c, payload, appConfig.Lang) {
+			return
+		}
 
 		c.Next()
 	}
@@ -85,6 +95,32 @@ func AdminMiddleware(token port.TokenService, appConfig config.App) gin.HandlerF
 	}
 }
 
+// RequirePermission مسیر را به ادمین‌هایی محدود می‌کند که دسترسی permission در توکنشان ثبت شده است؛
+// جای AdminMiddleware می‌نشیند و باید بعد از AuthMiddleware بیاید
+func RequirePermission(token port.TokenService, appConfig config.App,
+	permission domain.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := httputil.GetAuthPayload(ctx)
+		if payload == nil {
+			handler.HandleAbort(ctx, errors.New(msg.ErrUnauthorized), appConfig.Lang)
+			return
+		}
+
+		isAdmin := payload.UserRole == domain.SuperAdmin || payload.UserRole == domain.Admin
+		if !isAdmin {
+			handler.HandleAbort(ctx, errors.New(msg.ErrOperationNotAllowedForThisUser), appConfig.Lang)
+			return
+		}
+
+		if !payload.HasPermission(permission) {
+			handler.HandleAbort(ctx, errors.New(msg.ErrPermissionDenied), appConfig.Lang)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
 func ApprovedUserMiddleware(token port.TokenService, appConfig config.App) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
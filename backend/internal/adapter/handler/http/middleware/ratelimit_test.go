@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestPhone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, phone := range []string{"09121234567", "+989121234567", "00989121234567", "۰۹۱۲۱۲۳۴۵۶۷"} {
+		body := `{"phone":"` + phone + `"}`
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+
+		if got := requestPhone(c); got != "9121234567" {
+			t.Errorf("requestPhone(%q) = %q, want 9121234567", phone, got)
+		}
+
+		// بدنه باید برای هندلر دست‌نخورده بماند
+		rest, _ := io.ReadAll(c.Request.Body)
+		if string(rest) != body {
+			t.Errorf("body after peek = %q, want %q", rest, body)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package adminrole
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.AdminRoleHandler) {
+	adminRoleGroup := parent.Group("/admin-role").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionAdmins))
+
+	adminRoleGroup.GET("/fetch", handler.GetAdminRoles)
+	adminRoleGroup.GET("/permissions", handler.GetPermissions)
+	adminRoleGroup.POST("/save", handler.Save)
+	adminRoleGroup.DELETE("/delete/:id", handler.Delete)
+}
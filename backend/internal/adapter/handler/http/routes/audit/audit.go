@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.AuditHandler) {
+	auditGroup := parent.Group("/audit-log").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionAudit))
+
+	auditGroup.POST("/fetch", handler.FetchAuditLogs)
+}
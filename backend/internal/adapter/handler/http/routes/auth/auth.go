@@ -3,22 +3,28 @@ package auth
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/adapter/ratelimit"
 )
 
 func AddRoutes(
 	parent *gin.RouterGroup,
 	authHandler *handler.AuthHandler,
 	userHandler *handler.UserHandler,
+	rateLimit *middleware.RateLimit,
 ) {
-	authGroup := parent.Group("/auth")
+	authGroup := parent.Group("/auth").Use(rateLimit.ByIP(ratelimit.PolicyAuth))
+	otpLimit := rateLimit.ByPhone(ratelimit.PolicyOTP)
 
-	authGroup.POST("/login", authHandler.Login)
+	authGroup.POST("/login", otpLimit, authHandler.Login)
 
 	if userHandler != nil {
-		authGroup.POST("/register", userHandler.Register)
+		authGroup.POST("/register", otpLimit, userHandler.Register)
 	}
 
-	authGroup.POST("/verify-code", authHandler.VerifyCode)
+	authGroup.POST("/verify-code", otpLimit, authHandler.VerifyCode)
+	authGroup.POST("/verify-totp", otpLimit, authHandler.VerifyTOTP)
 
 	authGroup.POST("/refresh-token", authHandler.RefreshAccessToken)
+	authGroup.POST("/logout", authHandler.Logout)
 }
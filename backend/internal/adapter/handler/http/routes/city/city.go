@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.CityHandler) {
@@ -13,7 +14,7 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.CityHandler) {
 	adminCityGroup := cityGroup.Use(
 		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
 		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	adminCityGroup.POST("/create", handler.Create)
 	adminCityGroup.PUT("/update", handler.Update)
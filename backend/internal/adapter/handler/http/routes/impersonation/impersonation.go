@@ -0,0 +1,23 @@
+package impersonation
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.ImpersonationHandler) {
+	adminImpersonationGroup := parent.Group("/impersonation").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionImpersonation))
+
+	adminImpersonationGroup.POST("/start/:userId", handler.StartImpersonation)
+
+	// پایان نشست با توکن جعل هویت فراخوانی می‌شود و در نشست فقط‌خواندنی هم مجاز است
+	sessionGroup := parent.Group("/impersonation").Use(
+		middleware.AllowReadOnlyImpersonation(),
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig))
+
+	sessionGroup.POST("/end", handler.EndImpersonation)
+}
@@ -0,0 +1,20 @@
+package loginevent
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.LoginEventHandler) {
+	loginEventGroup := parent.Group("/login-event").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig))
+
+	loginEventGroup.GET("/fetch", handler.GetLoginEvents)
+
+	adminLoginEventGroup := loginEventGroup.Use(
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionUsers))
+
+	adminLoginEventGroup.GET("/fetch/:userId", handler.GetUserLoginEvents)
+}
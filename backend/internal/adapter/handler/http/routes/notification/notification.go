@@ -0,0 +1,16 @@
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.NotificationHandler) {
+	notificationGroup := parent.Group("/notification").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig))
+
+	notificationGroup.GET("/fetch", handler.GetNotifications)
+	notificationGroup.POST("/read/:id", handler.MarkRead)
+}
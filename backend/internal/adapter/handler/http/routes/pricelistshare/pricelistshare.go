@@ -0,0 +1,29 @@
+package pricelistshare
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/adapter/ratelimit"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.PriceListShareHandler,
+	rateLimit *middleware.RateLimit) {
+	priceListShareGroup := parent.Group("/price-list-share").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.NonAdminMiddleware(handler.TokenService, handler.AppConfig))
+
+	priceListShareGroup.GET("/fetch", handler.FetchAll)
+	priceListShareGroup.POST("/create", handler.Create)
+	priceListShareGroup.POST("/revoke/:id", handler.Revoke)
+
+	// بدون ورود؛ دسترسی فقط با توکن امضاشدهٔ لینک
+	publicGroup := parent.Group("/public/price-list").Use(rateLimit.ByIP(ratelimit.PolicyPublic))
+
+	publicGroup.GET("/:token", handler.FetchPublic)
+	publicGroup.GET("/:token/pdf", handler.FetchPublicPDF)
+	// لینک‌های رمزدار رمز را در هدر یا بدنهٔ POST می‌فرستند
+	publicGroup.POST("/:token", handler.FetchPublic)
+	publicGroup.POST("/:token/pdf", handler.FetchPublicPDF)
+}
@@ -0,0 +1,20 @@
+package pricelisttemplate
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.PriceListTemplateHandler) {
+	priceListTemplateGroup := parent.Group("/price-list-template").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.NonAdminMiddleware(handler.TokenService, handler.AppConfig))
+
+	priceListTemplateGroup.GET("/fetch", handler.FetchAll)
+	priceListTemplateGroup.GET("/fetch/:id", handler.Fetch)
+	priceListTemplateGroup.POST("/create", handler.Create)
+	priceListTemplateGroup.POST("/update", handler.Update)
+	priceListTemplateGroup.DELETE("/delete/:id", handler.Delete)
+}
@@ -4,15 +4,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/adapter/ratelimit"
+	"github.com/nerkhin/internal/core/domain"
 )
 
-func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductHandler) {
+func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductHandler,
+	rateLimit *middleware.RateLimit) {
 	productGroup := parent.Group("/product").Use(
 		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
 		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig))
 	fast := parent.Group("/product")
 
-	productGroup.POST("/fetch-products", handler.FetchProductsByFilter)
+	productGroup.POST("/fetch-products", rateLimit.ByUser(ratelimit.PolicySearch),
+		handler.FetchProductsByFilter)
 	productGroup.GET("/fetch/:id", handler.Fetch)
 	productGroup.GET("/by-brand/:brandId", handler.GetByBrand)
 	productGroup.GET("/by-category/:categoryId", handler.GetByCategory)
@@ -20,7 +24,7 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductHandler) {
 	fast.POST("/import-csv", handler.ImportFromCSV)
 
 	adminProductGroup := productGroup.Use(
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	adminProductGroup.POST("/create", handler.Create)
 	adminProductGroup.POST("/update", handler.Update)
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductBrandHandler) {
@@ -15,7 +16,7 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductBrandHandler) {
 	productBrandGroup.GET("/fetch-brands/:categoryId", handler.FetchBrands)
 	productBrandGroup.GET("/fetch/:id", handler.Fetch)
 	adminProductBrandGroup := productBrandGroup.Use(
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	adminProductBrandGroup.POST("/create", handler.Create)
 	adminProductBrandGroup.PUT("/update", handler.Update)
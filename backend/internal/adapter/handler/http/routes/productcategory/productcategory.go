@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductCategoryHandler) {
@@ -17,7 +18,7 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductCategoryHandler)
 	productCategoryGroup.GET("/fetch-brand-models/:categoryId", handler.FetchRelatedBrandModels)
 
 	adminCategoryGroup := productCategoryGroup.Use(
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	adminCategoryGroup.POST("/create", handler.Create)
 	adminCategoryGroup.PUT("/update", handler.Update)
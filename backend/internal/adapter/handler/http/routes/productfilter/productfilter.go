@@ -4,13 +4,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductFilterHandler) {
 	productFilterGroup := parent.Group("/product-filter").Use(
 		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
 		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	productFilterGroup.POST("/create", handler.Create)
 	productFilterGroup.POST("/option/create", handler.CreateFilteroption)
@@ -4,12 +4,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductFilterImportHandler) {
 	productFilterImportGroup := parent.Group("/product-filter-import").Use(
 		middleware.AuthMiddleware(handler.Token, handler.AppConfig),
-		middleware.ApprovedUserMiddleware(handler.Token, handler.AppConfig))
+		middleware.ApprovedUserMiddleware(handler.Token, handler.AppConfig),
+		middleware.RequirePermission(handler.Token, handler.AppConfig, domain.PermissionCatalog))
 
 	productFilterImportGroup.POST("/import-csv", handler.ImportCSV)
 }
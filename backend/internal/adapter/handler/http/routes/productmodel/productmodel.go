@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductModelHandler) {
@@ -15,7 +16,7 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.ProductModelHandler) {
 	productModelGroup.GET("/fetch-models/:categoryId", handler.FetchModels)
 	productModelGroup.GET("/by-brand/:brandId", handler.FetchModelsByBrandID)
 	adminProductModelGroup := productModelGroup.Use(
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
 
 	adminProductModelGroup.POST("/create", handler.Create)
 	adminProductModelGroup.PUT("/update", handler.Update)
@@ -0,0 +1,18 @@
+package roundingrule
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.RoundingRuleHandler) {
+	roundingRuleGroup := parent.Group("/rounding-rule").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.NonAdminMiddleware(handler.TokenService, handler.AppConfig))
+
+	roundingRuleGroup.GET("/fetch", handler.GetRoundingRules)
+	roundingRuleGroup.POST("/save", handler.Save)
+	roundingRuleGroup.DELETE("/delete/:id", handler.Delete)
+}
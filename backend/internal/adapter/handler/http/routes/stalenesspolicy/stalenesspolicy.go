@@ -0,0 +1,18 @@
+package stalenesspolicy
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.StalenessPolicyHandler) {
+	stalenessPolicyGroup := parent.Group("/staleness-policy").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionCatalog))
+
+	stalenessPolicyGroup.GET("/fetch", handler.GetStalenessPolicies)
+	stalenessPolicyGroup.POST("/save", handler.Save)
+	stalenessPolicyGroup.DELETE("/delete/:id", handler.Delete)
+}
@@ -0,0 +1,29 @@
+package totp
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+func AddRoutes(parent *gin.RouterGroup, handler *handler.TOTPHandler) {
+	totpGroup := parent.Group("/totp", middleware.AuthMiddleware(handler.TokenService, handler.AppConfig))
+
+	// تنظیمات ورود دومرحله‌ای خود کاربر
+	totpGroup.GET("/status", handler.GetStatus)
+	totpGroup.POST("/enroll", handler.BeginEnrollment)
+	totpGroup.POST("/confirm", handler.ConfirmEnrollment)
+	totpGroup.POST("/recovery-codes", handler.RegenerateRecoveryCodes)
+	totpGroup.POST("/disable", handler.DisableTOTP)
+
+	// گروه جدا تا دسترسی ادمین به ترتیب ثبت مسیرها وابسته نباشد
+	adminTOTPGroup := parent.Group("/totp",
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionAdmins))
+
+	adminTOTPGroup.GET("/policy", handler.GetTOTPPolicy)
+	adminTOTPGroup.PUT("/policy", handler.UpdateTOTPPolicy)
+	adminTOTPGroup.POST("/users/:userId/enroll", handler.IssueUserEnrollment)
+	adminTOTPGroup.DELETE("/users/:userId", handler.ResetUserTOTP)
+}
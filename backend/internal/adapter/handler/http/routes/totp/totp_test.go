@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// fakeTokenService رشتهٔ توکن را به payload از پیش ساخته نگاشت می‌کند
+type fakeTokenService struct {
+	port.TokenService
+	payloads map[string]*domain.TokenPayload
+}
+
+func (ts *fakeTokenService) VerifyAccessToken(tokenString string) (*domain.TokenPayload, error) {
+	payload, ok := ts.payloads[tokenString]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return payload, nil
+}
+
+func (*fakeTokenService) ValidateSession(context.Context, *domain.TokenPayload) error {
+	return nil
+}
+
+type fakeTOTPService struct {
+	port.TOTPService
+	calls []string
+}
+
+func (s *fakeTOTPService) GetStatus(context.Context, int64, domain.UserRole) (*domain.TOTPStatus, error) {
+	s.calls = append(s.calls, "status")
+	return &domain.TOTPStatus{}, nil
+}
+
+func (s *fakeTOTPService) GetTOTPPolicy(context.Context) (*domain.TOTPPolicy, error) {
+	s.calls = append(s.calls, "policy")
+	return &domain.TOTPPolicy{}, nil
+}
+
+func TestTOTPRoutesSeparateSelfServiceAndAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	approved := func(role domain.UserRole, permissions ...domain.Permission) *domain.TokenPayload {
+		return &domain.TokenPayload{UserID: 10, UserRole: role, UserState: domain.ApprovedUser,
+			Type: "access", Permissions: permissions}
+	}
+	tokens := &fakeTokenService{payloads: map[string]*domain.TokenPayload{
+		"shop":     approved(domain.Wholesaler),
+		"payments": approved(domain.Admin, domain.PermissionPayments),
+		"admins":   approved(domain.Admin, domain.PermissionAdmins),
+	}}
+
+	tests := []struct {
+		name     string
+		token    string
+		path     string
+		wantCall string
+	}{
+		{name: "shop reads own status", token: "shop", path: "/totp/status", wantCall: "status"},
+		{name: "admin reads own status", token: "payments", path: "/totp/status", wantCall: "status"},
+		{name: "shop cannot read policy", token: "shop", path: "/totp/policy"},
+		{name: "admin without admins permission", token: "payments", path: "/totp/policy"},
+		{name: "admin with admins permission", token: "admins", path: "/totp/policy", wantCall: "policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeTOTPService{}
+			router := gin.New()
+			AddRoutes(router.Group(""), handler.RegisterTOTPHandler(service, tokens, config.App{}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if tt.wantCall == "" {
+				if rec.Code == http.StatusOK || len(service.calls) != 0 {
+					t.Fatalf("status = %d, calls = %v; want the request rejected", rec.Code, service.calls)
+				}
+				return
+			}
+			if rec.Code != http.StatusOK || len(service.calls) != 1 || service.calls[0] != tt.wantCall {
+				t.Fatalf("status = %d, calls = %v; want %s: %s", rec.Code, service.calls, tt.wantCall, rec.Body)
+			}
+		})
+	}
+}
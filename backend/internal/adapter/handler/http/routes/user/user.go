@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.UserHandler) {
@@ -18,23 +19,32 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.UserHandler) {
 	userGroup.PUT("/update-dollar-price", handler.UpdateDollarPrice)
 	userGroup.GET("dollar-price/:id", handler.GetDollarPrice)
 
+	// مدیریت دستگاه‌های خود کاربر؛ باید قبل از گروه ادمین ثبت شوند
+	userGroup.GET("/devices", handler.ListOwnDevices)
+	userGroup.PUT("/devices/:id/name", handler.RenameOwnDevice)
+	userGroup.DELETE("/devices/:id", handler.SignOutOwnDevice)
+	userGroup.POST("/devices/sign-out-others/send-code", handler.SendSignOutOtherDevicesCode)
+	userGroup.POST("/devices/sign-out-others", handler.SignOutOtherDevices)
+
 	adminUserGroup := userGroup.Use(
 		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
 
-	adminUserGroup.DELETE("/delete/:userId", handler.Delete)
-	adminUserGroup.POST("/change-state", handler.ChangeState)
-	adminUserGroup.POST("/fetch-users", handler.FetchUsersByFilter)
-	adminUserGroup.POST("/add-new-user", handler.AddNewUser)
-	adminUserGroup.POST("/add-new-admin", handler.AddNewAdmin)
-	adminUserGroup.POST("/delete-admin/:adminId", handler.DeleteAdmin)
-	adminUserGroup.GET("/get-admin-access/:adminId", handler.GetAdminAccess)
-	adminUserGroup.POST("/update-admin-access/:adminId", handler.UpdateAdminAccess)
-	adminUserGroup.PUT("/users/device-limit", handler.UpdateUserDeviceLimit)
+	manageUsers := middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionUsers)
+	manageAdmins := middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionAdmins)
+
+	adminUserGroup.DELETE("/delete/:userId", manageUsers, handler.Delete)
+	adminUserGroup.POST("/change-state", manageUsers, handler.ChangeState)
+	adminUserGroup.POST("/fetch-users", manageUsers, handler.FetchUsersByFilter)
+	adminUserGroup.POST("/add-new-user", manageUsers, handler.AddNewUser)
+	adminUserGroup.POST("/add-new-admin", manageAdmins, handler.AddNewAdmin)
+	adminUserGroup.POST("/delete-admin/:adminId", manageAdmins, handler.DeleteAdmin)
+	adminUserGroup.GET("/get-admin-access/:adminId", manageAdmins, handler.GetAdminAccess)
+	adminUserGroup.POST("/update-admin-access/:adminId", manageAdmins, handler.UpdateAdminAccess)
+	adminUserGroup.PUT("/users/device-limit", manageUsers, handler.UpdateUserDeviceLimit)
 	// ... داخل گروه ادمین
-	adminUserGroup.GET("/users/:userId/devices", handler.ListUserDevices)
-	adminUserGroup.DELETE("/users/:userId/devices/:deviceId", handler.DeleteUserDevice)
-	adminUserGroup.DELETE("/users/:userId/devices", handler.DeleteAllUserDevices)
-	adminUserGroup.PUT("/users/all/device-limit", handler.UpdateAllUsersDeviceLimit)
-	adminUserGroup.GET("/users-subscriptions", handler.FetchAdminUserList)
-	adminUserGroup.POST("/users/:userId/impersonate", handler.ImpersonateUser)
+	adminUserGroup.GET("/users/:userId/devices", manageUsers, handler.ListUserDevices)
+	adminUserGroup.DELETE("/users/:userId/devices/:deviceId", manageUsers, handler.DeleteUserDevice)
+	adminUserGroup.DELETE("/users/:userId/devices", manageUsers, handler.DeleteAllUserDevices)
+	adminUserGroup.PUT("/users/all/device-limit", manageUsers, handler.UpdateAllUsersDeviceLimit)
+	adminUserGroup.GET("/users-subscriptions", manageUsers, handler.FetchAdminUserList)
 }
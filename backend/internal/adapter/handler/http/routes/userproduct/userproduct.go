@@ -4,26 +4,48 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/adapter/ratelimit"
+	"github.com/nerkhin/internal/core/domain"
 )
 
-func AddRoutes(parent *gin.RouterGroup, handler *handler.UserProductHandler) {
+func AddRoutes(parent *gin.RouterGroup, handler *handler.UserProductHandler,
+	rateLimit *middleware.RateLimit) {
 	userProductGroup := parent.Group("/user-product").Use(
 		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
 		middleware.ApprovedUserMiddleware(handler.TokenService, handler.AppConfig),
 		middleware.NonAdminMiddleware(handler.TokenService, handler.AppConfig))
+	searchLimit := rateLimit.ByUser(ratelimit.PolicySearch)
 
 	userProductGroup.POST("/create", handler.Create)
 	userProductGroup.POST("/update", handler.Update)
 	userProductGroup.GET("/fetch-shop", handler.FetchShopProducts)
 	userProductGroup.POST("/change-order", handler.ChangeOrder)
-	userProductGroup.POST("/fetch-products", handler.FetchProductsByFilter)
-	userProductGroup.GET("/fetch-shops/:productId", handler.FetchShops)
+	userProductGroup.POST("/fetch-products", searchLimit, handler.FetchProductsByFilter)
+	userProductGroup.GET("/fetch-shops/:productId", searchLimit, handler.FetchShops)
 	userProductGroup.GET("/fetch-price-list", handler.FetchPriceList)
 	userProductGroup.GET("/fetch-price-list-pdf", handler.FetchPriceListPDF)
+	userProductGroup.GET("/fetch-price-list-export", handler.ExportPriceList)
 	userProductGroup.GET("/fetch-shop/:uid", handler.FetchShopByUserId)
+	userProductGroup.GET("/fetch-shop/:uid/price-list-pdf", handler.FetchShopPriceListPDF)
+	userProductGroup.GET("/fetch-shop/:uid/price-list-export", handler.ExportShopPriceList)
 	userProductGroup.GET("/fetch/:upId", handler.Fetch)
-	userProductGroup.GET("/search", handler.Search)
+	userProductGroup.GET("/fetch-by-sku/:sku", handler.FetchBySKU)
+	userProductGroup.GET("/search", searchLimit, handler.Search)
 	userProductGroup.POST("/prices/adjust", handler.AdjustUserFinalPricesByPercent)
+	userProductGroup.POST("/prices/adjust/preview", handler.PreviewPriceAdjustment)
+	userProductGroup.POST("/prices/adjust/undo", handler.UndoLastPriceAdjustment)
+	userProductGroup.POST("/prices/confirm", handler.ConfirmPricesStillValid)
+	userProductGroup.POST("/import", handler.ImportUserProducts)
+	userProductGroup.GET("/export", handler.ExportUserProducts)
+	userProductGroup.GET("/competitive-position", handler.FetchCompetitivePositions)
+	userProductGroup.GET("/competitive-position/export", handler.ExportCompetitivePositions)
 	userProductGroup.DELETE("/delete/:id", handler.Delete)
 	userProductGroup.POST("/change-status", handler.ChangeVisibilityStatus)
+
+	adminUserProductGroup := parent.Group("/user-product/admin").Use(
+		middleware.AuthMiddleware(handler.TokenService, handler.AppConfig),
+		middleware.RequirePermission(handler.TokenService, handler.AppConfig, domain.PermissionReports))
+
+	adminUserProductGroup.GET("/price-flags", handler.FetchPriceDeviationFlags)
+	adminUserProductGroup.POST("/price-flags/:id/review", handler.ReviewPriceDeviationFlag)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nerkhin/internal/adapter/handler/http/handler"
 	"github.com/nerkhin/internal/adapter/handler/http/middleware"
+	"github.com/nerkhin/internal/core/domain"
 )
 
 func AddRoutes(parent *gin.RouterGroup, handler *handler.UserSubscriptionHandler) {
@@ -16,8 +17,13 @@ func AddRoutes(parent *gin.RouterGroup, handler *handler.UserSubscriptionHandler
 	userSubscriptionGroup.GET("/fetch/:cityId", handler.Fetch)
 	userSubscriptionGroup.GET("/fetch-payment-transactions", handler.FetchPaymentTransactionsHistory)
 	userSubscriptionGroup.GET("/fetch-user-subscriptions", handler.FetchUserSubscription)
-	adminUserGroup := userSubscriptionGroup.Use(
-		middleware.AdminMiddleware(handler.TokenService, handler.AppConfig))
-	adminUserGroup.POST("/subscriptions/grant", handler.GrantSubscriptionDays)
 
+	manageSubscriptions := middleware.RequirePermission(handler.TokenService, handler.AppConfig,
+		domain.PermissionSubscriptions)
+	managePayments := middleware.RequirePermission(handler.TokenService, handler.AppConfig,
+		domain.PermissionPayments)
+
+	userSubscriptionGroup.POST("/subscriptions/grant", manageSubscriptions, handler.GrantSubscriptionDays)
+	userSubscriptionGroup.GET("/users/:userId/payment-transactions", managePayments,
+		handler.FetchUserPaymentTransactions)
 }
@@ -0,0 +1,104 @@
+package usersubscription
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/adapter/handler/http/handler"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// fakeTokenService رشتهٔ توکن را به payload از پیش ساخته نگاشت می‌کند
+type fakeTokenService struct {
+	port.TokenService
+	payloads map[string]*domain.TokenPayload
+}
+
+func (ts *fakeTokenService) VerifyAccessToken(tokenString string) (*domain.TokenPayload, error) {
+	payload, ok := ts.payloads[tokenString]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return payload, nil
+}
+
+func (*fakeTokenService) ValidateSession(context.Context, *domain.TokenPayload) error {
+	return nil
+}
+
+type fakeUserSubscriptionService struct {
+	port.UserSubscriptionService
+	historyFor []int64
+}
+
+func (s *fakeUserSubscriptionService) FetchUserPaymentTransactionsHistory(_ context.Context, userID int64) (
+	[]*domain.PaymentTransactionHistoryViewModel, error) {
+	s.historyFor = append(s.historyFor, userID)
+	return nil, nil
+}
+
+func TestPaymentTransactionRoutesRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	approved := func(role domain.UserRole, permissions ...domain.Permission) *domain.TokenPayload {
+		return &domain.TokenPayload{UserID: 10, UserRole: role, UserState: domain.ApprovedUser,
+			Type: "access", Permissions: permissions}
+	}
+	tokens := &fakeTokenService{payloads: map[string]*domain.TokenPayload{
+		"shop":          approved(domain.Wholesaler),
+		"payments":      approved(domain.Admin, domain.PermissionPayments),
+		"subscriptions": approved(domain.Admin, domain.PermissionSubscriptions),
+		"super":         approved(domain.SuperAdmin),
+	}}
+
+	tests := []struct {
+		name       string
+		token      string
+		path       string
+		wantStatus int
+		wantUserID int64
+	}{
+		{name: "user reads own history", token: "shop", path: "/user-subscription/fetch-payment-transactions",
+			wantStatus: http.StatusOK, wantUserID: 10},
+		{name: "shop cannot read others", token: "shop", path: "/user-subscription/users/5/payment-transactions"},
+		{name: "admin without payments", token: "subscriptions",
+			path: "/user-subscription/users/5/payment-transactions"},
+		{name: "admin with payments", token: "payments", path: "/user-subscription/users/5/payment-transactions",
+			wantStatus: http.StatusOK, wantUserID: 5},
+		{name: "super admin", token: "super", path: "/user-subscription/users/5/payment-transactions",
+			wantStatus: http.StatusOK, wantUserID: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeUserSubscriptionService{}
+			router := gin.New()
+			AddRoutes(router.Group(""), handler.RegisterUserSubscriptionHandler(service, tokens, config.App{}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if tt.wantStatus == 0 {
+				if rec.Code == http.StatusOK || len(service.historyFor) != 0 {
+					t.Fatalf("status = %d, history read for %v; want the request rejected",
+						rec.Code, service.historyFor)
+				}
+				return
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if len(service.historyFor) != 1 || service.historyFor[0] != tt.wantUserID {
+				t.Fatalf("history read for %v, want [%d]", service.historyFor, tt.wantUserID)
+			}
+		})
+	}
+}
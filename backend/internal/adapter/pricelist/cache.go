@@ -0,0 +1,151 @@
+package pricelist
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+	ptime "github.com/yaa110/go-persian-calendar"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachedRenderer خروجی هر renderer را با کلید فروشگاه + هش محتوای لیست قیمت نگه می‌دارد.
+// تاریخ روز جزو محتوا حساب می‌شود چون در سربرگ PDF چاپ می‌شود.
+// درخواست‌های هم‌زمان با کلید یکسان فقط یک بار رندر می‌شوند.
+type CachedRenderer struct {
+	renderer   port.PriceListRenderer
+	maxEntries int
+	ttl        time.Duration
+	timeout    time.Duration // سقف رندر مشترک، مستقل از لغو درخواست‌ها
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // ابتدای لیست تازه‌ترین استفاده
+
+	group singleflight.Group
+}
+
+type cacheEntry struct {
+	key       string
+	pdf       []byte
+	expiresAt time.Time
+}
+
+func NewCachedRenderer(renderer port.PriceListRenderer, maxEntries int,
+	ttl, timeout time.Duration) *CachedRenderer {
+	return &CachedRenderer{
+		renderer:   renderer,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		timeout:    timeout,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (cr *CachedRenderer) RenderPriceListPDF(ctx context.Context,
+	priceList *domain.ShopViewModel) ([]byte, error) {
+	key, err := priceListCacheKey(priceList)
+	if err != nil {
+		return nil, err
+	}
+
+	if pdf, ok := cr.get(key); ok {
+		return pdf, nil
+	}
+
+	result := cr.group.DoChan(key, func() (interface{}, error) {
+		// رندر بین همهٔ منتظرها مشترک است؛ لغو درخواست اولی نباید بقیه را ناکام بگذارد
+		renderCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cr.timeout)
+		defer cancel()
+
+		pdf, err := cr.renderer.RenderPriceListPDF(renderCtx, priceList)
+		if err != nil {
+			return nil, err
+		}
+
+		cr.put(key, pdf)
+		return pdf, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+func priceListCacheKey(priceList *domain.ShopViewModel) (string, error) {
+	content, err := json.Marshal(priceList)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(content)
+	hash.Write([]byte(ptime.Now().Format("yyyy/MM/dd")))
+
+	var shopID int64
+	if priceList.ShopInfo != nil {
+		shopID = priceList.ShopInfo.ID
+	}
+
+	return fmt.Sprintf("%d:%s", shopID, hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+func (cr *CachedRenderer) get(key string) ([]byte, bool) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	elem, ok := cr.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		cr.order.Remove(elem)
+		delete(cr.entries, key)
+		return nil, false
+	}
+
+	cr.order.MoveToFront(elem)
+	return entry.pdf, true
+}
+
+func (cr *CachedRenderer) put(key string, pdf []byte) {
+	if cr.maxEntries < 1 {
+		return
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if elem, ok := cr.entries[key]; ok {
+		cr.order.Remove(elem)
+		delete(cr.entries, key)
+	}
+
+	cr.entries[key] = cr.order.PushFront(&cacheEntry{
+		key:       key,
+		pdf:       pdf,
+		expiresAt: time.Now().Add(cr.ttl),
+	})
+
+	for cr.order.Len() > cr.maxEntries {
+		oldest := cr.order.Back()
+		cr.order.Remove(oldest)
+		delete(cr.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
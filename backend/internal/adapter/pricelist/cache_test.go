@@ -0,0 +1,71 @@
+package pricelist
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type blockingRenderer struct {
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (r *blockingRenderer) RenderPriceListPDF(ctx context.Context,
+	_ *domain.ShopViewModel) ([]byte, error) {
+	r.calls.Add(1)
+	close(r.started)
+	select {
+	case <-r.release:
+		return []byte("%PDF"), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCachedRendererSharedRenderSurvivesFirstCallerCancel(t *testing.T) {
+	renderer := &blockingRenderer{started: make(chan struct{}), release: make(chan struct{})}
+	cr := NewCachedRenderer(renderer, 10, time.Minute, time.Minute)
+	priceList := &domain.ShopViewModel{}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cr.RenderPriceListPDF(firstCtx, priceList)
+		firstErr <- err
+	}()
+	<-renderer.started
+
+	secondResult := make(chan []byte, 1)
+	go func() {
+		pdf, _ := cr.RenderPriceListPDF(context.Background(), priceList)
+		secondResult <- pdf
+	}()
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller error = %v, want context.Canceled", err)
+	}
+
+	close(renderer.release)
+	select {
+	case pdf := <-secondResult:
+		if string(pdf) != "%PDF" {
+			t.Fatalf("second caller got %q", pdf)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second caller did not receive the shared render")
+	}
+
+	if pdf, err := cr.RenderPriceListPDF(context.Background(), priceList); err != nil || string(pdf) != "%PDF" {
+		t.Fatalf("cached render = %q, %v", pdf, err)
+	}
+	if calls := renderer.calls.Load(); calls != 1 {
+		t.Fatalf("renderer called %d times, want 1", calls)
+	}
+}
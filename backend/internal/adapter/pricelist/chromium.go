@@ -0,0 +1,269 @@
+package pricelist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	ptime "github.com/yaa110/go-persian-calendar"
+)
+
+const (
+	chromeProfileDir  = "/tmp/chromedp-profile"
+	chromeCrashpadDir = "/tmp/chrome-crashpad"
+)
+
+// ChromiumRenderer لیست قیمت را با یک Chromium مشترک به PDF تبدیل می‌کند.
+// به اندازهٔ PoolSize تب باز نگه داشته و بین درخواست‌ها دوباره استفاده می‌شود؛
+// حداکثر QueueSize درخواست منتظر تب می‌مانند و بقیه فوراً با ErrPriceListRendererBusy رد می‌شوند.
+type ChromiumRenderer struct {
+	cfg config.PriceListRendererConfig
+
+	mu            sync.Mutex
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+
+	tabs  chan *chromiumTab // nil یعنی تب هنوز ساخته نشده یا بعد از خطا دور انداخته شده
+	slots chan struct{}
+}
+
+type chromiumTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewChromiumRenderer(cfg config.PriceListRendererConfig) *ChromiumRenderer {
+	if cfg.PoolSize < 1 {
+		cfg.PoolSize = 1
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	if cfg.TimeoutSeconds < 1 {
+		cfg.TimeoutSeconds = 60
+	}
+
+	r := &ChromiumRenderer{
+		cfg:   cfg,
+		tabs:  make(chan *chromiumTab, cfg.PoolSize),
+		slots: make(chan struct{}, cfg.PoolSize+cfg.QueueSize),
+	}
+	for i := 0; i < cfg.PoolSize; i++ {
+		r.tabs <- nil
+	}
+
+	return r
+}
+
+func (r *ChromiumRenderer) RenderPriceListPDF(ctx context.Context,
+	priceList *domain.ShopViewModel) ([]byte, error) {
+	htmlStr := buildPriceListHTML(*priceList, ptime.Now())
+	return r.renderHTML(ctx, htmlStr, priceListTemplate(priceList).PaperSize)
+}
+
+// Close مرورگر و همهٔ تب‌ها را می‌بندد
+func (r *ChromiumRenderer) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.browserCancel != nil {
+		r.browserCancel()
+		r.browserCtx, r.browserCancel = nil, nil
+	}
+}
+
+func (r *ChromiumRenderer) renderHTML(ctx context.Context, htmlStr string,
+	paperSize domain.PaperSize) ([]byte, error) {
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		return nil, errors.New(msg.ErrPriceListRendererBusy)
+	}
+	defer func() { <-r.slots }()
+
+	var tab *chromiumTab
+	select {
+	case tab = <-r.tabs:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if tab == nil {
+		var err error
+		tab, err = r.newTab()
+		if err != nil {
+			r.tabs <- nil
+			return nil, err
+		}
+	}
+
+	pdf, err := r.printToPDF(ctx, tab, htmlStr, paperSize)
+	if err != nil {
+		// تب خراب را کنار می‌گذاریم تا درخواست بعدی تب تازه بسازد
+		tab.cancel()
+		r.tabs <- nil
+		return nil, fmt.Errorf("pdf render error: %w", err)
+	}
+
+	r.tabs <- tab
+	return pdf, nil
+}
+
+func (r *ChromiumRenderer) printToPDF(ctx context.Context, tab *chromiumTab,
+	htmlStr string, paperSize domain.PaperSize) (pdf []byte, err error) {
+	paperWidth, paperHeight := paperSizeInches(paperSize)
+
+	timeoutCtx, cancel := context.WithTimeout(tab.ctx, time.Duration(r.cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	// قطع شدن درخواست HTTP ساخت PDF را هم متوقف می‌کند؛ خود تب باز می‌ماند
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	err = chromedp.Run(
+		timeoutCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			ft, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(ft.Frame.ID, htmlStr).Do(ctx)
+		}),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return emulation.SetEmulatedMedia().WithMedia("screen").Do(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			data, _, err := page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPaperWidth(paperWidth).
+				WithPaperHeight(paperHeight).
+				WithMarginTop(0.39).
+				WithMarginBottom(0.39).
+				WithMarginLeft(0.39).
+				WithMarginRight(0.39).
+				Do(ctx)
+			if err != nil {
+				return err
+			}
+			pdf = data
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf, nil
+}
+
+// newTab روی مرورگر مشترک یک تب می‌سازد؛ اگر مرورگر از کار افتاده باشد یک بار دوباره راه‌اندازی می‌شود
+func (r *ChromiumRenderer) newTab() (*chromiumTab, error) {
+	browserCtx, err := r.browser(false)
+	if err != nil {
+		return nil, err
+	}
+
+	tab, err := openTab(browserCtx)
+	if err == nil {
+		return tab, nil
+	}
+
+	browserCtx, err = r.browser(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return openTab(browserCtx)
+}
+
+func openTab(browserCtx context.Context) (*chromiumTab, error) {
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	if err := chromedp.Run(tabCtx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("chrome tab error: %w", err)
+	}
+
+	return &chromiumTab{ctx: tabCtx, cancel: cancel}, nil
+}
+
+func (r *ChromiumRenderer) browser(restart bool) (context.Context, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if restart && r.browserCancel != nil {
+		r.browserCancel()
+		r.browserCtx, r.browserCancel = nil, nil
+	}
+
+	if r.browserCtx != nil && r.browserCtx.Err() == nil {
+		return r.browserCtx, nil
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), r.allocatorOptions()...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+
+	// اولین Run خود مرورگر را بالا می‌آورد
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return nil, fmt.Errorf("chrome boot error: %w", err)
+	}
+
+	r.browserCtx = browserCtx
+	r.browserCancel = func() {
+		browserCancel()
+		allocCancel()
+	}
+
+	return r.browserCtx, nil
+}
+
+// allocatorOptions مسیرهای نوشتنی Chrome/Crashpad را فقط برای پروسهٔ مرورگر تنظیم می‌کند،
+// نه برای کل پروسهٔ برنامه.
+func (r *ChromiumRenderer) allocatorOptions() []chromedp.ExecAllocatorOption {
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = "/home/appuser" // مطابق Dockerfile
+	}
+
+	xdgConfig := filepath.Join(home, ".config")
+	xdgCache := filepath.Join(home, ".cache")
+	xdgData := filepath.Join(home, ".local", "share")
+	for _, dir := range []string{xdgConfig, xdgCache, xdgData, chromeProfileDir, chromeCrashpadDir} {
+		_ = os.MkdirAll(dir, 0o777)
+	}
+
+	return append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.ExecPath(r.cfg.ChromePath),
+		chromedp.Env(
+			"HOME="+home,
+			"XDG_CONFIG_HOME="+xdgConfig,
+			"XDG_CACHE_HOME="+xdgCache,
+			"XDG_DATA_HOME="+xdgData,
+		),
+		chromedp.Flag("headless", "new"),
+		// برای کانتینر
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-setuid-sandbox", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("crash-dumps-dir", chromeCrashpadDir),
+		chromedp.Flag("disable-crash-reporter", true),
+		chromedp.Flag("disable-breakpad", true),
+		chromedp.UserDataDir(chromeProfileDir),
+		chromedp.Flag("no-first-run", true),
+		chromedp.Flag("no-default-browser-check", true),
+	)
+}
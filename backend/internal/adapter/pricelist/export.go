@@ -0,0 +1,403 @@
+package pricelist
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/xuri/excelize/v2"
+	ptime "github.com/yaa110/go-persian-calendar"
+	"golang.org/x/image/font/opentype"
+)
+
+const priceListSheetName = "لیست قیمت"
+
+// Exporter لیست قیمت را به اکسل، CSV و تصویر تبدیل می‌کند.
+// ردیف‌ها، تاریخ جلالی و قالب مبلغ همان خروجی PDF است.
+type Exporter struct {
+	regularFont   *opentype.Font
+	boldFont      *opentype.Font
+	imageBasePath string
+}
+
+func NewExporter(cfg config.PriceListRendererConfig, imageBasePath string) (*Exporter, error) {
+	regularData, boldData, err := loadNativeFonts(cfg.FontDir)
+	if err != nil {
+		return nil, err
+	}
+
+	regularFont, err := opentype.Parse(regularData)
+	if err != nil {
+		return nil, err
+	}
+	boldFont, err := opentype.Parse(boldData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		regularFont:   regularFont,
+		boldFont:      boldFont,
+		imageBasePath: imageBasePath,
+	}, nil
+}
+
+func (e *Exporter) ExportPriceList(ctx context.Context, priceList *domain.ShopViewModel,
+	format domain.PriceListFormat) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case domain.PriceListFormatXLSX:
+		return exportPriceListXLSX(priceList, e.imageBasePath, ptime.Now())
+	case domain.PriceListFormatCSV:
+		return exportPriceListCSV(priceList)
+	case domain.PriceListFormatPNG, domain.PriceListFormatJPEG:
+		return e.renderPriceListImage(priceList, format, ptime.Now())
+	}
+
+	return nil, errors.New(msg.ErrPriceListFormatIsNotValid)
+}
+
+// exportPriceListCSV فایل UTF-8 با BOM تا اکسل حروف فارسی را درست نشان دهد.
+// CSV فقط داده است: گروه به صورت ستون اول می‌آید، تصویر به صورت آدرس و متن سربرگ و شرایط حذف می‌شود.
+func exportPriceListCSV(priceList *domain.ShopViewModel) ([]byte, error) {
+	template := priceListTemplate(priceList)
+	columns := priceListColumns(priceList)
+	grouped := template.GroupBy != "" && template.GroupBy != domain.PriceListGroupNone
+
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+
+	header := []string{}
+	if grouped {
+		header = append(header, "گروه")
+	}
+	for _, col := range columns {
+		header = append(header, col.Title)
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, group := range priceListGroups(priceList) {
+		for _, row := range group.Rows {
+			record := []string{}
+			if grouped {
+				record = append(record, group.Title)
+			}
+			for _, col := range columns {
+				if col.Key == columnImage {
+					if row.ImageURL == "" {
+						record = append(record, "")
+					} else {
+						record = append(record, shopLogoURL(row.ImageURL))
+					}
+					continue
+				}
+				record = append(record, row.text(col.Key))
+			}
+
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// کد اندازهٔ کاغذ در تنظیمات چاپ اکسل
+var xlsxPaperSizes = map[domain.PaperSize]int{
+	domain.PaperSizeLetter: 1,
+	domain.PaperSizeA4:     9,
+	domain.PaperSizeA5:     11,
+}
+
+// exportPriceListXLSX یک شیت راست‌به‌چپ با سربرگ فروشگاه و جدول قیمت‌ها.
+// مبالغ عددی ذخیره می‌شوند تا قابل محاسبه باشند و با قالب #,##0 مثل moneyIRR نمایش داده می‌شوند.
+func exportPriceListXLSX(priceList *domain.ShopViewModel, imageBasePath string,
+	now ptime.Time) (file []byte, err error) {
+	template := priceListTemplate(priceList)
+	columns := priceListColumns(priceList)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := priceListSheetName
+	if err = f.SetSheetName("Sheet1", sheet); err != nil {
+		return
+	}
+	rtl := true
+	if err = f.SetSheetView(sheet, 0, &excelize.ViewOptions{RightToLeft: &rtl}); err != nil {
+		return
+	}
+	paperSize := xlsxPaperSizes[template.PaperSize]
+	if paperSize == 0 {
+		paperSize = xlsxPaperSizes[domain.PaperSizeA4]
+	}
+	if err = f.SetPageLayout(sheet, &excelize.PageLayoutOptions{Size: &paperSize}); err != nil {
+		return
+	}
+
+	styles, err := newPriceListXLSXStyles(f)
+	if err != nil {
+		return
+	}
+
+	lastCol := len(columns)
+	row := 0
+	// mergedRow یک ردیف تمام‌عرض (سربرگ، عنوان گروه یا متن آزاد قالب) می‌نویسد
+	mergedRow := func(value string, style int) error {
+		row++
+		first, _ := excelize.CoordinatesToCellName(1, row)
+		last, _ := excelize.CoordinatesToCellName(lastCol, row)
+		if err := f.MergeCell(sheet, first, last); err != nil {
+			return err
+		}
+		if err := f.SetCellValue(sheet, first, value); err != nil {
+			return err
+		}
+		return f.SetCellStyle(sheet, first, last, style)
+	}
+
+	// سربرگ: نام فروشگاه، تاریخ، تلفن‌ها و متن سربرگ قالب
+	phones := ""
+	if priceList.ShopInfo != nil {
+		phones = joinNonEmpty(priceList.ShopInfo.ShopPhone1, priceList.ShopInfo.ShopPhone2,
+			priceList.ShopInfo.ShopPhone3)
+	}
+	header := []struct {
+		value string
+		style int
+	}{
+		{priceListShopName(priceList), styles.title},
+		{"تاریخ: " + ymdJalali_LTR(now.Time()), styles.subtitle},
+		{"تلفن‌ها: " + phones, styles.subtitle},
+	}
+	if text := strings.TrimSpace(template.HeaderText); text != "" {
+		header = append(header, struct {
+			value string
+			style int
+		}{text, styles.note})
+	}
+	for _, h := range header {
+		if err = mergedRow(h.value, h.style); err != nil {
+			return
+		}
+	}
+
+	row++ // ردیف خالی
+	row++
+	tableHeaderRow := row
+	for i, col := range columns {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		if err = f.SetCellValue(sheet, cell, col.Title); err != nil {
+			return
+		}
+	}
+	first, _ := excelize.CoordinatesToCellName(1, row)
+	last, _ := excelize.CoordinatesToCellName(lastCol, row)
+	if err = f.SetCellStyle(sheet, first, last, styles.tableHeader); err != nil {
+		return
+	}
+
+	for _, group := range priceListGroups(priceList) {
+		if group.Title != "" {
+			if err = mergedRow(group.Title, styles.group); err != nil {
+				return
+			}
+		}
+
+		for _, item := range group.Rows {
+			row++
+			if template.ShowImage {
+				if err = f.SetRowHeight(sheet, row, 48); err != nil {
+					return
+				}
+			}
+
+			for i, col := range columns {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				value, style := xlsxCellValue(item, col, styles)
+				if col.Key == columnImage {
+					addXLSXProductImage(f, sheet, cell, imageBasePath, item.ImageURL)
+				} else if err = f.SetCellValue(sheet, cell, value); err != nil {
+					return
+				}
+				if err = f.SetCellStyle(sheet, cell, cell, style); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if text := strings.TrimSpace(template.FooterText); text != "" {
+		row++
+		if err = mergedRow(text, styles.note); err != nil {
+			return
+		}
+	}
+
+	for i, col := range columns {
+		name, _ := excelize.ColumnNumberToName(i + 1)
+		if err = f.SetColWidth(sheet, name, name, col.Weight*1.25); err != nil {
+			return
+		}
+	}
+
+	topLeft, _ := excelize.CoordinatesToCellName(1, tableHeaderRow+1)
+	err = f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      tableHeaderRow,
+		TopLeftCell: topLeft,
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return
+	}
+
+	return buf.Bytes(), nil
+}
+
+// xlsxCellValue مبالغ به صورت عدد و بقیه به صورت همان متن خروجی‌های دیگر
+func xlsxCellValue(row priceListRow, col priceListColumn,
+	styles priceListXLSXStyles) (interface{}, int) {
+	switch col.Key {
+	case columnIndex:
+		return row.Index, styles.center
+	case columnPrice:
+		return row.Price.IntPart(), styles.money
+	case columnRetailPrice:
+		if row.RetailPrice.Valid {
+			return row.RetailPrice.Decimal.IntPart(), styles.money
+		}
+	case columnDollarPrice:
+		if row.DollarPrice.Valid {
+			value, _ := row.DollarPrice.Decimal.Round(2).Float64()
+			return value, styles.dollar
+		}
+	case columnTitle, columnDescription:
+		return row.text(col.Key), styles.text
+	}
+
+	return row.text(col.Key), styles.center
+}
+
+// addXLSXProductImage تصویر محصول را از دیسک داخل خانه قرار می‌دهد؛
+// نبودن یا خراب بودن تصویر نباید کل فایل را از کار بیندازد
+func addXLSXProductImage(f *excelize.File, sheet, cell, imageBasePath, imageURL string) {
+	data, imageType := readShopLogo(imageBasePath, imageURL)
+	if data == nil {
+		return
+	}
+
+	extensions := map[string]string{"PNG": ".png", "JPG": ".jpg", "GIF": ".gif"}
+	_ = f.AddPictureFromBytes(sheet, cell, &excelize.Picture{
+		Extension: extensions[imageType],
+		File:      data,
+		Format: &excelize.GraphicOptions{
+			AutoFit:         true,
+			LockAspectRatio: true,
+			Positioning:     "oneCell",
+		},
+	})
+}
+
+type priceListXLSXStyles struct {
+	title       int
+	subtitle    int
+	note        int
+	tableHeader int
+	group       int
+	text        int
+	center      int
+	money       int
+	dollar      int
+}
+
+func newPriceListXLSXStyles(f *excelize.File) (styles priceListXLSXStyles, err error) {
+	border := []excelize.Border{
+		{Type: "left", Color: "CFCFCF", Style: 1},
+		{Type: "right", Color: "CFCFCF", Style: 1},
+		{Type: "top", Color: "CFCFCF", Style: 1},
+		{Type: "bottom", Color: "CFCFCF", Style: 1},
+	}
+	moneyFormat := "#,##0"
+	dollarFormat := "$#,##0.##"
+
+	definitions := []struct {
+		target *int
+		style  *excelize.Style
+	}{
+		{&styles.title, &excelize.Style{
+			Font:      &excelize.Font{Bold: true, Size: 14},
+			Alignment: &excelize.Alignment{Horizontal: "right"},
+		}},
+		{&styles.subtitle, &excelize.Style{
+			Font:      &excelize.Font{Size: 10, Color: "555555"},
+			Alignment: &excelize.Alignment{Horizontal: "right"},
+		}},
+		{&styles.note, &excelize.Style{
+			Font:      &excelize.Font{Size: 10, Color: "333333"},
+			Alignment: &excelize.Alignment{Horizontal: "right", Vertical: "top", WrapText: true},
+		}},
+		{&styles.group, &excelize.Style{
+			Font:      &excelize.Font{Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F0F0F0"}},
+			Alignment: &excelize.Alignment{Horizontal: "right", Vertical: "center"},
+			Border:    border,
+		}},
+		{&styles.tableHeader, &excelize.Style{
+			Font:      &excelize.Font{Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F5F5F5"}},
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+			Border:    border,
+		}},
+		{&styles.text, &excelize.Style{
+			Alignment: &excelize.Alignment{Horizontal: "right", Vertical: "center"},
+			Border:    border,
+		}},
+		{&styles.center, &excelize.Style{
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+			Border:    border,
+		}},
+		{&styles.money, &excelize.Style{
+			Alignment:    &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+			Border:       border,
+			CustomNumFmt: &moneyFormat,
+		}},
+		{&styles.dollar, &excelize.Style{
+			Alignment:    &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+			Border:       border,
+			CustomNumFmt: &dollarFormat,
+		}},
+	}
+
+	for _, d := range definitions {
+		if *d.target, err = f.NewStyle(d.style); err != nil {
+			return
+		}
+	}
+
+	return styles, nil
+}
@@ -0,0 +1,571 @@
+package pricelist
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/shopspring/decimal"
+	"github.com/skip2/go-qrcode"
+	ptime "github.com/yaa110/go-persian-calendar"
+)
+
+/* ───────── Small helpers ───────── */
+
+func moneyIRR(n decimal.Decimal) string {
+
+	// مقدار اصلی
+	d := n
+
+	// تبدیل به عدد صحیح ریالی (بدون اعشار)
+	// اگر اعشار داری و میخوای حذف بشه، IntPart استفاده کن
+	num := d.IntPart()
+	s := fmt.Sprintf("%d", num)
+
+	neg := ""
+	if num < 0 {
+		neg = "-"
+		s = s[1:] // علامت منفی رو حذف می‌کنیم
+	}
+
+	// اضافه کردن ویرگول به هر سه رقم
+	var out []byte
+	c := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		out = append(out, s[i])
+		c++
+		if c%3 == 0 && i != 0 {
+			out = append(out, ',')
+		}
+	}
+
+	// برگردوندن آرایه (چون برعکس ساخته شده)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return neg + string(out)
+}
+
+// moneyUSD قیمت دلاری با جداکنندهٔ هزارگان و حداکثر دو رقم اعشار
+func moneyUSD(n decimal.Decimal) string {
+	n = n.Round(2)
+	whole := n.Truncate(0)
+	s := moneyIRR(whole)
+	if frac := n.Sub(whole).Abs(); !frac.IsZero() {
+		s += strings.TrimPrefix(frac.StringFixed(2), "0")
+	}
+	return "$" + s
+}
+
+// paperSizeInches ابعاد کاغذ برای چاپ PDF در Chromium
+func paperSizeInches(size domain.PaperSize) (width, height float64) {
+	switch size {
+	case domain.PaperSizeA5:
+		return 5.83, 8.27
+	case domain.PaperSizeLetter:
+		return 8.5, 11
+	}
+	return 8.27, 11.69 // A4
+}
+
+const lrm = "\u200E"
+
+func moneyIRR_LTR(n decimal.Decimal) string {
+	return lrm + moneyIRR(n) + lrm
+}
+
+// ymdJalali_LTR تاریخ میلادی را به شکل yyyy/mm/dd جلالی برمی‌گرداند
+func ymdJalali_LTR(t time.Time) string {
+	jt := ptime.New(t)
+	s := fmt.Sprintf("%d/%s/%s", jt.Year(), pad2(int(jt.Month())), pad2(jt.Day()))
+	return lrm + s + lrm
+}
+
+var jalaliMonths = [...]string{
+	"", "فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور",
+	"مهر", "آبان", "آذر", "دی", "بهمن", "اسفند",
+}
+
+func jalaliDateLong(t ptime.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), jalaliMonths[int(t.Month())], t.Year())
+}
+
+func pad2(n int) string {
+	if n < 10 {
+		return fmt.Sprintf("0%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func htmlEsc(s string) string { return html.EscapeString(s) }
+
+// مسیر فونت‌ها (طبق Dockerfile شما)
+const localFontDir = "/assets/fonts"
+
+// نام‌های احتمالی فونت‌ها (هر کدام موجود بود برداشته می‌شود)
+var vazirRegCandidates = []string{
+	"Vazirmatn-Regular.woff2",
+	"Vazirmatn-FD-Regular.woff2",
+	"Vazirmatn-Regular.ttf",
+	"Vazirmatn-FD-Regular.ttf",
+	"VazirFD.ttf",
+}
+var vazirBoldCandidates = []string{
+	"Vazirmatn-Bold.woff2",
+	"Vazirmatn-FD-Bold.woff2",
+	"Vazirmatn-Bold.ttf",
+	"Vazirmatn-FD-Bold.ttf",
+	"Vazirmatn-FD-Bold.ttf",
+}
+
+// تاریخ ایتم‌ها به صورت yyyy/mm/dd جلالی (LTR)
+func jalaliYMDFromAny(v any) string {
+	t := v.(time.Time)
+	if t.IsZero() {
+		return "—"
+	}
+	return ymdJalali_LTR(t) // خروجی مثل: 1404/06/18
+}
+
+func pickExistingFont(fontDir string, names []string) (fullPath, mime, format string, ok bool) {
+	for _, n := range names {
+		p := filepath.Join(fontDir, n)
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			ext := strings.ToLower(filepath.Ext(n))
+			switch ext {
+			case ".woff2":
+				return p, "font/woff2", "woff2", true
+			case ".ttf":
+				return p, "font/ttf", "truetype", true
+			default:
+				return p, "font/ttf", "truetype", true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+func fontDataURI(path, mime string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
+
+const siteBaseURL = "https://nerrkhin.com"
+
+// CSS @font-face با فونت لوکال به صورت data:URI
+func buildLocalVazirmatnCSS() string {
+	regPath, regMime, regFmt, ok1 := pickExistingFont(localFontDir, vazirRegCandidates)
+	boldPath, boldMime, boldFmt, ok2 := pickExistingFont(localFontDir, vazirBoldCandidates)
+	if !ok1 || !ok2 {
+		// اگر فونت پیدا نشد، CSS خالی برگردان (fallback به سیستم)
+		return ""
+	}
+	regURI, err1 := fontDataURI(regPath, regMime)
+	boldURI, err2 := fontDataURI(boldPath, boldMime)
+	if err1 != nil || err2 != nil {
+		return ""
+	}
+
+	family := "Vazirmatn Local"
+	css := fmt.Sprintf(`
+@font-face {
+  font-family: '%s';
+  src: url('%s') format('%s');
+  font-weight: 400;
+  font-style: normal;
+  font-display: swap;
+}
+@font-face {
+  font-family: '%s';
+  src: url('%s') format('%s');
+  font-weight: 700;
+  font-style: normal;
+  font-display: swap;
+}
+`, family, regURI, regFmt, family, boldURI, boldFmt)
+
+	return css
+}
+
+// ===================== ساخت HTML (RTL+Vazirmatn) =====================
+// نیاز به این پکیج دارید:
+// go get github.com/skip2/go-qrcode
+// اگر قبلاً دارید، همین را نگه دارید.
+
+// buildQRDataURI: متن را به PNG QR تبدیل می‌کند و result را به‌صورت data:URI برمی‌گرداند.
+// priceListQR مقصد QR هدر و متن زیر آن؛ لینک عمومی لیست قیمت اگر ساخته شده باشد، وگرنه سایت فروشگاه
+func priceListQR(vm *domain.ShopViewModel) (url, caption string) {
+	if vm.ShareURL != "" {
+		return vm.ShareURL, "مشاهدهٔ آنلاین لیست قیمت"
+	}
+
+	url = siteBaseURL
+	if vm.ShopInfo != nil && strings.TrimSpace(vm.ShopInfo.WebsiteUrl) != "" {
+		url = strings.TrimSpace(vm.ShopInfo.WebsiteUrl)
+	}
+	return url, url
+}
+
+func buildQRDataURI(text string, size int) string {
+	png := buildQRPNG(text, size)
+	if len(png) == 0 {
+		return ""
+	}
+	b64 := base64.StdEncoding.EncodeToString(png)
+	return "data:image/png;base64," + b64
+}
+
+// buildQRPNG همان QR بالا به صورت بایت‌های PNG (برای renderer بدون مرورگر)
+func buildQRPNG(text string, size int) []byte {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if size <= 0 {
+		size = 128
+	}
+	png, err := qrcode.Encode(text, qrcode.Medium, size)
+	if err != nil {
+		return nil
+	}
+	return png
+}
+
+func shopLogoURL(u string) string {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return ""
+	}
+	// اگر خودش کامل بود، دست نزن
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	// اگر هرجای رشته "/uploads/" داشت → همون‌جارو به مسیر وب تبدیل کن
+	if i := strings.Index(u, "/uploads/"); i >= 0 {
+		return siteBaseURL + u[i:] // از /uploads/... به بعد
+	}
+	// اگر خودش از /uploads شروع می‌شود
+	if strings.HasPrefix(u, "/uploads/") {
+		return siteBaseURL + u
+	}
+	// در بدترین حالت: فرض کن فقط نام فایل است
+	return siteBaseURL + "/uploads/" + u
+}
+
+// now از نوع ptime.Time در کد شماست؛ اینجا signature شما را دست‌نخورده نگه می‌دارم.
+func buildPriceListHTML(vm domain.ShopViewModel, now interface{}) string {
+	shopName := strings.TrimSpace(vm.ShopInfo.ShopName)
+	if shopName == "" {
+		shopName = "—"
+	}
+	phones := strings.Join([]string{vm.ShopInfo.ShopPhone1, vm.ShopInfo.ShopPhone2, vm.ShopInfo.ShopPhone3}, " , ")
+	addr := strings.TrimSpace(vm.ShopInfo.ShopAddress)
+
+	// URL لینک عمومی یا سایت (برای QR بالای تاریخ)
+	qrURL, qrCaption := priceListQR(&vm)
+	siteQR := buildQRDataURI(qrURL, 96)
+
+	// لوگو (از /uploads/... به URL کامل)
+
+	// Social QR (اختیاری)
+	socials := []struct {
+		label string
+		url   string
+	}{
+		{"Instagram", strings.TrimSpace(vm.ShopInfo.InstagramUrl)},
+		{"Telegram", strings.TrimSpace(vm.ShopInfo.TelegramUrl)},
+		{"WhatsApp", strings.TrimSpace(vm.ShopInfo.WhatsappUrl)},
+	}
+	var socialsQR strings.Builder
+	for _, s := range socials {
+		if s.url == "" {
+			continue
+		}
+		qr := buildQRDataURI(s.url, 110)
+		if qr == "" {
+			continue
+		}
+		socialsQR.WriteString(fmt.Sprintf(`
+			<div class="qr-card">
+				<img src="%s" alt="%s QR"/>
+				<div class="qr-label">%s</div>
+			</div>
+		`, htmlEsc(qr), htmlEsc(s.label), htmlEsc(s.label)))
+	}
+
+	// ستون‌ها و ردیف‌های جدول طبق قالب فروشگاه
+	template := priceListTemplate(&vm)
+	columns := priceListColumns(&vm)
+	widths := priceListColumnWidths(columns, 100)
+
+	var head strings.Builder
+	for i, col := range columns {
+		head.WriteString(fmt.Sprintf(`
+      <th class="c" style="width:%.1f%%">%s</th>`, widths[i], htmlEsc(col.Title)))
+	}
+
+	var rows strings.Builder
+	for _, group := range priceListGroups(&vm) {
+		if group.Title != "" {
+			rows.WriteString(fmt.Sprintf(`
+			<tr class="group"><td colspan="%d">%s</td></tr>`, len(columns), htmlEsc(group.Title)))
+		}
+
+		for _, row := range group.Rows {
+			rows.WriteString("\n\t\t\t<tr>")
+			for _, col := range columns {
+				class := strings.ToLower(col.Align)
+				switch col.Key {
+				case columnImage:
+					cell := ""
+					if row.ImageURL != "" {
+						cell = fmt.Sprintf(`<img class="product-image" src="%s" alt=""/>`,
+							htmlEsc(shopLogoURL(row.ImageURL)))
+					}
+					rows.WriteString(fmt.Sprintf(`<td class="c">%s</td>`, cell))
+				default:
+					text := row.text(col.Key)
+					if text == "" {
+						text = " "
+					}
+					rows.WriteString(fmt.Sprintf(`<td class="%s">%s</td>`, class, htmlEsc(text)))
+				}
+			}
+			rows.WriteString("</tr>")
+		}
+	}
+
+	headerTextHTML := ""
+	if t := strings.TrimSpace(template.HeaderText); t != "" {
+		headerTextHTML = fmt.Sprintf(`<div class="note">%s</div>`, htmlEsc(t))
+	}
+	footerTextHTML := ""
+	if t := strings.TrimSpace(template.FooterText); t != "" {
+		footerTextHTML = fmt.Sprintf(`<div class="note terms">%s</div>`, htmlEsc(t))
+	}
+
+	fontCSS := buildLocalVazirmatnCSS()
+
+	// لوگو HTML (اگر نبود، جای‌گیر ظریف)
+	var logoHTML string
+	if u := shopLogoURL(vm.ShopInfo.ImageUrl); u != "" {
+		logoHTML = fmt.Sprintf(`<img class="shop-logo" src="%s" alt="shop logo"/>`, htmlEsc(u))
+	} else {
+		logoHTML = `<div class="shop-logo placeholder"></div>`
+	}
+	// QR سایت (اگر نبود، فاصله‌ی هم‌تراز)
+	siteQRHTML := `<div class="site-qr placeholder"></div>`
+	if siteQR != "" {
+		siteQRHTML = fmt.Sprintf(`
+			<div class="site-qr">
+				<img src="%s" alt="site QR"/>
+				<div class="small">%s</div>
+			</div>`, htmlEsc(siteQR), htmlEsc(qrCaption))
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="fa" dir="rtl">
+<head>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1" />
+<title>%s</title>
+<style>
+%s
+
+* { box-sizing: border-box; }
+body {
+  font-family: "Vazirmatn Local", system-ui, -apple-system, Segoe UI, Roboto, Arial, sans-serif;
+  direction: rtl; unicode-bidi: embed;
+  margin: 24px; color: #111;
+}
+
+/* ===== Header (هم‌تراز و حرفه‌ای) ===== */
+.header {
+  display: grid;
+  grid-template-columns: 1.2fr 1fr 1fr;
+  align-items: center;  /* ← تمام آیتم‌ها عموداً وسط */
+  gap: 16px;
+  margin-bottom: 14px;
+  padding: 10px 12px;
+  border: 1px solid #eee;
+  border-radius: 12px;
+  background: #fafafa;
+}
+
+.header .right {
+  display: flex;
+  align-items: center;
+  gap: 12px;
+}
+.shop-logo {
+  width: 74px; height: 74px;
+  border-radius: 50%%; object-fit: cover; object-position: center;
+  border: 1px solid #e9e9e9; background: #fff;
+}
+.shop-logo.placeholder { background: #f3f3f3; }
+
+.shop-title {
+  display: flex; flex-direction: column; gap: 2px;
+}
+.shop-title .name {
+  font-size: 26px; font-weight: 800; line-height: 1.1;
+}
+.shop-title .sub {
+  font-size: 12px; color: #666;
+}
+
+/* ستون وسط: QR سایت */
+.header .center {
+  display: flex; justify-content: center;
+}
+.site-qr { text-align: center; }
+.site-qr img { width: 86px; height: 86px; display: block; margin: 0 auto 6px; }
+.site-qr .small { font-size: 11px; color: #666; direction: ltr; }
+.site-qr.placeholder { width: 86px; height: 86px; border-radius: 8px; background: #f3f3f3; }
+
+/* ستون چپ: تاریخ */
+.header .left {
+  display: flex; justify-content: flex-start; /* چون RTL است، چپِ بصری */
+  align-items: center; gap: 8px;
+}
+.date {
+  font-size: 14px; color: #333; font-weight: 700;
+  white-space: nowrap;
+}
+
+/* متا: آدرس/تلفن‌ها */
+.meta {
+  display: grid; grid-template-columns: 1fr; gap: 6px;
+  font-size: 13px; margin: 10px 0 16px;
+}
+.meta .row { display: flex; align-items: baseline; gap: 8px; }
+.meta .label { font-weight: 800; color: #222; min-width: 64px; }
+.meta .value { color: #111; }
+
+/* Divider */
+.hr { height: 1px; background: #e5e5e5; margin: 10px 0 16px; }
+
+/* جدول اقلام */
+table { width: 100%%; border-collapse: collapse; }
+th, td { border: 1px solid #cfcfcf; padding: 8px 10px; font-size: 13px; }
+th { background: #f5f5f5; font-weight: 700; }
+td.r { text-align: right; }
+td.c { text-align: center; }
+tr.group td { background: #f0f0f0; font-weight: 800; text-align: right; }
+.product-image { width: 48px; height: 48px; object-fit: contain; display: block; margin: 0 auto; }
+.note { white-space: pre-line; font-size: 13px; color: #333; margin: 0 0 14px; }
+.note.terms { margin: 14px 0 0; font-size: 12px; color: #555; }
+
+/* فوتر QR شبکه‌های اجتماعی (اختیاری) */
+.footer { margin-top: 16px; }
+.qr-list { display: flex; flex-wrap: wrap; gap: 12px; }
+.qr-card { width: 110px; text-align: center; }
+.qr-card img {
+  width: 110px; height: 110px; display: block;
+  border: 1px solid #eee; background: #fff; border-radius: 8px;
+}
+.qr-label { margin-top: 6px; font-size: 12px; color: #444; font-weight: 600; }
+</style>
+</head>
+<body>
+
+<!-- Header -->
+<div class="header">
+  <!-- Right: Logo + Name (هم‌ردیف و وسط‌چین عمودی) -->
+  <div class="right">
+    %s
+    <div class="shop-title">
+      <div class="name">%s</div>
+      <div class="sub">لیست قیمت فروشگاه</div>
+    </div>
+  </div>
+
+  <!-- Center: Site QR -->
+  <div class="center">
+    %s
+  </div>
+
+  <!-- Left: Date -->
+  <div class="left">
+    <!-- اگر خواستی یک آیکون تقویم SVG کوچک اینجا اضافه کن -->
+    <div class="date">%s</div>
+  </div>
+</div>
+
+<!-- Address & Phones -->
+<div class="meta">
+  <div class="row"><div class="label">آدرس:</div><div class="value">%s</div></div>
+  <div class="row"><div class="label">تلفن‌ها:</div><div class="value">%s</div></div>
+</div>
+
+<div class="hr"></div>
+
+%s
+
+<!-- Table -->
+<table>
+  <thead>
+    <tr>%s
+    </tr>
+  </thead>
+  <tbody>
+    %s
+  </tbody>
+</table>
+
+%s
+
+<!-- Footer (Social QR, only if URLs exist) -->
+<div class="footer">
+  <div class="qr-list">%s</div>
+</div>
+
+</body>
+</html>`,
+		// <title>
+		htmlEsc(shopName),
+		fontCSS,
+
+		// header.right → logo + title
+		logoHTML,
+		htmlEsc(shopName),
+
+		// header.center → site QR
+		siteQRHTML,
+
+		// header.left → date
+		htmlEsc(jalaliDateLong(now.(ptime.Time))),
+
+		// meta
+		htmlEsc(addr),
+		htmlEsc(phones),
+
+		// header text + table + footer terms
+		headerTextHTML,
+		head.String(),
+		rows.String(),
+		footerTextHTML,
+
+		// socials
+		socialsQR.String(),
+	)
+}
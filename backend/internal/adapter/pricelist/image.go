@@ -0,0 +1,414 @@
+package pricelist
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	ptime "github.com/yaa110/go-persian-calendar"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// ابعاد تصویر بر حسب پیکسل؛ عرض مناسب نمایش در واتس‌اپ و تلگرام
+const (
+	imageWidth           = 1080
+	imagePadding         = 40
+	imageHeaderHeight    = 170
+	imageTableHeadHeight = 64
+	imageRowHeight       = 58
+	imageImageRowHeight  = 96
+	imageNoteLineHeight  = 34
+	imageFooterHeight    = 70
+	imageLogoSize        = 120
+	imageQRSize          = 120
+	imageJPEGQuality     = 90
+	// imageMaxHeight سقف ارتفاع بوم؛ هر پیکسل RGBA چهار بایت حافظه می‌گیرد
+	// و لیست‌های بلندتر باید PDF (صفحه‌بندی‌شده) بگیرند
+	imageMaxHeight = 12000
+)
+
+var (
+	imageBackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	imageTextColor  = color.RGBA{0x11, 0x11, 0x11, 0xFF}
+	imageMutedColor = color.RGBA{0x66, 0x66, 0x66, 0xFF}
+	imageBorder     = color.RGBA{0xE5, 0xE5, 0xE5, 0xFF}
+	imageHeadFill   = color.RGBA{0xF5, 0xF5, 0xF5, 0xFF}
+	imageZebraFill  = color.RGBA{0xFA, 0xFA, 0xFA, 0xFF}
+	imageGroupFill  = color.RGBA{0xF0, 0xF0, 0xF0, 0xFF}
+)
+
+type priceListImageFaces struct {
+	title font.Face
+	text  font.Face
+	bold  font.Face
+	small font.Face
+}
+
+func (e *Exporter) newImageFaces() (faces priceListImageFaces, err error) {
+	newFace := func(f *opentype.Font, size float64) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    size,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+	}
+
+	if faces.title, err = newFace(e.boldFont, 42); err != nil {
+		return
+	}
+	if faces.text, err = newFace(e.regularFont, 25); err != nil {
+		return
+	}
+	if faces.bold, err = newFace(e.boldFont, 25); err != nil {
+		return
+	}
+	if faces.small, err = newFace(e.regularFont, 20); err != nil {
+		return
+	}
+
+	return faces, nil
+}
+
+func (faces priceListImageFaces) Close() {
+	for _, face := range []font.Face{faces.title, faces.text, faces.bold, faces.small} {
+		if face != nil {
+			face.Close()
+		}
+	}
+}
+
+// renderPriceListImage کل لیست قیمت را در یک تصویر بلند می‌کشد (بدون صفحه‌بندی)؛
+// لیستی که از imageMaxHeight بلندتر شود رد می‌شود.
+// عرض تصویر ثابت است، پس اندازهٔ کاغذ قالب روی آن اثری ندارد.
+func (e *Exporter) renderPriceListImage(priceList *domain.ShopViewModel,
+	format domain.PriceListFormat, now ptime.Time) ([]byte, error) {
+	// face ها هم‌زمانی امن نیستند؛ برای هر تصویر جدا ساخته می‌شوند
+	faces, err := e.newImageFaces()
+	if err != nil {
+		return nil, err
+	}
+	defer faces.Close()
+
+	template := priceListTemplate(priceList)
+	columns := priceListColumns(priceList)
+	groups := priceListGroups(priceList)
+
+	canvas := &priceListCanvas{
+		faces:   faces,
+		columns: columns,
+	}
+	canvas.widths = canvas.columnWidths(groups)
+
+	rowHeight := imageRowHeight
+	if template.ShowImage {
+		rowHeight = imageImageRowHeight
+	}
+	headerLines := canvas.noteLines(template.HeaderText)
+	footerLines := canvas.noteLines(template.FooterText)
+
+	height := 2*imagePadding + imageHeaderHeight + imageTableHeadHeight + imageFooterHeight +
+		(len(headerLines)+len(footerLines))*imageNoteLineHeight
+	if len(footerLines) > 0 {
+		height += imageNoteLineHeight / 2
+	}
+	for _, group := range groups {
+		if group.Title != "" {
+			height += imageRowHeight
+		}
+		height += len(group.Rows) * rowHeight
+	}
+	if height > imageMaxHeight {
+		return nil, errors.New(msg.ErrPriceListTooLargeForImage)
+	}
+
+	canvas.img = image.NewRGBA(image.Rect(0, 0, imageWidth, height))
+	canvas.fill(canvas.img.Bounds(), imageBackground)
+
+	shop := priceList.ShopInfo
+	if shop == nil {
+		shop = &domain.User{}
+	}
+
+	y := imagePadding
+	qrURL, _ := priceListQR(priceList)
+	canvas.drawHeader(shop, readShopLogoImage(e.imageBasePath, shop.ImageUrl), qrURL, now, y)
+	y += imageHeaderHeight
+	y = canvas.drawNote(headerLines, y)
+
+	canvas.drawTableHead(y)
+	y += imageTableHeadHeight
+
+	for _, group := range groups {
+		if group.Title != "" {
+			canvas.drawGroupTitle(group.Title, y)
+			y += imageRowHeight
+		}
+
+		for _, row := range group.Rows {
+			background := imageBackground
+			if row.Index%2 == 0 {
+				background = imageZebraFill
+			}
+
+			canvas.drawCells(y, rowHeight, background, faces.text,
+				func(i int, cell image.Rectangle) (string, string) {
+					col := columns[i]
+					if col.Key == columnImage {
+						if img := readShopLogoImage(e.imageBasePath, row.ImageURL); img != nil {
+							canvas.image(img, cell.Inset(6))
+						}
+						return "", col.Align
+					}
+					return canvas.fit(faces.text, row.text(col.Key), cell.Dx()-24), col.Align
+				})
+			y += rowHeight
+		}
+	}
+
+	if len(footerLines) > 0 {
+		y = canvas.drawNote(footerLines, y+imageNoteLineHeight/2)
+	}
+
+	siteURL := strings.TrimSpace(shop.WebsiteUrl)
+	if siteURL == "" {
+		siteURL = siteBaseURL
+	}
+	canvas.text(faces.small, siteURL, 0, imageWidth, canvas.baseline(faces.small, y, imageFooterHeight),
+		"C", imageMutedColor)
+
+	var buf bytes.Buffer
+	if format == domain.PriceListFormatJPEG {
+		err = jpeg.Encode(&buf, canvas.img, &jpeg.Options{Quality: imageJPEGQuality})
+	} else {
+		err = png.Encode(&buf, canvas.img)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type priceListCanvas struct {
+	img     *image.RGBA
+	faces   priceListImageFaces
+	columns []priceListColumn
+	widths  []int
+}
+
+// drawHeader: لوگو و نام فروشگاه در راست، QR لینک لیست قیمت یا سایت و تاریخ جلالی در چپ
+func (pc *priceListCanvas) drawHeader(shop *domain.User, logo image.Image, qrURL string, now ptime.Time,
+	top int) {
+	right := imageWidth - imagePadding
+	titleRight := right
+	if logo != nil {
+		pc.image(logo, image.Rect(right-imageLogoSize, top, right, top+imageLogoSize))
+		titleRight = right - imageLogoSize - 20
+	}
+
+	shopName := strings.TrimSpace(shop.ShopName)
+	if shopName == "" {
+		shopName = "—"
+	}
+	pc.text(pc.faces.title, shopName, imagePadding, titleRight, top+48, "R", imageTextColor)
+	pc.text(pc.faces.text, "لیست قیمت فروشگاه", imagePadding, titleRight, top+88, "R", imageMutedColor)
+
+	phones := joinNonEmpty(shop.ShopPhone1, shop.ShopPhone2, shop.ShopPhone3)
+	if phones != "" {
+		pc.text(pc.faces.small, "تلفن‌ها: "+phones, imagePadding, titleRight, top+122, "R", imageTextColor)
+	}
+
+	dateLeft := imagePadding
+	if qr, err := png.Decode(bytes.NewReader(buildQRPNG(qrURL, imageQRSize))); err == nil {
+		pc.image(qr, image.Rect(imagePadding, top, imagePadding+imageQRSize, top+imageQRSize))
+		dateLeft += imageQRSize + 16
+	}
+	pc.text(pc.faces.bold, jalaliDateLong(now), dateLeft, dateLeft+300, top+48, "L", imageTextColor)
+
+	pc.fill(image.Rect(imagePadding, top+imageHeaderHeight-20, right, top+imageHeaderHeight-18),
+		imageBorder)
+}
+
+// columnWidths عرض ستون‌ها بر اساس محتوا؛ ستون تصویر دست‌کم جای یک تصویر کوچک را دارد
+func (pc *priceListCanvas) columnWidths(groups []priceListGroup) []int {
+	measure := func(face font.Face) func(string) float64 {
+		return func(s string) float64 { return float64(font.MeasureString(face, s).Ceil()) }
+	}
+
+	widths := priceListContentWidths(pc.columns, groups, float64(imageWidth-2*imagePadding),
+		measure(pc.faces.bold), measure(pc.faces.text), 24, imageImageRowHeight-40)
+
+	result := make([]int, len(widths))
+	for i, width := range widths {
+		result[i] = int(width)
+	}
+	return result
+}
+
+// drawTableHead عنوان ستون‌های باریک را در دو خط می‌نویسد
+func (pc *priceListCanvas) drawTableHead(top int) {
+	face := pc.faces.bold
+	measure := func(s string) float64 { return float64(font.MeasureString(face, s).Ceil()) }
+	lineHeight := face.Metrics().Height.Ceil()
+
+	pc.drawCells(top, imageTableHeadHeight, imageHeadFill, face,
+		func(i int, cell image.Rectangle) (string, string) {
+			lines := wrapText(pc.columns[i].Title, float64(cell.Dx()-24), measure)
+			if len(lines) <= 1 {
+				return pc.fit(face, pc.columns[i].Title, cell.Dx()-24), "C"
+			}
+
+			lines = lines[:2]
+			first := top + (imageTableHeadHeight-2*lineHeight)/2
+			for j, line := range lines {
+				pc.text(face, pc.fit(face, line, cell.Dx()-24), cell.Min.X+12, cell.Max.X-12,
+					pc.baseline(face, first+j*lineHeight, lineHeight), "C", imageTextColor)
+			}
+			return "", "C"
+		})
+}
+
+// drawCells ستون‌ها را مثل PDF از راست به چپ می‌چیند؛ cell کادر خانه را هم می‌گیرد تا بتواند تصویر بکشد
+func (pc *priceListCanvas) drawCells(top, height int, background color.Color, face font.Face,
+	cell func(i int, box image.Rectangle) (text, align string)) {
+	right := imageWidth - imagePadding
+	pc.fill(image.Rect(imagePadding, top, right, top+height), background)
+
+	x := right
+	for i := range pc.columns {
+		width := pc.widths[i]
+		if i == len(pc.columns)-1 {
+			width = x - imagePadding
+		}
+		text, align := cell(i, image.Rect(x-width, top, x, top+height))
+		if text != "" {
+			pc.text(face, text, x-width+12, x-12, pc.baseline(face, top, height), align, imageTextColor)
+		}
+
+		x -= width
+		if i < len(pc.columns)-1 {
+			pc.fill(image.Rect(x, top, x+1, top+height), imageBorder)
+		}
+	}
+
+	pc.fill(image.Rect(imagePadding, top+height-1, right, top+height), imageBorder)
+}
+
+func (pc *priceListCanvas) drawGroupTitle(title string, top int) {
+	right := imageWidth - imagePadding
+	pc.fill(image.Rect(imagePadding, top, right, top+imageRowHeight), imageGroupFill)
+	pc.text(pc.faces.bold, title, imagePadding+12, right-12,
+		pc.baseline(pc.faces.bold, top, imageRowHeight), "R", imageTextColor)
+	pc.fill(image.Rect(imagePadding, top+imageRowHeight-1, right, top+imageRowHeight), imageBorder)
+}
+
+// noteLines متن آزاد قالب را به خطوط هم‌عرض تصویر می‌شکند
+func (pc *priceListCanvas) noteLines(text string) []string {
+	measure := func(s string) float64 {
+		return float64(font.MeasureString(pc.faces.small, s).Ceil())
+	}
+	return wrapText(text, float64(imageWidth-2*imagePadding), measure)
+}
+
+func (pc *priceListCanvas) drawNote(lines []string, top int) int {
+	for _, line := range lines {
+		pc.text(pc.faces.small, line, imagePadding, imageWidth-imagePadding,
+			pc.baseline(pc.faces.small, top, imageNoteLineHeight), "R", imageTextColor)
+		top += imageNoteLineHeight
+	}
+	return top
+}
+
+// baseline خط پایهٔ متنی که باید وسط کادر قرار بگیرد
+func (pc *priceListCanvas) baseline(face font.Face, top, height int) int {
+	metrics := face.Metrics()
+	return top + (height+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2
+}
+
+func (pc *priceListCanvas) text(face font.Face, text string, left, right, baseline int,
+	align string, c color.Color) {
+	visual := visualText(text)
+	width := font.MeasureString(face, visual).Ceil()
+
+	x := left
+	switch align {
+	case "R":
+		x = right - width
+	case "C":
+		x = left + (right-left-width)/2
+	}
+
+	drawer := &font.Drawer{
+		Dst:  pc.img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	drawer.DrawString(visual)
+}
+
+// fit متن بلند را با «…» کوتاه می‌کند تا در عرض ستون جا شود
+func (pc *priceListCanvas) fit(face font.Face, text string, width int) string {
+	if font.MeasureString(face, visualText(text)).Ceil() <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, visualText(candidate)).Ceil() <= width {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func (pc *priceListCanvas) fill(rect image.Rectangle, c color.Color) {
+	draw.Draw(pc.img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// image تصویر را با حفظ نسبت ابعاد در مرکز کادر می‌نشاند
+func (pc *priceListCanvas) image(src image.Image, box image.Rectangle) {
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return
+	}
+
+	w, h := box.Dx(), box.Dy()
+	if bounds.Dx()*h > bounds.Dy()*w {
+		h = bounds.Dy() * w / bounds.Dx()
+	} else {
+		w = bounds.Dx() * h / bounds.Dy()
+	}
+	x := box.Min.X + (box.Dx()-w)/2
+	y := box.Min.Y + (box.Dy()-h)/2
+
+	xdraw.CatmullRom.Scale(pc.img, image.Rect(x, y, x+w, y+h), src, bounds, draw.Over, nil)
+}
+
+func readShopLogoImage(imageBasePath, imageURL string) image.Image {
+	data, _ := readShopLogo(imageBasePath, imageURL)
+	if data == nil {
+		return nil
+	}
+
+	logo, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	return logo
+}
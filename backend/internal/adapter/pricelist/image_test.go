@@ -0,0 +1,64 @@
+package pricelist
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"testing"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/shopspring/decimal"
+)
+
+func newTestExporter(t *testing.T) *Exporter {
+	t.Helper()
+	exporter, err := NewExporter(config.PriceListRendererConfig{FontDir: "../../../assets/fonts"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	return exporter
+}
+
+func priceListWithProducts(count int) *domain.ShopViewModel {
+	products := make([]*domain.UserProductView, count)
+	for i := range products {
+		products[i] = &domain.UserProductView{
+			ProductCategory: "گوشی",
+			ProductBrand:    "سامسونگ",
+			UserProduct: domain.UserProduct{
+				ModelName:  "A55",
+				FinalPrice: decimal.NewFromInt(25_000_000),
+			},
+		}
+	}
+	return &domain.ShopViewModel{ShopInfo: &domain.User{}, Products: products}
+}
+
+func TestExportPriceListImage(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	out, err := exporter.ExportPriceList(context.Background(), priceListWithProducts(3),
+		domain.PriceListFormatPNG)
+	if err != nil {
+		t.Fatalf("ExportPriceList: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if img.Bounds().Dx() != imageWidth {
+		t.Fatalf("image width = %d, want %d", img.Bounds().Dx(), imageWidth)
+	}
+}
+
+func TestExportPriceListImageRejectsTallLists(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	_, err := exporter.ExportPriceList(context.Background(),
+		priceListWithProducts(imageMaxHeight/imageRowHeight+1), domain.PriceListFormatPNG)
+	if err == nil || err.Error() != msg.ErrPriceListTooLargeForImage {
+		t.Fatalf("error = %v, want %q", err, msg.ErrPriceListTooLargeForImage)
+	}
+}
@@ -0,0 +1,487 @@
+package pricelist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	ptime "github.com/yaa110/go-persian-calendar"
+)
+
+const nativeFontFamily = "Vazirmatn"
+
+var (
+	nativeRegularFontCandidates = []string{
+		"Vazirmatn-FD-Regular.ttf",
+		"Vazirmatn-Regular.ttf",
+		"VazirmatnFD.ttf",
+	}
+	nativeBoldFontCandidates = []string{
+		"Vazirmatn-FD-Bold.ttf",
+		"Vazirmatn-Bold.ttf",
+	}
+)
+
+// ابعاد صفحه و جدول بر حسب میلی‌متر
+const (
+	nativePageMargin     = 10.0
+	nativeBottomMargin   = 15.0
+	nativeRowHeight      = 8.0
+	nativeImageRowHeight = 14.0
+	nativeHeaderHeight   = 9.0
+)
+
+// NativeRenderer لیست قیمت را بدون Chromium و مستقیماً با gofpdf می‌سازد.
+// فونت‌ها یک بار هنگام راه‌اندازی خوانده می‌شوند و لوگوی فروشگاه از مسیر تصاویر روی دیسک برداشته می‌شود.
+type NativeRenderer struct {
+	regularFont   []byte
+	boldFont      []byte
+	imageBasePath string
+}
+
+func NewNativeRenderer(cfg config.PriceListRendererConfig, imageBasePath string) (
+	*NativeRenderer, error) {
+	regularFont, boldFont, err := loadNativeFonts(cfg.FontDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NativeRenderer{
+		regularFont:   regularFont,
+		boldFont:      boldFont,
+		imageBasePath: imageBasePath,
+	}, nil
+}
+
+// loadNativeFonts فایل‌های TTF وزیرمتن را می‌خواند؛ اگر نسخهٔ Bold نبود از Regular استفاده می‌شود
+// resolveFontDir مسیر نسبی را اول نسبت به پوشهٔ کاری و بعد کنار فایل اجرایی جست‌وجو می‌کند
+func resolveFontDir(fontDir string) string {
+	if filepath.IsAbs(fontDir) {
+		return fontDir
+	}
+	if st, err := os.Stat(fontDir); err == nil && st.IsDir() {
+		return fontDir
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), fontDir)
+		if st, err := os.Stat(candidate); err == nil && st.IsDir() {
+			return candidate
+		}
+	}
+	return fontDir
+}
+
+func loadNativeFonts(fontDir string) (regular, bold []byte, err error) {
+	if fontDir == "" {
+		return nil, nil, fmt.Errorf("price list font directory is not configured")
+	}
+	fontDir = resolveFontDir(fontDir)
+
+	regularPath, _, _, ok := pickExistingFont(fontDir, nativeRegularFontCandidates)
+	if !ok {
+		return nil, nil, fmt.Errorf("price list font not found in %s", fontDir)
+	}
+	boldPath, _, _, ok := pickExistingFont(fontDir, nativeBoldFontCandidates)
+	if !ok {
+		boldPath = regularPath
+	}
+
+	if regular, err = os.ReadFile(regularPath); err != nil {
+		return nil, nil, err
+	}
+	if bold, err = os.ReadFile(boldPath); err != nil {
+		return nil, nil, err
+	}
+
+	return regular, bold, nil
+}
+
+func (nr *NativeRenderer) RenderPriceListPDF(ctx context.Context,
+	priceList *domain.ShopViewModel) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	template := priceListTemplate(priceList)
+	doc := &nativeDocument{
+		pdf:      gofpdf.New("P", "mm", string(template.PaperSize), ""),
+		renderer: nr,
+		template: template,
+		columns:  priceListColumns(priceList),
+	}
+	return doc.render(priceList, ptime.Now())
+}
+
+type nativeDocument struct {
+	pdf          *gofpdf.Fpdf
+	renderer     *NativeRenderer
+	template     *domain.PriceListTemplate
+	columns      []priceListColumn
+	widths       []float64
+	imageCounter int
+	inTable      bool
+}
+
+func (d *nativeDocument) render(vm *domain.ShopViewModel, now ptime.Time) ([]byte, error) {
+	pdf := d.pdf
+	pdf.AddUTF8FontFromBytes(nativeFontFamily, "", d.renderer.regularFont)
+	pdf.AddUTF8FontFromBytes(nativeFontFamily, "B", d.renderer.boldFont)
+	pdf.SetMargins(nativePageMargin, nativePageMargin, nativePageMargin)
+	pdf.SetAutoPageBreak(true, nativeBottomMargin)
+	pdf.AliasNbPages("{nb}")
+
+	shop := vm.ShopInfo
+	if shop == nil {
+		shop = &domain.User{}
+	}
+	shopName := strings.TrimSpace(shop.ShopName)
+	if shopName == "" {
+		shopName = "—"
+	}
+	pdf.SetTitle(shopName, true)
+	pdf.SetCreator("nerkhin", true)
+
+	// سرستون جدول در صفحه‌های بعدی تکرار می‌شود
+	pdf.SetHeaderFunc(func() {
+		if d.inTable {
+			d.drawTableHeader()
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont(nativeFontFamily, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, visualText(fmt.Sprintf("صفحه %d از {nb}", pdf.PageNo())),
+			"", 0, "C", false, 0, "")
+	})
+
+	groups := priceListGroups(vm)
+	pageWidth, _ := pdf.GetPageSize()
+	d.widths = d.columnWidths(groups, pageWidth-2*nativePageMargin)
+
+	pdf.AddPage()
+	qrURL, qrCaption := priceListQR(vm)
+	d.drawHeader(shop, shopName, qrURL, qrCaption, now)
+	d.drawNote(d.template.HeaderText, 10)
+
+	rowHeight := nativeRowHeight
+	if d.template.ShowImage {
+		rowHeight = nativeImageRowHeight
+	}
+
+	d.inTable = true
+	d.drawTableHeader()
+	for _, group := range groups {
+		if group.Title != "" {
+			// عنوان گروه تنها در پایین صفحه نمی‌ماند
+			d.ensureSpace(nativeRowHeight + rowHeight)
+			d.drawGroupTitle(group.Title)
+		}
+		for _, row := range group.Rows {
+			d.ensureSpace(rowHeight)
+			d.drawRow(row, rowHeight)
+		}
+	}
+	d.inTable = false
+
+	pdf.Ln(4)
+	d.drawNote(d.template.FooterText, 9)
+	d.drawSocialQRs(shop)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawHeader: لوگو و نام فروشگاه در راست، QR لینک لیست قیمت یا سایت در وسط و تاریخ جلالی در چپ
+func (d *nativeDocument) drawHeader(shop *domain.User, shopName, qrURL, qrCaption string, now ptime.Time) {
+	pdf := d.pdf
+	pageWidth, _ := pdf.GetPageSize()
+	right := pageWidth - nativePageMargin
+	top := nativePageMargin
+
+	const logoSize = 22.0
+	titleRight := right
+	if logo, imageType := readShopLogo(d.renderer.imageBasePath, shop.ImageUrl); logo != nil {
+		if d.image(logo, imageType, right-logoSize, top, logoSize, logoSize) {
+			titleRight = right - logoSize - 3
+		}
+	}
+
+	// نیمهٔ راست برای نام فروشگاه و نیمهٔ چپ برای تاریخ؛ QR سایت وسط است
+	titleWidth := titleRight - pageWidth/2 - 12
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont(nativeFontFamily, "B", 16)
+	pdf.SetXY(titleRight-titleWidth, top+4)
+	pdf.CellFormat(titleWidth, 9, visualText(d.fit(shopName, titleWidth)), "", 0, "R", false, 0, "")
+	pdf.SetFont(nativeFontFamily, "", 9)
+	pdf.SetTextColor(102, 102, 102)
+	pdf.SetXY(titleRight-titleWidth, top+13)
+	pdf.CellFormat(titleWidth, 5, visualText("لیست قیمت فروشگاه"), "", 0, "R", false, 0, "")
+
+	const qrSize = 20.0
+	qrX := (pageWidth - qrSize) / 2
+	if d.image(buildQRPNG(qrURL, 256), "PNG", qrX, top, qrSize, qrSize) {
+		pdf.SetFont(nativeFontFamily, "", 7)
+		pdf.SetXY(qrX-15, top+qrSize)
+		pdf.CellFormat(qrSize+30, 4, visualText(qrCaption), "", 0, "C", false, 0, "")
+	}
+
+	pdf.SetTextColor(51, 51, 51)
+	pdf.SetFont(nativeFontFamily, "B", 11)
+	pdf.SetXY(nativePageMargin, top+7)
+	pdf.CellFormat(pageWidth/2-12-nativePageMargin, 7, visualText(jalaliDateLong(now)),
+		"", 0, "L", false, 0, "")
+
+	contentWidth := pageWidth - 2*nativePageMargin
+	phones := joinNonEmpty(shop.ShopPhone1, shop.ShopPhone2, shop.ShopPhone3)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont(nativeFontFamily, "", 10)
+	pdf.SetXY(nativePageMargin, top+logoSize+6)
+	pdf.CellFormat(contentWidth, 6, visualText("آدرس: "+strings.TrimSpace(shop.ShopAddress)),
+		"", 1, "R", false, 0, "")
+	pdf.CellFormat(contentWidth, 6, visualText("تلفن‌ها: "+phones), "", 1, "R", false, 0, "")
+
+	y := pdf.GetY() + 3
+	pdf.SetDrawColor(229, 229, 229)
+	pdf.Line(nativePageMargin, y, right, y)
+	pdf.SetY(y + 4)
+}
+
+func (d *nativeDocument) drawTableHeader() {
+	pdf := d.pdf
+	pdf.SetFont(nativeFontFamily, "B", 10)
+	pdf.SetFillColor(245, 245, 245)
+	pdf.SetDrawColor(207, 207, 207)
+	pdf.SetTextColor(17, 17, 17)
+
+	const lineHeight = 4.0
+	measure := func(s string) float64 { return pdf.GetStringWidth(s) }
+	d.drawCells(nativeHeaderHeight, true, func(i int, x, y float64) (string, string) {
+		// عنوان ستون‌های باریک دو خطی می‌شود
+		lines := wrapText(d.columns[i].Title, d.widths[i]-3, measure)
+		if len(lines) <= 1 {
+			return d.fit(d.columns[i].Title, d.widths[i]-3), "C"
+		}
+
+		top := y + (nativeHeaderHeight-2*lineHeight)/2
+		for j, line := range lines[:2] {
+			pdf.SetXY(x, top+float64(j)*lineHeight)
+			pdf.CellFormat(d.widths[i], lineHeight, visualText(d.fit(line, d.widths[i]-3)),
+				"", 0, "C", false, 0, "")
+		}
+		return "", "C"
+	})
+}
+
+// columnWidths عرض ستون‌ها بر اساس محتوا با همان قلم‌هایی که جدول با آن‌ها کشیده می‌شود
+func (d *nativeDocument) columnWidths(groups []priceListGroup, total float64) []float64 {
+	pdf := d.pdf
+	measure := func(style string, size float64) func(string) float64 {
+		return func(s string) float64 {
+			pdf.SetFont(nativeFontFamily, style, size)
+			return pdf.GetStringWidth(s)
+		}
+	}
+
+	return priceListContentWidths(d.columns, groups, total,
+		measure("B", 10), measure("", 9), 3, nativeImageRowHeight-2)
+}
+
+func (d *nativeDocument) drawGroupTitle(title string) {
+	pdf := d.pdf
+	pageWidth, _ := pdf.GetPageSize()
+	pdf.SetFont(nativeFontFamily, "B", 10)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetDrawColor(207, 207, 207)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetX(nativePageMargin)
+	pdf.CellFormat(pageWidth-2*nativePageMargin, nativeRowHeight, visualText(title),
+		"1", 1, "R", true, 0, "")
+}
+
+func (d *nativeDocument) drawRow(row priceListRow, height float64) {
+	pdf := d.pdf
+	pdf.SetFont(nativeFontFamily, "", 9)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetDrawColor(207, 207, 207)
+	pdf.SetFillColor(250, 250, 250)
+
+	d.drawCells(height, row.Index%2 == 0, func(i int, x, y float64) (string, string) {
+		col := d.columns[i]
+		if col.Key == columnImage {
+			if data, imageType := readShopLogo(d.renderer.imageBasePath, row.ImageURL); data != nil {
+				const pad = 1.0
+				d.image(data, imageType, x+pad, y+pad, d.widths[i]-2*pad, height-2*pad)
+			}
+			return "", col.Align
+		}
+		return d.fit(row.text(col.Key), d.widths[i]-3), col.Align
+	})
+}
+
+// drawCells ستون‌ها را از راست به چپ می‌چیند؛ cell مختصات خانه را هم می‌گیرد تا بتواند تصویر بکشد
+func (d *nativeDocument) drawCells(height float64, fill bool,
+	cell func(i int, x, y float64) (text, align string)) {
+	pdf := d.pdf
+	pageWidth, _ := pdf.GetPageSize()
+	x := pageWidth - nativePageMargin
+	y := pdf.GetY()
+
+	for i := range d.columns {
+		x -= d.widths[i]
+		// کادر و پس‌زمینه قبل از محتوا کشیده می‌شود تا تصویر زیر آن نرود
+		pdf.SetXY(x, y)
+		pdf.CellFormat(d.widths[i], height, "", "1", 0, "", fill, 0, "")
+		text, align := cell(i, x, y)
+		if text != "" {
+			pdf.SetXY(x, y)
+			pdf.CellFormat(d.widths[i], height, visualText(text), "", 0, align, false, 0, "")
+		}
+	}
+
+	pdf.SetXY(nativePageMargin, y+height)
+}
+
+// ensureSpace اگر ارتفاع لازم در صفحه نمانده باشد صفحهٔ تازه باز می‌کند
+func (d *nativeDocument) ensureSpace(height float64) {
+	_, pageHeight := d.pdf.GetPageSize()
+	if d.pdf.GetY()+height > pageHeight-nativeBottomMargin {
+		d.pdf.AddPage()
+	}
+}
+
+// drawNote متن آزاد قالب (سربرگ یا شرایط فروش) را راست‌چین و چندخطی می‌نویسد
+func (d *nativeDocument) drawNote(text string, fontSize float64) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	pdf := d.pdf
+	pageWidth, _ := pdf.GetPageSize()
+	width := pageWidth - 2*nativePageMargin
+	lineHeight := fontSize * 0.5
+
+	pdf.SetFont(nativeFontFamily, "", fontSize)
+	pdf.SetTextColor(51, 51, 51)
+	for _, line := range wrapText(text, width, pdf.GetStringWidth) {
+		d.ensureSpace(lineHeight)
+		pdf.SetX(nativePageMargin)
+		pdf.CellFormat(width, lineHeight, visualText(line), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+}
+
+// fit متن بلند را با «…» کوتاه می‌کند تا در عرض ستون جا شود
+func (d *nativeDocument) fit(text string, width float64) string {
+	if d.pdf.GetStringWidth(visualText(text)) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if d.pdf.GetStringWidth(visualText(candidate)) <= width {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func (d *nativeDocument) drawSocialQRs(shop *domain.User) {
+	socials := []struct {
+		label string
+		url   string
+	}{
+		{"Instagram", strings.TrimSpace(shop.InstagramUrl)},
+		{"Telegram", strings.TrimSpace(shop.TelegramUrl)},
+		{"WhatsApp", strings.TrimSpace(shop.WhatsappUrl)},
+	}
+
+	pdf := d.pdf
+	pageWidth, pageHeight := pdf.GetPageSize()
+	const cardSize = 26.0
+
+	x := pageWidth - nativePageMargin
+	y := pdf.GetY() + 6
+	for _, s := range socials {
+		png := buildQRPNG(s.url, 256)
+		if png == nil {
+			continue
+		}
+
+		if y+cardSize+6 > pageHeight-nativeBottomMargin {
+			pdf.AddPage()
+			y = pdf.GetY()
+		}
+
+		x -= cardSize
+		if !d.image(png, "PNG", x, y, cardSize, cardSize) {
+			x += cardSize
+			continue
+		}
+		pdf.SetFont(nativeFontFamily, "B", 8)
+		pdf.SetTextColor(68, 68, 68)
+		pdf.SetXY(x, y+cardSize+1)
+		pdf.CellFormat(cardSize, 4, s.label, "", 0, "C", false, 0, "")
+		x -= 4
+	}
+}
+
+// image تصویر را ثبت و رسم می‌کند؛ اگر تصویر قابل خواندن نباشد false برمی‌گرداند
+func (d *nativeDocument) image(data []byte, imageType string, x, y, w, h float64) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	pdf := d.pdf
+	d.imageCounter++
+	name := fmt.Sprintf("img-%d", d.imageCounter)
+	options := gofpdf.ImageOptions{ImageType: imageType}
+
+	info := pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(data))
+	if pdf.Err() || info == nil {
+		// تصویر خراب نباید کل PDF را از کار بیندازد
+		pdf.ClearError()
+		return false
+	}
+
+	pdf.ImageOptions(name, x, y, w, h, false, options, 0, "")
+	return true
+}
+
+// readShopLogo لوگوی فروشگاه را از مسیر تصاویر آپلودشده می‌خواند؛ فقط PNG/JPEG/GIF پشتیبانی می‌شود
+func readShopLogo(imageBasePath, imageURL string) ([]byte, string) {
+	name := filepath.Base(strings.TrimSpace(imageURL))
+	if name == "" || name == "." || name == "/" || imageBasePath == "" {
+		return nil, ""
+	}
+
+	data, err := os.ReadFile(filepath.Join(imageBasePath, name))
+	if err != nil {
+		return nil, ""
+	}
+
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return data, "PNG"
+	case "image/jpeg":
+		return data, "JPG"
+	case "image/gif":
+		return data, "GIF"
+	}
+
+	return nil, ""
+}
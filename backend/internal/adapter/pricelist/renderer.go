@@ -0,0 +1,43 @@
+package pricelist
+
+import (
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/port"
+)
+
+const (
+	EngineChromium = "chromium"
+	EngineNative   = "native"
+)
+
+// NewPriceListRenderer موتور ساخت PDF را بر اساس تنظیمات انتخاب می‌کند و آن را پشت کش قرار می‌دهد.
+// تابع برگشتی close باید هنگام خاموش شدن برنامه صدا زده شود.
+func NewPriceListRenderer(appConfig config.App) (renderer port.PriceListRenderer,
+	closeFn func(), err error) {
+	cfg := appConfig.PriceListRenderer
+	closeFn = func() {}
+
+	switch cfg.Engine {
+	case EngineNative:
+		native, err := NewNativeRenderer(cfg, appConfig.ImageBasePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		renderer = native
+	default:
+		chromium := NewChromiumRenderer(cfg)
+		renderer = chromium
+		closeFn = chromium.Close
+	}
+
+	ttl := time.Duration(cfg.CacheTTLMinutes) * time.Minute
+	timeoutSeconds := cfg.TimeoutSeconds
+	if timeoutSeconds < 1 {
+		timeoutSeconds = 60
+	}
+	// دو برابر زمان رندر تا انتظار در صف Chromium هم پوشش داده شود
+	timeout := 2 * time.Duration(timeoutSeconds) * time.Second
+	return NewCachedRenderer(renderer, cfg.CacheSize, ttl, timeout), closeFn, nil
+}
@@ -0,0 +1,304 @@
+package pricelist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/shopspring/decimal"
+)
+
+type priceListColumnKey string
+
+const (
+	columnIndex       priceListColumnKey = "index"
+	columnSKU         priceListColumnKey = "sku"
+	columnImage       priceListColumnKey = "image"
+	columnTitle       priceListColumnKey = "title"
+	columnDescription priceListColumnKey = "description"
+	columnDollarPrice priceListColumnKey = "dollar_price"
+	columnPrice       priceListColumnKey = "price"
+	columnRetailPrice priceListColumnKey = "retail_price"
+	columnUpdated     priceListColumnKey = "updated"
+)
+
+// priceListColumn ستون خروجی‌ها به ترتیب راست به چپ؛ Weight سهم نسبی از عرض جدول است
+type priceListColumn struct {
+	Key    priceListColumnKey
+	Title  string
+	Weight float64
+	Align  string // "R" یا "C"
+}
+
+// priceListColumns ستون‌های مشترک همهٔ خروجی‌های لیست قیمت (PDF، اکسل، CSV و تصویر) بر اساس قالب.
+// ستون کد کالا فقط وقتی می‌آید که دست‌کم یک محصول لیست کد داشته باشد.
+func priceListColumns(vm *domain.ShopViewModel) []priceListColumn {
+	template := priceListTemplate(vm)
+	columns := []priceListColumn{{columnIndex, "ردیف", 8, "C"}}
+	if template.ShowSKU && priceListHasSKU(vm) {
+		columns = append(columns, priceListColumn{columnSKU, "کد کالا", 14, "C"})
+	}
+	if template.ShowImage {
+		columns = append(columns, priceListColumn{columnImage, "تصویر", 12, "C"})
+	}
+	columns = append(columns, priceListColumn{columnTitle, "نام محصول", 44, "R"})
+	if template.ShowDescription {
+		columns = append(columns, priceListColumn{columnDescription, "توضیحات", 30, "R"})
+	}
+	if template.ShowDollarPrice {
+		columns = append(columns, priceListColumn{columnDollarPrice, "قیمت دلاری", 16, "C"})
+	}
+	columns = append(columns, priceListColumn{columnPrice, "قیمت", 20, "C"})
+	if template.MarkupPercent.Valid {
+		columns = append(columns, priceListColumn{columnRetailPrice, "قیمت پیشنهادی مصرف‌کننده", 20, "C"})
+	}
+	columns = append(columns, priceListColumn{columnUpdated, "آخرین بروزرسانی", 20, "C"})
+
+	return columns
+}
+
+func priceListHasSKU(vm *domain.ShopViewModel) bool {
+	for _, item := range vm.Products {
+		if item.SKUValue() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// priceListColumnWidths عرض هر ستون از total به نسبت Weight
+func priceListColumnWidths(columns []priceListColumn, total float64) []float64 {
+	sum := 0.0
+	for _, col := range columns {
+		sum += col.Weight
+	}
+
+	widths := make([]float64, len(columns))
+	for i, col := range columns {
+		widths[i] = col.Weight / sum * total
+	}
+	return widths
+}
+
+// priceListContentWidths ستون‌های عددی و تاریخ را به اندازهٔ پهن‌ترین مقدارشان باز می‌کند تا عددی
+// بریده نشود و باقی عرض را به نسبت Weight بین نام محصول و توضیحات تقسیم می‌کند.
+// عنوان ستون‌ها می‌تواند دو خطی شود، پس از عنوان فقط طولانی‌ترین کلمه ملاک است.
+// اگر ستون‌های ثابت بیش از سه‌چهارم عرض را بگیرند همان تقسیم نسبی برگردانده می‌شود.
+func priceListContentWidths(columns []priceListColumn, groups []priceListGroup, total float64,
+	measureTitle, measureText func(string) float64, padding, minImageWidth float64) []float64 {
+	widths := make([]float64, len(columns))
+	fixedWidth, flexWeight := 0.0, 0.0
+	for i, col := range columns {
+		if col.Key == columnTitle || col.Key == columnDescription {
+			flexWeight += col.Weight
+			continue
+		}
+
+		for _, word := range strings.Fields(col.Title) {
+			widths[i] = max(widths[i], measureTitle(visualText(word)))
+		}
+		if col.Key == columnImage {
+			widths[i] = max(widths[i], minImageWidth)
+		}
+		for _, group := range groups {
+			for _, row := range group.Rows {
+				widths[i] = max(widths[i], measureText(visualText(row.text(col.Key))))
+			}
+		}
+		widths[i] += padding
+		fixedWidth += widths[i]
+	}
+
+	flexWidth := total - fixedWidth
+	if flexWeight == 0 || flexWidth < total/4 {
+		return priceListColumnWidths(columns, total)
+	}
+
+	for i, col := range columns {
+		if col.Key == columnTitle || col.Key == columnDescription {
+			widths[i] = col.Weight / flexWeight * flexWidth
+		}
+	}
+	return widths
+}
+
+// priceListRow یک ردیف لیست قیمت
+type priceListRow struct {
+	Index       int
+	SKU         string
+	Title       string
+	Category    string
+	Brand       string
+	Description string
+	ImageURL    string
+	DollarPrice decimal.NullDecimal // فقط برای محصولات دلاری
+	Price       decimal.Decimal
+	RetailPrice decimal.NullDecimal
+	UpdatedAt   time.Time
+}
+
+// priceListGroup ردیف‌های یک دسته یا برند؛ بدون گروه‌بندی فقط یک گروه با عنوان خالی وجود دارد
+type priceListGroup struct {
+	Title string
+	Rows  []priceListRow
+}
+
+func priceListTemplate(vm *domain.ShopViewModel) *domain.PriceListTemplate {
+	if vm.Template == nil {
+		return domain.DefaultPriceListTemplate()
+	}
+	return vm.Template
+}
+
+// priceListGroups ردیف‌ها را طبق قالب مرتب و گروه‌بندی می‌کند.
+// ترتیب پیش‌فرض همان ترتیب GetPriceList (بر اساس برند) است و شماره ردیف در کل لیست پیوسته است.
+func priceListGroups(vm *domain.ShopViewModel) []priceListGroup {
+	template := priceListTemplate(vm)
+
+	rows := make([]priceListRow, 0, len(vm.Products))
+	for _, item := range vm.Products {
+		row := priceListRow{
+			SKU:         item.SKUValue(),
+			Title:       joinNonEmpty(item.ProductCategory, item.ProductBrand, item.ModelName),
+			Category:    strings.TrimSpace(item.ProductCategory),
+			Brand:       strings.TrimSpace(item.ProductBrand),
+			Description: strings.TrimSpace(item.Description),
+			ImageURL:    strings.TrimSpace(item.DefaultImageUrl),
+			Price:       item.FinalPrice,
+			RetailPrice: template.RetailPrice(item.FinalPrice),
+			UpdatedAt:   item.UpdatedAt.Time,
+		}
+		if item.IsDollar {
+			row.DollarPrice = item.DollarPrice
+		}
+		rows = append(rows, row)
+	}
+
+	sortPriceListRows(rows, template)
+
+	var groups []priceListGroup
+	if template.GroupBy == domain.PriceListGroupNone || template.GroupBy == "" {
+		groups = []priceListGroup{{Rows: rows}}
+	} else {
+		byTitle := map[string]int{}
+		for _, row := range rows {
+			title := row.Category
+			if template.GroupBy == domain.PriceListGroupBrand {
+				title = row.Brand
+			}
+
+			i, ok := byTitle[title]
+			if !ok {
+				i = len(groups)
+				byTitle[title] = i
+				groups = append(groups, priceListGroup{Title: title})
+			}
+			groups[i].Rows = append(groups[i].Rows, row)
+		}
+
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].Title < groups[j].Title })
+	}
+
+	index := 0
+	for _, group := range groups {
+		for i := range group.Rows {
+			index++
+			group.Rows[i].Index = index
+		}
+	}
+
+	return groups
+}
+
+func sortPriceListRows(rows []priceListRow, template *domain.PriceListTemplate) {
+	var less func(a, b priceListRow) bool
+	switch template.SortBy {
+	case domain.PriceListSortModel:
+		less = func(a, b priceListRow) bool { return a.Title < b.Title }
+	case domain.PriceListSortPrice:
+		less = func(a, b priceListRow) bool { return a.Price.LessThan(b.Price) }
+	case domain.PriceListSortUpdated:
+		less = func(a, b priceListRow) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		return
+	}
+
+	desc := template.SortDir == domain.SortDesc
+	sort.SliceStable(rows, func(i, j int) bool {
+		if desc {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+}
+
+// text مقدار متنی ستون؛ ستون تصویر متن ندارد
+func (r priceListRow) text(key priceListColumnKey) string {
+	switch key {
+	case columnIndex:
+		return fmt.Sprintf("%d", r.Index)
+	case columnSKU:
+		if r.SKU == "" {
+			return "—"
+		}
+		return r.SKU
+	case columnTitle:
+		return r.Title
+	case columnDescription:
+		return r.Description
+	case columnDollarPrice:
+		if !r.DollarPrice.Valid {
+			return "—"
+		}
+		return moneyUSD(r.DollarPrice.Decimal)
+	case columnPrice:
+		return moneyIRR(r.Price)
+	case columnRetailPrice:
+		if !r.RetailPrice.Valid {
+			return "—"
+		}
+		return moneyIRR(r.RetailPrice.Decimal)
+	case columnUpdated:
+		return r.updatedJalali()
+	}
+	return ""
+}
+
+// updatedJalali تاریخ آخرین بروزرسانی ردیف به شکل yyyy/mm/dd جلالی
+func (r priceListRow) updatedJalali() string {
+	if r.UpdatedAt.IsZero() {
+		return "—"
+	}
+	return ymdJalali_LTR(r.UpdatedAt)
+}
+
+func priceListShopName(vm *domain.ShopViewModel) string {
+	if vm.ShopInfo == nil {
+		return ""
+	}
+	return joinNonEmpty(vm.ShopInfo.ShopName)
+}
+
+// wrapText متن را (به ترتیب منطقی) در خطوطی می‌شکند که عرض نمایشی هر کدام از width بیشتر نشود
+func wrapText(text string, width float64, measure func(string) float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		words := strings.Fields(paragraph)
+		line := ""
+		for _, word := range words {
+			candidate := joinNonEmpty(line, word)
+			if line != "" && measure(visualText(candidate)) > width {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line = candidate
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,222 @@
+package pricelist
+
+import "strings"
+
+// gofpdf متن را همان‌طور که هست چاپ می‌کند؛ نه حروف فارسی را به هم می‌چسباند و نه ترتیب
+// راست‌به‌چپ را رعایت می‌کند. visualText این دو کار را با یک پیاده‌سازی ساده انجام می‌دهد:
+// حروف به شکل‌های Presentation Forms تبدیل می‌شوند و سپس متن به ترتیب نمایشی (چپ به راست) مرتب می‌شود.
+
+// letterForms شکل‌های تنها، پایانی، آغازی و میانی یک حرف؛
+// برای حروفی که فقط از راست می‌چسبند initial و medial صفر است.
+type letterForms struct {
+	isolated, final, initial, medial rune
+}
+
+func (lf letterForms) joinsForward() bool {
+	return lf.initial != 0
+}
+
+var persianLetterForms = map[rune]letterForms{
+	'ء': {0xFE80, 0, 0, 0},                // ء
+	'آ': {0xFE81, 0xFE82, 0, 0},           // آ
+	'أ': {0xFE83, 0xFE84, 0, 0},           // أ
+	'ؤ': {0xFE85, 0xFE86, 0, 0},           // ؤ
+	'إ': {0xFE87, 0xFE88, 0, 0},           // إ
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C}, // ئ
+	'ا': {0xFE8D, 0xFE8E, 0, 0},           // ا
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92}, // ب
+	'ة': {0xFE93, 0xFE94, 0, 0},           // ة
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98}, // ت
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C}, // ث
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0}, // ج
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4}, // ح
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8}, // خ
+	'د': {0xFEA9, 0xFEAA, 0, 0},           // د
+	'ذ': {0xFEAB, 0xFEAC, 0, 0},           // ذ
+	'ر': {0xFEAD, 0xFEAE, 0, 0},           // ر
+	'ز': {0xFEAF, 0xFEB0, 0, 0},           // ز
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4}, // س
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8}, // ش
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC}, // ص
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0}, // ض
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4}, // ط
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8}, // ظ
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC}, // ع
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0}, // غ
+	'ـ': {0x0640, 0x0640, 0x0640, 0x0640}, // ـ
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4}, // ف
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8}, // ق
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC}, // ك
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0}, // ل
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4}, // م
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8}, // ن
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC}, // ه
+	'و': {0xFEED, 0xFEEE, 0, 0},           // و
+	'ى': {0xFEEF, 0xFEF0, 0, 0},           // ى
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4}, // ي
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59}, // پ
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D}, // چ
+	'ژ': {0xFB8A, 0xFB8B, 0, 0},           // ژ
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91}, // ک
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95}, // گ
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF}, // ی
+	'ە': {0x06D5, 0x06D5, 0, 0},           // ە
+}
+
+// lamAlefForms لیگاتور «لا» برای هر نوع الف: شکل تنها و پایانی
+var lamAlefForms = map[rune][2]rune{
+	'آ': {0xFEF5, 0xFEF6},
+	'أ': {0xFEF7, 0xFEF8},
+	'إ': {0xFEF9, 0xFEFA},
+	'ا': {0xFEFB, 0xFEFC},
+}
+
+const (
+	zwnj = '‌'
+	lam  = 'ل'
+)
+
+// isTransparent اعراب در اتصال حروف نقشی ندارند
+func isTransparent(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+func isRTLRune(r rune) bool {
+	if r >= 0x06F0 && r <= 0x06F9 { // ارقام فارسی مثل ارقام لاتین چپ‌به‌راست هستند
+		return false
+	}
+	return (r >= 0x0600 && r <= 0x06FF) || (r >= 0xFB50 && r <= 0xFDFF) || (r >= 0xFE70 && r <= 0xFEFF)
+}
+
+func isLTRRune(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+		(r >= 0x06F0 && r <= 0x06F9) || (r >= 0x00C0 && r <= 0x024F) || r == '{' || r == '}'
+}
+
+// shapePersian حروف را (به ترتیب منطقی) به شکل چسبیدهٔ مناسب تبدیل می‌کند
+func shapePersian(runes []rune) []rune {
+	neighbor := func(i, step int) (letterForms, bool) {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if isTransparent(runes[j]) {
+				continue
+			}
+			lf, ok := persianLetterForms[runes[j]]
+			return lf, ok
+		}
+		return letterForms{}, false
+	}
+
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		lf, ok := persianLetterForms[r]
+		if !ok {
+			if r != zwnj {
+				out = append(out, r)
+			}
+			continue
+		}
+
+		prev, hasPrev := neighbor(i, -1)
+		joinsPrev := hasPrev && prev.joinsForward()
+
+		if r == lam && i+1 < len(runes) {
+			if ligature, ok := lamAlefForms[runes[i+1]]; ok {
+				if joinsPrev {
+					out = append(out, ligature[1])
+				} else {
+					out = append(out, ligature[0])
+				}
+				i++
+				continue
+			}
+		}
+
+		_, hasNext := neighbor(i, 1)
+		joinsNext := hasNext && lf.joinsForward()
+
+		switch {
+		case joinsPrev && joinsNext:
+			out = append(out, lf.medial)
+		case joinsPrev && lf.final != 0:
+			out = append(out, lf.final)
+		case joinsNext:
+			out = append(out, lf.initial)
+		default:
+			out = append(out, lf.isolated)
+		}
+	}
+
+	return out
+}
+
+var mirroredRunes = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '<': '>', '>': '<', '«': '»', '»': '«',
+}
+
+// visualText متن منطقی را به ترتیب نمایشی برای چاپ چپ‌به‌راست تبدیل می‌کند؛ جهت پایه راست‌به‌چپ است.
+// تکه‌های لاتین و عددی ترتیب خودشان را حفظ می‌کنند و علامت‌های خنثی جهت همسایه‌ها را می‌گیرند.
+func visualText(s string) string {
+	runes := []rune(s)
+
+	hasRTL := false
+	for _, r := range runes {
+		if isRTLRune(r) {
+			hasRTL = true
+			break
+		}
+	}
+	if !hasRTL {
+		return s
+	}
+
+	runes = shapePersian(runes)
+
+	// true یعنی راست‌به‌چپ
+	dirs := make([]bool, len(runes))
+	for i, r := range runes {
+		if isRTLRune(r) || isLTRRune(r) {
+			dirs[i] = isRTLRune(r)
+			continue
+		}
+
+		prevRTL, nextRTL := true, true
+		for j := i - 1; j >= 0; j-- {
+			if isRTLRune(runes[j]) || isLTRRune(runes[j]) {
+				prevRTL = isRTLRune(runes[j])
+				break
+			}
+		}
+		for j := i + 1; j < len(runes); j++ {
+			if isRTLRune(runes[j]) || isLTRRune(runes[j]) {
+				nextRTL = isRTLRune(runes[j])
+				break
+			}
+		}
+		dirs[i] = prevRTL || nextRTL
+	}
+
+	var b strings.Builder
+	for end := len(runes); end > 0; {
+		start := end - 1
+		for start > 0 && dirs[start-1] == dirs[end-1] {
+			start--
+		}
+
+		if dirs[end-1] {
+			for i := end - 1; i >= start; i-- {
+				if m, ok := mirroredRunes[runes[i]]; ok {
+					b.WriteRune(m)
+					continue
+				}
+				b.WriteRune(runes[i])
+			}
+		} else {
+			b.WriteString(string(runes[start:end]))
+		}
+
+		end = start
+	}
+
+	return b.String()
+}
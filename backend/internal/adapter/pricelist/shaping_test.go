@@ -0,0 +1,60 @@
+package pricelist
+
+import "testing"
+
+func TestShapePersian(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "isolated letter", in: "ب", want: "ﺏ"},
+		{name: "initial medial final", in: "ببب", want: "ﺑﺒﺐ"},
+		{name: "right-joining letter breaks the chain", in: "دب", want: "ﺩﺏ"},
+		{name: "right-joining letter takes final form", in: "بد", want: "ﺑﺪ"},
+		{name: "isolated lam-alef", in: "لا", want: "ﻻ"},
+		{name: "final lam-alef", in: "بلا", want: "ﺑﻼ"},
+		{name: "lam-alef with madda", in: "لآ", want: "ﻵ"},
+		{name: "letter after lam-alef starts a new chain", in: "سلام", want: "ﺳﻼﻡ"},
+		{name: "zwnj stops joining and is dropped", in: "می‌شود", want: "ﻣﯽﺷﻮﺩ"},
+		{name: "harakat are transparent", in: "بَب", want: "ﺑَﺐ"},
+		{name: "non-letters pass through", in: "ب 1", want: "ﺏ 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(shapePersian([]rune(tt.in)))
+			if got != tt.want {
+				t.Fatalf("shapePersian(%q) = %+q, want %+q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisualText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "latin only is untouched", in: "Price List (2)", want: "Price List (2)"},
+		{name: "persian is reversed", in: "سلام", want: "ﻡﻼﺳ"},
+		{
+			name: "latin digits keep their order",
+			in:   "قیمت 120 تومان",
+			want: "ﻥﺎﻣﻮﺗ 120 ﺖﻤﯿﻗ",
+		},
+		{name: "persian digits keep their order", in: "کد ۱۲۳", want: "۱۲۳ ﺪﮐ"},
+		{name: "latin word inside persian", in: "مدل ABC", want: "ABC ﻝﺪﻣ"},
+		{name: "brackets are mirrored", in: "(الف)", want: "(ﻒﻟﺍ)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := visualText(tt.in)
+			if got != tt.want {
+				t.Fatalf("visualText(%q) = %+q, want %+q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
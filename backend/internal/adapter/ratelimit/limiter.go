@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+)
+
+// نام سیاست‌ها؛ هر گروه از مسیرها سیاست و کلید خودش را دارد
+const (
+	PolicyAPI    = "api"    // همهٔ مسیرها به ازای IP
+	PolicyAuth   = "auth"   // ورود، ثبت‌نام و تمدید توکن به ازای IP
+	PolicyOTP    = "otp"    // ارسال و بررسی کد تایید به ازای شماره
+	PolicySearch = "search" // جست‌وجو و فیلتر محصولات به ازای کاربر
+	PolicyPublic = "public" // لیست قیمت عمومی به ازای IP
+)
+
+// Policy یک token bucket با ظرفیت Burst که هر ثانیه Rate توکن پر می‌شود
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+type Limiter struct {
+	store    Store
+	policies map[string]Policy
+}
+
+// NewLimiter سیاست‌ها را از کانفیگ می‌سازد؛ سیاستی که نرخ یا ظرفیتش صفر باشد غیرفعال است
+func NewLimiter(store Store, cfg config.RateLimitConfig) *Limiter {
+	limiter := &Limiter{
+		store:    store,
+		policies: map[string]Policy{},
+	}
+	if !cfg.Enabled {
+		return limiter
+	}
+
+	for name, policyConfig := range map[string]config.RateLimitPolicyConfig{
+		PolicyAPI:    cfg.API,
+		PolicyAuth:   cfg.Auth,
+		PolicyOTP:    cfg.OTP,
+		PolicySearch: cfg.Search,
+		PolicyPublic: cfg.Public,
+	} {
+		if policyConfig.PerMinute <= 0 || policyConfig.Burst <= 0 {
+			continue
+		}
+		limiter.policies[name] = Policy{
+			Name:  name,
+			Rate:  float64(policyConfig.PerMinute) / 60,
+			Burst: policyConfig.Burst,
+		}
+	}
+
+	return limiter
+}
+
+// Enabled مشخص می‌کند سیاست name فعال است یا نه
+func (l *Limiter) Enabled(name string) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.policies[name]
+	return ok
+}
+
+// Allow یک درخواست با کلید key را طبق سیاست name می‌شمارد؛ سیاست غیرفعال همیشه مجاز است
+func (l *Limiter) Allow(ctx context.Context, name, key string) (Result, error) {
+	if l == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	policy, ok := l.policies[name]
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+
+	return l.store.Take(ctx, name+":"+key, policy, time.Now())
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store وضعیت سطل‌های token bucket را نگه می‌دارد. MemoryStore برای یک نمونهٔ برنامه کافی است؛
+// برای اجرای چند نمونه پشت load balancer باید یک Store مشترک (مثلا Redis با اسکریپت اتمیک)
+// همین رابط را پیاده کند تا همهٔ نمونه‌ها یک سطل را ببینند.
+type Store interface {
+	// Take یک توکن از سطل key برمی‌دارد و اگر سطل خالی باشد زمان پر شدن توکن بعدی را برمی‌گرداند
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// memorySweepInterval فاصلهٔ پاک‌سازی سطل‌هایی که دوباره پر شده‌اند و دیگر لازم نیستند
+const memorySweepInterval = time.Minute
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// زمانی که سطل کاملا پر می‌شود؛ بعد از آن نگه داشتن سطل فرقی با نبودنش ندارد
+	fullAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (ms *MemoryStore) Take(_ context.Context, key string, policy Policy,
+	now time.Time) (Result, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.sweep(now)
+
+	burst := float64(policy.Burst)
+	b, ok := ms.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		ms.buckets[key] = b
+	} else if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*policy.Rate)
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / policy.Rate
+		return Result{RetryAfter: time.Duration(wait * float64(time.Second))}, nil
+	}
+
+	b.tokens--
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / policy.Rate * float64(time.Second)))
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.lastSweep) < memorySweepInterval {
+		return
+	}
+	ms.lastSweep = now
+
+	for key, b := range ms.buckets {
+		if !now.Before(b.fullAt) {
+			delete(ms.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Rate: 1, Burst: 3} // یک توکن در ثانیه
+	now := time.Unix(1_700_000_000, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, _ := store.Take(ctx, "k", policy, now)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i, result, 2-i)
+		}
+	}
+
+	result, _ := store.Take(ctx, "k", policy, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("empty bucket = %+v, want denied with 1s retry", result)
+	}
+
+	if result, _ := store.Take(ctx, "other", policy, now); !result.Allowed {
+		t.Fatal("buckets are not separated by key")
+	}
+
+	result, _ = store.Take(ctx, "k", policy, now.Add(500*time.Millisecond))
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("half refilled = %+v, want denied with 500ms retry", result)
+	}
+
+	if result, _ := store.Take(ctx, "k", policy, now.Add(time.Second)); !result.Allowed {
+		t.Fatal("bucket did not refill")
+	}
+
+	// بعد از مدت طولانی سطل از ظرفیتش بیشتر پر نمی‌شود
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take(ctx, "k", policy, later); !result.Allowed {
+			t.Fatalf("take %d after refill denied", i)
+		}
+	}
+	if result, _ := store.Take(ctx, "k", policy, later); result.Allowed {
+		t.Fatal("bucket refilled beyond burst")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Rate: 1, Burst: 2}
+	now := time.Unix(1_700_000_000, 0)
+
+	store.Take(context.Background(), "k", policy, now)
+	store.Take(context.Background(), "other", policy, now.Add(2*memorySweepInterval))
+
+	if _, ok := store.buckets["k"]; ok {
+		t.Fatal("full bucket was not swept")
+	}
+	if _, ok := store.buckets["other"]; !ok {
+		t.Fatal("active bucket was swept")
+	}
+}
+
+func TestLimiterPolicies(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled: true,
+		OTP:     config.RateLimitPolicyConfig{PerMinute: 60, Burst: 1},
+		API:     config.RateLimitPolicyConfig{PerMinute: 0, Burst: 10},
+	}
+	limiter := NewLimiter(NewMemoryStore(), cfg)
+
+	if !limiter.Enabled(PolicyOTP) || limiter.Enabled(PolicyAPI) {
+		t.Fatal("policies with a zero rate or burst must be disabled")
+	}
+
+	ctx := context.Background()
+	if result, _ := limiter.Allow(ctx, PolicyOTP, "phone:9121234567"); !result.Allowed {
+		t.Fatal("first request denied")
+	}
+	if result, _ := limiter.Allow(ctx, PolicyOTP, "phone:9121234567"); result.Allowed {
+		t.Fatal("second request allowed past burst")
+	}
+	if result, _ := limiter.Allow(ctx, PolicyAPI, "ip:1.2.3.4"); !result.Allowed {
+		t.Fatal("disabled policy denied a request")
+	}
+
+	disabled := NewLimiter(NewMemoryStore(), config.RateLimitConfig{OTP: cfg.OTP})
+	if disabled.Enabled(PolicyOTP) {
+		t.Fatal("limiter must be off when rate limiting is disabled")
+	}
+}
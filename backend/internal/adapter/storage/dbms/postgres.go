@@ -9,6 +9,7 @@ import (
 
 	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain/audit"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -74,6 +75,17 @@ func (p *PostgresDBMS) BeginTransaction(ctx context.Context, dbSession interface
 		}
 	}()
 
+	// تریگرهای audit_row_change تغییرات را با شناسهٔ درخواست ادمین ثبت می‌کنند. تنظیم با is_local
+	// فقط تا پایان همین تراکنش می‌ماند تا به اتصال بعدی pool نرسد؛ برای همین تغییری که بیرون از
+	// BeginTransaction نوشته شود در audit_change نمی‌آید و سرویس‌ها نوشتن‌ها را داخل تراکنش انجام می‌دهند.
+	if requestID := audit.RequestID(ctx); requestID != "" {
+		err = tx.Exec("SELECT set_config('nerkhin.audit_request_id', ?, true)", requestID).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	err = fn(tx)
 	if err != nil {
 		tx.Rollback()
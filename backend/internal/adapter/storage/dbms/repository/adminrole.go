@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type AdminRoleRepository struct{}
+
+func (arr *AdminRoleRepository) GetAdminRoles(ctx context.Context, dbSession interface{}) (
+	roles []*domain.AdminRole, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	roles = []*domain.AdminRole{}
+	err = db.Order("id").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rolePermissions := []*domain.AdminRolePermission{}
+	err = db.Order("role_id, permission").Find(&rolePermissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rolesByID := make(map[int64]*domain.AdminRole, len(roles))
+	for _, role := range roles {
+		role.Permissions = []domain.Permission{}
+		rolesByID[role.ID] = role
+	}
+	for _, rp := range rolePermissions {
+		if role, ok := rolesByID[rp.RoleID]; ok {
+			role.Permissions = append(role.Permissions, rp.Permission)
+		}
+	}
+
+	return roles, nil
+}
+
+// GetAdminRole نقش را با دسترسی‌هایش برمی‌گرداند؛ اگر نبود nil
+func (arr *AdminRoleRepository) GetAdminRole(ctx context.Context, dbSession interface{}, id int64) (
+	role *domain.AdminRole, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	role = &domain.AdminRole{}
+	err = db.Where("id = ?", id).Take(role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	role.Permissions = []domain.Permission{}
+	err = db.Model(&domain.AdminRolePermission{}).
+		Where("role_id = ?", id).
+		Order("permission").
+		Pluck("permission", &role.Permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// SaveAdminRole نقش را ایجاد یا به‌روزرسانی و دسترسی‌هایش را با role.Permissions جایگزین می‌کند
+func (arr *AdminRoleRepository) SaveAdminRole(ctx context.Context, dbSession interface{},
+	role *domain.AdminRole) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	if role.ID == 0 {
+		err = db.Create(role).Error
+	} else {
+		err = db.Model(&domain.AdminRole{}).
+			Where("id = ?", role.ID).
+			Updates(map[string]interface{}{
+				"name":       role.Name,
+				"updated_at": time.Now(),
+			}).Error
+	}
+	if err != nil {
+		return
+	}
+
+	err = db.Where("role_id = ?", role.ID).Delete(&domain.AdminRolePermission{}).Error
+	if err != nil {
+		return
+	}
+
+	if len(role.Permissions) > 0 {
+		permissions := make([]*domain.AdminRolePermission, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			permissions = append(permissions, &domain.AdminRolePermission{
+				RoleID:     role.ID,
+				Permission: permission,
+			})
+		}
+
+		err = db.Create(&permissions).Error
+		if err != nil {
+			return
+		}
+	}
+
+	return role.ID, nil
+}
+
+func (arr *AdminRoleRepository) DeleteAdminRole(ctx context.Context, dbSession interface{},
+	id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("id = ?", id).Delete(&domain.AdminRole{}).Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct{}
+
+func (*AuditRepository) CreateAuditLog(ctx context.Context,
+	dbSession interface{}, log *domain.AuditLog) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(log).Error
+}
+
+func (*AuditRepository) GetAuditLogs(ctx context.Context, dbSession interface{},
+	filter domain.AuditLogFilter, limit, offset int) (
+	logs []*domain.AuditLog, totalCount int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	applyFilters := func(q *gorm.DB) *gorm.DB {
+		if filter.ActorID > 0 {
+			q = q.Where("actor_id = ?", filter.ActorID)
+		}
+		if filter.ImpersonatedUserID > 0 {
+			q = q.Where("impersonated_user_id = ?", filter.ImpersonatedUserID)
+		}
+		if filter.EntityType != "" {
+			q = q.Where("entity_type = ?", filter.EntityType)
+		}
+		if filter.EntityID != "" {
+			q = q.Where("entity_id = ?", filter.EntityID)
+		}
+		if filter.Method != "" {
+			q = q.Where("method = ?", filter.Method)
+		}
+		if filter.From != nil {
+			q = q.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			q = q.Where("created_at < ?", *filter.To)
+		}
+		return q
+	}
+
+	logs = []*domain.AuditLog{}
+	err = applyFilters(db.Model(&domain.AuditLog{})).Count(&totalCount).Error
+	if err != nil || totalCount == 0 {
+		return
+	}
+
+	err = applyFilters(db.Model(&domain.AuditLog{})).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, totalCount, nil
+}
+
+func (*AuditRepository) GetAuditChanges(ctx context.Context, dbSession interface{},
+	requestIDs []uuid.UUID) (changes []*domain.AuditChange, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	changes = []*domain.AuditChange{}
+	if len(requestIDs) == 0 {
+		return changes, nil
+	}
+
+	err = db.Where("request_id IN ?", requestIDs).
+		Order("id ASC").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// DeleteAuditDataBefore سوابق درخواست‌ها و تغییرات ردیف‌های قدیمی‌تر از before را پاک می‌کند
+func (*AuditRepository) DeleteAuditDataBefore(ctx context.Context,
+	dbSession interface{}, before time.Time) (deleted int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	err = db.Where("created_at < ?", before).Delete(&domain.AuditChange{}).Error
+	if err != nil {
+		return
+	}
+
+	result := db.Where("created_at < ?", before).Delete(&domain.AuditLog{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -31,3 +31,36 @@ func (r *DollarLogRepository) GetLatest(ctx context.Context, dbSession interface
 	}
 	return &log, nil
 }
+
+func (r *DollarLogRepository) CreateRecomputeRun(ctx context.Context, dbSession interface{},
+	run *domain.DollarRecomputeRun) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+	if err = db.Create(run).Error; err != nil {
+		return
+	}
+	return run.ID, nil
+}
+
+func (r *DollarLogRepository) FinishRecomputeRun(ctx context.Context, dbSession interface{},
+	run *domain.DollarRecomputeRun) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+	return db.Model(run).Select(
+		"users_count", "batches_count", "failed_batches", "failed_users",
+		"rows_changed", "duration_ms", "finished_at",
+	).Updates(run).Error
+}
+
+func (r *DollarLogRepository) InsertRecomputeFailure(ctx context.Context, dbSession interface{},
+	failure *domain.DollarRecomputeFailure) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+	return db.Create(failure).Error
+}
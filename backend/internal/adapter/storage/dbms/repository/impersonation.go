@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImpersonationRepository struct{}
+
+func (*ImpersonationRepository) CreateImpersonationSession(ctx context.Context,
+	dbSession interface{}, session *domain.ImpersonationSession) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(session).Error
+}
+
+// GetImpersonationSession نشست را برمی‌گرداند؛ اگر نبود nil
+func (*ImpersonationRepository) GetImpersonationSession(ctx context.Context,
+	dbSession interface{}, id uuid.UUID) (session *domain.ImpersonationSession, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	session = &domain.ImpersonationSession{}
+	err = db.Where("id = ?", id).Take(session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetExpiredImpersonationSessions نشست‌های بازی که زمانشان تمام شده را با قفل ردیف برمی‌گرداند
+func (*ImpersonationRepository) GetExpiredImpersonationSessions(ctx context.Context,
+	dbSession interface{}, now time.Time) (sessions []*domain.ImpersonationSession, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	sessions = []*domain.ImpersonationSession{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("ended_at IS NULL AND expires_at <= ?", now).
+		Order("expires_at ASC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// EndImpersonationSession نشست باز را می‌بندد؛ ended برای نشستی که قبلا بسته شده false است
+func (*ImpersonationRepository) EndImpersonationSession(ctx context.Context,
+	dbSession interface{}, id uuid.UUID, endedAt time.Time,
+	reason domain.ImpersonationEndReason) (ended bool, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	result := db.Model(&domain.ImpersonationSession{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"ended_at":   endedAt,
+			"end_reason": reason,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
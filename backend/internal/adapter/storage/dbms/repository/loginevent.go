@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type LoginEventRepository struct{}
+
+func (*LoginEventRepository) CreateLoginEvent(ctx context.Context, dbSession interface{},
+	event *domain.LoginEvent) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(event).Error
+}
+
+func (*LoginEventRepository) GetLoginEvents(ctx context.Context, dbSession interface{},
+	userID int64, limit int) (events []*domain.LoginEvent, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	events = []*domain.LoginEvent{}
+	err = db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetLoginHistoryStats تعداد ورودهای موفق کاربر از since به بعد، در کل و از همین دستگاه و IP
+func (*LoginEventRepository) GetLoginHistoryStats(ctx context.Context, dbSession interface{},
+	userID int64, deviceID, ipAddress string, since time.Time) (stats *domain.LoginHistoryStats, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	stats = &domain.LoginHistoryStats{}
+	err = db.Model(&domain.LoginEvent{}).
+		Select("COUNT(*) AS total, "+
+			"COUNT(*) FILTER (WHERE device_id = ?) AS device_count, "+
+			"COUNT(*) FILTER (WHERE ip_address = ?) AS ip_count", deviceID, ipAddress).
+		Where("user_id = ? AND outcome = ? AND created_at >= ?", userID, domain.LoginSucceeded, since).
+		Scan(stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct{}
+
+func (nr *NotificationRepository) CreateNotification(ctx context.Context, dbSession interface{},
+	notification *domain.Notification) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	if err = db.Create(notification).Error; err != nil {
+		return
+	}
+
+	return notification.ID, nil
+}
+
+func (nr *NotificationRepository) GetNotifications(ctx context.Context, dbSession interface{},
+	userID int64, limit int) (notifications []*domain.Notification, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	notifications = []*domain.Notification{}
+	err = db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (nr *NotificationRepository) MarkNotificationRead(ctx context.Context, dbSession interface{},
+	userID, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", gorm.Expr("NOW()")).Error
+}
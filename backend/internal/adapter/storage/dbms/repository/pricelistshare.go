@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceListShareRepository struct{}
+
+func (psr *PriceListShareRepository) GetPriceListShareLinks(ctx context.Context,
+	dbSession interface{}, userID int64) (links []*domain.PriceListShareLink, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	links = []*domain.PriceListShareLink{}
+	err = db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// GetPriceListShareLinkByID لینک متعلق به همان کاربر را برمی‌گرداند؛ اگر نبود nil
+func (psr *PriceListShareRepository) GetPriceListShareLinkByID(ctx context.Context,
+	dbSession interface{}, userID, id int64) (link *domain.PriceListShareLink, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	link = &domain.PriceListShareLink{}
+	err = db.Where("id = ? AND user_id = ?", id, userID).Take(link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// LockPriceListShareLink لینک را برای ثبت بازدید یا رمز اشتباه قفل می‌کند؛ اگر نبود nil
+func (psr *PriceListShareRepository) LockPriceListShareLink(ctx context.Context,
+	dbSession interface{}, id int64) (link *domain.PriceListShareLink, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	link = &domain.PriceListShareLink{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Take(link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// GetAutoPriceListShareLink آخرین لینک خودکار و بدون رمز همان قالب که تا expiresAfter معتبر است؛ اگر نبود nil
+func (psr *PriceListShareRepository) GetAutoPriceListShareLink(ctx context.Context,
+	dbSession interface{}, userID int64, templateID *int64, expiresAfter time.Time) (
+	link *domain.PriceListShareLink, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	query := db.Where("user_id = ? AND is_auto AND pin_hash IS NULL AND revoked_at IS NULL", userID).
+		Where("expires_at > ?", expiresAfter)
+	if templateID == nil {
+		query = query.Where("template_id IS NULL")
+	} else {
+		query = query.Where("template_id = ?", *templateID)
+	}
+
+	link = &domain.PriceListShareLink{}
+	err = query.Order("expires_at DESC").Take(link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (psr *PriceListShareRepository) CreatePriceListShareLink(ctx context.Context,
+	dbSession interface{}, link *domain.PriceListShareLink) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	if err = db.Create(link).Error; err != nil {
+		return
+	}
+
+	return link.ID, nil
+}
+
+func (psr *PriceListShareRepository) RevokePriceListShareLink(ctx context.Context,
+	dbSession interface{}, userID, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	return db.Model(&domain.PriceListShareLink{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		}).Error
+}
+
+// RecordPriceListShareView بازدید موفق را می‌شمارد و تلاش‌های ناموفق رمز را صفر می‌کند
+func (psr *PriceListShareRepository) RecordPriceListShareView(ctx context.Context,
+	dbSession interface{}, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.PriceListShareLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": time.Now(),
+		}).Error
+}
+
+// GetPriceListSharePinAttempt تلاش‌های اشتباه رمز لینک از این IP؛ اگر نبود nil
+func (psr *PriceListShareRepository) GetPriceListSharePinAttempt(ctx context.Context,
+	dbSession interface{}, linkID int64, ipAddress string) (
+	attempt *domain.PriceListSharePinAttempt, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	attempt = &domain.PriceListSharePinAttempt{}
+	err = db.Where("link_id = ? AND ip_address = ?", linkID, ipAddress).
+		Take(attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+func (psr *PriceListShareRepository) SavePriceListSharePinAttempt(ctx context.Context,
+	dbSession interface{}, attempt *domain.PriceListSharePinAttempt) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "link_id"}, {Name: "ip_address"}},
+		UpdateAll: true,
+	}).Create(attempt).Error
+}
+
+func (psr *PriceListShareRepository) DeletePriceListSharePinAttempt(ctx context.Context,
+	dbSession interface{}, linkID int64, ipAddress string) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("link_id = ? AND ip_address = ?", linkID, ipAddress).
+		Delete(&domain.PriceListSharePinAttempt{}).Error
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type PriceListTemplateRepository struct{}
+
+func (ptr *PriceListTemplateRepository) GetPriceListTemplates(ctx context.Context,
+	dbSession interface{}, userID int64) (templates []*domain.PriceListTemplate, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	templates = []*domain.PriceListTemplate{}
+	err = db.Where("user_id = ?", userID).
+		Order("is_default DESC, id").
+		Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// GetPriceListTemplateByID قالب متعلق به همان کاربر را برمی‌گرداند؛ اگر نبود nil
+func (ptr *PriceListTemplateRepository) GetPriceListTemplateByID(ctx context.Context,
+	dbSession interface{}, userID, id int64) (template *domain.PriceListTemplate, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	template = &domain.PriceListTemplate{}
+	err = db.Where("id = ? AND user_id = ?", id, userID).Take(template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetDefaultPriceListTemplate قالب پیش‌فرض فروشگاه؛ اگر تعریف نشده باشد nil
+func (ptr *PriceListTemplateRepository) GetDefaultPriceListTemplate(ctx context.Context,
+	dbSession interface{}, userID int64) (template *domain.PriceListTemplate, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	template = &domain.PriceListTemplate{}
+	err = db.Where("user_id = ? AND is_default", userID).Take(template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (ptr *PriceListTemplateRepository) CreatePriceListTemplate(ctx context.Context,
+	dbSession interface{}, template *domain.PriceListTemplate) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	if err = db.Create(template).Error; err != nil {
+		return
+	}
+
+	return template.ID, nil
+}
+
+func (ptr *PriceListTemplateRepository) UpdatePriceListTemplate(ctx context.Context,
+	dbSession interface{}, template *domain.PriceListTemplate) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.PriceListTemplate{}).
+		Where("id = ? AND user_id = ?", template.ID, template.UserID).
+		Updates(map[string]interface{}{
+			"title":             template.Title,
+			"is_default":        template.IsDefault,
+			"show_dollar_price": template.ShowDollarPrice,
+			"show_image":        template.ShowImage,
+			"show_description":  template.ShowDescription,
+			"show_sku":          template.ShowSKU,
+			"group_by":          template.GroupBy,
+			"sort_by":           template.SortBy,
+			"sort_dir":          template.SortDir,
+			"header_text":       template.HeaderText,
+			"footer_text":       template.FooterText,
+			"markup_percent":    template.MarkupPercent,
+			"paper_size":        template.PaperSize,
+			"updated_at":        time.Now(),
+		}).Error
+}
+
+// ClearDefaultPriceListTemplate قالب‌های دیگر کاربر را از حالت پیش‌فرض خارج می‌کند
+func (ptr *PriceListTemplateRepository) ClearDefaultPriceListTemplate(ctx context.Context,
+	dbSession interface{}, userID, exceptID int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.PriceListTemplate{}).
+		Where("user_id = ? AND id <> ? AND is_default", userID, exceptID).
+		Update("is_default", false).Error
+}
+
+func (ptr *PriceListTemplateRepository) DeletePriceListTemplate(ctx context.Context,
+	dbSession interface{}, userID, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.PriceListTemplate{}).Error
+}
@@ -572,3 +572,23 @@ func (pr *ProductRepository) GetProductNameByBrandId(ctx context.Context, dbSess
 	err = db.Model(&domain.Product{}).Where("brand_id = ?", BrandId).Order("id ASC").Find(&products).Error
 	return products, err
 }
+
+func (pr *ProductRepository) FindProductIDsByBrandAndModel(ctx context.Context, dbSession interface{},
+	brandTitle, modelName string) (ids []int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	err = db.Model(&domain.Product{}).
+		Joins("JOIN product_brand b ON b.id = product.brand_id").
+		Where("LOWER(TRIM(b.title)) = LOWER(TRIM(?))", brandTitle).
+		Where("LOWER(TRIM(product.model_name)) = LOWER(TRIM(?))", modelName).
+		Order("product.id ASC").
+		Pluck("product.id", &ids).Error
+	if err != nil {
+		return
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshTokenRepository struct{}
+
+func (*RefreshTokenRepository) CreateRefreshTokenFamily(ctx context.Context,
+	dbSession interface{}, family *domain.RefreshTokenFamily) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(family).Error
+}
+
+// GetRefreshTokenFamily خانوادهٔ توکن را برمی‌گرداند؛ اگر با حذف دستگاه پاک شده باشد nil
+func (*RefreshTokenRepository) GetRefreshTokenFamily(ctx context.Context,
+	dbSession interface{}, familyID uuid.UUID) (family *domain.RefreshTokenFamily, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	family = &domain.RefreshTokenFamily{}
+	err = db.Where("id = ?", familyID).Take(family).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return family, nil
+}
+
+func (*RefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context,
+	dbSession interface{}, familyID uuid.UUID, reason domain.RefreshTokenRevokeReason) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.RefreshTokenFamily{}).
+		Where("id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error
+}
+
+// RevokeDeviceRefreshTokenFamilies همهٔ خانواده‌های باز یک دستگاه را باطل می‌کند
+func (*RefreshTokenRepository) RevokeDeviceRefreshTokenFamilies(ctx context.Context,
+	dbSession interface{}, activeDeviceID int64, reason domain.RefreshTokenRevokeReason) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.RefreshTokenFamily{}).
+		Where("active_device_id = ? AND revoked_at IS NULL", activeDeviceID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error
+}
+
+func (*RefreshTokenRepository) CreateRefreshToken(ctx context.Context,
+	dbSession interface{}, token *domain.RefreshToken) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(token).Error
+}
+
+// LockRefreshToken توکن را با قفل ردیف برمی‌گرداند تا دو تمدید هم‌زمان یک توکن را دو بار مصرف نکنند؛ اگر نبود nil
+func (*RefreshTokenRepository) LockRefreshToken(ctx context.Context,
+	dbSession interface{}, jti uuid.UUID) (token *domain.RefreshToken, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	token = &domain.RefreshToken{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("jti = ?", jti).
+		Take(token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (*RefreshTokenRepository) MarkRefreshTokenUsed(ctx context.Context,
+	dbSession interface{}, jti uuid.UUID, usedAt time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.RefreshToken{}).
+		Where("jti = ?", jti).
+		Update("used_at", usedAt).Error
+}
+
+// DeleteExpiredRefreshTokens توکن‌های منقضی خانواده را پاک می‌کند؛ آن‌ها دیگر برای تشخیص استفادهٔ دوباره لازم نیستند
+func (*RefreshTokenRepository) DeleteExpiredRefreshTokens(ctx context.Context,
+	dbSession interface{}, familyID uuid.UUID, now time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("family_id = ? AND expires_at < ?", familyID, now).
+		Delete(&domain.RefreshToken{}).Error
+}
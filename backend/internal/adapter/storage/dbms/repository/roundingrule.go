@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type RoundingRuleRepository struct{}
+
+func (rrr *RoundingRuleRepository) GetRoundingRules(ctx context.Context, dbSession interface{},
+	userID int64) (rules []*domain.RoundingRule, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	rules = []*domain.RoundingRule{}
+	err = db.Where("user_id = ?", userID).
+		Order("category_id NULLS FIRST, id").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ResolveRoundingRule قانون مؤثر برای یک محصول را برمی‌گرداند (اول قانون دسته، بعد پیش‌فرض).
+// اگر قانونی تعریف نشده باشد nil برمی‌گردد.
+func (rrr *RoundingRuleRepository) ResolveRoundingRule(ctx context.Context, dbSession interface{},
+	userID, productID int64) (rule *domain.RoundingRule, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	rule = &domain.RoundingRule{}
+	err = db.Table("rounding_rule AS rr").
+		Select("rr.*").
+		Joins("JOIN product AS p ON p.id = ?", productID).
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
+		Where("rr.user_id = ? AND (rr.category_id = pb.category_id OR rr.category_id IS NULL)", userID).
+		Order("rr.category_id NULLS LAST").
+		Take(rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SaveRoundingRule قانون را ایجاد یا (اگر برای همان دسته وجود داشته باشد) به‌روزرسانی می‌کند
+func (rrr *RoundingRuleRepository) SaveRoundingRule(ctx context.Context, dbSession interface{},
+	rule *domain.RoundingRule) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	qb := db.Model(&domain.RoundingRule{}).Where("user_id = ?", rule.UserID)
+	if rule.CategoryID == nil {
+		qb = qb.Where("category_id IS NULL")
+	} else {
+		qb = qb.Where("category_id = ?", *rule.CategoryID)
+	}
+
+	existing := &domain.RoundingRule{}
+	err = qb.Take(existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+
+	now := time.Now()
+	rule.UpdatedAt = now
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rule.CreatedAt = now
+		if err = db.Create(rule).Error; err != nil {
+			return
+		}
+		return rule.ID, nil
+	}
+
+	err = db.Model(existing).Updates(map[string]interface{}{
+		"step_c":     rule.Step,
+		"direction":  rule.Direction,
+		"ending":     rule.Ending,
+		"updated_at": now,
+	}).Error
+	if err != nil {
+		return
+	}
+
+	return existing.ID, nil
+}
+
+func (rrr *RoundingRuleRepository) DeleteRoundingRule(ctx context.Context, dbSession interface{},
+	userID, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.RoundingRule{}).Error
+}
+
+// roundingRuleJoinSQL قانون مؤثر هر ردیف user_product را با alias «rr» در دسترس می‌گذارد.
+// پیش‌نیاز: aliasهای up (user_product) و pb (product_brand) در کوئری وجود داشته باشند.
+const roundingRuleJoinSQL = `
+	LEFT JOIN LATERAL (
+		SELECT r.step_c, r.direction, r.ending
+		FROM rounding_rule AS r
+		WHERE r.user_id = up.user_id
+		  AND (r.category_id = pb.category_id OR r.category_id IS NULL)
+		ORDER BY r.category_id NULLS LAST
+		LIMIT 1
+	) AS rr ON TRUE`
+
+// roundingSQL معادل SQL متد domain.RoundingRules.Apply است؛ باید با آن هم‌خوان بماند.
+// value عبارت قیمت خام و legacyRounded عبارت بولی User.Rounded است.
+func roundingSQL(value, legacyRounded string) string {
+	return fmt.Sprintf(`
+		CASE
+			WHEN rr.step_c IS NOT NULL AND rr.step_c > 0 THEN
+				GREATEST(
+					(CASE rr.direction
+						WHEN 'up'   THEN CEIL(((%[1]s) - rr.ending) / rr.step_c)
+						WHEN 'down' THEN FLOOR(((%[1]s) - rr.ending) / rr.step_c)
+						ELSE ROUND(((%[1]s) - rr.ending) / rr.step_c)
+					END) * rr.step_c + rr.ending,
+					0)
+			WHEN %[2]s THEN
+				(%[1]s) - MOD(%[1]s, 100000)
+					+ CASE WHEN MOD(%[1]s, 100000) > 65000 THEN 100000 ELSE 0 END
+			ELSE ROUND(%[1]s)
+		END`, value, legacyRounded)
+}
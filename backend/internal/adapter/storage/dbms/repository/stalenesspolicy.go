@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StalenessPolicyRepository struct{}
+
+// stalenessPolicyJoinSQL سیاست مؤثر هر ردیف user_product را با alias «sp» در دسترس می‌گذارد.
+// پیش‌نیاز: aliasهای up (user_product) و pb (product_brand) در کوئری وجود داشته باشند.
+const stalenessPolicyJoinSQL = `
+	LEFT JOIN LATERAL (
+		SELECT s.max_age_days, s.auto_hide
+		FROM price_staleness_policy AS s
+		WHERE s.category_id = pb.category_id OR s.category_id IS NULL
+		ORDER BY s.category_id NULLS LAST
+		LIMIT 1
+	) AS sp ON TRUE`
+
+var (
+	isStaleSQL       = isStaleOfferSQL("up")
+	notAutoHiddenSQL = notAutoHiddenOfferSQL("up")
+)
+
+// isStaleOfferSQL قیمتی که از حداکثر عمر مجاز دسته‌اش قدیمی‌تر باشد؛
+// alias جدول user_product را مشخص می‌کند و سیاست از «sp» خوانده می‌شود.
+func isStaleOfferSQL(alias string) string {
+	return fmt.Sprintf(`COALESCE(
+		COALESCE(%[1]s.updated_at, %[1]s.created_at) < NOW() - make_interval(days => sp.max_age_days),
+		FALSE)`, alias)
+}
+
+// notAutoHiddenOfferSQL قیمت‌های قدیمی در دسته‌هایی که AutoHide دارند از بازار حذف می‌شوند
+func notAutoHiddenOfferSQL(alias string) string {
+	return `NOT (` + isStaleOfferSQL(alias) + ` AND COALESCE(sp.auto_hide, FALSE))`
+}
+
+func (spr *StalenessPolicyRepository) GetStalenessPolicies(ctx context.Context,
+	dbSession interface{}) (policies []*domain.StalenessPolicy, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	policies = []*domain.StalenessPolicy{}
+	err = db.Order("category_id NULLS FIRST, id").Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (spr *StalenessPolicyRepository) SaveStalenessPolicy(ctx context.Context, dbSession interface{},
+	policy *domain.StalenessPolicy) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	qb := db.Model(&domain.StalenessPolicy{})
+	if policy.CategoryID == nil {
+		qb = qb.Where("category_id IS NULL")
+	} else {
+		qb = qb.Where("category_id = ?", *policy.CategoryID)
+	}
+
+	existing := &domain.StalenessPolicy{}
+	err = qb.Take(existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+
+	now := time.Now()
+	policy.UpdatedAt = now
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		policy.CreatedAt = now
+		if err = db.Create(policy).Error; err != nil {
+			return
+		}
+		return policy.ID, nil
+	}
+
+	err = db.Model(existing).Updates(map[string]interface{}{
+		"max_age_days": policy.MaxAgeDays,
+		"auto_hide":    policy.AutoHide,
+		"updated_at":   now,
+	}).Error
+	if err != nil {
+		return
+	}
+
+	return existing.ID, nil
+}
+
+func (spr *StalenessPolicyRepository) DeleteStalenessPolicy(ctx context.Context, dbSession interface{},
+	id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("id = ?", id).Delete(&domain.StalenessPolicy{}).Error
+}
+
+// GetShopsWithStalePrices فروشگاه‌هایی که محصول قابل نمایش با قیمت قدیمی دارند و
+// در remindEveryDays روز گذشته یادآوری نگرفته‌اند
+func (spr *StalenessPolicyRepository) GetShopsWithStalePrices(ctx context.Context,
+	dbSession interface{}, remindEveryDays int) (shops []*domain.StalePriceShop, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	shops = []*domain.StalePriceShop{}
+	err = db.Table("user_product AS up").
+		Joins("JOIN user_t AS u ON u.id = up.user_id").
+		Joins("JOIN product AS p ON p.id = up.product_id").
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
+		Joins(stalenessPolicyJoinSQL).
+		Joins("LEFT JOIN stale_price_reminder AS r ON r.user_id = up.user_id").
+		Where("up.is_hidden = FALSE AND u.state_c = ?", domain.ApprovedUser).
+		Where(isStaleSQL).
+		Where("r.last_sent_at IS NULL OR r.last_sent_at < NOW() - make_interval(days => ?)",
+			remindEveryDays).
+		Group("up.user_id, u.phone, u.shop_name").
+		Select(`
+			up.user_id  AS user_id,
+			u.phone     AS phone,
+			u.shop_name AS shop_name,
+			COUNT(*)    AS stale_count
+		`).
+		Order("up.user_id").
+		Scan(&shops).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return shops, nil
+}
+
+func (spr *StalenessPolicyRepository) MarkStalePriceReminderSent(ctx context.Context,
+	dbSession interface{}, shop *domain.StalePriceShop) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Table("stale_price_reminder").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"stale_count", "last_sent_at"}),
+		}).
+		Create(map[string]interface{}{
+			"user_id":      shop.UserID,
+			"stale_count":  shop.StaleCount,
+			"last_sent_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
+	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TOTPRepository struct{}
+
+// GetUserTOTP رمز TOTP کاربر را با قفل ردیف برمی‌گرداند تا تلاش‌های هم‌زمان شمارش شوند؛ اگر نبود nil
+func (*TOTPRepository) GetUserTOTP(ctx context.Context, dbSession interface{},
+	userID int64) (totp *domain.UserTOTP, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	totp = &domain.UserTOTP{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Take(totp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return totp, nil
+}
+
+// SaveUserTOTP رمز جدید را جایگزین رمز قبلی کاربر می‌کند
+func (*TOTPRepository) SaveUserTOTP(ctx context.Context, dbSession interface{},
+	totp *domain.UserTOTP) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		UpdateAll: true,
+	}).Create(totp).Error
+}
+
+// DeleteUserTOTP ورود دومرحله‌ای کاربر را با کدهای بازیابی آن حذف می‌کند
+func (*TOTPRepository) DeleteUserTOTP(ctx context.Context, dbSession interface{},
+	userID int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	err = db.Where("user_id = ?", userID).Delete(&domain.TOTPRecoveryCode{}).Error
+	if err != nil {
+		return
+	}
+
+	return db.Where("user_id = ?", userID).Delete(&domain.UserTOTP{}).Error
+}
+
+// ConfirmUserTOTP ثبت‌نام را بعد از اولین کد درست کامل می‌کند
+func (*TOTPRepository) ConfirmUserTOTP(ctx context.Context, dbSession interface{},
+	userID int64, confirmedAt time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.UserTOTP{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"confirmed_at": confirmedAt,
+			"updated_at":   confirmedAt,
+		}).Error
+}
+
+// MarkUserTOTPDelivered یعنی رمز ساخته‌شده توسط ادمین یک بار به کاربر نشان داده شد
+func (*TOTPRepository) MarkUserTOTPDelivered(ctx context.Context, dbSession interface{},
+	userID int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.UserTOTP{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"deliver_at_login": false,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+// MarkUserTOTPUsed بازهٔ کد پذیرفته‌شده را ثبت می‌کند تا همان کد دوباره پذیرفته نشود
+func (*TOTPRepository) MarkUserTOTPUsed(ctx context.Context, dbSession interface{},
+	userID int64, step int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    nil,
+		"updated_at":      time.Now(),
+	}
+	if step > 0 {
+		updates["last_used_step"] = step
+	}
+
+	return db.Model(&domain.UserTOTP{}).
+		Where("user_id = ?", userID).
+		Updates(updates).Error
+}
+
+func (*TOTPRepository) RecordUserTOTPFailure(ctx context.Context, dbSession interface{},
+	userID int64, attempts int, lockedUntil *time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.UserTOTP{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_attempts": attempts,
+			"locked_until":    lockedUntil,
+		}).Error
+}
+
+// ReplaceRecoveryCodes کدهای بازیابی قبلی را باطل و کدهای جدید را ثبت می‌کند
+func (*TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, dbSession interface{},
+	userID int64, codeHashes []string) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	err = db.Where("user_id = ?", userID).Delete(&domain.TOTPRecoveryCode{}).Error
+	if err != nil || len(codeHashes) == 0 {
+		return
+	}
+
+	codes := make([]*domain.TOTPRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, &domain.TOTPRecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+	}
+
+	return db.Create(&codes).Error
+}
+
+// UseRecoveryCode کد بازیابی استفاده‌نشده را مصرف می‌کند؛ used برای کد نامعتبر false است
+func (*TOTPRepository) UseRecoveryCode(ctx context.Context, dbSession interface{},
+	userID int64, codeHash string) (used bool, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	result := db.Model(&domain.TOTPRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (*TOTPRepository) CountRecoveryCodes(ctx context.Context, dbSession interface{},
+	userID int64) (count int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	err = db.Model(&domain.TOTPRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return
+}
+
+func (*TOTPRepository) GetTOTPPolicy(ctx context.Context, dbSession interface{}) (
+	policy *domain.TOTPPolicy, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	policy = &domain.TOTPPolicy{}
+	err = db.Where("id = 1").Take(policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.TOTPPolicy{ID: 1, Enforcement: domain.TOTPOptional}, nil
+		}
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (*TOTPRepository) UpdateTOTPPolicy(ctx context.Context, dbSession interface{},
+	policy *domain.TOTPPolicy) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	policy.ID = 1
+	policy.UpdatedAt = time.Now()
+	return db.Save(policy).Error
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
 	"github.com/nerkhin/internal/core/domain"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct{}
@@ -123,8 +125,10 @@ func (ur *UserRepository) GetUserByPhone(ctx context.Context, dbSession interfac
 		return
 	}
 
+	// شماره ممکن است با 0، +98 یا بدون پیشوند ذخیره شده باشد
 	err = db.Model(&domain.User{}).
-		Where(&domain.User{Phone: phone}).
+		Where("phone IN ?", domain.PhoneVariants(phone)).
+		Order("id ASC").
 		Take(&user).Error
 	if err != nil {
 		return
@@ -133,6 +137,20 @@ func (ur *UserRepository) GetUserByPhone(ctx context.Context, dbSession interfac
 	return user, nil
 }
 
+func (ur *UserRepository) LockUser(ctx context.Context, dbSession interface{}, id int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	var lockedID int64
+	return db.Model(&domain.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		Select("id").
+		Take(&lockedID).Error
+}
+
 func (ur *UserRepository) DeleteUser(ctx context.Context, dbSession interface{}, id int64) (err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
@@ -281,11 +299,7 @@ func (ur *UserRepository) UpdateDollarPrice(
 
 	const (
 		userTable     = "user_t"
-		upTable       = "user_product"
 		userDollarCol = "dollar_price" // روی user_t
-		baseDollarCol = "dollar_price" // روی user_product (قیمت پایه دلاری ردیف)
-		rialCostsCol  = "other_costs"
-		finalPriceCol = "final_price"
 		colUpdFlag    = "dollar_update"
 		colRounded    = "rounded"
 	)
@@ -303,37 +317,88 @@ func (ur *UserRepository) UpdateDollarPrice(
 			return err
 		}
 
-	
-		raw := fmt.Sprintf(`
-            WITH priced AS (
-                SELECT
-                    up.id,
-                    ((COALESCE(up.%s, 0) * u.%s) + COALESCE(up.%s, 0))::numeric AS raw_val,
-                    u.%s AS rounded
-                FROM %s AS up
-                JOIN %s AS u ON u.id = up.user_id
-                WHERE up.user_id = $1
-                  AND up.is_dollar = TRUE
-            )
-            UPDATE %s AS up
-            SET %s = CASE
-                        WHEN priced.rounded THEN
-                            (priced.raw_val - mod(priced.raw_val, 100000)
-                             + CASE WHEN mod(priced.raw_val, 100000) > 65000 THEN 100000 ELSE 0 END)
-                        ELSE priced.raw_val
-                     END,
-                updated_at = NOW()
-            FROM priced
-            WHERE up.id = priced.id
-        `, baseDollarCol, userDollarCol, rialCostsCol, colRounded, upTable, userTable, upTable, finalPriceCol)
-
-		if err := tx.Exec(raw, user.ID).Error; err != nil {
+		if err := tx.Exec(dollarRecomputeSQL, []int64{user.ID}).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 }
 
+// dollarRecomputeSQL قیمت نهایی محصولات دلاری کاربران داده‌شده را با نرخ دلار هر کاربر دوباره حساب می‌کند.
+// گرد کردن طبق قانون دسته/فروشگاه (rounding_rule) و در نبود آن طبق فلگ rounded است؛
+// فقط ردیف‌هایی که قیمتشان واقعا تغییر می‌کند قفل و به‌روزرسانی می‌شوند.
+var dollarRecomputeSQL = fmt.Sprintf(`
+	WITH priced AS (
+		SELECT
+			up.id,
+			%s AS new_price
+		FROM user_product AS up
+		JOIN user_t AS u ON u.id = up.user_id
+		JOIN product AS p ON p.id = up.product_id
+		JOIN product_brand AS pb ON pb.id = p.brand_id
+		%s
+		WHERE up.user_id IN ?
+		  AND up.is_dollar = TRUE
+		  AND u.dollar_price IS NOT NULL
+	)
+	UPDATE user_product AS up
+	SET final_price = priced.new_price,
+	    updated_at = NOW()
+	FROM priced
+	WHERE up.id = priced.id
+	  AND up.final_price IS DISTINCT FROM priced.new_price
+`, roundingSQL("(COALESCE(up.dollar_price, 0) * u.dollar_price) + COALESCE(up.other_costs, 0)",
+	"u.rounded"), roundingRuleJoinSQL)
+
+func (ur *UserRepository) GetDollarUpdateUserIDs(ctx context.Context, dbSession interface{}) (
+	userIDs []int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	userIDs = []int64{}
+	err = db.Model(&domain.User{}).
+		Where("dollar_update = TRUE AND dollar_price IS NOT NULL").
+		Order("id").
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+func (ur *UserRepository) UpdateUsersDollarPrice(ctx context.Context, dbSession interface{},
+	dollarPrice decimal.Decimal) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Exec(`UPDATE user_t SET dollar_price = ?, updated_at = NOW() WHERE dollar_update = TRUE`,
+		dollarPrice).Error
+}
+
+func (ur *UserRepository) RecomputeDollarPrices(ctx context.Context, dbSession interface{},
+	userIDs []int64) (rowsChanged int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	result := db.Exec(dollarRecomputeSQL, userIDs)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 func (*UserRepository) CreateAdminAccess(ctx context.Context, dbSession interface{}, userID int64) (
 	err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
@@ -359,19 +424,55 @@ func (*UserRepository) GetAdminAccess(ctx context.Context, dbSession interface{}
 		return
 	}
 	err = db.Model(&domain.AdminAccess{}).Where("user_id = ?", adminID).First(&adminAccess).Error
+	if err != nil {
+		return
+	}
+
+	err = db.Model(&domain.AdminAccessPermission{}).
+		Where("user_id = ?", adminID).
+		Order("permission").
+		Pluck("permission", &adminAccess.Permissions).Error
+	if err != nil {
+		return
+	}
+
+	if adminAccess.RoleID != nil {
+		err = db.Model(&domain.AdminRolePermission{}).
+			Where("role_id = ?", *adminAccess.RoleID).
+			Order("permission").
+			Pluck("permission", &adminAccess.RolePermissions).Error
+	}
 	return adminAccess, err
 }
 
+// UpdateAdminAccess نقش ادمین را تنظیم و دسترسی‌های مستقیم او را با Permissions جایگزین می‌کند
 func (*UserRepository) UpdateAdminAccess(ctx context.Context, dbSession interface{},
 	adminAccess *domain.AdminAccess) (err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
 		return
 	}
-	err = db.Where("user_id = ?", adminAccess.UserID).Select(
-		"save_product", "change_user_state", "change_shop_state", "change_account_state",
-	).Updates(&adminAccess).Error
-	return err
+	err = db.Model(&domain.AdminAccess{}).
+		Where("user_id = ?", adminAccess.UserID).
+		Update("role_id", adminAccess.RoleID).Error
+	if err != nil {
+		return
+	}
+
+	err = db.Where("user_id = ?", adminAccess.UserID).
+		Delete(&domain.AdminAccessPermission{}).Error
+	if err != nil || len(adminAccess.Permissions) == 0 {
+		return
+	}
+
+	permissions := make([]*domain.AdminAccessPermission, 0, len(adminAccess.Permissions))
+	for _, permission := range adminAccess.Permissions {
+		permissions = append(permissions, &domain.AdminAccessPermission{
+			UserID:     adminAccess.UserID,
+			Permission: permission,
+		})
+	}
+	return db.Create(&permissions).Error
 }
 
 // --- ADDED: New functions for device management ---
@@ -401,6 +502,7 @@ func (ur *UserRepository) UpdateDeviceLastLogin(ctx context.Context, dbSession i
 	}
 	return db.Model(&domain.ActiveDevice{}).Where("id = ?", device.ID).Updates(map[string]interface{}{
 		"last_login_at": device.LastLoginAt,
+		"last_seen_at":  device.LastLoginAt,
 		"ip_address":    device.IPAddress,
 		"user_agent":    device.UserAgent,
 	}).Error
@@ -438,6 +540,81 @@ func (ur *UserRepository) DeleteAllUserDevices(ctx context.Context, dbSession in
 	return db.Where("user_id = ?", userID).Delete(&domain.ActiveDevice{}).Error
 }
 
+// GetUserSessionVersion نسخهٔ فعلی نشست کاربر؛ کاربر حذف‌شده نسخهٔ صفر دارد
+func (ur *UserRepository) GetUserSessionVersion(ctx context.Context, dbSession interface{},
+	userID int64) (version int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Select("session_version").
+		Scan(&version).Error
+	return version, err
+}
+
+// BumpUserSessionVersion همهٔ access tokenهای فعلی کاربر را بی‌اعتبار می‌کند
+func (ur *UserRepository) BumpUserSessionVersion(ctx context.Context, dbSession interface{},
+	userID int64) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("session_version", gorm.Expr("session_version + 1")).Error
+}
+
+func (ur *UserRepository) UpdateUserDeviceName(ctx context.Context, dbSession interface{},
+	id int64, name *string) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+	return db.Model(&domain.ActiveDevice{}).Where("id = ?", id).Update("name", name).Error
+}
+
+// TouchUserDevice زمان آخرین استفاده از دستگاه را برای انقضای دستگاه‌های بی‌استفاده ثبت می‌کند
+func (ur *UserRepository) TouchUserDevice(ctx context.Context, dbSession interface{},
+	id int64, seenAt time.Time) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+	return db.Model(&domain.ActiveDevice{}).Where("id = ?", id).Update("last_seen_at", seenAt).Error
+}
+
+// DeleteOtherUserDevices همهٔ دستگاه‌های کاربر به جز keepDeviceID را حذف می‌کند
+func (ur *UserRepository) DeleteOtherUserDevices(ctx context.Context, dbSession interface{},
+	userID int64, keepDeviceID string) error {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ? AND device_id <> ?", userID, keepDeviceID).
+		Delete(&domain.ActiveDevice{}).Error
+}
+
+// DeleteIdleDevices دستگاه‌هایی که از before به بعد استفاده نشده‌اند را حذف می‌کند؛ userID صفر یعنی همهٔ کاربران
+func (ur *UserRepository) DeleteIdleDevices(ctx context.Context, dbSession interface{},
+	userID int64, before time.Time) (count int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return 0, err
+	}
+
+	query := db.Where("last_seen_at < ?", before)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	result := query.Delete(&domain.ActiveDevice{})
+	return result.RowsAffected, result.Error
+}
+
 // UpdateAllUsersDeviceLimit updates the device_limit for all non-admin users.
 func (ur *UserRepository) UpdateAllUsersDeviceLimit(ctx context.Context, dbSession interface{}, limit int) error {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
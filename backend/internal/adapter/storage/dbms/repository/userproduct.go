@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
 	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/pkg/pagination"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -47,7 +49,8 @@ func (upr *UserProductRepository) FetchMarketProductsFiltered(
 		Joins("JOIN product AS p ON p.id = up.product_id").
 		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
 		Joins("LEFT JOIN product_category AS pc ON pc.id = pb.category_id").
-		Joins("LEFT JOIN city AS c ON c.id = u.city_id")
+		Joins("LEFT JOIN city AS c ON c.id = u.city_id").
+		Joins(stalenessPolicyJoinSQL)
 
 	if q.ViewerID > 0 {
 		base = base.Joins("LEFT JOIN favorite_product AS fp ON fp.product_id = up.product_id AND fp.user_id = ?", q.ViewerID)
@@ -56,7 +59,8 @@ func (upr *UserProductRepository) FetchMarketProductsFiltered(
 
 	// فیلترها
 
-	base = base.Where("up.is_hidden = false")
+	base = base.Where("up.is_hidden = false").
+		Where(notAutoHiddenSQL)
 
 	if q.CategoryID > 0 {
 		base = base.Where("pb.category_id = ?", q.CategoryID)
@@ -162,17 +166,19 @@ func (upr *UserProductRepository) FetchMarketProductsFiltered(
 		c.name AS city_name,
 		up.updated_at AS updated_at,
 		up.created_at AS created_at,
+		%s AS is_stale,
 		%s,
 		ROW_NUMBER() OVER (
 			PARTITION BY up.product_id
-			ORDER BY DATE(up.updated_at) DESC NULLS LAST, up.final_price ASC NULLS LAST, up.id ASC
+			ORDER BY %s ASC, DATE(up.updated_at) DESC NULLS LAST, up.final_price ASC NULLS LAST, up.id ASC
 		) AS rn
-	`, isFavExpr))
+	`, isStaleSQL, isFavExpr, isStaleSQL))
 
 	// انتخاب فقط rn=1 و سپس سورت نهایی
 	rows := db.Table("(?) AS x", sub).
 		Where("x.rn = 1").
 		Order("x.is_favorite DESC").
+		Order("x.is_stale ASC"). // قیمت‌های قدیمی به انتهای لیست می‌روند
 		Order("x.updated_at DESC NULLS LAST").
 		Order("x.final_price ASC NULLS LAST").
 		Order("x.product_id ASC").
@@ -204,14 +210,16 @@ func (upr *UserProductRepository) CountMarketProductsFiltered(
 		Joins("JOIN product AS p ON p.id = up.product_id").
 		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
 		Joins("LEFT JOIN product_category AS pc ON pc.id = pb.category_id").
-		Joins("LEFT JOIN city AS c ON c.id = u.city_id")
+		Joins("LEFT JOIN city AS c ON c.id = u.city_id").
+		Joins(stalenessPolicyJoinSQL)
 
 	onlyVisible := true
 	if q.OnlyVisible != nil {
 		onlyVisible = *q.OnlyVisible
 	}
 	if onlyVisible {
-		base = base.Where("up.is_hidden = FALSE")
+		base = base.Where("up.is_hidden = FALSE").
+			Where(notAutoHiddenSQL)
 	}
 	if q.CategoryID > 0 {
 		base = base.Where("pb.category_id = ?", q.CategoryID)
@@ -521,12 +529,16 @@ func (upr *UserProductRepository) GetProductShops(ctx context.Context,
 
 	err = db.Table("user_product AS up").
 		Joins("JOIN product AS p ON p.id = up.product_id").
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
 		Joins("JOIN user_t AS u ON u.id = up.user_id").
 		Joins("LEFT JOIN user_subscription us ON us.user_id = u.id").
 		Joins("JOIN city AS c ON c.id = u.city_id").
+		Joins(stalenessPolicyJoinSQL).
 		Where("up.product_id = ? AND c.id IN ?", productID, allowedCityIDs).
 		Where("us.expires_at > NOW()").
-		Group("up.id, u.id, c.id").
+		Where(notAutoHiddenSQL).
+		Group("up.id, u.id, c.id, sp.max_age_days, sp.auto_hide").
+		Order(isStaleSQL+" ASC").
 		Order("up.id ASC").
 		Select(
 			"up.user_id 		  AS id",
@@ -540,6 +552,7 @@ func (upr *UserProductRepository) GetProductShops(ctx context.Context,
 			"u.shop_name",
 			"u.likes_count",
 			"up.updated_at",
+			isStaleSQL+" AS is_stale",
 		).Scan(&shopProducts).Error
 	if err != nil {
 		return
@@ -548,10 +561,12 @@ func (upr *UserProductRepository) GetProductShops(ctx context.Context,
 }
 
 // مدل جدید بدون product_model و بدون p.category_id (دسته از برند می‌آید) + استفاده از p.model_name
+// فیلترهای query مثل FetchShopProductsFiltered اعمال می‌شوند؛ صفحه‌بندی و ترتیب آن نادیده گرفته می‌شود
 func (pr *UserProductRepository) GetPriceList(
 	ctx context.Context,
 	dbSession interface{},
 	userID int64,
+	query *domain.UserProductQuery,
 ) (priceList []*domain.UserProductView, err error) {
 
 	db, err := gormutil.CastToGORM(ctx, dbSession)
@@ -559,7 +574,7 @@ func (pr *UserProductRepository) GetPriceList(
 		return
 	}
 
-	err = db.WithContext(ctx).
+	qb := db.WithContext(ctx).
 		Table("user_product AS up").
 		Joins("JOIN product AS p            ON p.id = up.product_id").
 		Joins("JOIN product_brand AS pb     ON pb.id = p.brand_id").
@@ -568,7 +583,34 @@ func (pr *UserProductRepository) GetPriceList(
 		Joins("LEFT JOIN user_subscription AS us ON us.user_id = u.id").
 		Joins("JOIN city AS c               ON c.id = u.city_id").
 		Where("up.is_hidden = FALSE AND up.user_id = ?", userID).
-		Where("us.expires_at > NOW()").
+		Where("us.expires_at > NOW()")
+
+	if query != nil {
+		if len(query.BrandIDs) > 0 {
+			qb = qb.Where("pb.id IN ?", query.BrandIDs)
+		}
+		if query.CategoryID > 0 {
+			qb = qb.Where("pb.category_id = ?", query.CategoryID)
+		}
+		if query.SubCategoryID > 0 {
+			qb = qb.Where("p.sub_category_id = ?", query.SubCategoryID)
+		}
+		if query.IsDollar != nil {
+			qb = qb.Where("up.is_dollar = ?", *query.IsDollar)
+		}
+		if s := strings.TrimSpace(query.Search); s != "" {
+			like := "%" + s + "%"
+			qb = qb.Where(`(
+				p.model_name ILIKE ? OR
+				pb.title     ILIKE ? OR
+				pc.title     ILIKE ? OR
+				p.description ILIKE ? OR
+				up.sku       ILIKE ?
+			)`, like, like, like, like, like)
+		}
+	}
+
+	err = qb.
 		Select(`
 			up.*,
 			pb.category_id      AS category_id,      -- category از brand
@@ -576,7 +618,9 @@ func (pr *UserProductRepository) GetPriceList(
 			p.model_name        AS model_name,       -- جایگزین model_id/pm.title
 			p.model_name        AS product_model,    -- برای سازگاری با فرانت قدیمی (اختیاری)
 			pc.title            AS product_category,
-			pb.title            AS product_brand
+			pb.title            AS product_brand,
+			p.description       AS description,
+			p.default_image_url AS default_image_url
 		`).
 		Order("p.brand_id ASC").
 		Scan(&priceList).Error
@@ -704,8 +748,9 @@ func (upr *UserProductRepository) FetchShopProductsFiltered(
 			p.model_name ILIKE ? OR 
 			pb.title     ILIKE ? OR
 			pc.title     ILIKE ? OR
-			p.description ILIKE ?
-		`, like, like, like, like)
+			p.description ILIKE ? OR
+			up.sku       ILIKE ?
+		`, like, like, like, like, like)
 	}
 
 	// total count
@@ -967,102 +1012,522 @@ func (ur *UserProductRepository) GetAggregatedFilterDataForSearch(ctx context.Co
 	return aggregatedData, nil
 }
 
-// func (upr *UserProductRepository) AdjustUserFinalPricesByRate(
-// 	ctx context.Context, dbSession interface{}, userID int64, rate decimal.Decimal,
-// ) error {
-// 	db, err := gormutil.CastToGORM(ctx, dbSession)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	// خواندن تنظیم گرد کردن کاربر
-// 	var u struct {
-// 		Rounded *bool `gorm:"column:rounded"`
-// 	}
-// 	if err := db.Model(&domain.User{}).
-// 		Select("rounded").
-// 		Where("id = ?", userID).
-// 		Take(&u).Error; err != nil {
-// 		return err
-// 	}
-// 	rounded := u.Rounded != nil && *u.Rounded
-
-// 	// factor = 1 + rate   (rate در سرویس = percent/100)
-// 	factor := decimal.NewFromInt(1).Add(rate)
-// 	f := factor.String()
-
-// 	var expr string
-// 	var args []any
-
-// 	if rounded {
-// 		// رند طبق آستانه ۶۵هزار روی مضارب ۱۰۰هزار:
-// 		// remainder < 65000  → پایین
-// 		// remainder ≥ 65000  → بالا
-// 		expr = `
-// 			GREATEST(
-// 				FLOOR( (final_price * CAST(? AS NUMERIC) + 35000) / 100000 ) * 100000,
-// 				0
-// 			)`
-// 		args = []any{f}
-// 	} else {
-// 		// بدون رند کردن
-// 		expr = `GREATEST(final_price * CAST(? AS NUMERIC), 0)`
-// 		args = []any{f}
-// 	}
-
-// 	return db.Model(&domain.UserProduct{}).
-// 		Where("user_id = ? AND is_dollar = FALSE", userID).
-// 		Update("final_price", gorm.Expr(expr, args...)).
-// 		Error
-// }
-
-func (upr *UserProductRepository) AdjustUserFinalPricesByRate(
-	ctx context.Context, dbSession interface{}, userID int64, rate decimal.Decimal,
-) error {
+func (upr *UserProductRepository) GetAdjustablePrices(ctx context.Context, dbSession interface{},
+	userID int64, scope domain.PriceAdjustmentScope) (items []*domain.PriceAdjustmentItem, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return nil, err
+	}
+
+	// فقط محصولات ریالی؛ قیمت محصولات دلاری از روی نرخ دلار محاسبه می‌شود
+	qb := db.Table("user_product AS up").
+		Joins("JOIN product AS p ON p.id = up.product_id").
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
+		Joins("LEFT JOIN product_category AS pc ON pc.id = pb.category_id").
+		Where("up.user_id = ? AND up.is_dollar = FALSE", userID)
+
+	if scope.CategoryID > 0 {
+		qb = qb.Where("pb.category_id = ?", scope.CategoryID)
+	}
+	if len(scope.BrandIDs) > 0 {
+		qb = qb.Where("pb.id IN ?", scope.BrandIDs)
+	}
+	if len(scope.UserProductIDs) > 0 {
+		qb = qb.Where("up.id IN ?", scope.UserProductIDs)
+	}
+
+	err = qb.Select(`
+			up.id           AS user_product_id,
+			up.product_id   AS product_id,
+			pb.category_id  AS category_id,
+			p.model_name    AS model_name,
+			pb.title        AS product_brand,
+			pc.title        AS product_category,
+			up.final_price  AS old_price
+		`).
+		Order("up.order_c ASC, up.id ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (upr *UserProductRepository) CreatePriceAdjustmentBatch(ctx context.Context,
+	dbSession interface{}, batch *domain.PriceAdjustmentBatch,
+	items []*domain.PriceAdjustmentItem) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	if err = db.Create(batch).Error; err != nil {
+		return
+	}
+
+	if len(items) > 0 {
+		for _, item := range items {
+			item.BatchID = batch.ID
+		}
+		if err = db.CreateInBatches(items, 500).Error; err != nil {
+			return
+		}
+	}
+
+	return batch.ID, nil
+}
+
+func (upr *UserProductRepository) ApplyPriceAdjustmentBatch(ctx context.Context,
+	dbSession interface{}, batchID int64) (affected int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	result := db.Exec(`
+		UPDATE user_product AS up
+		SET final_price = i.new_price,
+		    updated_at  = NOW()
+		FROM price_adjustment_item AS i
+		WHERE i.batch_id = ?
+		  AND up.id = i.user_product_id
+		  AND up.final_price = i.old_price
+		  AND up.is_dollar = FALSE
+	`, batchID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (upr *UserProductRepository) GetLastPriceAdjustmentBatch(ctx context.Context,
+	dbSession interface{}, userID int64) (batch *domain.PriceAdjustmentBatch, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	batch = &domain.PriceAdjustmentBatch{}
+	err = db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(batch).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// RevertPriceAdjustmentBatch قیمت‌های قبلی را از snapshot برمی‌گرداند؛ ردیف‌هایی که بعد از
+// عملیات گروهی دستی ویرایش شده‌اند (قیمت فعلی ≠ قیمت جدید) دست نمی‌خورند.
+func (upr *UserProductRepository) RevertPriceAdjustmentBatch(ctx context.Context,
+	dbSession interface{}, batchID int64) (restored int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	result := db.Exec(`
+		UPDATE user_product AS up
+		SET final_price = i.old_price,
+		    updated_at  = NOW()
+		FROM price_adjustment_item AS i
+		WHERE i.batch_id = ?
+		  AND up.id = i.user_product_id
+		  AND up.final_price = i.new_price
+		  AND up.is_dollar = FALSE
+	`, batchID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	err = db.Model(&domain.PriceAdjustmentBatch{}).
+		Where("id = ?", batchID).
+		Update("reverted_at", gorm.Expr("NOW()")).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetMarketPriceStats میانهٔ قیمت نهایی محصول بین فروشگاه‌های دیگر (بدون محصولات مخفی)
+func (upr *UserProductRepository) GetMarketPriceStats(ctx context.Context, dbSession interface{},
+	productID, excludeUserID int64) (stats *domain.MarketPriceStats, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	stats = &domain.MarketPriceStats{}
+	err = db.Table("user_product AS up").
+		Where("up.product_id = ? AND up.user_id <> ? AND up.is_hidden = FALSE", productID, excludeUserID).
+		Select(`
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY up.final_price) AS median,
+			COUNT(*)                                                    AS sample_size
+		`).
+		Scan(stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (upr *UserProductRepository) CreatePriceDeviationFlag(ctx context.Context, dbSession interface{},
+	flag *domain.PriceDeviationFlag) (id int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	if err = db.Create(flag).Error; err != nil {
+		return
+	}
+
+	return flag.ID, nil
+}
+
+func (upr *UserProductRepository) GetPriceDeviationFlags(ctx context.Context, dbSession interface{},
+	filter domain.PriceDeviationFlagFilter, pag pagination.Pagination) (
+	flags []*domain.PriceDeviationFlagView, total int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	qb := db.Table("price_deviation_flag AS f").
+		Joins("JOIN product AS p ON p.id = f.product_id").
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
+		Joins("JOIN user_t AS u ON u.id = f.user_id").
+		Joins("LEFT JOIN user_product AS up ON up.id = f.user_product_id")
+
+	if filter.Reviewed != nil {
+		if *filter.Reviewed {
+			qb = qb.Where("f.reviewed_at IS NOT NULL")
+		} else {
+			qb = qb.Where("f.reviewed_at IS NULL")
+		}
+	}
+
+	if err = qb.Count(&total).Error; err != nil {
+		return
+	}
+
+	flags = []*domain.PriceDeviationFlagView{}
+	err = qb.Select(`
+			f.*,
+			p.model_name    AS model_name,
+			pb.title        AS brand_title,
+			u.shop_name     AS shop_name,
+			up.final_price  AS current_price
+		`).
+		Order("f.created_at DESC, f.id DESC").
+		Limit(pag.PageSize).
+		Offset((pag.Page - 1) * pag.PageSize).
+		Scan(&flags).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return flags, total, nil
+}
+
+func (upr *UserProductRepository) ReviewPriceDeviationFlag(ctx context.Context, dbSession interface{},
+	id, adminID int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Model(&domain.PriceDeviationFlag{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"reviewed_at": gorm.Expr("NOW()"),
+			"reviewed_by": adminID,
+		}).Error
+}
+
+// TouchUserProducts بدون تغییر قیمت، زمان به‌روزرسانی را تازه می‌کند تا قیمت‌ها دیگر قدیمی حساب نشوند.
+// اگر ids خالی باشد همهٔ محصولات فروشگاه تایید می‌شوند.
+func (upr *UserProductRepository) TouchUserProducts(ctx context.Context, dbSession interface{},
+	userID int64, ids []int64) (affected int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	qb := db.Model(&domain.UserProduct{}).Where("user_id = ?", userID)
+	if len(ids) > 0 {
+		qb = qb.Where("id IN ?", ids)
+	}
+
+	result := qb.Update("updated_at", gorm.Expr("NOW()"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetUserProductsByProductIDs محصولات فعلی فروشگاه را بر اساس شناسهٔ محصول کاتالوگ برمی‌گرداند
+func (upr *UserProductRepository) GetUserProductsByProductIDs(ctx context.Context, dbSession interface{},
+	userID int64, productIDs []int64) (userProducts map[int64]*domain.UserProduct, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	userProducts = make(map[int64]*domain.UserProduct, len(productIDs))
+	if len(productIDs) == 0 {
+		return userProducts, nil
+	}
+
+	var rows []*domain.UserProduct
+	err = db.Model(&domain.UserProduct{}).
+		Where("user_id = ? AND product_id IN ?", userID, productIDs).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		userProducts[row.ProductID] = row
+	}
+
+	return userProducts, nil
+}
+
+func (upr *UserProductRepository) GetUserProductsByIDs(ctx context.Context, dbSession interface{},
+	userID int64, ids []int64) (userProducts map[int64]*domain.UserProduct, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	userProducts = make(map[int64]*domain.UserProduct, len(ids))
+	if len(ids) == 0 {
+		return userProducts, nil
+	}
+
+	var rows []*domain.UserProduct
+	err = db.Model(&domain.UserProduct{}).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		userProducts[row.ID] = row
+	}
+
+	return userProducts, nil
+}
+
+func (upr *UserProductRepository) ReorderUserProducts(ctx context.Context, dbSession interface{},
+	userID int64, orders map[int64]int64) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	movedIDs := make([]int64, 0, len(orders))
+	for id, order := range orders {
+		err = db.Model(&domain.UserProduct{}).
+			Where("id = ? AND user_id = ?", id, userID).
+			Update("order_c", order).Error
+		if err != nil {
+			return
+		}
+		movedIDs = append(movedIDs, id)
+	}
+
+	if len(movedIDs) == 0 {
+		return nil
+	}
+
+	return db.Exec(`
+		UPDATE user_product AS up
+		SET order_c = r.rn
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY order_c ASC, (id IN ?) DESC, id ASC) AS rn
+			FROM user_product
+			WHERE user_id = ?
+		) AS r
+		WHERE up.id = r.id AND up.order_c <> r.rn
+	`, movedIDs, userID).Error
+}
+
+// GetUserProductBySKU محصول فروشگاه با کد کالای داده‌شده؛ اگر نباشد nil برمی‌گردد
+func (upr *UserProductRepository) GetUserProductBySKU(ctx context.Context, dbSession interface{},
+	userID int64, sku string) (userProduct *domain.UserProduct, err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
-		return err
-	}
-
-	// خواندن تنظیم گرد کردن کاربر
-	var u struct {
-		Rounded *bool `gorm:"column:rounded"`
-	}
-	if err := db.Model(&domain.User{}).
-		Select("rounded").
-		Where("id = ?", userID).
-		Take(&u).Error; err != nil {
-		return err
-	}
-	rounded := u.Rounded != nil && *u.Rounded
-
-	// factor = 1 + rate   (rate در سرویس = percent/100)
-	factor := decimal.NewFromInt(1).Add(rate)
-	f := factor.String()
-
-	var expr string
-	var args []any
-
-	if rounded {
-		// این بخش که منطق اصلی شماست، دست‌نخورده باقی می‌ماند
-		// رند طبق آستانه ۶۵هزار روی مضارب ۱۰۰هزار:
-		// remainder < 65000  → پایین
-		// remainder ≥ 65000  → بالا
-		expr = `
-            GREATEST(
-                FLOOR( (final_price * CAST(? AS NUMERIC) + 35000) / 100000 ) * 100000,
-                0
-            )`
-		args = []any{f}
-	} else {
-		// ✅ تغییر در اینجا اعمال شده است
-		// قیمت جدید به نزدیک‌ترین عدد صحیح رند می‌شود
-		expr = `GREATEST(ROUND(final_price * CAST(? AS NUMERIC)), 0)`
-		args = []any{f}
+		return
+	}
+
+	err = db.Model(&domain.UserProduct{}).
+		Where("user_id = ? AND sku = ?", userID, sku).
+		Take(&userProduct).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return userProduct, nil
+}
+
+func (upr *UserProductRepository) GetUserProductsBySKUs(ctx context.Context, dbSession interface{},
+	userID int64, skus []string) (userProducts map[string]*domain.UserProduct, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	userProducts = make(map[string]*domain.UserProduct, len(skus))
+	if len(skus) == 0 {
+		return userProducts, nil
+	}
+
+	var rows []*domain.UserProduct
+	err = db.Model(&domain.UserProduct{}).
+		Where("user_id = ? AND sku IN ?", userID, skus).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		userProducts[row.SKUValue()] = row
+	}
+
+	return userProducts, nil
+}
+
+func (upr *UserProductRepository) UpdateUserProductSKU(ctx context.Context, dbSession interface{},
+	userID, id int64, sku *string) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
 	}
 
 	return db.Model(&domain.UserProduct{}).
-		Where("user_id = ? AND is_dollar = FALSE", userID).
-		Update("final_price", gorm.Expr(expr, args...)).
-		Error
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("sku", sku).Error
+}
+
+// competitorStatsSQL آمار قیمت رقبا برای محصول ردیف up با همان قواعد نمایش GetProductShops:
+// محصول مخفی نیست، اشتراک فروشگاه فعال است و قیمت به‌خاطر قدیمی بودن پنهان نشده است.
+// ردیف خود فروشگاه کنار گذاشته می‌شود تا جایگاهش با قیمت خودش سنجیده نشود.
+// سیاست قدیمی بودن از «sp» بیرونی خوانده می‌شود چون دستهٔ محصول یکی است.
+// %[1]s شرط اضافه (مثلا محدود به شهر) و %[2]s alias خروجی است.
+const competitorStatsSQL = `
+	LEFT JOIN LATERAL (
+		SELECT
+			MIN(o.final_price)                                         AS min_price,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY o.final_price) AS median_price,
+			COUNT(*)                                                   AS shops_count,
+			COUNT(*) FILTER (WHERE o.final_price < up.final_price)     AS undercut_count
+		FROM user_product AS o
+		JOIN user_t AS ou ON ou.id = o.user_id
+		WHERE o.product_id = up.product_id
+		  AND o.user_id <> up.user_id
+		  AND o.is_hidden = FALSE
+		  AND EXISTS (
+			SELECT 1 FROM user_subscription AS ous
+			WHERE ous.user_id = ou.id AND ous.expires_at > NOW()
+		  )
+		  AND %[3]s
+		  %[1]s
+	) AS %[2]s ON TRUE`
+
+var competitivePositionSortSQL = map[domain.CompetitivePositionSort]string{
+	domain.CompetitivePositionSortModel:              "p.model_name",
+	domain.CompetitivePositionSortMyPrice:            "up.final_price",
+	domain.CompetitivePositionSortCityRank:           "cs.undercut_count",
+	domain.CompetitivePositionSortNationalRank:       "ns.undercut_count",
+	domain.CompetitivePositionSortCityGapPercent:     "(up.final_price - cs.min_price) / NULLIF(cs.min_price, 0)",
+	domain.CompetitivePositionSortNationalGapPercent: "(up.final_price - ns.min_price) / NULLIF(ns.min_price, 0)",
+}
+
+// GetCompetitivePositions جایگاه قیمت هر محصول فروشگاه در بازار شهر خودش و کل کشور
+func (upr *UserProductRepository) GetCompetitivePositions(ctx context.Context, dbSession interface{},
+	filter *domain.CompetitivePositionFilter) (positions []*domain.CompetitivePosition,
+	total int64, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	qb := db.Table("user_product AS up").
+		Joins("JOIN user_t AS me ON me.id = up.user_id").
+		Joins("JOIN product AS p ON p.id = up.product_id").
+		Joins("JOIN product_brand AS pb ON pb.id = p.brand_id").
+		Joins("LEFT JOIN product_category AS pc ON pc.id = pb.category_id").
+		Where("up.user_id = ?", filter.UserID)
+
+	if filter.CategoryID > 0 {
+		qb = qb.Where("pb.category_id = ?", filter.CategoryID)
+	}
+	if len(filter.BrandIDs) > 0 {
+		qb = qb.Where("pb.id IN ?", filter.BrandIDs)
+	}
+
+	if err = qb.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return
+	}
+
+	marketSQL := notAutoHiddenOfferSQL("o")
+	qb = qb.Joins(stalenessPolicyJoinSQL).
+		Joins(fmt.Sprintf(competitorStatsSQL, "AND ou.city_id = me.city_id", "cs", marketSQL)).
+		Joins(fmt.Sprintf(competitorStatsSQL, "", "ns", marketSQL)).
+		Select(`
+			up.id                    AS user_product_id,
+			up.product_id            AS product_id,
+			p.model_name             AS model_name,
+			pb.category_id           AS category_id,
+			pc.title                 AS category_title,
+			pb.id                    AS brand_id,
+			pb.title                 AS brand_title,
+			up.is_hidden             AS is_hidden,
+			up.final_price           AS my_price,
+			cs.min_price             AS city_min_price,
+			cs.median_price          AS city_median_price,
+			cs.shops_count           AS city_shops_count,
+			cs.undercut_count + 1    AS city_rank,
+			cs.undercut_count        AS city_undercut_count,
+			ns.min_price             AS national_min_price,
+			ns.median_price          AS national_median_price,
+			ns.shops_count           AS national_shops_count,
+			ns.undercut_count + 1    AS national_rank,
+			ns.undercut_count        AS national_undercut_count
+		`)
+
+	sortExpr, ok := competitivePositionSortSQL[filter.SortBy]
+	if !ok {
+		sortExpr = competitivePositionSortSQL[domain.CompetitivePositionSortModel]
+	}
+	sortDir := "ASC"
+	if filter.SortDir == domain.SortDesc {
+		sortDir = "DESC"
+	}
+	qb = qb.Order(fmt.Sprintf("%s %s NULLS LAST", sortExpr, sortDir)).
+		Order("up.id ASC")
+
+	if filter.Limit > 0 {
+		qb = qb.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	positions = []*domain.CompetitivePosition{}
+	if err = qb.Scan(&positions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return positions, total, nil
 }
@@ -2,59 +2,168 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
 	"github.com/nerkhin/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VerificationCodeRepository struct{}
 
+// SaveVerificationCode کد جدید را جایگزین کد قبلی کاربر می‌کند؛ تلاش‌ها و قفل قبلی پاک می‌شوند
 func (*VerificationCodeRepository) SaveVerificationCode(ctx context.Context,
-	dbSession interface{}, userId int64, code string) (err error) {
+	dbSession interface{}, vc *domain.VerificationCode) (err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
 		return
 	}
 
-	var vcToBeSaved *domain.VerificationCode
+	var existingID int64
 	err = db.Model(&domain.VerificationCode{}).
-		Where(&domain.VerificationCode{UserID: userId}).
-		Scan(&vcToBeSaved).Error
+		Where(&domain.VerificationCode{UserID: vc.UserID}).
+		Select("id").
+		Scan(&existingID).Error
 	if err != nil {
 		return
 	}
 
-	if vcToBeSaved != nil {
-		vcToBeSaved.Code = code
-	} else {
-		vcToBeSaved = &domain.VerificationCode{
-			UserID: userId,
-			Code:   code,
+	vc.ID = existingID
+	err = db.Save(vc).Error
+	if err != nil {
+		return
+	}
+
+	return nil
+}
+
+// GetVerificationCode کد کاربر را با قفل ردیف برمی‌گرداند تا تلاش‌های هم‌زمان شمارش شوند؛ اگر نبود nil
+func (*VerificationCodeRepository) GetVerificationCode(ctx context.Context, dbSession interface{},
+	userId int64) (vc *domain.VerificationCode, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	vc = &domain.VerificationCode{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userId).
+		Take(vc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
+		return nil, err
 	}
 
-	err = db.Save(vcToBeSaved).Error
+	return vc, nil
+}
+
+// RecordVerificationCodeFailure تعداد تلاش اشتباه را ثبت می‌کند؛ با lockedUntil کد هم باطل می‌شود
+func (*VerificationCodeRepository) RecordVerificationCodeFailure(ctx context.Context,
+	dbSession interface{}, id int64, attempts int, lockedUntil *time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
 		return
 	}
 
-	return nil
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"locked_until": lockedUntil,
+	}
+	if lockedUntil != nil {
+		updates["code_hash"] = nil
+	}
+
+	return db.Model(&domain.VerificationCode{}).
+		Where("id = ?", id).
+		Updates(updates).Error
 }
 
-func (*VerificationCodeRepository) GetVerificationCode(ctx context.Context, dbSession interface{},
-	userId int64) (code string, err error) {
+// InvalidateVerificationCode کد استفاده‌شده را باطل می‌کند تا دوباره پذیرفته نشود
+func (*VerificationCodeRepository) InvalidateVerificationCode(ctx context.Context,
+	dbSession interface{}, id int64) (err error) {
 	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
 		return
 	}
 
-	err = db.Model(&domain.VerificationCode{}).
-		Where(&domain.VerificationCode{UserID: userId}).
-		Select("code").
-		Scan(&code).Error
+	return db.Model(&domain.VerificationCode{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"code_hash":    nil,
+			"attempts":     0,
+			"locked_until": nil,
+		}).Error
+}
+
+func (*VerificationCodeRepository) DeleteVerificationCode(ctx context.Context,
+	dbSession interface{}, userID int64, codeHash string) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Where("user_id = ? AND code_hash = ?", userID, codeHash).
+		Delete(&domain.VerificationCode{}).Error
+}
+
+func (*VerificationCodeRepository) CreateVerificationCodeSend(ctx context.Context,
+	dbSession interface{}, send *domain.VerificationCodeSend) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	return db.Create(send).Error
+}
+
+// CountVerificationCodeSendsByPhone تعداد ارسال‌های شماره از since به بعد و زمان قدیمی‌ترین آن‌ها
+func (vcr *VerificationCodeRepository) CountVerificationCodeSendsByPhone(ctx context.Context,
+	dbSession interface{}, phone string, since time.Time) (count int64, oldest *time.Time, err error) {
+	return vcr.countVerificationCodeSends(ctx, dbSession, "phone = ?", phone, since)
+}
+
+// CountVerificationCodeSendsByIP تعداد ارسال‌های درخواست‌شده از IP از since به بعد و زمان قدیمی‌ترین آن‌ها
+func (vcr *VerificationCodeRepository) CountVerificationCodeSendsByIP(ctx context.Context,
+	dbSession interface{}, ipAddress string, since time.Time) (count int64, oldest *time.Time, err error) {
+	return vcr.countVerificationCodeSends(ctx, dbSession, "ip_address = ?", ipAddress, since)
+}
+
+func (*VerificationCodeRepository) countVerificationCodeSends(ctx context.Context,
+	dbSession interface{}, condition string, value string, since time.Time) (
+	count int64, oldest *time.Time, err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
+	if err != nil {
+		return
+	}
+
+	var stats struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err = db.Model(&domain.VerificationCodeSend{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where(condition, value).
+		Where("created_at >= ?", since).
+		Scan(&stats).Error
+	if err != nil {
+		return
+	}
+
+	return stats.Count, stats.Oldest, nil
+}
+
+// DeleteVerificationCodeSendsBefore سوابق ارسالی که دیگر در هیچ بازهٔ محدودیتی نیستند را پاک می‌کند
+func (*VerificationCodeRepository) DeleteVerificationCodeSendsBefore(ctx context.Context,
+	dbSession interface{}, before time.Time) (err error) {
+	db, err := gormutil.CastToGORM(ctx, dbSession)
 	if err != nil {
 		return
 	}
 
-	return code, nil
+	return db.Where("created_at < ?", before).
+		Delete(&domain.VerificationCodeSend{}).Error
 }
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditData محتوای یک ستون jsonb که در پاسخ API به همان شکل JSON برگردانده می‌شود
+type AuditData string
+
+func (d AuditData) MarshalJSON() ([]byte, error) {
+	if d == "" {
+		return []byte("null"), nil
+	}
+	return []byte(d), nil
+}
+
+// AuditLog یک درخواست تغییردهنده از ادمین یا با توکن جعل هویت
+type AuditLog struct {
+	ID                 int64          `json:"id"`
+	RequestID          uuid.UUID      `json:"requestId"`
+	ActorID            int64          `json:"actorId"`            // ادمینی که درخواست را فرستاده، حتی هنگام جعل هویت
+	ImpersonatedUserID *int64         `json:"impersonatedUserId"` // کاربری که ادمین به جای او وارد شده است
+	Method             string         `json:"method"`
+	Route              string         `json:"route"` // الگوی مسیر مثل /api/go/product/:id
+	Path               string         `json:"path"`
+	StatusCode         int            `json:"statusCode"`
+	EntityType         string         `json:"entityType"`
+	EntityID           *string        `json:"entityId"`
+	IPAddress          string         `json:"ipAddress"`
+	UserAgent          string         `json:"userAgent"`
+	RequestBody        *AuditData     `gorm:"type:jsonb" json:"requestBody"` // فیلدهای حساس مثل کد تایید حذف شده‌اند
+	CreatedAt          time.Time      `json:"createdAt"`
+	Changes            []*AuditChange `gorm:"-" json:"changes"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+// AuditChange تغییر یک ردیف در تراکنش‌های یک درخواست؛ برای UPDATE فقط ستون‌های تغییرکرده آمده‌اند
+type AuditChange struct {
+	ID         int64      `json:"id"`
+	RequestID  uuid.UUID  `json:"requestId"`
+	Table      string     `gorm:"column:table_name" json:"tableName"`
+	Operation  string     `json:"operation"`
+	RowID      *string    `json:"rowId"`
+	BeforeData *AuditData `gorm:"type:jsonb" json:"before"`
+	AfterData  *AuditData `gorm:"type:jsonb" json:"after"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (AuditChange) TableName() string {
+	return "audit_change"
+}
+
+type AuditLogFilter struct {
+	ActorID            int64
+	ImpersonatedUserID int64
+	EntityType         string
+	EntityID           string
+	Method             string
+	From               *time.Time
+	To                 *time.Time
+}
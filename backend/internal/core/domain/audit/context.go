@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+// GinContextKey کلید Entry در gin.Context؛ هندلرها ctx خود gin را هم به سرویس‌ها می‌دهند
+const GinContextKey = "audit_entry"
+
+type entryKey struct{}
+
+// Entry سابقهٔ درخواست جاری. میدلور Audit آن را خالی می‌سازد و AuthMiddleware
+// برای ادمین یا توکن جعل هویت فعالش می‌کند؛ تراکنش‌های درخواست فعال شناسهٔ آن را
+// به تریگرهای audit_change می‌دهند. تغییر ردیف‌ها فقط داخل dbms.BeginTransaction ثبت می‌شود.
+type Entry struct {
+	RequestID          uuid.UUID
+	ActorID            int64
+	ImpersonatedUserID *int64
+}
+
+func (e *Entry) Active() bool {
+	return e != nil && e.RequestID != uuid.Nil
+}
+
+func NewContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext سابقهٔ درخواست را از context درخواست یا gin.Context برمی‌گرداند؛ اگر نبود nil
+func FromContext(ctx context.Context) *Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		return entry
+	}
+	if entry, ok := ctx.Value(GinContextKey).(*Entry); ok {
+		return entry
+	}
+	return nil
+}
+
+// RequestID شناسهٔ سابقهٔ فعال درخواست؛ برای درخواست‌هایی که ثبت نمی‌شوند خالی است
+func RequestID(ctx context.Context) string {
+	if entry := FromContext(ctx); entry.Active() {
+		return entry.RequestID.String()
+	}
+	return ""
+}
+
+// Begin اگر درخواست از ادمین یا با توکن جعل هویت باشد سابقه را فعال می‌کند.
+// هنگام جعل هویت، انجام‌دهنده ادمین است و کاربر جعل‌شده جدا نگه داشته می‌شود.
+func Begin(ctx context.Context, payload *domain.TokenPayload) {
+	entry := FromContext(ctx)
+	if entry == nil || entry.Active() || payload == nil {
+		return
+	}
+
+	switch {
+	case payload.ImpersonatorAdminID != nil:
+		userID := payload.UserID
+		entry.ActorID = *payload.ImpersonatorAdminID
+		entry.ImpersonatedUserID = &userID
+	case payload.UserRole == domain.SuperAdmin || payload.UserRole == domain.Admin:
+		entry.ActorID = payload.UserID
+	default:
+		return
+	}
+
+	entry.RequestID = uuid.New()
+}
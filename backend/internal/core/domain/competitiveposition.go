@@ -0,0 +1,64 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+type CompetitivePositionSort string
+
+const (
+	CompetitivePositionSortModel              CompetitivePositionSort = "model"
+	CompetitivePositionSortMyPrice            CompetitivePositionSort = "my_price"
+	CompetitivePositionSortCityRank           CompetitivePositionSort = "city_rank"
+	CompetitivePositionSortNationalRank       CompetitivePositionSort = "national_rank"
+	CompetitivePositionSortCityGapPercent     CompetitivePositionSort = "city_gap_percent"
+	CompetitivePositionSortNationalGapPercent CompetitivePositionSort = "national_gap_percent"
+)
+
+func IsCompetitivePositionSortValid(sort CompetitivePositionSort) bool {
+	switch sort {
+	case CompetitivePositionSortModel, CompetitivePositionSortMyPrice,
+		CompetitivePositionSortCityRank, CompetitivePositionSortNationalRank,
+		CompetitivePositionSortCityGapPercent, CompetitivePositionSortNationalGapPercent:
+		return true
+	}
+	return false
+}
+
+// CompetitivePositionFilter فیلتر گزارش جایگاه رقابتی محصولات یک فروشگاه.
+// Limit صفر یعنی همهٔ ردیف‌ها (برای خروجی گرفتن).
+type CompetitivePositionFilter struct {
+	UserID     int64
+	CategoryID int64
+	BrandIDs   []int64
+	SortBy     CompetitivePositionSort
+	SortDir    SortDir
+	Limit      int
+	Offset     int
+}
+
+// CompetitivePosition جایگاه قیمت یک محصول فروشگاه در بازار شهر خودش و کل کشور.
+// بازار همان فروشگاه‌های دیگری است که در صفحهٔ محصول دیده می‌شوند: محصول مخفی نیست،
+// اشتراک فروشگاه فعال است و قیمت به‌خاطر قدیمی بودن پنهان نشده است.
+// رتبه رقابتی است: ۱ + تعداد فروشگاه‌هایی که ارزان‌تر می‌فروشند.
+type CompetitivePosition struct {
+	UserProductID int64           `json:"userProductId"`
+	ProductID     int64           `json:"productId"`
+	ModelName     string          `json:"modelName"`
+	CategoryID    int64           `json:"categoryId"`
+	CategoryTitle string          `json:"categoryTitle"`
+	BrandID       int64           `json:"brandId"`
+	BrandTitle    string          `json:"brandTitle"`
+	IsHidden      bool            `json:"isHidden"`
+	MyPrice       decimal.Decimal `json:"myPrice"`
+
+	CityMinPrice      decimal.NullDecimal `json:"cityMinPrice"`
+	CityMedianPrice   decimal.NullDecimal `json:"cityMedianPrice"`
+	CityShopsCount    int                 `json:"cityShopsCount"`
+	CityRank          int                 `json:"cityRank"`
+	CityUndercutCount int                 `json:"cityUndercutCount"`
+
+	NationalMinPrice      decimal.NullDecimal `json:"nationalMinPrice"`
+	NationalMedianPrice   decimal.NullDecimal `json:"nationalMedianPrice"`
+	NationalShopsCount    int                 `json:"nationalShopsCount"`
+	NationalRank          int                 `json:"nationalRank"`
+	NationalUndercutCount int                 `json:"nationalUndercutCount"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// حداکثر طول دلیل ورود به جای کاربر
+const ImpersonationReasonMaxLength = 500
+
+type ImpersonationEndReason string
+
+const (
+	ImpersonationEndedByAdmin ImpersonationEndReason = "ended"   // ادمین نشست را بست
+	ImpersonationExpired      ImpersonationEndReason = "expired" // زمان نشست تمام شد
+)
+
+// ImpersonationSession یک ورود محدود ادمین به جای کاربر
+type ImpersonationSession struct {
+	ID        uuid.UUID               `json:"id"`
+	AdminID   int64                   `json:"adminId"`
+	UserID    int64                   `json:"userId"`
+	Reason    string                  `json:"reason"`
+	ReadOnly  bool                    `json:"readOnly"` // فقط درخواست‌های GET پذیرفته می‌شوند
+	ExpiresAt time.Time               `json:"expiresAt"`
+	EndedAt   *time.Time              `json:"endedAt"`
+	EndReason *ImpersonationEndReason `json:"endReason"`
+	CreatedAt time.Time               `json:"createdAt"`
+}
+
+func (ImpersonationSession) TableName() string {
+	return "impersonation_session"
+}
+
+func (s *ImpersonationSession) IsActive(now time.Time) bool {
+	return s.EndedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type ImpersonationRequest struct {
+	UserID          int64
+	Reason          string
+	ReadOnly        bool
+	DurationMinutes int // صفر یعنی مدت پیش‌فرض
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+type LoginOutcome string
+
+const (
+	LoginSucceeded LoginOutcome = "success"
+	LoginFailed    LoginOutcome = "failure"
+)
+
+// LoginEvent یک تلاش ورود با کد تایید؛ فقط اضافه می‌شود و ویرایش نمی‌شود
+type LoginEvent struct {
+	ID            int64        `json:"id"`
+	UserID        int64        `json:"userId"`
+	DeviceID      string       `json:"deviceId"`
+	IPAddress     string       `json:"ipAddress"`
+	UserAgent     string       `json:"userAgent"`
+	Outcome       LoginOutcome `json:"outcome"`
+	FailureReason *string      `json:"failureReason"` // کلید خطا از msg
+	NewDevice     bool         `json:"newDevice"`     // ورود موفقی از این دستگاه در بازهٔ بررسی نبوده است
+	NewIP         bool         `json:"newIp"`         // ورود موفقی از این IP در بازهٔ بررسی نبوده است
+	CreatedAt     time.Time    `json:"createdAt"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_event"
+}
+
+// LoginHistoryStats ورودهای موفق قبلی کاربر که برای تشخیص ورود مشکوک لازم است
+type LoginHistoryStats struct {
+	Total       int64
+	DeviceCount int64
+	IPCount     int64
+}
@@ -56,6 +56,8 @@ var (
 	ErrTokenCreation              = "auth: creating token failed"
 	ErrExpiredToken               = "auth: access token is expired"
 	ErrInvalidToken               = "auth: access token is invalid"
+	ErrRefreshTokenReused         = "auth: refresh token was already used"
+	ErrSessionIsRevoked           = "auth: session is no longer valid"
 	ErrUserDoesNotExist           = "auth: user does not exist"
 	ErrUserIsNotApprovedYet       = "auth: user is not approved yet"
 	ErrEmptyAuthorizationHeader   = "auth: authorization header is not provided"
@@ -67,6 +69,42 @@ var (
 	ErrVerificationCodeLengthIsNotValid = "verification-code: code length is not valid"
 	ErrCodeIsWrong                      = "verification-code: code is wrong"
 	ErrSendingVerificationCodeFailed    = "verification-code: sending code failed"
+	ErrVerificationCodeIsExpired        = "verification-code: code is expired"
+	ErrVerificationCodeIsLocked         = "verification-code: too many wrong attempts"
+	ErrVerificationCodeResendTooSoon    = "verification-code: resend is not allowed yet"
+	ErrVerificationCodeTooManyRequests  = "verification-code: too many code requests"
+
+	// active device
+	ErrDeviceLimitReached   = "device: maximum number of devices reached"
+	ErrDeviceDoesNotExist   = "device: device does not exist"
+	ErrDeviceNameIsNotValid = "device: device name is not valid"
+	ErrDeviceIDIsNotSet     = "device: current device id is not set"
+
+	// rate limit
+	ErrTooManyRequests = "rate-limit: too many requests"
+
+	// admin permission
+	ErrPermissionDenied           = "permission: admin does not have the required permission"
+	ErrPermissionIsNotValid       = "permission: permission is not valid"
+	ErrAdminRoleNameCannotBeEmpty = "admin role: name cannot be empty"
+	ErrAdminRoleDoesNotExist      = "admin role: role does not exist"
+
+	// impersonation
+	ErrImpersonationReasonIsNotValid = "impersonation: reason is required and must not be too long"
+	ErrCannotImpersonateAdmin        = "impersonation: admins cannot be impersonated"
+	ErrImpersonationIsReadOnly       = "impersonation: session is read-only"
+	ErrNotImpersonating              = "impersonation: request is not from an impersonation session"
+
+	// totp
+	ErrTOTPCodeIsWrong           = "totp: code is wrong"
+	ErrTOTPIsLocked              = "totp: too many wrong attempts"
+	ErrTOTPAlreadyEnabled        = "totp: totp is already enabled"
+	ErrTOTPIsNotEnabled          = "totp: totp is not enabled"
+	ErrTOTPIsRequired            = "totp: totp is required for this account"
+	ErrTOTPEnforcementIsNotValid = "totp: enforcement is not valid"
+	ErrTOTPEnableBeforeEnforcing = "totp: enable totp on your own account before requiring it"
+	ErrTOTPEnrollmentRequired    = "totp: totp must be set up from a signed-in session or by an admin before login"
+	ErrTOTPTargetRoleIsHigher    = "totp: admins can only manage two-factor settings of lower roles"
 
 	// product model
 	ErrModelTitleCannotBeEmpty          = "product model: title cannot be empty"
@@ -117,6 +155,60 @@ var (
 	ErrPricesDoNotMatch                         = "user product: prices do not match and are invalid"
 	ErrYouDoNotAccessToThisShop                 = "user product: you do not have access to this shop"
 	ErrNoSubscriptionsBought                    = "user product: you have to buy at least a subscription to see data"
+	ErrPriceAdjustmentModeIsNotValid            = "user product: price adjustment mode is not valid"
+	ErrPriceAdjustmentValueIsNotValid           = "user product: price adjustment value is not valid"
+	ErrPriceAdjustmentPercentIsNotValid         = "user product: price adjustment percent must be greater than -100"
+	ErrNoProductsMatchPriceAdjustment           = "user product: no products match the price adjustment scope"
+	ErrNoPriceAdjustmentToUndo                  = "user product: there is no price adjustment to undo"
+	ErrPriceAdjustmentMakesPriceNegative        = "user product: price adjustment makes some prices negative"
+	ErrPriceDeviatesFromMarket                  = "user product: price deviates from the market median and needs confirmation"
+	ErrCompetitivePositionSortIsNotValid        = "user product: competitive position sort is not valid"
+	ErrUserProductSKUIsNotValid                 = "user product: sku is too long"
+	ErrUserProductSKUIsTaken                    = "user product: sku is already used by another product of the shop"
+	ErrUserProductSKUDoesNotExist               = "user product: no product with this sku"
+
+	// user product import
+	ErrUserProductImportFileIsNotValid     = "user product import: file must be a csv or xlsx spreadsheet"
+	ErrUserProductImportColumnsAreMissing  = "user product import: id, sku, product id or brand and model columns are required"
+	ErrUserProductImportIsEmpty            = "user product import: file has no rows"
+	ErrUserProductImportHasTooManyRows     = "user product import: file has too many rows"
+	ErrUserProductImportValueIsNotValid    = "user product import: a cell value is not valid"
+	ErrUserProductImportProductIsNotSet    = "user product import: sku, product id or brand and model is required"
+	ErrUserProductImportProductNotFound    = "user product import: product does not exist"
+	ErrUserProductImportProductIsAmbiguous = "user product import: brand and model match more than one product"
+	ErrUserProductImportProductIsRepeated  = "user product import: product is repeated in the file"
+	ErrUserProductImportProductIsNotInShop = "user product import: product is not in the shop"
+	ErrUserProductImportRowChangedOnServer = "user product import: row changed on the server after the export"
+
+	// rounding rule
+	ErrRoundingStepIsNotValid      = "rounding rule: step must be greater than zero"
+	ErrRoundingDirectionIsNotValid = "rounding rule: direction is not valid"
+	ErrRoundingEndingIsNotValid    = "rounding rule: ending must be between zero and step"
+
+	// price list
+	ErrPriceListRendererBusy     = "price list: renderer is busy, try again later"
+	ErrPriceListFormatIsNotValid = "price list: export format is not valid"
+	ErrPriceListTooLargeForImage = "price list: too many rows for an image export, use pdf"
+
+	// price list template
+	ErrPriceListTemplateTitleIsNotValid = "price list template: title is required"
+	ErrPriceListTemplateDoesNotExist    = "price list template: template does not exist"
+	ErrPriceListGroupByIsNotValid       = "price list template: group by is not valid"
+	ErrPriceListSortIsNotValid          = "price list template: sort is not valid"
+	ErrPaperSizeIsNotValid              = "price list template: paper size is not valid"
+	ErrPriceListMarkupIsNotValid        = "price list template: markup percent must be between 0 and 1000"
+
+	// price list share link
+	ErrPriceListShareLinkIsNotValid    = "price list share link: link is not valid, expired or revoked"
+	ErrPriceListShareLinkDoesNotExist  = "price list share link: link does not exist"
+	ErrPriceListShareLinkTTLIsNotValid = "price list share link: expiry is not valid"
+	ErrPriceListSharePinIsNotValid     = "price list share link: pin must be 4 to 8 digits"
+	ErrPriceListSharePinRequired       = "price list share link: pin is required"
+	ErrPriceListSharePinIsWrong        = "price list share link: pin is wrong"
+	ErrPriceListSharePinLocked         = "price list share link: too many wrong pins, try again later"
+
+	// staleness policy
+	ErrStalenessMaxAgeIsNotValid = "staleness policy: max age days must be greater than zero"
 
 	// subscription
 	ErrPriceIsNotValid              = "subscription: price is not valid"
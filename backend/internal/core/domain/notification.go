@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationStalePrices     NotificationType = "stale_prices"
+	NotificationSuspiciousLogin NotificationType = "suspicious_login"
+	NotificationImpersonation   NotificationType = "impersonation"
+	NotificationTOTP            NotificationType = "totp"
+)
+
+// Notification پیام درون‌برنامه‌ای برای کاربر
+type Notification struct {
+	ID        int64            `json:"id"`
+	UserID    int64            `json:"userId"`
+	Type      NotificationType `gorm:"column:type_c" json:"type"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body"`
+	ReadAt    *time.Time       `json:"readAt"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func (Notification) TableName() string {
+	return "notification"
+}
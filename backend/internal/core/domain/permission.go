@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Permission یک توانایی نام‌دار در پنل ادمین؛ مسیرها با RequirePermission به آن محدود می‌شوند
+type Permission string
+
+const (
+	PermissionCatalog       Permission = "catalog"       // دسته‌بندی، برند، مدل، محصول، فیلتر، شهر و درخواست محصول
+	PermissionUsers         Permission = "users"         // وضعیت و دستگاه‌های کاربران و فروشگاه‌ها
+	PermissionAdmins        Permission = "admins"        // ادمین‌ها، نقش‌ها و دسترسی‌ها
+	PermissionSubscriptions Permission = "subscriptions" // طرح‌های اشتراک و اعطای روز اشتراک
+	PermissionPayments      Permission = "payments"      // تراکنش‌های پرداخت
+	PermissionReports       Permission = "reports"       // گزارش‌های کاربران و پرچم‌های قیمت
+	PermissionImpersonation Permission = "impersonation" // ورود به جای کاربر
+	PermissionAudit         Permission = "audit"         // مشاهدهٔ سوابق کارهای ادمین‌ها
+)
+
+// AllPermissions همهٔ دسترسی‌ها؛ سوپرادمین همهٔ آن‌ها را دارد
+var AllPermissions = []Permission{
+	PermissionCatalog,
+	PermissionUsers,
+	PermissionAdmins,
+	PermissionSubscriptions,
+	PermissionPayments,
+	PermissionReports,
+	PermissionImpersonation,
+	PermissionAudit,
+}
+
+func (p Permission) IsValid() bool {
+	return slices.Contains(AllPermissions, p)
+}
+
+// AdminRole نقشی که به ادمین‌ها داده می‌شود
+type AdminRole struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+func (AdminRole) TableName() string {
+	return "admin_role"
+}
+
+type AdminRolePermission struct {
+	RoleID     int64
+	Permission Permission
+}
+
+func (AdminRolePermission) TableName() string {
+	return "admin_role_permission"
+}
+
+// AdminAccessPermission دسترسی‌ای که مستقیم، بدون نقش، به ادمین داده شده است
+type AdminAccessPermission struct {
+	UserID     int64
+	Permission Permission
+}
+
+func (AdminAccessPermission) TableName() string {
+	return "admin_access_permission"
+}
+
+// PermissionsFor دسترسی‌های مؤثر کاربر: سوپرادمین همه، ادمین دسترسی‌های مستقیم و نقش، بقیه هیچ
+func PermissionsFor(role UserRole, access *AdminAccess) []Permission {
+	switch {
+	case role == SuperAdmin:
+		return slices.Clone(AllPermissions)
+	case role != Admin || access == nil:
+		return nil
+	}
+
+	permissions := slices.Concat(access.Permissions, access.RolePermissions)
+	slices.Sort(permissions)
+	return slices.Compact(permissions)
+}
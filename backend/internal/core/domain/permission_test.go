@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPermissionsFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   UserRole
+		access *AdminAccess
+		want   []Permission
+	}{
+		{name: "super admin has every permission", role: SuperAdmin, want: AllPermissions},
+		{name: "admin without access record", role: Admin, want: nil},
+		{
+			name: "admin merges direct and role permissions",
+			role: Admin,
+			access: &AdminAccess{
+				Permissions:     []Permission{PermissionUsers, PermissionCatalog},
+				RolePermissions: []Permission{PermissionCatalog, PermissionPayments},
+			},
+			want: []Permission{PermissionCatalog, PermissionPayments, PermissionUsers},
+		},
+		{
+			name:   "non admin ignores an access record",
+			role:   Wholesaler,
+			access: &AdminAccess{Permissions: []Permission{PermissionPayments}},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PermissionsFor(tt.role, tt.access)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PermissionsFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenPayloadHasPermission(t *testing.T) {
+	adminID := int64(1)
+
+	tests := []struct {
+		name    string
+		payload TokenPayload
+		want    bool
+	}{
+		{name: "super admin", payload: TokenPayload{UserRole: SuperAdmin}, want: true},
+		{
+			name:    "admin with permission",
+			payload: TokenPayload{UserRole: Admin, Permissions: []Permission{PermissionPayments}},
+			want:    true,
+		},
+		{
+			name:    "admin without permission",
+			payload: TokenPayload{UserRole: Admin, Permissions: []Permission{PermissionSubscriptions}},
+			want:    false,
+		},
+		{
+			name:    "non admin carrying permissions",
+			payload: TokenPayload{UserRole: Retailer, Permissions: []Permission{PermissionPayments}},
+			want:    false,
+		},
+		{
+			name:    "impersonation token",
+			payload: TokenPayload{UserRole: SuperAdmin, ImpersonatorAdminID: &adminID},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.HasPermission(PermissionPayments); got != tt.want {
+				t.Errorf("HasPermission = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionIsValid(t *testing.T) {
+	for _, permission := range AllPermissions {
+		if !permission.IsValid() {
+			t.Errorf("%q is not valid", permission)
+		}
+	}
+	if Permission("billing").IsValid() {
+		t.Error("unknown permission is valid")
+	}
+}
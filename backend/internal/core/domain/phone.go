@@ -0,0 +1,34 @@
+package domain
+
+import "strings"
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+
+// NormalizePhone شمارهٔ موبایل را به شکل ده‌رقمی بدون پیشوند (مثل 9121234567) برمی‌گرداند تا
+// 09121234567، +989121234567، 00989121234567 و ارقام فارسی همه یک شماره حساب شوند.
+// شماره‌ای که موبایل ایران نیست فقط یکدست می‌شود.
+func NormalizePhone(phone string) string {
+	phone = phoneSeparators.Replace(skuDigits.Replace(strings.TrimSpace(phone)))
+
+	switch {
+	case strings.HasPrefix(phone, "+98"):
+		phone = phone[len("+98"):]
+	case strings.HasPrefix(phone, "0098"):
+		phone = phone[len("0098"):]
+	case len(phone) == 12 && strings.HasPrefix(phone, "98"):
+		phone = phone[len("98"):]
+	}
+
+	return strings.TrimPrefix(phone, "0")
+}
+
+// PhoneVariants شکل‌هایی که شمارهٔ موبایل ممکن است با آن‌ها ذخیره شده باشد؛
+// ثبت‌نام هر سه شکل 0912…، +98912… و 912… را می‌پذیرد
+func PhoneVariants(phone string) []string {
+	national := NormalizePhone(phone)
+	if len(national) != 10 || national[0] != '9' {
+		return []string{strings.TrimSpace(phone)}
+	}
+
+	return []string{"0" + national, "+98" + national, national}
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"09121234567", "9121234567"},
+		{"+989121234567", "9121234567"},
+		{"00989121234567", "9121234567"},
+		{"989121234567", "9121234567"},
+		{"9121234567", "9121234567"},
+		{" 0912 123 4567 ", "9121234567"},
+		{"۰۹۱۲۱۲۳۴۵۶۷", "9121234567"},
+		{"02188776655", "2188776655"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePhone(tt.in); got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneVariants(t *testing.T) {
+	want := []string{"09121234567", "+989121234567", "9121234567"}
+	for _, in := range []string{"09121234567", "+989121234567", "00989121234567"} {
+		if got := PhoneVariants(in); !slices.Equal(got, want) {
+			t.Errorf("PhoneVariants(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if got := PhoneVariants("12345"); !slices.Equal(got, []string{"12345"}) {
+		t.Errorf("PhoneVariants(non-mobile) = %v", got)
+	}
+}
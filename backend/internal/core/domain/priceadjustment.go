@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PriceAdjustmentMode string
+
+const (
+	PriceAdjustmentPercent PriceAdjustmentMode = "percent"
+	PriceAdjustmentAmount  PriceAdjustmentMode = "amount"
+)
+
+func IsPriceAdjustmentModeValid(mode PriceAdjustmentMode) bool {
+	return mode == PriceAdjustmentPercent || mode == PriceAdjustmentAmount
+}
+
+// PriceAdjustmentScope محدودهٔ محصولاتی که تغییر قیمت گروهی روی آن‌ها اعمال می‌شود.
+// اگر همهٔ فیلدها خالی باشند، همهٔ محصولات ریالی فروشگاه در نظر گرفته می‌شوند.
+type PriceAdjustmentScope struct {
+	CategoryID     int64   `json:"categoryId,omitempty"`
+	BrandIDs       []int64 `json:"brandIds,omitempty"`
+	UserProductIDs []int64 `json:"userProductIds,omitempty"`
+}
+
+type PriceAdjustment struct {
+	UserID int64                `json:"-"`
+	Mode   PriceAdjustmentMode  `json:"mode"`
+	Value  decimal.Decimal      `json:"value"` // درصد (مثلا 10 یا -5) یا مبلغ ثابت (مثبت یا منفی)
+	Scope  PriceAdjustmentScope `json:"scope"`
+}
+
+// PriceAdjustmentItem یک ردیف از پیش‌نمایش یا snapshot تغییر قیمت گروهی
+type PriceAdjustmentItem struct {
+	ID              int64           `json:"-"`
+	BatchID         int64           `json:"-"`
+	UserProductID   int64           `json:"userProductId"`
+	ProductID       int64           `json:"productId"        gorm:"->"`
+	CategoryID      int64           `json:"categoryId"       gorm:"->"`
+	ModelName       string          `json:"modelName"        gorm:"->"`
+	ProductBrand    string          `json:"productBrand"     gorm:"->"`
+	ProductCategory string          `json:"productCategory"  gorm:"->"`
+	OldPrice        decimal.Decimal `json:"oldPrice"`
+	NewPrice        decimal.Decimal `json:"newPrice"`
+}
+
+func (PriceAdjustmentItem) TableName() string {
+	return "price_adjustment_item"
+}
+
+// PriceAdjustmentBatch سابقهٔ یک عملیات تغییر قیمت گروهی؛ برای بازگردانی (undo) نگه داشته می‌شود.
+type PriceAdjustmentBatch struct {
+	ID            int64               `json:"id"`
+	UserID        int64               `json:"userId"`
+	Mode          PriceAdjustmentMode `gorm:"column:mode_c" json:"mode"`
+	Value         decimal.Decimal     `gorm:"column:value_c" json:"value"`
+	Scope         string              `gorm:"column:scope;type:jsonb" json:"scope"`
+	AffectedCount int                 `json:"affectedCount"`
+	RevertedAt    *time.Time          `json:"revertedAt"`
+	CreatedAt     time.Time           `json:"createdAt"`
+}
+
+func (PriceAdjustmentBatch) TableName() string {
+	return "price_adjustment_batch"
+}
+
+type PriceAdjustmentPreview struct {
+	Items         []*PriceAdjustmentItem `json:"items"`
+	AffectedCount int                    `json:"affectedCount"`
+}
+
+type PriceAdjustmentResult struct {
+	BatchID       int64 `json:"batchId"`
+	AffectedCount int   `json:"affectedCount"`
+}
+
+type PriceAdjustmentUndoResult struct {
+	BatchID       int64 `json:"batchId"`
+	RestoredCount int   `json:"restoredCount"`
+	SkippedCount  int   `json:"skippedCount"` // ردیف‌هایی که بعد از عملیات دستی ویرایش شده‌اند
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarketPriceStats میانهٔ قیمت یک محصول بین فروشگاه‌های دیگر
+type MarketPriceStats struct {
+	Median     decimal.NullDecimal `json:"median"`
+	SampleSize int                 `json:"sampleSize"`
+}
+
+// PriceDeviation جزئیات انحراف قیمت واردشده از میانهٔ بازار
+type PriceDeviation struct {
+	Price            decimal.Decimal `json:"price"`
+	MarketMedian     decimal.Decimal `json:"marketMedian"`
+	MinAllowed       decimal.Decimal `json:"minAllowed"`
+	MaxAllowed       decimal.Decimal `json:"maxAllowed"`
+	DeviationPercent decimal.Decimal `json:"deviationPercent"`
+	SampleSize       int             `json:"sampleSize"`
+}
+
+// PriceDeviationError وقتی برگردانده می‌شود که قیمت خارج از بازهٔ مجاز است و
+// کاربر هنوز آن را تایید نکرده است؛ کلاینت باید با ConfirmPriceDeviation دوباره ارسال کند.
+type PriceDeviationError struct {
+	Message string
+	PriceDeviation
+}
+
+func (e *PriceDeviationError) Error() string {
+	return e.Message
+}
+
+// PriceDeviationFlag قیمتی که با وجود هشدار تایید شده و برای بررسی ادمین ثبت می‌شود
+type PriceDeviationFlag struct {
+	ID               int64           `json:"id"`
+	UserID           int64           `json:"userId"`
+	ProductID        int64           `json:"productId"`
+	UserProductID    int64           `json:"userProductId"`
+	Price            decimal.Decimal `json:"price"`
+	MarketMedian     decimal.Decimal `json:"marketMedian"`
+	DeviationPercent decimal.Decimal `json:"deviationPercent"`
+	ReviewedAt       *time.Time      `json:"reviewedAt"`
+	ReviewedBy       *int64          `json:"reviewedBy"`
+	CreatedAt        time.Time       `json:"createdAt"`
+}
+
+func (PriceDeviationFlag) TableName() string {
+	return "price_deviation_flag"
+}
+
+type PriceDeviationFlagView struct {
+	PriceDeviationFlag
+	ModelName    string          `json:"modelName"`
+	BrandTitle   string          `json:"brandTitle"`
+	ShopName     string          `json:"shopName"`
+	CurrentPrice decimal.Decimal `json:"currentPrice"`
+}
+
+type PriceDeviationFlagFilter struct {
+	Reviewed *bool
+}
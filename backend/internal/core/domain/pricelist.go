@@ -0,0 +1,43 @@
+package domain
+
+type PriceListFormat string
+
+const (
+	PriceListFormatPDF  PriceListFormat = "pdf"
+	PriceListFormatXLSX PriceListFormat = "xlsx"
+	PriceListFormatCSV  PriceListFormat = "csv"
+	PriceListFormatPNG  PriceListFormat = "png"
+	PriceListFormatJPEG PriceListFormat = "jpeg"
+)
+
+// IsPriceListExportFormatValid فرمت‌هایی که از مسیر خروجی فایل پشتیبانی می‌شوند؛ PDF مسیر جدا دارد
+func IsPriceListExportFormatValid(format PriceListFormat) bool {
+	switch format {
+	case PriceListFormatXLSX, PriceListFormatCSV, PriceListFormatPNG, PriceListFormatJPEG:
+		return true
+	}
+	return false
+}
+
+func (f PriceListFormat) ContentType() string {
+	switch f {
+	case PriceListFormatPDF:
+		return "application/pdf"
+	case PriceListFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PriceListFormatCSV:
+		return "text/csv; charset=utf-8"
+	case PriceListFormatPNG:
+		return "image/png"
+	case PriceListFormatJPEG:
+		return "image/jpeg"
+	}
+	return "application/octet-stream"
+}
+
+func (f PriceListFormat) Extension() string {
+	if f == PriceListFormatJPEG {
+		return "jpg"
+	}
+	return string(f)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// PriceListShareLink لینک عمومی و فقط‌خواندنی لیست قیمت یک فروشگاه.
+// خود توکن ذخیره نمی‌شود؛ از شناسه و زمان انقضای لینک با امضای HMAC ساخته می‌شود
+// و لغو لینک با revoked_at انجام می‌شود.
+type PriceListShareLink struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"userId"`
+	TemplateID   *int64     `json:"templateId"` // nil → قالب پیش‌فرض فروشگاه
+	Title        string     `json:"title"`
+	PinHash      *string    `json:"-"`
+	IsAuto       bool       `json:"isAuto"` // ساخته‌شدهٔ خودکار برای QR فایل‌های لیست قیمت
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	ViewCount    int64      `json:"viewCount"`
+	LastViewedAt *time.Time `json:"lastViewedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+
+	HasPin bool   `gorm:"-" json:"hasPin"`
+	Token  string `gorm:"-" json:"token"`
+	URL    string `gorm:"-" json:"url"`
+}
+
+func (PriceListShareLink) TableName() string {
+	return "price_list_share_link"
+}
+
+func (l *PriceListShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// PriceListSharePinAttempt تلاش‌های اشتباه رمز یک لینک از یک IP؛ قفل فقط همان IP را پشت لینک نگه می‌دارد
+type PriceListSharePinAttempt struct {
+	LinkID         int64  `gorm:"primaryKey"`
+	IPAddress      string `gorm:"primaryKey"`
+	FailedAttempts int
+	LockedUntil    *time.Time
+	UpdatedAt      time.Time
+}
+
+func (PriceListSharePinAttempt) TableName() string {
+	return "price_list_share_pin_attempt"
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type PriceListGroupBy string
+
+const (
+	PriceListGroupNone     PriceListGroupBy = "none"
+	PriceListGroupCategory PriceListGroupBy = "category"
+	PriceListGroupBrand    PriceListGroupBy = "brand"
+)
+
+func IsPriceListGroupByValid(groupBy PriceListGroupBy) bool {
+	return groupBy == PriceListGroupNone || groupBy == PriceListGroupCategory ||
+		groupBy == PriceListGroupBrand
+}
+
+type PriceListSort string
+
+const (
+	PriceListSortDefault PriceListSort = "default" // ترتیب فعلی لیست قیمت (بر اساس برند)
+	PriceListSortModel   PriceListSort = "model"
+	PriceListSortPrice   PriceListSort = "price"
+	PriceListSortUpdated PriceListSort = "updated"
+)
+
+func IsPriceListSortValid(sort PriceListSort) bool {
+	switch sort {
+	case PriceListSortDefault, PriceListSortModel, PriceListSortPrice, PriceListSortUpdated:
+		return true
+	}
+	return false
+}
+
+type PaperSize string
+
+const (
+	PaperSizeA4     PaperSize = "A4"
+	PaperSizeA5     PaperSize = "A5"
+	PaperSizeLetter PaperSize = "Letter"
+)
+
+func IsPaperSizeValid(size PaperSize) bool {
+	return size == PaperSizeA4 || size == PaperSizeA5 || size == PaperSizeLetter
+}
+
+// PriceListTemplate قالب ذخیره‌شدهٔ لیست قیمت یک فروشگاه که همهٔ خروجی‌ها (PDF، اکسل، CSV و تصویر) از آن پیروی می‌کنند.
+// اگر MarkupPercent پر باشد ستون «قیمت پیشنهادی مصرف‌کننده» = قیمت × (۱ + درصد/۱۰۰) اضافه می‌شود.
+// هر فروشگاه حداکثر یک قالب پیش‌فرض دارد؛ بدون قالب، DefaultPriceListTemplate استفاده می‌شود.
+type PriceListTemplate struct {
+	ID              int64               `json:"id"`
+	UserID          int64               `json:"userId"`
+	Title           string              `json:"title"`
+	IsDefault       bool                `json:"isDefault"`
+	ShowDollarPrice bool                `json:"showDollarPrice"`
+	ShowImage       bool                `json:"showImage"`
+	ShowDescription bool                `json:"showDescription"`
+	ShowSKU         bool                `json:"showSku"` // ستون کد کالا فقط اگر محصولی کد داشته باشد چاپ می‌شود
+	GroupBy         PriceListGroupBy    `json:"groupBy"`
+	SortBy          PriceListSort       `json:"sortBy"`
+	SortDir         SortDir             `json:"sortDir"`
+	HeaderText      string              `json:"headerText"`
+	FooterText      string              `json:"footerText"`
+	MarkupPercent   decimal.NullDecimal `json:"markupPercent"`
+	PaperSize       PaperSize           `json:"paperSize"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+}
+
+func (PriceListTemplate) TableName() string {
+	return "price_list_template"
+}
+
+// DefaultPriceListTemplate همان چیدمان ثابت قبلی لیست قیمت
+func DefaultPriceListTemplate() *PriceListTemplate {
+	return &PriceListTemplate{
+		ShowSKU:   true,
+		GroupBy:   PriceListGroupNone,
+		SortBy:    PriceListSortDefault,
+		SortDir:   SortAsc,
+		PaperSize: PaperSizeA4,
+	}
+}
+
+// RetailPrice قیمت پیشنهادی مصرف‌کننده؛ بدون درصد سود مقدار ندارد
+func (t *PriceListTemplate) RetailPrice(price decimal.Decimal) decimal.NullDecimal {
+	if t == nil || !t.MarkupPercent.Valid {
+		return decimal.NullDecimal{}
+	}
+
+	factor := decimal.NewFromInt(1).Add(t.MarkupPercent.Decimal.Div(decimal.NewFromInt(100)))
+	return decimal.NullDecimal{Decimal: price.Mul(factor).Round(0), Valid: true}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRevokeReason string
+
+const (
+	RefreshTokenRevokedByReuse   RefreshTokenRevokeReason = "reuse"   // توکن چرخیده دوباره ارائه شد
+	RefreshTokenRevokedByRelogin RefreshTokenRevokeReason = "relogin" // همان دستگاه دوباره وارد شد
+)
+
+// RefreshTokenFamily زنجیرهٔ refresh tokenهای یک ورود روی یک دستگاه فعال.
+// با هر تمدید توکن قبلی مصرف و توکن تازه در همین خانواده ساخته می‌شود.
+type RefreshTokenFamily struct {
+	ID             uuid.UUID
+	UserID         int64
+	ActiveDeviceID int64
+	RevokedAt      *time.Time
+	RevokeReason   *RefreshTokenRevokeReason
+	CreatedAt      time.Time
+}
+
+func (RefreshTokenFamily) TableName() string {
+	return "refresh_token_family"
+}
+
+type RefreshToken struct {
+	JTI       uuid.UUID `gorm:"column:jti;primaryKey"`
+	FamilyID  uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_token"
+}
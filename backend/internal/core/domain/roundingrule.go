@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type RoundingDirection string
+
+const (
+	RoundingUp      RoundingDirection = "up"
+	RoundingDown    RoundingDirection = "down"
+	RoundingNearest RoundingDirection = "nearest"
+)
+
+func IsRoundingDirectionValid(direction RoundingDirection) bool {
+	return direction == RoundingUp || direction == RoundingDown || direction == RoundingNearest
+}
+
+// RoundingRule قانون گرد کردن قیمت‌های محاسبه‌شدهٔ یک فروشگاه.
+// اگر CategoryID خالی باشد قانون پیش‌فرض فروشگاه است، در غیر این صورت فقط برای آن دسته اعمال می‌شود.
+// Ending برای قیمت‌های «روانی» است؛ مثلا Step=1000 و Ending=900 قیمت را به …900 ختم می‌کند.
+type RoundingRule struct {
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"userId"`
+	CategoryID *int64            `json:"categoryId"`
+	Step       decimal.Decimal   `gorm:"column:step_c" json:"step"`
+	Direction  RoundingDirection `json:"direction"`
+	Ending     decimal.Decimal   `gorm:"column:ending" json:"ending"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+}
+
+func (RoundingRule) TableName() string {
+	return "rounding_rule"
+}
+
+// Apply مقدار را طبق قانون گرد می‌کند: round((v - ending) / step) * step + ending
+func (r *RoundingRule) Apply(value decimal.Decimal) decimal.Decimal {
+	if r == nil || !r.Step.IsPositive() {
+		return value.Round(0)
+	}
+
+	steps := value.Sub(r.Ending).Div(r.Step)
+	switch r.Direction {
+	case RoundingUp:
+		steps = steps.Ceil()
+	case RoundingDown:
+		steps = steps.Floor()
+	default:
+		steps = steps.Round(0)
+	}
+
+	rounded := steps.Mul(r.Step).Add(r.Ending)
+	if rounded.IsNegative() {
+		return decimal.Zero
+	}
+
+	return rounded
+}
+
+var (
+	legacyRoundingStep      = decimal.NewFromInt(100000)
+	legacyRoundingThreshold = decimal.NewFromInt(65000)
+)
+
+// RoundingRules قوانین یک فروشگاه؛ اولویت با قانون دسته، بعد قانون پیش‌فرض فروشگاه
+// و در نهایت رفتار قدیمی User.Rounded (مضرب ۱۰۰هزار با آستانهٔ ۶۵هزار).
+type RoundingRules struct {
+	Default    *RoundingRule
+	ByCategory map[int64]*RoundingRule
+	Legacy     bool
+}
+
+func NewRoundingRules(rules []*RoundingRule, legacyRounded bool) *RoundingRules {
+	rs := &RoundingRules{
+		ByCategory: map[int64]*RoundingRule{},
+		Legacy:     legacyRounded,
+	}
+
+	for _, rule := range rules {
+		if rule.CategoryID == nil {
+			rs.Default = rule
+			continue
+		}
+		rs.ByCategory[*rule.CategoryID] = rule
+	}
+
+	return rs
+}
+
+func (rs *RoundingRules) Resolve(categoryID int64) *RoundingRule {
+	if rule, ok := rs.ByCategory[categoryID]; ok {
+		return rule
+	}
+
+	return rs.Default
+}
+
+func (rs *RoundingRules) Apply(categoryID int64, value decimal.Decimal) decimal.Decimal {
+	return RoundPrice(rs.Resolve(categoryID), rs.Legacy, value)
+}
+
+// RoundPrice قانون مؤثر را اعمال می‌کند؛ اگر قانونی نباشد سراغ فلگ قدیمی rounded می‌رود
+func RoundPrice(rule *RoundingRule, legacyRounded bool, value decimal.Decimal) decimal.Decimal {
+	if rule != nil {
+		return rule.Apply(value)
+	}
+
+	if legacyRounded {
+		// همان مقایسهٔ قدیمی: فقط باقیماندهٔ بیشتر از ۶۵هزار رو به بالا گرد می‌شود
+		remainder := value.Mod(legacyRoundingStep)
+		rounded := value.Sub(remainder)
+		if remainder.GreaterThan(legacyRoundingThreshold) {
+			rounded = rounded.Add(legacyRoundingStep)
+		}
+		return rounded
+	}
+
+	return value.Round(0)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundPrice(t *testing.T) {
+	categoryID := int64(3)
+	up := &RoundingRule{Step: decimal.NewFromInt(1000), Direction: RoundingUp}
+	down := &RoundingRule{Step: decimal.NewFromInt(1000), Direction: RoundingDown}
+	nearest := &RoundingRule{Step: decimal.NewFromInt(1000), Direction: RoundingNearest}
+	ending := &RoundingRule{Step: decimal.NewFromInt(1000), Direction: RoundingDown,
+		Ending: decimal.NewFromInt(900), CategoryID: &categoryID}
+
+	tests := []struct {
+		name   string
+		rule   *RoundingRule
+		legacy bool
+		value  string
+		want   string
+	}{
+		{"up", up, false, "12001", "13000"},
+		{"down", down, false, "12999", "12000"},
+		{"nearest rounds half up", nearest, false, "12500", "13000"},
+		{"nearest", nearest, false, "12499", "12000"},
+		{"ending", ending, false, "12850", "11900"},
+		{"rule wins over legacy flag", down, true, "165000", "165000"},
+		{"no rule drops fractions", nil, false, "12345.6", "12346"},
+		{"legacy remainder equal to threshold stays down", nil, true, "165000", "100000"},
+		{"legacy remainder above threshold goes up", nil, true, "165001", "200000"},
+		{"legacy small remainder goes down", nil, true, "1230000", "1200000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RoundPrice(tt.rule, tt.legacy, decimal.RequireFromString(tt.value))
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatalf("RoundPrice(%s) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundingRulesResolve(t *testing.T) {
+	categoryID := int64(3)
+	shopDefault := &RoundingRule{Step: decimal.NewFromInt(1000)}
+	categoryRule := &RoundingRule{Step: decimal.NewFromInt(10000), CategoryID: &categoryID}
+	rules := NewRoundingRules([]*RoundingRule{shopDefault, categoryRule}, true)
+
+	if rules.Resolve(3) != categoryRule {
+		t.Fatal("category rule should override the shop default")
+	}
+	if rules.Resolve(4) != shopDefault {
+		t.Fatal("other categories should use the shop default")
+	}
+
+	legacyOnly := NewRoundingRules(nil, true)
+	if got := legacyOnly.Apply(4, decimal.NewFromInt(170000)); !got.Equal(decimal.NewFromInt(200000)) {
+		t.Fatalf("legacy fallback = %s", got)
+	}
+}
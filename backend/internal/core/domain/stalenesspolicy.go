@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// StalenessPolicy حداکثر عمر قیمت؛ بعد از آن قیمت «قدیمی» حساب می‌شود و در بازار پایین‌تر
+// نمایش داده می‌شود (یا در صورت AutoHide نمایش داده نمی‌شود).
+// اگر CategoryID خالی باشد سیاست پیش‌فرض همهٔ دسته‌هاست.
+type StalenessPolicy struct {
+	ID         int64     `json:"id"`
+	CategoryID *int64    `json:"categoryId"`
+	MaxAgeDays int       `json:"maxAgeDays"`
+	AutoHide   bool      `json:"autoHide"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func (StalenessPolicy) TableName() string {
+	return "price_staleness_policy"
+}
+
+// StalePriceShop فروشگاهی که قیمت قدیمی دارد و باید یادآوری دریافت کند
+type StalePriceShop struct {
+	UserID     int64  `json:"userId"`
+	Phone      string `json:"phone"`
+	ShopName   string `json:"shopName"`
+	StaleCount int    `json:"staleCount"`
+}
+
+type StalePriceReminderResult struct {
+	ShopsCount    int `json:"shopsCount"`
+	SmsSentCount  int `json:"smsSentCount"`
+	SmsFailCount  int `json:"smsFailCount"`
+	NotifiedCount int `json:"notifiedCount"`
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,14 +15,34 @@ type TokenPayload struct {
 	UserState   UserState
 	CityID      int64
 	AdminAccess *AdminAccess
+	Permissions []Permission `json:"permissions"`
 	Type        string `json:"type"`
 	Expiration  time.Time
 	ImpersonatorAdminID  *int64              `json:"impersonator_admin_id,omitempty"` // <-- فیلد جدید
+	SessionVersion       int64               `json:"session_version"`
+	// خانوادهٔ refresh token ورودی که توکن از آن صادر شده؛ با خروج یا حذف دستگاه توکن هم پذیرفته نمی‌شود
+	FamilyID uuid.UUID `json:"family_id"`
+	// نشست جعل هویتی که توکن برای آن ساخته شده؛ با بسته شدن نشست توکن هم پذیرفته نمی‌شود
+	ImpersonationSessionID *uuid.UUID `json:"impersonation_session_id,omitempty"`
+	ImpersonationReadOnly  bool       `json:"impersonation_read_only,omitempty"`
 
 }
+// HasPermission دسترسی‌های ثبت‌شده در توکن را بررسی می‌کند؛ سوپرادمین همه را دارد و
+// توکن ورود به جای کاربر هیچ دسترسی ادمینی ندارد
+func (p *TokenPayload) HasPermission(permission Permission) bool {
+	if p.ImpersonatorAdminID != nil {
+		return false
+	}
+	if p.UserRole == SuperAdmin {
+		return true
+	}
+	return p.UserRole == Admin && slices.Contains(p.Permissions, permission)
+}
+
 type RefreshTokenPayload struct {
 	JTI      uuid.UUID `json:"jti"`
 	UserID   int64     `json:"user_id"`
 	UserRole UserRole  `json:"user_role"`
+	FamilyID uuid.UUID `json:"family_id"`
 	Type     string    `json:"type"`
 }
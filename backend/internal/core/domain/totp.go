@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPEnforcement سیاست سراسری عامل دوم که ادمین‌ها تعیین می‌کنند
+type TOTPEnforcement string
+
+const (
+	TOTPOptional          TOTPEnforcement = "optional" // فقط از کاربرانی که TOTP را فعال کرده‌اند پرسیده می‌شود
+	TOTPRequiredForAdmins TOTPEnforcement = "admins"   // ادمین‌ها بدون TOTP نمی‌توانند وارد شوند
+)
+
+func (e TOTPEnforcement) IsValid() bool {
+	return e == TOTPOptional || e == TOTPRequiredForAdmins
+}
+
+type TOTPPolicy struct {
+	ID          int16           `json:"-"`
+	Enforcement TOTPEnforcement `json:"enforcement"`
+	UpdatedBy   *int64          `json:"updatedBy"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+func (TOTPPolicy) TableName() string {
+	return "totp_policy"
+}
+
+// RequiresTOTP یعنی کاربری با این نقش بدون TOTP اجازهٔ ورود ندارد
+func (p *TOTPPolicy) RequiresTOTP(role UserRole) bool {
+	return p.Enforcement == TOTPRequiredForAdmins && (role == Admin || role == SuperAdmin)
+}
+
+// UserTOTP رمز TOTP کاربر؛ تا ConfirmedAt خالی است ثبت‌نام کامل نشده است
+type UserTOTP struct {
+	UserID          int64  `gorm:"primaryKey"`
+	SecretEncrypted string // رمز base32 که با کلید سرور رمزنگاری شده است
+	ConfirmedAt     *time.Time
+	LastUsedStep    int64 // آخرین بازهٔ زمانی پذیرفته‌شده؛ کد تکراری دوباره پذیرفته نمی‌شود
+	FailedAttempts  int
+	LockedUntil     *time.Time
+	DeliverAtLogin  bool // رمزی که ادمین ساخته و هنوز یک بار بعد از کد پیامکی به خود کاربر نشان داده نشده
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// TOTPRecoveryCode کد بازیابی یک‌بارمصرف برای وقتی که برنامهٔ احراز هویت در دسترس نیست
+type TOTPRecoveryCode struct {
+	ID        int64
+	UserID    int64
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (TOTPRecoveryCode) TableName() string {
+	return "user_totp_recovery_code"
+}
+
+// TOTPEnrollment اطلاعاتی که کاربر برای افزودن حساب به برنامهٔ احراز هویت لازم دارد
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURL string `json:"otpauthUrl"`
+	QRCode     []byte `json:"qrCode"` // تصویر PNG؛ در JSON به صورت base64
+}
+
+type TOTPStatus struct {
+	Enabled           bool       `json:"enabled"`
+	ConfirmedAt       *time.Time `json:"confirmedAt"`
+	RecoveryCodesLeft int64      `json:"recoveryCodesLeft"`
+	Required          bool       `json:"required"` // سیاست فعلی غیرفعال کردن را برای این کاربر مجاز نمی‌داند
+}
+
+// TOTPLoginChallenge یعنی ورود بعد از کد پیامکی هنوز کامل نیست و کد TOTP لازم است
+type TOTPLoginChallenge struct {
+	// رمز از نشست قبلی کاربر یا توسط ادمین ساخته شده و اولین کد درست آن را فعال می‌کند
+	PendingEnrollment bool
+	// رمزی که ادمین ساخته فقط همین یک بار و فقط بعد از کد پیامکی خود کاربر نشان داده می‌شود
+	Enrollment *TOTPEnrollment
+}
+
+// MFATokenPayload توکن کوتاه‌مدتی که بین کد پیامکی و کد TOTP به کلاینت داده می‌شود
+type MFATokenPayload struct {
+	JTI      uuid.UUID `json:"jti"`
+	UserID   int64     `json:"user_id"`
+	DeviceID string    `json:"device_id"`
+	Type     string    `json:"type"`
+}
@@ -143,6 +143,94 @@ var translations = map[string]map[string]string{
 	msg.ErrSendingVerificationCodeFailed: {
 		LANG_FA: "ارسال کد تایید با خطا مواجە شد",
 	},
+	msg.ErrVerificationCodeIsExpired: {
+		LANG_FA: "کد تایید منقضی شدە است؛ دوبارە درخواست کد دهید",
+	},
+	msg.ErrVerificationCodeIsLocked: {
+		LANG_FA: "به دلیل تلاش‌های ناموفق زیاد، ورود با این شمارە موقتا مسدود شدە است",
+	},
+	msg.ErrVerificationCodeResendTooSoon: {
+		LANG_FA: "کد تایید به تازگی ارسال شدە است؛ برای دریافت کد جدید کمی صبر کنید",
+	},
+	msg.ErrVerificationCodeTooManyRequests: {
+		LANG_FA: "تعداد درخواست‌های کد تایید بیش از حد مجاز است؛ بعدا دوبارە تلاش کنید",
+	},
+
+	// active device
+	msg.ErrDeviceLimitReached: {
+		LANG_FA: "شما به حداکثر تعداد دستگاه‌های مجاز برای ورود رسیدە‌اید؛ از یکی از دستگاه‌های واردشدە، دستگاه دیگری را خارج کنید",
+	},
+	msg.ErrDeviceDoesNotExist: {
+		LANG_FA: "دستگاه مورد نظر پیدا نشد",
+	},
+	msg.ErrDeviceNameIsNotValid: {
+		LANG_FA: "نام دستگاه معتبر نیست",
+	},
+	msg.ErrDeviceIDIsNotSet: {
+		LANG_FA: "شناسه دستگاه فعلی مشخص نشدە است",
+	},
+
+	// rate limit
+	msg.ErrTooManyRequests: {
+		LANG_FA: "تعداد درخواست‌ها بیش از حد مجاز است؛ کمی بعد دوبارە تلاش کنید",
+	},
+
+	// admin permission
+	msg.ErrPermissionDenied: {
+		LANG_FA: "شما دسترسی لازم برای این بخش را ندارید",
+	},
+	msg.ErrPermissionIsNotValid: {
+		LANG_FA: "دسترسی انتخاب‌شدە معتبر نیست",
+	},
+	msg.ErrAdminRoleNameCannotBeEmpty: {
+		LANG_FA: "نام نقش نباید خالی باشد",
+	},
+	msg.ErrAdminRoleDoesNotExist: {
+		LANG_FA: "نقش مورد نظر پیدا نشد",
+	},
+
+	// impersonation
+	msg.ErrImpersonationReasonIsNotValid: {
+		LANG_FA: "دلیل ورود بە جای کاربر را بنویسید (حداکثر ۵۰۰ کاراکتر)",
+	},
+	msg.ErrCannotImpersonateAdmin: {
+		LANG_FA: "ورود بە جای مدیران امکان‌پذیر نیست",
+	},
+	msg.ErrImpersonationIsReadOnly: {
+		LANG_FA: "این نشست فقط برای مشاهدە است و امکان تغییر ندارد",
+	},
+	msg.ErrNotImpersonating: {
+		LANG_FA: "شما بە جای کاربری وارد نشدە‌اید",
+	},
+
+	// totp
+	msg.ErrTOTPCodeIsWrong: {
+		LANG_FA: "کد برنامە احراز هویت یا کد بازیابی اشتباە است",
+	},
+	msg.ErrTOTPIsLocked: {
+		LANG_FA: "بە دلیل تلاش‌های ناموفق زیاد، کمی بعد دوبارە تلاش کنید",
+	},
+	msg.ErrTOTPAlreadyEnabled: {
+		LANG_FA: "ورود دومرحلە‌ای قبلا فعال شدە است",
+	},
+	msg.ErrTOTPIsNotEnabled: {
+		LANG_FA: "ورود دومرحلە‌ای فعال نیست",
+	},
+	msg.ErrTOTPIsRequired: {
+		LANG_FA: "ورود دومرحلە‌ای برای این حساب الزامی است و نمی‌توان آن را غیرفعال کرد",
+	},
+	msg.ErrTOTPEnforcementIsNotValid: {
+		LANG_FA: "سیاست ورود دومرحلە‌ای معتبر نیست",
+	},
+	msg.ErrTOTPEnableBeforeEnforcing: {
+		LANG_FA: "ابتدا ورود دومرحلە‌ای را برای حساب خودتان فعال کنید",
+	},
+	msg.ErrTOTPEnrollmentRequired: {
+		LANG_FA: "ورود دومرحلە‌ای برای این حساب الزامی است؛ برای راه‌اندازی آن با مدیر سامانە تماس بگیرید",
+	},
+	msg.ErrTOTPTargetRoleIsHigher: {
+		LANG_FA: "فقط ورود دومرحلە‌ای کاربران با نقش پایین‌تر از خودتان را می‌توانید تغییر دهید",
+	},
 
 	// product model
 	msg.ErrModelTitleCannotBeEmpty: {
@@ -263,6 +351,72 @@ var translations = map[string]map[string]string{
 	msg.ErrNoSubscriptionsBought: {
 		LANG_FA: "باید حداقل اشتراک یک شهر را خریداری کنید",
 	},
+	msg.ErrPriceAdjustmentModeIsNotValid: {
+		LANG_FA: "نوع تغییر قیمت معتبر نیست",
+	},
+	msg.ErrPriceAdjustmentValueIsNotValid: {
+		LANG_FA: "مقدار تغییر قیمت معتبر نیست",
+	},
+	msg.ErrPriceAdjustmentPercentIsNotValid: {
+		LANG_FA: "درصد تغییر قیمت باید بیشتر از منفی ۱۰۰ باشد",
+	},
+	msg.ErrNoProductsMatchPriceAdjustment: {
+		LANG_FA: "هیچ محصولی با محدودە انتخاب شدە پیدا نشد",
+	},
+	msg.ErrNoPriceAdjustmentToUndo: {
+		LANG_FA: "تغییر قیمتی برای بازگردانی وجود ندارد",
+	},
+	msg.ErrPriceAdjustmentMakesPriceNegative: {
+		LANG_FA: "این تغییر قیمت، قیمت بعضی محصولات را منفی می‌کند",
+	},
+	msg.ErrPriceDeviatesFromMarket: {
+		LANG_FA: "قیمت واردشدە با قیمت بازار فاصلە زیادی دارد؛ در صورت اطمینان آن را تایید کنید",
+	},
+	msg.ErrCompetitivePositionSortIsNotValid: {
+		LANG_FA: "ترتیب مرتب‌سازی گزارش جایگاه رقابتی معتبر نیست",
+	},
+	msg.ErrUserProductSKUIsNotValid: {
+		LANG_FA: "کد کالا نباید بیشتر از ۶۴ نویسه باشد",
+	},
+	msg.ErrUserProductSKUIsTaken: {
+		LANG_FA: "این کد کالا برای محصول دیگری از فروشگاه ثبت شدە است",
+	},
+	msg.ErrUserProductSKUDoesNotExist: {
+		LANG_FA: "محصولی با این کد کالا پیدا نشد",
+	},
+	msg.ErrUserProductImportFileIsNotValid: {
+		LANG_FA: "فایل باید از نوع CSV یا XLSX باشد",
+	},
+	msg.ErrUserProductImportColumnsAreMissing: {
+		LANG_FA: "فایل باید یکی از ستون‌های «شناسه»، «کد کالا»، «شناسه محصول» یا ستون‌های «برند» و «مدل» را داشته باشد",
+	},
+	msg.ErrUserProductImportIsEmpty: {
+		LANG_FA: "فایل هیچ ردیفی ندارد",
+	},
+	msg.ErrUserProductImportHasTooManyRows: {
+		LANG_FA: "تعداد ردیف‌های فایل بیش از حد مجاز است",
+	},
+	msg.ErrUserProductImportValueIsNotValid: {
+		LANG_FA: "مقدار یکی از خانه‌های این ردیف معتبر نیست",
+	},
+	msg.ErrUserProductImportProductIsNotSet: {
+		LANG_FA: "کد کالا، شناسه محصول یا برند و مدل مشخص نشدە است",
+	},
+	msg.ErrUserProductImportProductNotFound: {
+		LANG_FA: "محصولی با این مشخصات در کاتالوگ پیدا نشد",
+	},
+	msg.ErrUserProductImportProductIsAmbiguous: {
+		LANG_FA: "این برند و مدل با بیش از یک محصول مطابقت دارد؛ از شناسه محصول استفاده کنید",
+	},
+	msg.ErrUserProductImportProductIsRepeated: {
+		LANG_FA: "این محصول در فایل تکرار شدە است",
+	},
+	msg.ErrUserProductImportProductIsNotInShop: {
+		LANG_FA: "این محصول در فروشگاه شما ثبت نشدە است",
+	},
+	msg.ErrUserProductImportRowChangedOnServer: {
+		LANG_FA: "این ردیف بعد از دریافت فایل تغییر کردە است؛ فایل تازه بگیرید و دوبارە ویرایش کنید",
+	},
 
 	// subscription
 	msg.ErrPriceIsNotValid: {
@@ -305,6 +459,66 @@ var translations = map[string]map[string]string{
 	},
 
 	// favorite account
+	msg.ErrRoundingStepIsNotValid: {
+		LANG_FA: "گام گرد کردن باید بزرگتر از صفر باشد",
+	},
+	msg.ErrRoundingDirectionIsNotValid: {
+		LANG_FA: "جهت گرد کردن معتبر نیست",
+	},
+	msg.ErrRoundingEndingIsNotValid: {
+		LANG_FA: "رقم پایانی قیمت باید کوچکتر از گام گرد کردن باشد",
+	},
+	msg.ErrPriceListRendererBusy: {
+		LANG_FA: "در حال حاضر درخواست‌های ساخت لیست قیمت زیاد است؛ چند لحظه دیگر دوبارە تلاش کنید",
+	},
+	msg.ErrPriceListFormatIsNotValid: {
+		LANG_FA: "فرمت خروجی لیست قیمت معتبر نیست",
+	},
+	msg.ErrPriceListTooLargeForImage: {
+		LANG_FA: "تعداد محصولات برای خروجی تصویری زیاد است؛ از خروجی PDF استفاده کنید",
+	},
+	msg.ErrPriceListTemplateTitleIsNotValid: {
+		LANG_FA: "عنوان قالب لیست قیمت الزامی است",
+	},
+	msg.ErrPriceListTemplateDoesNotExist: {
+		LANG_FA: "قالب لیست قیمت پیدا نشد",
+	},
+	msg.ErrPriceListGroupByIsNotValid: {
+		LANG_FA: "نحوهٔ گروه‌بندی لیست قیمت معتبر نیست",
+	},
+	msg.ErrPriceListSortIsNotValid: {
+		LANG_FA: "ترتیب مرتب‌سازی لیست قیمت معتبر نیست",
+	},
+	msg.ErrPaperSizeIsNotValid: {
+		LANG_FA: "اندازهٔ کاغذ معتبر نیست",
+	},
+	msg.ErrPriceListMarkupIsNotValid: {
+		LANG_FA: "درصد سود باید بین ۰ تا ۱۰۰۰ باشد",
+	},
+	msg.ErrPriceListShareLinkIsNotValid: {
+		LANG_FA: "لینک لیست قیمت معتبر نیست یا منقضی شدە است",
+	},
+	msg.ErrPriceListShareLinkDoesNotExist: {
+		LANG_FA: "لینک لیست قیمت پیدا نشد",
+	},
+	msg.ErrPriceListShareLinkTTLIsNotValid: {
+		LANG_FA: "مدت اعتبار لینک معتبر نیست",
+	},
+	msg.ErrPriceListSharePinIsNotValid: {
+		LANG_FA: "رمز لینک باید بین ۴ تا ۸ رقم باشد",
+	},
+	msg.ErrPriceListSharePinRequired: {
+		LANG_FA: "برای مشاهدهٔ این لیست قیمت رمز لازم است",
+	},
+	msg.ErrPriceListSharePinIsWrong: {
+		LANG_FA: "رمز واردشده اشتباه است",
+	},
+	msg.ErrPriceListSharePinLocked: {
+		LANG_FA: "به دلیل تلاش‌های ناموفق زیاد، چند دقیقهٔ دیگر دوبارە تلاش کنید",
+	},
+	msg.ErrStalenessMaxAgeIsNotValid: {
+		LANG_FA: "حداکثر عمر قیمت باید بزرگتر از صفر روز باشد",
+	},
 	msg.ErrLikingOwnShopIsForbidden: {
 		LANG_FA: "امکان پسند کردن فروشگاه خودتان وجود ندارد",
 	},
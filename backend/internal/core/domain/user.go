@@ -47,6 +47,9 @@ type User struct {
 	DollarUpdate  bool                `json:"dollarUpdate"`
 	Rounded       bool                `json:"rounded"`
 
+	// با تغییر نقش، وضعیت، دسترسی یا دستگاه‌ها در دیتابیس بالا می‌رود؛ فقط‌خواندنی
+	SessionVersion int64 `gorm:"->" json:"-"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -54,23 +57,28 @@ type ActiveDevice struct {
 	ID          int64     `gorm:"primaryKey" json:"id"`
 	UserID      int64     `json:"userId"`
 	DeviceID    string    `json:"deviceId"`
+	Name        *string   `json:"name"` // نامی که کاربر برای دستگاه گذاشته است
 	UserAgent   string    `json:"userAgent"`
 	IPAddress   string    `json:"ipAddress"`
 	LastLoginAt time.Time `json:"lastLoginAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"` // آخرین ورود یا تمدید توکن
 	CreatedAt   time.Time `json:"createdAt"`
+
+	IsCurrent bool `gorm:"-" json:"isCurrent"`
 }
 
+const ActiveDeviceNameMaxLength = 50
+
 func (ActiveDevice) TableName() string {
 	return "active_devices"
 }
 
 type AdminAccess struct {
-	ID                 int64 `json:"id"`
-	UserID             int64 `json:"userId"`
-	SaveProduct        bool  `json:"saveProduct"`
-	ChangeUserState    bool  `json:"changeUserState"`
-	ChangeShopState    bool  `json:"changeShopState"`
-	ChangeAccountState bool  `json:"changeAccountState"`
+	ID              int64        `json:"id"`
+	UserID          int64        `json:"userId"`
+	RoleID          *int64       `json:"roleId"`
+	Permissions     []Permission `gorm:"-" json:"permissions"`     // دسترسی‌های مستقیم
+	RolePermissions []Permission `gorm:"-" json:"rolePermissions"` // دسترسی‌های نقش
 }
 
 func (AdminAccess) TableName() string {
@@ -129,6 +137,36 @@ func (DollarLog) TableName() string {
 	return "dollar_log"
 }
 
+// DollarRecomputeRun خلاصهٔ یک اجرای محاسبهٔ مجدد قیمت محصولات دلاری
+type DollarRecomputeRun struct {
+	ID            int64           `json:"id"`
+	DollarPrice   decimal.Decimal `json:"dollarPrice"`
+	UsersCount    int             `json:"usersCount"`
+	BatchesCount  int             `json:"batchesCount"`
+	FailedBatches int             `json:"failedBatches"`
+	FailedUsers   int             `json:"failedUsers"`
+	RowsChanged   int64           `json:"rowsChanged"`
+	DurationMs    int64           `json:"durationMs"`
+	StartedAt     time.Time       `json:"startedAt"`
+	FinishedAt    *time.Time      `json:"finishedAt"`
+}
+
+func (DollarRecomputeRun) TableName() string {
+	return "dollar_recompute_run"
+}
+
+type DollarRecomputeFailure struct {
+	ID        int64     `json:"id"`
+	RunID     int64     `json:"runId"`
+	UserID    *int64    `json:"userId"`
+	Error     string    `gorm:"column:error_c" json:"error"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (DollarRecomputeFailure) TableName() string {
+	return "dollar_recompute_failure"
+}
+
 // UserFilter defines the available filters for the admin user list.
 type UserFilterSubScribe struct {
 	IsWholesaler    *bool // Pointer to handle three states: true, false, and not specified
@@ -139,6 +177,12 @@ func IsUserRoleValid(userRole UserRole) bool {
 	return userRole > roleStart && userRole < roleEnd
 }
 
+// CanManage یعنی صاحب این نقش می‌تواند تنظیمات امنیتی کاربری با نقش target را تغییر دهد؛
+// فقط نقش‌های پایین‌تر، و سوپرادمین روی سوپرادمین دیگر هم
+func (r UserRole) CanManage(target UserRole) bool {
+	return r == SuperAdmin || r < target
+}
+
 func IsUserStateValid(userState UserState) bool {
 	return userState > stateStart && userState < stateEnd
 }
@@ -147,12 +191,41 @@ func (User) TableName() string {
 	return "user_t"
 }
 
+// VerificationCode کد ورود فعلی کاربر؛ فقط hash کد نگه داشته می‌شود و
+// CodeHash خالی یعنی کد استفاده یا باطل شده است
 type VerificationCode struct {
-	ID     int64
-	UserID int64
-	Code   string
+	ID          int64
+	UserID      int64
+	CodeHash    *string
+	Attempts    int
+	ExpiresAt   time.Time
+	LockedUntil *time.Time
+	CreatedAt   time.Time
 }
 
 func (VerificationCode) TableName() string {
 	return "verification_code"
 }
+
+// VerificationCodeSend هر پیامک کد تایید ارسال‌شده؛ برای محدود کردن ارسال به ازای شماره و IP
+type VerificationCodeSend struct {
+	ID        int64
+	Phone     string
+	IPAddress string
+	CreatedAt time.Time
+}
+
+func (VerificationCodeSend) TableName() string {
+	return "verification_code_send"
+}
+
+// VerificationCodeThrottleError وقتی برگردانده می‌شود که ارسال یا بررسی کد موقتا مسدود است؛
+// RetryAfter زمان باقی‌مانده تا تلاش دوباره است.
+type VerificationCodeThrottleError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *VerificationCodeThrottleError) Error() string {
+	return e.Message
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -49,6 +50,8 @@ type ShopViewModel struct {
 	ShopInfo *User              `json:"shopInfo"`
 	Products []*UserProductView `json:"products"`
 	Total    int64              `json:"total"`
+	Template *PriceListTemplate `json:"template,omitempty"` // فقط در لیست قیمت پر می‌شود
+	ShareURL string             `json:"shareUrl,omitempty"` // لینک عمومی لیست قیمت که QR فایل‌ها به آن اشاره می‌کند
 }
 type SearchProductsData struct {
 	ProductItems []*SearchProductViewModel `json:"productItems"`
@@ -106,6 +109,7 @@ type ProductShop struct {
 	IsLiked         bool            `json:"isLiked"`
 	LikesCount      int64           `json:"likesCount"`
 	UpdatedAt       time.Time       `json:"updatedAt"`
+	IsStale         bool            `json:"isStale"` // قیمت از حداکثر عمر مجاز دسته قدیمی‌تر است
 }
 
 type ProductInfoViewModel struct {
@@ -132,6 +136,9 @@ type UserProduct struct {
 	CategoryID int64  `json:"categoryId"  gorm:"->"`
 	ModelName  string `json:"modelName"   gorm:"->;column:model_name"`
 
+	// کد کالا یا بارکد خود فروشگاه (مثلا از نرم‌افزار حسابداری)؛ اختیاری و در هر فروشگاه یکتا
+	SKU *string `json:"sku" gorm:"column:sku"`
+
 	IsDollar    bool                `json:"isDollar"`
 	DollarPrice decimal.NullDecimal `json:"dollarPrice"`
 	OtherCosts  decimal.NullDecimal `json:"otherCosts"`
@@ -141,8 +148,37 @@ type UserProduct struct {
 	IsHidden  bool         `json:"isHidden"`
 	CreatedAt time.Time    `json:"createdAt"`
 	UpdatedAt sql.NullTime `json:"updatedAt"`
+	// نسخهٔ ردیف که تریگر پایگاه داده با هر تغییر قیمت یا وضعیت نمایش یکی بالا می‌برد
+	Version int64 `json:"version" gorm:"->"`
+
+	// تایید صریح کاربر برای ثبت قیمتی که خارج از بازهٔ مجاز بازار است
+	ConfirmPriceDeviation bool `json:"-" gorm:"-"`
 }
 
 func (UserProduct) TableName() string {
 	return "user_product"
 }
+
+// UserProductSKUMaxLength حداکثر طول کد کالای فروشگاه
+const UserProductSKUMaxLength = 64
+
+var skuDigits = strings.NewReplacer(
+	"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4",
+	"۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4",
+	"٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
+
+// NormalizeSKU فاصله‌های اطراف و ارقام فارسی و عربی کد کالا را یکدست می‌کند تا کدی که از
+// فایل یا نرم‌افزار دیگری می‌آید با کد ذخیره‌شده یکی شود
+func NormalizeSKU(sku string) string {
+	return skuDigits.Replace(strings.TrimSpace(sku))
+}
+
+// SKUValue کد کالا یا رشتهٔ خالی اگر فروشگاه کدی ثبت نکرده باشد
+func (up *UserProduct) SKUValue() string {
+	if up.SKU == nil {
+		return ""
+	}
+	return *up.SKU
+}
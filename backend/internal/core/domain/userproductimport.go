@@ -0,0 +1,82 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+type UserProductImportStatus string
+
+const (
+	UserProductImportCreated   UserProductImportStatus = "created"
+	UserProductImportUpdated   UserProductImportStatus = "updated"
+	UserProductImportUnchanged UserProductImportStatus = "unchanged"
+	UserProductImportConflict  UserProductImportStatus = "conflict"
+	UserProductImportFailed    UserProductImportStatus = "failed"
+)
+
+// UserProductImportRow یک ردیف فایل قیمت فروشگاه است. محصول با شناسهٔ ردیف خروجی فروشگاه،
+// کد کالای فروشگاه، شناسهٔ کاتالوگ یا برند و مدل پیدا می‌شود؛ ستون‌هایی که در فایل نیستند nil/نامعتبر می‌مانند
+// و مقدار فعلی حفظ می‌شود.
+type UserProductImportRow struct {
+	Row           int
+	UserProductID int64
+	ProductID     int64
+	Brand         string
+	Model         string
+	SKU           string
+	// نسخهٔ ردیف در زمان خروجی گرفتن؛ صفر یعنی فایل از خروجی فروشگاه ساخته نشده است
+	Version int64
+	Order   *int64
+
+	IsDollar    *bool
+	DollarPrice decimal.NullDecimal
+	OtherCosts  decimal.NullDecimal
+	FinalPrice  decimal.NullDecimal
+	IsHidden    *bool
+
+	// خطای خواندن ردیف در هندلر؛ ردیف بدون پردازش ناموفق گزارش می‌شود
+	ParseError string
+}
+
+type UserProductImportOptions struct {
+	DryRun                bool `json:"dryRun"`
+	CreateMissing         bool `json:"createMissing"`         // محصولاتی که فروشگاه ندارد اضافه شوند
+	CreateHidden          bool `json:"createHidden"`          // محصولات جدید مخفی ساخته شوند
+	ConfirmPriceDeviation bool `json:"confirmPriceDeviation"` // قیمت‌های خارج از بازهٔ بازار هم ثبت شوند
+}
+
+type UserProductImportRowResult struct {
+	Row           int                     `json:"row"`
+	ProductID     int64                   `json:"productId,omitempty"`
+	UserProductID int64                   `json:"userProductId,omitempty"`
+	Status        UserProductImportStatus `json:"status"`
+	FinalPrice    *decimal.Decimal        `json:"finalPrice,omitempty"`
+	Error         string                  `json:"error,omitempty"`
+}
+
+type UserProductImportResult struct {
+	DryRun    bool                          `json:"dryRun"`
+	Total     int                           `json:"total"`
+	Created   int                           `json:"created"`
+	Updated   int                           `json:"updated"`
+	Unchanged int                           `json:"unchanged"`
+	Conflicts int                           `json:"conflicts"`
+	Failed    int                           `json:"failed"`
+	Rows      []*UserProductImportRowResult `json:"rows"`
+}
+
+// Add نتیجهٔ یک ردیف را ثبت و شمارنده‌ها را به‌روز می‌کند
+func (r *UserProductImportResult) Add(row *UserProductImportRowResult) {
+	r.Total++
+	switch row.Status {
+	case UserProductImportCreated:
+		r.Created++
+	case UserProductImportUpdated:
+		r.Updated++
+	case UserProductImportUnchanged:
+		r.Unchanged++
+	case UserProductImportConflict:
+		r.Conflicts++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
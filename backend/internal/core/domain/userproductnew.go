@@ -69,6 +69,7 @@ type UserProductMarketView struct {
 	// زمان مرتب‌سازی
 	UpdatedAt  string `json:"updatedAt"`
 	IsFavorite bool   `json:"	" gorm:"column:is_favorite"`
+	IsStale    bool   `json:"isStale" gorm:"column:is_stale"` // قیمت قدیمی؛ در مرتب‌سازی عقب می‌افتد
 }
 type MarketSearchResult struct {
 	Items []*UserProductMarketView `json:"items"`
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type AdminRoleRepository interface {
+	GetAdminRoles(ctx context.Context, dbSession interface{}) (roles []*domain.AdminRole, err error)
+	GetAdminRole(ctx context.Context, dbSession interface{}, id int64) (role *domain.AdminRole, err error)
+	SaveAdminRole(ctx context.Context, dbSession interface{}, role *domain.AdminRole) (id int64, err error)
+	DeleteAdminRole(ctx context.Context, dbSession interface{}, id int64) (err error)
+}
+
+type AdminRoleService interface {
+	GetAdminRoles(ctx context.Context) (roles []*domain.AdminRole, err error)
+	SaveAdminRole(ctx context.Context, role *domain.AdminRole) (id int64, err error)
+	DeleteAdminRole(ctx context.Context, id int64) (err error)
+}
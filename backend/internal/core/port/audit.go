@@ -0,0 +1,26 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type AuditRepository interface {
+	CreateAuditLog(ctx context.Context, dbSession interface{}, log *domain.AuditLog) (err error)
+	GetAuditLogs(ctx context.Context, dbSession interface{}, filter domain.AuditLogFilter,
+		limit, offset int) (logs []*domain.AuditLog, totalCount int64, err error)
+	GetAuditChanges(ctx context.Context, dbSession interface{}, requestIDs []uuid.UUID) (
+		changes []*domain.AuditChange, err error)
+	DeleteAuditDataBefore(ctx context.Context, dbSession interface{}, before time.Time) (
+		deleted int64, err error)
+}
+
+type AuditService interface {
+	RecordAuditLog(ctx context.Context, log *domain.AuditLog) (err error)
+	GetAuditLogs(ctx context.Context, filter domain.AuditLogFilter, page, limit int) (
+		logs []*domain.AuditLog, totalCount int64, err error)
+	PurgeAuditLogs(ctx context.Context) (deleted int64, err error)
+}
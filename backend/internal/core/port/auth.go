@@ -4,35 +4,89 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/nerkhin/internal/core/domain"
 )
 
 
 type TokenService interface {
-	CreateAccessToken(user *domain.User, adminAccess *domain.AdminAccess) (tokenString string, payload *domain.TokenPayload, expiresAt time.Time, err error)
-	CreateRefreshToken(user *domain.User) (tokenString string, payload *domain.RefreshTokenPayload, expiresAt time.Time, err error)
+	CreateAccessToken(user *domain.User, adminAccess *domain.AdminAccess, familyID uuid.UUID) (tokenString string, payload *domain.TokenPayload, expiresAt time.Time, err error)
+	CreateRefreshToken(user *domain.User, familyID uuid.UUID) (tokenString string, payload *domain.RefreshTokenPayload, expiresAt time.Time, err error)
 	VerifyAccessToken(tokenString string) (payload *domain.TokenPayload, err error)
 	VerifyRefreshToken(tokenString string) (payload *domain.RefreshTokenPayload, err error)
+	ValidateSession(ctx context.Context, payload *domain.TokenPayload) (err error)
 	GetAccessTokenDuration() time.Duration
 	GetRefreshTokenDuration() time.Duration
-	CreateImpersonationToken(targetUser *domain.User, adminID int64) (string, *domain.TokenPayload, time.Time, error)
+	CreateImpersonationToken(targetUser *domain.User, session *domain.ImpersonationSession) (string, *domain.TokenPayload, time.Time, error)
+	// CreateMFAToken توکن کوتاه‌مدت بین کد پیامکی و کد TOTP؛ فقط VerifyMFAToken آن را می‌پذیرد
+	CreateMFAToken(userID int64, deviceID string, duration time.Duration) (tokenString string, expiresAt time.Time, err error)
+	VerifyMFAToken(tokenString string) (payload *domain.MFATokenPayload, err error)
+}
+
+// SessionService نسخهٔ نشست access token را با نسخهٔ فعلی کاربر و خانوادهٔ refresh token آن را با دیتابیس مقایسه می‌کند
+type SessionService interface {
+	ValidateSession(ctx context.Context, payload *domain.TokenPayload) (err error)
 }
 
 type VerificationCodeRepository interface {
 	SaveVerificationCode(ctx context.Context, dbSession interface{},
-		userId int64, code string) (err error)
+		vc *domain.VerificationCode) (err error)
 	GetVerificationCode(ctx context.Context, dbSession interface{},
-		userId int64) (code string, err error)
+		userId int64) (vc *domain.VerificationCode, err error)
+	RecordVerificationCodeFailure(ctx context.Context, dbSession interface{},
+		id int64, attempts int, lockedUntil *time.Time) (err error)
+	InvalidateVerificationCode(ctx context.Context, dbSession interface{}, id int64) (err error)
+	// DeleteVerificationCode کدی را که پیامکش ارسال نشد پاک می‌کند؛ کد تازه‌تر دست نمی‌خورد
+	DeleteVerificationCode(ctx context.Context, dbSession interface{}, userID int64,
+		codeHash string) (err error)
+	CreateVerificationCodeSend(ctx context.Context, dbSession interface{},
+		send *domain.VerificationCodeSend) (err error)
+	CountVerificationCodeSendsByPhone(ctx context.Context, dbSession interface{},
+		phone string, since time.Time) (count int64, oldest *time.Time, err error)
+	CountVerificationCodeSendsByIP(ctx context.Context, dbSession interface{},
+		ipAddress string, since time.Time) (count int64, oldest *time.Time, err error)
+	DeleteVerificationCodeSendsBefore(ctx context.Context, dbSession interface{},
+		before time.Time) (err error)
 }
 
 type VerificationCodeService interface {
-	SendVerificationCode(ctx context.Context, phone string) (code string, err error)
+	SendVerificationCode(ctx context.Context, phone, ipAddress string) (err error)
 
 	VerifyCode(ctx context.Context, phone, code string, deviceID string, userAgent string, ipAddress string) (user *domain.User, adminAccess *domain.AdminAccess, err error)
+	ConfirmCode(ctx context.Context, userID int64, code string) (err error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshTokenFamily(ctx context.Context, dbSession interface{},
+		family *domain.RefreshTokenFamily) (err error)
+	GetRefreshTokenFamily(ctx context.Context, dbSession interface{},
+		familyID uuid.UUID) (family *domain.RefreshTokenFamily, err error)
+	RevokeRefreshTokenFamily(ctx context.Context, dbSession interface{},
+		familyID uuid.UUID, reason domain.RefreshTokenRevokeReason) (err error)
+	RevokeDeviceRefreshTokenFamilies(ctx context.Context, dbSession interface{},
+		activeDeviceID int64, reason domain.RefreshTokenRevokeReason) (err error)
+	CreateRefreshToken(ctx context.Context, dbSession interface{},
+		token *domain.RefreshToken) (err error)
+	LockRefreshToken(ctx context.Context, dbSession interface{},
+		jti uuid.UUID) (token *domain.RefreshToken, err error)
+	MarkRefreshTokenUsed(ctx context.Context, dbSession interface{},
+		jti uuid.UUID, usedAt time.Time) (err error)
+	DeleteExpiredRefreshTokens(ctx context.Context, dbSession interface{},
+		familyID uuid.UUID, now time.Time) (err error)
 }
 
 // AuthService - بدون تغییر باقی می‌ماند اگر Login فقط OTP ارسال می‌کند
 type AuthService interface {
-	Login(ctx context.Context, phone string) (userId int64, err error)
+	Login(ctx context.Context, phone, ipAddress string) (userId int64, err error)
 	GetUserByID(ctx context.Context, userID int64) (*domain.User, error) // <--- این متد جدید را اضافه کنید// یا هر چیزی که Login شما برمی‌گرداند
+
+	// CreateSession بعد از همهٔ مرحله‌های ورود دستگاه را ثبت و برایش خانوادهٔ تازهٔ refresh token می‌سازد
+	CreateSession(ctx context.Context, user *domain.User, device *domain.ActiveDevice) (refreshToken string,
+		familyID uuid.UUID, err error)
+	// RefreshSession توکن را مصرف و توکن بعدی همان خانواده را برمی‌گرداند؛
+	// ارائهٔ دوبارهٔ توکن مصرف‌شده کل خانواده را باطل می‌کند
+	RefreshSession(ctx context.Context, refreshToken string) (user *domain.User,
+		adminAccess *domain.AdminAccess, newRefreshToken string, familyID uuid.UUID, err error)
+	// Logout دستگاه صاحب توکن را حذف و همهٔ توکن‌هایش را باطل می‌کند
+	Logout(ctx context.Context, refreshToken string) (err error)
 }
@@ -9,6 +9,8 @@ import (
 type DBMS interface {
 	InitDB(ctx context.Context, dbConfig config.DBConfig) (err error)
 	NewDB(ctx context.Context) (db interface{}, err error)
+	// BeginTransaction تنها جایی است که شناسهٔ سابقهٔ درخواست ادمین به تریگرهای audit داده می‌شود؛
+	// نوشتن مستقیم روی نشست NewDB در audit_change ثبت نمی‌شود
 	BeginTransaction(ctx context.Context, db interface{}, fn func(txSession interface{}) error) error
 	MigrateUp(ctx context.Context, dbConfig config.DBConfig) (err error)
 }
@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type ImpersonationRepository interface {
+	CreateImpersonationSession(ctx context.Context, dbSession interface{},
+		session *domain.ImpersonationSession) (err error)
+	GetImpersonationSession(ctx context.Context, dbSession interface{}, id uuid.UUID) (
+		session *domain.ImpersonationSession, err error)
+	GetExpiredImpersonationSessions(ctx context.Context, dbSession interface{}, now time.Time) (
+		sessions []*domain.ImpersonationSession, err error)
+	EndImpersonationSession(ctx context.Context, dbSession interface{}, id uuid.UUID,
+		endedAt time.Time, reason domain.ImpersonationEndReason) (ended bool, err error)
+}
+
+type ImpersonationService interface {
+	StartImpersonation(ctx context.Context, adminID int64, req *domain.ImpersonationRequest) (
+		token string, session *domain.ImpersonationSession, targetUser *domain.User, err error)
+	EndImpersonation(ctx context.Context, payload *domain.TokenPayload) (err error)
+	CloseExpiredImpersonations(ctx context.Context) (count int, err error)
+}
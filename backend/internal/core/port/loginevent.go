@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type LoginEventRepository interface {
+	CreateLoginEvent(ctx context.Context, dbSession interface{}, event *domain.LoginEvent) (err error)
+	GetLoginEvents(ctx context.Context, dbSession interface{}, userID int64, limit int) (
+		events []*domain.LoginEvent, err error)
+	GetLoginHistoryStats(ctx context.Context, dbSession interface{}, userID int64,
+		deviceID, ipAddress string, since time.Time) (stats *domain.LoginHistoryStats, err error)
+}
+
+type LoginEventService interface {
+	RecordLoginEvent(ctx context.Context, event *domain.LoginEvent) (err error)
+	GetLoginEvents(ctx context.Context, userID int64) (events []*domain.LoginEvent, err error)
+}
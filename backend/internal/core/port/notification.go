@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, dbSession interface{},
+		notification *domain.Notification) (id int64, err error)
+	GetNotifications(ctx context.Context, dbSession interface{}, userID int64, limit int) (
+		notifications []*domain.Notification, err error)
+	MarkNotificationRead(ctx context.Context, dbSession interface{}, userID, id int64) (err error)
+}
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, userID int64) (
+		notifications []*domain.Notification, err error)
+	MarkNotificationRead(ctx context.Context, userID, id int64) (err error)
+}
@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+// PriceListRenderer خروجی PDF لیست قیمت یک فروشگاه را می‌سازد
+type PriceListRenderer interface {
+	RenderPriceListPDF(ctx context.Context, priceList *domain.ShopViewModel) (pdf []byte, err error)
+}
+
+// PriceListExporter لیست قیمت را به اکسل، CSV یا تصویر قابل اشتراک تبدیل می‌کند
+type PriceListExporter interface {
+	ExportPriceList(ctx context.Context, priceList *domain.ShopViewModel,
+		format domain.PriceListFormat) (file []byte, err error)
+}
@@ -0,0 +1,41 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type PriceListShareRepository interface {
+	GetPriceListShareLinks(ctx context.Context, dbSession interface{}, userID int64) (
+		links []*domain.PriceListShareLink, err error)
+	GetPriceListShareLinkByID(ctx context.Context, dbSession interface{}, userID, id int64) (
+		link *domain.PriceListShareLink, err error)
+	LockPriceListShareLink(ctx context.Context, dbSession interface{}, id int64) (
+		link *domain.PriceListShareLink, err error)
+	GetAutoPriceListShareLink(ctx context.Context, dbSession interface{}, userID int64,
+		templateID *int64, expiresAfter time.Time) (link *domain.PriceListShareLink, err error)
+	CreatePriceListShareLink(ctx context.Context, dbSession interface{},
+		link *domain.PriceListShareLink) (id int64, err error)
+	RevokePriceListShareLink(ctx context.Context, dbSession interface{}, userID, id int64) (err error)
+	RecordPriceListShareView(ctx context.Context, dbSession interface{}, id int64) (err error)
+	GetPriceListSharePinAttempt(ctx context.Context, dbSession interface{}, linkID int64,
+		ipAddress string) (attempt *domain.PriceListSharePinAttempt, err error)
+	SavePriceListSharePinAttempt(ctx context.Context, dbSession interface{},
+		attempt *domain.PriceListSharePinAttempt) (err error)
+	DeletePriceListSharePinAttempt(ctx context.Context, dbSession interface{}, linkID int64,
+		ipAddress string) (err error)
+}
+
+type PriceListShareService interface {
+	GetPriceListShareLinks(ctx context.Context, userID int64) (
+		links []*domain.PriceListShareLink, err error)
+	CreatePriceListShareLink(ctx context.Context, link *domain.PriceListShareLink,
+		ttlHours int, pin string) (created *domain.PriceListShareLink, err error)
+	RevokePriceListShareLink(ctx context.Context, userID, id int64) (err error)
+	GetPriceListShareURL(ctx context.Context, userID, templateID, linkID int64) (url string, err error)
+	// OpenSharedPriceList تلاش‌های اشتباه رمز را به ازای لینک و ipAddress می‌شمارد
+	OpenSharedPriceList(ctx context.Context, token, pin, ipAddress string) (
+		priceList *domain.ShopViewModel, err error)
+}
@@ -0,0 +1,34 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type PriceListTemplateRepository interface {
+	GetPriceListTemplates(ctx context.Context, dbSession interface{}, userID int64) (
+		templates []*domain.PriceListTemplate, err error)
+	GetPriceListTemplateByID(ctx context.Context, dbSession interface{}, userID, id int64) (
+		template *domain.PriceListTemplate, err error)
+	GetDefaultPriceListTemplate(ctx context.Context, dbSession interface{}, userID int64) (
+		template *domain.PriceListTemplate, err error)
+	CreatePriceListTemplate(ctx context.Context, dbSession interface{},
+		template *domain.PriceListTemplate) (id int64, err error)
+	UpdatePriceListTemplate(ctx context.Context, dbSession interface{},
+		template *domain.PriceListTemplate) (err error)
+	ClearDefaultPriceListTemplate(ctx context.Context, dbSession interface{},
+		userID, exceptID int64) (err error)
+	DeletePriceListTemplate(ctx context.Context, dbSession interface{}, userID, id int64) (err error)
+}
+
+type PriceListTemplateService interface {
+	GetPriceListTemplates(ctx context.Context, userID int64) (
+		templates []*domain.PriceListTemplate, err error)
+	GetPriceListTemplate(ctx context.Context, userID, id int64) (
+		template *domain.PriceListTemplate, err error)
+	CreatePriceListTemplate(ctx context.Context, template *domain.PriceListTemplate) (
+		id int64, err error)
+	UpdatePriceListTemplate(ctx context.Context, template *domain.PriceListTemplate) (err error)
+	DeletePriceListTemplate(ctx context.Context, userID, id int64) (err error)
+}
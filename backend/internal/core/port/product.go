@@ -54,6 +54,10 @@ type ProductRepository interface {
 		pag pagination.Pagination,
 	) (pagination.PaginatedResult[*domain.ProductViewModel], error)
 	GetProductNameByBrandId(ctx context.Context, dbSession interface{}, BrandId int64) ([]*domain.ProductNameModel, error)
+	// FindProductIDsByBrandAndModel تطبیق بدون حساسیت به حروف و فاصله‌های اطراف؛ ممکن است
+	// یک برند در چند دسته تکرار شده باشد و بیش از یک شناسه برگردد
+	FindProductIDsByBrandAndModel(ctx context.Context, dbSession interface{}, brandTitle, modelName string) (
+		ids []int64, err error)
 }
 
 type ProductService interface {
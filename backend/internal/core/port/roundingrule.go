@@ -0,0 +1,23 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type RoundingRuleRepository interface {
+	GetRoundingRules(ctx context.Context, dbSession interface{}, userID int64) (
+		rules []*domain.RoundingRule, err error)
+	ResolveRoundingRule(ctx context.Context, dbSession interface{}, userID, productID int64) (
+		rule *domain.RoundingRule, err error)
+	SaveRoundingRule(ctx context.Context, dbSession interface{}, rule *domain.RoundingRule) (
+		id int64, err error)
+	DeleteRoundingRule(ctx context.Context, dbSession interface{}, userID, id int64) (err error)
+}
+
+type RoundingRuleService interface {
+	GetRoundingRules(ctx context.Context, userID int64) (rules []*domain.RoundingRule, err error)
+	SaveRoundingRule(ctx context.Context, rule *domain.RoundingRule) (id int64, err error)
+	DeleteRoundingRule(ctx context.Context, userID, id int64) (err error)
+}
@@ -0,0 +1,26 @@
+package port
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+type StalenessPolicyRepository interface {
+	GetStalenessPolicies(ctx context.Context, dbSession interface{}) (
+		policies []*domain.StalenessPolicy, err error)
+	SaveStalenessPolicy(ctx context.Context, dbSession interface{},
+		policy *domain.StalenessPolicy) (id int64, err error)
+	DeleteStalenessPolicy(ctx context.Context, dbSession interface{}, id int64) (err error)
+	GetShopsWithStalePrices(ctx context.Context, dbSession interface{}, remindEveryDays int) (
+		shops []*domain.StalePriceShop, err error)
+	MarkStalePriceReminderSent(ctx context.Context, dbSession interface{},
+		shop *domain.StalePriceShop) (err error)
+}
+
+type StalenessPolicyService interface {
+	GetStalenessPolicies(ctx context.Context) (policies []*domain.StalenessPolicy, err error)
+	SaveStalenessPolicy(ctx context.Context, policy *domain.StalenessPolicy) (id int64, err error)
+	DeleteStalenessPolicy(ctx context.Context, id int64) (err error)
+	SendStalePriceReminders(ctx context.Context) (result *domain.StalePriceReminderResult, err error)
+}
@@ -0,0 +1,56 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+)
+
+// TOTPAuthenticator کدهای TOTP (RFC 6238) را می‌سازد و بررسی می‌کند و رمزها را برای ذخیره رمزنگاری می‌کند
+type TOTPAuthenticator interface {
+	GenerateSecret() (secret string, err error)
+	// ValidateCode کد را با پنجرهٔ کوچکی از اختلاف ساعت بررسی می‌کند؛ بازه‌های تا afterStep پذیرفته نمی‌شوند
+	ValidateCode(secret, code string, afterStep int64, now time.Time) (step int64, ok bool)
+	KeyURI(accountName, secret string) string
+	QRCode(keyURI string) (png []byte, err error)
+	EncryptSecret(secret string) (encrypted string, err error)
+	DecryptSecret(encrypted string) (secret string, err error)
+}
+
+type TOTPRepository interface {
+	GetUserTOTP(ctx context.Context, dbSession interface{}, userID int64) (
+		totp *domain.UserTOTP, err error)
+	SaveUserTOTP(ctx context.Context, dbSession interface{}, totp *domain.UserTOTP) (err error)
+	DeleteUserTOTP(ctx context.Context, dbSession interface{}, userID int64) (err error)
+	ConfirmUserTOTP(ctx context.Context, dbSession interface{}, userID int64,
+		confirmedAt time.Time) (err error)
+	MarkUserTOTPDelivered(ctx context.Context, dbSession interface{}, userID int64) (err error)
+	MarkUserTOTPUsed(ctx context.Context, dbSession interface{}, userID int64, step int64) (err error)
+	RecordUserTOTPFailure(ctx context.Context, dbSession interface{}, userID int64,
+		attempts int, lockedUntil *time.Time) (err error)
+	ReplaceRecoveryCodes(ctx context.Context, dbSession interface{}, userID int64,
+		codeHashes []string) (err error)
+	UseRecoveryCode(ctx context.Context, dbSession interface{}, userID int64,
+		codeHash string) (used bool, err error)
+	CountRecoveryCodes(ctx context.Context, dbSession interface{}, userID int64) (count int64, err error)
+	GetTOTPPolicy(ctx context.Context, dbSession interface{}) (policy *domain.TOTPPolicy, err error)
+	UpdateTOTPPolicy(ctx context.Context, dbSession interface{}, policy *domain.TOTPPolicy) (err error)
+}
+
+type TOTPService interface {
+	GetLoginChallenge(ctx context.Context, user *domain.User) (
+		challenge *domain.TOTPLoginChallenge, err error)
+	VerifyLoginCode(ctx context.Context, userID int64, code, deviceID, userAgent, ipAddress string) (
+		user *domain.User, adminAccess *domain.AdminAccess, recoveryCodes []string, err error)
+	BeginEnrollment(ctx context.Context, userID int64) (enrollment *domain.TOTPEnrollment, err error)
+	IssueEnrollment(ctx context.Context, adminID, userID int64) (err error)
+	ConfirmEnrollment(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error)
+	GetStatus(ctx context.Context, userID int64, role domain.UserRole) (status *domain.TOTPStatus, err error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (
+		recoveryCodes []string, err error)
+	DisableTOTP(ctx context.Context, userID int64, role domain.UserRole, code string) (err error)
+	ResetUserTOTP(ctx context.Context, adminID, userID int64) (err error)
+	GetTOTPPolicy(ctx context.Context) (policy *domain.TOTPPolicy, err error)
+	UpdateTOTPPolicy(ctx context.Context, adminID int64, enforcement domain.TOTPEnforcement) (err error)
+}
@@ -2,7 +2,9 @@ package port
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/nerkhin/internal/core/domain"
 	"github.com/shopspring/decimal"
 )
@@ -13,6 +15,8 @@ type UserRepository interface {
 	GetUserByID(ctx context.Context, dbSession interface{}, id int64) (user *domain.User, err error)
 	GetUserByPhone(ctx context.Context, dbSession interface{}, phone string) (
 		user *domain.User, err error)
+	// LockUser ردیف کاربر را تا پایان تراکنش قفل می‌کند تا عملیات هم‌زمان یک کاربر پشت سر هم اجرا شوند
+	LockUser(ctx context.Context, dbSession interface{}, id int64) (err error)
 	DeleteUser(ctx context.Context, dbSession interface{}, id int64) (err error)
 	GetUsersByFilter(ctx context.Context, dbSession interface{}, filter domain.UserFilter, limit int,
 		offset int) (
@@ -24,6 +28,11 @@ type UserRepository interface {
 		dbSession interface{},
 		user *domain.User,
 	) error
+	GetDollarUpdateUserIDs(ctx context.Context, dbSession interface{}) (userIDs []int64, err error)
+	UpdateUsersDollarPrice(ctx context.Context, dbSession interface{},
+		dollarPrice decimal.Decimal) (err error)
+	RecomputeDollarPrices(ctx context.Context, dbSession interface{}, userIDs []int64) (
+		rowsChanged int64, err error)
 	CreateAdminAccess(ctx context.Context, dbSession interface{}, userID int64) (err error)
 	GetAdminAccess(ctx context.Context, dbSession interface{}, adminID int64) (
 		adminAccess *domain.AdminAccess, err error)
@@ -47,6 +56,12 @@ type UserRepository interface {
 	DeleteAllUserDevices(ctx context.Context, dbSession interface{}, userID int64) error // <-- ADDED
 
 	UpdateAllUsersDeviceLimit(ctx context.Context, dbSession interface{}, limit int) error
+	GetUserSessionVersion(ctx context.Context, dbSession interface{}, userID int64) (version int64, err error)
+	BumpUserSessionVersion(ctx context.Context, dbSession interface{}, userID int64) error
+	UpdateUserDeviceName(ctx context.Context, dbSession interface{}, id int64, name *string) error
+	TouchUserDevice(ctx context.Context, dbSession interface{}, id int64, seenAt time.Time) error
+	DeleteOtherUserDevices(ctx context.Context, dbSession interface{}, userID int64, keepDeviceID string) error
+	DeleteIdleDevices(ctx context.Context, dbSession interface{}, userID int64, before time.Time) (count int64, err error)
 	FetchAdminUserList(ctx context.Context, dbSession interface{}, filter *domain.UserFilterSubScribe) ([]*domain.AdminUserViewModel, error)
 }
 
@@ -85,5 +100,11 @@ type UserService interface {
 	DeleteAllUserDevices(ctx context.Context, userID int64) error                           // <-- ADDED
 	UpdateAllUsersDeviceLimit(ctx context.Context, limit int) error
 	FetchAdminUserList(ctx context.Context, filter *domain.UserFilterSubScribe) ([]*domain.AdminUserViewModel, error)
-	ImpersonateUser(ctx context.Context, targetUserID, adminID int64) (string, *domain.User, error)
+
+	GetOwnDevices(ctx context.Context, userID int64, currentDeviceID string) ([]*domain.ActiveDevice, error)
+	RenameOwnDevice(ctx context.Context, userID, id int64, name string) error
+	SignOutOwnDevice(ctx context.Context, userID, id int64) error
+	SendSignOutOtherDevicesCode(ctx context.Context, userID int64, ipAddress string) error
+	SignOutOtherDevices(ctx context.Context, userID int64, familyID uuid.UUID, code string) error
+	ExpireIdleDevices(ctx context.Context) (count int64, err error)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/pkg/pagination"
 	"github.com/shopspring/decimal"
 )
 
@@ -21,8 +22,8 @@ type UserProductRepository interface {
 		shopProducts []*domain.ShopProduct, err error)
 	GetProductShops(ctx context.Context, dbSession interface{},
 		productID int64, allowedCityIDs []int64) (productShops []*domain.ProductShop, err error)
-	GetPriceList(ctx context.Context, dbSession interface{}, currentUserID int64) (
-		priceList []*domain.UserProductView, err error)
+	GetPriceList(ctx context.Context, dbSession interface{}, currentUserID int64,
+		query *domain.UserProductQuery) (priceList []*domain.UserProductView, err error)
 	GetUserProductByID(ctx context.Context, dbSession interface{}, userProductID int64) (
 		userProduct *domain.UserProduct, err error)
 	GetMaxOrder(ctx context.Context, txSession interface{}, userId int64) (maxOrder int64, err error)
@@ -52,7 +53,43 @@ type UserProductRepository interface {
 		dbSession interface{},
 		q *domain.UserProductSearchQuery,
 	) (int64, error)
-	AdjustUserFinalPricesByRate(ctx context.Context, dbSession interface{}, userID int64, rate decimal.Decimal) error
+	GetAdjustablePrices(ctx context.Context, dbSession interface{}, userID int64,
+		scope domain.PriceAdjustmentScope) (items []*domain.PriceAdjustmentItem, err error)
+	CreatePriceAdjustmentBatch(ctx context.Context, dbSession interface{},
+		batch *domain.PriceAdjustmentBatch, items []*domain.PriceAdjustmentItem) (id int64, err error)
+	ApplyPriceAdjustmentBatch(ctx context.Context, dbSession interface{}, batchID int64) (
+		affected int64, err error)
+	GetLastPriceAdjustmentBatch(ctx context.Context, dbSession interface{}, userID int64) (
+		batch *domain.PriceAdjustmentBatch, err error)
+	RevertPriceAdjustmentBatch(ctx context.Context, dbSession interface{}, batchID int64) (
+		restored int64, err error)
+	GetMarketPriceStats(ctx context.Context, dbSession interface{}, productID, excludeUserID int64) (
+		stats *domain.MarketPriceStats, err error)
+	CreatePriceDeviationFlag(ctx context.Context, dbSession interface{},
+		flag *domain.PriceDeviationFlag) (id int64, err error)
+	GetPriceDeviationFlags(ctx context.Context, dbSession interface{},
+		filter domain.PriceDeviationFlagFilter, pag pagination.Pagination) (
+		flags []*domain.PriceDeviationFlagView, total int64, err error)
+	ReviewPriceDeviationFlag(ctx context.Context, dbSession interface{}, id, adminID int64) (err error)
+	TouchUserProducts(ctx context.Context, dbSession interface{}, userID int64, ids []int64) (
+		affected int64, err error)
+	GetUserProductsByProductIDs(ctx context.Context, dbSession interface{}, userID int64,
+		productIDs []int64) (userProducts map[int64]*domain.UserProduct, err error)
+	GetUserProductsByIDs(ctx context.Context, dbSession interface{}, userID int64,
+		ids []int64) (userProducts map[int64]*domain.UserProduct, err error)
+	// ReorderUserProducts ترتیب‌های داده‌شده را می‌نشاند و سپس ترتیب همهٔ محصولات فروشگاه را
+	// از یک پشت سر هم شماره‌گذاری می‌کند؛ در ترتیب یکسان ردیف جابه‌جاشده جلوتر می‌آید
+	ReorderUserProducts(ctx context.Context, dbSession interface{}, userID int64,
+		orders map[int64]int64) (err error)
+	GetUserProductBySKU(ctx context.Context, dbSession interface{}, userID int64, sku string) (
+		userProduct *domain.UserProduct, err error)
+	GetUserProductsBySKUs(ctx context.Context, dbSession interface{}, userID int64, skus []string) (
+		userProducts map[string]*domain.UserProduct, err error)
+	UpdateUserProductSKU(ctx context.Context, dbSession interface{}, userID, id int64,
+		sku *string) (err error)
+	GetCompetitivePositions(ctx context.Context, dbSession interface{},
+		filter *domain.CompetitivePositionFilter) (positions []*domain.CompetitivePosition,
+		total int64, err error)
 
 }
 
@@ -67,7 +104,11 @@ type UserProductService interface {
 		productsData *domain.SearchProductsData, err error)
 	FetchRelatedShopProducts(ctx context.Context, productId int64, currentUserId int64) (
 		userProducts *domain.ProductInfoViewModel, err error)
-	GetPriceList(ctx context.Context, currentUserID int64) (
+	// GetPriceList با templateID صفر قالب پیش‌فرض فروشگاه را اعمال می‌کند
+	GetPriceList(ctx context.Context, currentUserID, templateID int64) (
+		priceList *domain.ShopViewModel, err error)
+	// GetShopPriceList لیست قیمت کامل فروشگاه دیگر با قالب پیش‌فرض همان فروشگاه
+	GetShopPriceList(ctx context.Context, currentUserID, shopID int64, query *domain.UserProductQuery) (
 		priceList *domain.ShopViewModel, err error)
 	UpdateUserProduct(ctx context.Context, userProduct *domain.UserProduct) (err error)
 	FetchUserProductById(ctx context.Context, upId int64) (
@@ -84,6 +125,30 @@ type UserProductService interface {
 
 		q *domain.UserProductSearchQuery,
 	) (*domain.MarketSearchResult, error)
-	AdjustUserFinalPricesByPercent(ctx context.Context, userID int64, percent decimal.Decimal) error
+	PreviewPriceAdjustment(ctx context.Context, adjustment *domain.PriceAdjustment) (
+		preview *domain.PriceAdjustmentPreview, err error)
+	AdjustPrices(ctx context.Context, adjustment *domain.PriceAdjustment) (
+		result *domain.PriceAdjustmentResult, err error)
+	UndoLastPriceAdjustment(ctx context.Context, userID int64) (
+		result *domain.PriceAdjustmentUndoResult, err error)
+	GetPriceDeviationFlags(ctx context.Context, filter domain.PriceDeviationFlagFilter,
+		pag pagination.Pagination) (result pagination.PaginatedResult[*domain.PriceDeviationFlagView], err error)
+	ReviewPriceDeviationFlag(ctx context.Context, id, adminID int64) (err error)
+	ConfirmPricesStillValid(ctx context.Context, userID int64, ids []int64) (
+		confirmedCount int64, err error)
+	GetCompetitivePositions(ctx context.Context, filter *domain.CompetitivePositionFilter,
+		pag pagination.Pagination) (
+		result pagination.PaginatedResult[*domain.CompetitivePosition], err error)
+	ExportCompetitivePositions(ctx context.Context, filter *domain.CompetitivePositionFilter) (
+		positions []*domain.CompetitivePosition, err error)
+	// ImportUserProducts قیمت‌های فایل فروشگاه را ردیف به ردیف اعمال می‌کند؛ خطای هر ردیف
+	// در گزارش برمی‌گردد و مانع ردیف‌های دیگر نمی‌شود
+	ImportUserProducts(ctx context.Context, userID int64, rows []*domain.UserProductImportRow,
+		options domain.UserProductImportOptions) (result *domain.UserProductImportResult, err error)
+	// ExportUserProducts همهٔ محصولات فروشگاه (از جمله مخفی‌ها) به ترتیب نمایش برای ویرایش آفلاین
+	ExportUserProducts(ctx context.Context, userID int64) (products []*domain.UserProductView, err error)
+	// FetchUserProductBySKU محصول فروشگاه را با کد کالای خود فروشگاه پیدا می‌کند (برای یکپارچه‌سازی)
+	FetchUserProductBySKU(ctx context.Context, userID int64, sku string) (
+		userProduct *domain.UserProductView, err error)
 
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type AdminRoleService struct {
+	dbms port.DBMS
+	repo port.AdminRoleRepository
+}
+
+func RegisterAdminRoleService(dbms port.DBMS, repo port.AdminRoleRepository) *AdminRoleService {
+	return &AdminRoleService{
+		dbms,
+		repo,
+	}
+}
+
+func (ars *AdminRoleService) GetAdminRoles(ctx context.Context) (roles []*domain.AdminRole, err error) {
+	db, err := ars.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ars.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		roles, err = ars.repo.GetAdminRoles(ctx, txSession)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return roles, nil
+}
+
+func (ars *AdminRoleService) SaveAdminRole(ctx context.Context, role *domain.AdminRole) (
+	id int64, err error) {
+	db, err := ars.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ars.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		role.Permissions, err = normalizePermissions(role.Permissions)
+		if err != nil {
+			return err
+		}
+
+		role.Name = strings.TrimSpace(role.Name)
+		if role.Name == "" {
+			return errors.New(msg.ErrAdminRoleNameCannotBeEmpty)
+		}
+
+		roles, err := ars.repo.GetAdminRoles(ctx, txSession)
+		if err != nil {
+			return err
+		}
+
+		if role.ID != 0 && !slices.ContainsFunc(roles, func(r *domain.AdminRole) bool {
+			return r.ID == role.ID
+		}) {
+			return errors.New(msg.ErrAdminRoleDoesNotExist)
+		}
+
+		if slices.ContainsFunc(roles, func(r *domain.AdminRole) bool {
+			return r.ID != role.ID && r.Name == role.Name
+		}) {
+			return errors.New(msg.ErrConflictingData)
+		}
+
+		id, err = ars.repo.SaveAdminRole(ctx, txSession, role)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return id, nil
+}
+
+// DeleteAdminRole نقش را حذف می‌کند؛ ادمین‌های آن نقش فقط دسترسی‌های مستقیم خود را نگه می‌دارند
+func (ars *AdminRoleService) DeleteAdminRole(ctx context.Context, id int64) (err error) {
+	db, err := ars.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ars.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		return ars.repo.DeleteAdminRole(ctx, txSession, id)
+	})
+}
+
+// normalizePermissions دسترسی‌های نامعتبر را رد و تکراری‌ها را حذف می‌کند
+func normalizePermissions(permissions []domain.Permission) ([]domain.Permission, error) {
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, errors.New(msg.ErrPermissionIsNotValid)
+		}
+	}
+
+	permissions = slices.Clone(permissions)
+	slices.Sort(permissions)
+	return slices.Compact(permissions), nil
+}
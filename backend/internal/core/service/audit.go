@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type AuditService struct {
+	dbms      port.DBMS
+	repo      port.AuditRepository
+	appConfig config.App
+}
+
+func RegisterAuditService(dbms port.DBMS, repo port.AuditRepository,
+	appConfig config.App) *AuditService {
+	return &AuditService{
+		dbms,
+		repo,
+		appConfig,
+	}
+}
+
+func (as *AuditService) RecordAuditLog(ctx context.Context, log *domain.AuditLog) (err error) {
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return as.repo.CreateAuditLog(ctx, db, log)
+}
+
+// GetAuditLogs سوابق را با تغییرات ردیف‌های هر درخواست برمی‌گرداند
+func (as *AuditService) GetAuditLogs(ctx context.Context, filter domain.AuditLogFilter,
+	page, limit int) (logs []*domain.AuditLog, totalCount int64, err error) {
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	logs, totalCount, err = as.repo.GetAuditLogs(ctx, db, filter, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	requestIDs := make([]uuid.UUID, 0, len(logs))
+	byRequestID := make(map[uuid.UUID]*domain.AuditLog, len(logs))
+	for _, log := range logs {
+		log.Changes = []*domain.AuditChange{}
+		requestIDs = append(requestIDs, log.RequestID)
+		byRequestID[log.RequestID] = log
+	}
+
+	changes, err := as.repo.GetAuditChanges(ctx, db, requestIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, change := range changes {
+		if log, ok := byRequestID[change.RequestID]; ok {
+			log.Changes = append(log.Changes, change)
+		}
+	}
+
+	return logs, totalCount, nil
+}
+
+// PurgeAuditLogs سوابق قدیمی‌تر از مدت نگهداری را پاک می‌کند
+func (as *AuditService) PurgeAuditLogs(ctx context.Context) (deleted int64, err error) {
+	retentionDays := as.appConfig.Audit.RetentionDays
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = as.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		deleted, err = as.repo.DeleteAuditDataBefore(ctx, txSession,
+			time.Now().AddDate(0, 0, -retentionDays))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
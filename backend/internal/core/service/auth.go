@@ -3,31 +3,46 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"regexp"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/core/domain"
 	"github.com/nerkhin/internal/core/domain/msg"
 	"github.com/nerkhin/internal/core/port"
 )
 
 type AuthService struct {
-	dbms      port.DBMS
-	userRepo  port.UserRepository
-	vcService port.VerificationCodeService
-	vcRepo    port.VerificationCodeRepository
+	dbms              port.DBMS
+	userRepo          port.UserRepository
+	vcService         port.VerificationCodeService
+	vcRepo            port.VerificationCodeRepository
+	refreshTokenRepo  port.RefreshTokenRepository
+	tokenService      port.TokenService
+	loginEventService port.LoginEventService
+	appConfig         config.App
 }
 
 func RegisterAuthService(dbms port.DBMS, repo port.UserRepository,
-	vc port.VerificationCodeService, vcRepo port.VerificationCodeRepository) *AuthService {
+	vc port.VerificationCodeService, vcRepo port.VerificationCodeRepository,
+	refreshTokenRepo port.RefreshTokenRepository, tokenService port.TokenService,
+	loginEventService port.LoginEventService, appConfig config.App) *AuthService {
 	return &AuthService{
 		dbms,
 		repo,
 		vc,
 		vcRepo,
+		refreshTokenRepo,
+		tokenService,
+		loginEventService,
+		appConfig,
 	}
 }
 
-func (as *AuthService) Login(ctx context.Context, phone string) (
+func (as *AuthService) Login(ctx context.Context, phone, ipAddress string) (
 	userID int64, err error) {
 	db, err := as.dbms.NewDB(ctx)
 	if err != nil {
@@ -53,16 +68,6 @@ func (as *AuthService) Login(ctx context.Context, phone string) (
 			return errors.New(msg.ErrUserIsNotApprovedYet)
 		}
 
-		code, err := as.vcService.SendVerificationCode(ctx, user.Phone)
-		if err != nil {
-			return errors.New(msg.ErrSendingVerificationCodeFailed)
-		}
-
-		err = as.vcRepo.SaveVerificationCode(ctx, txSession, user.ID, code)
-		if err != nil {
-			return err
-		}
-		
 		if user.Role == domain.Admin {
 			err := as.userRepo.CreateAdminAccess(ctx, txSession, user.ID)
 			if err != nil {
@@ -71,12 +76,20 @@ func (as *AuthService) Login(ctx context.Context, phone string) (
 		}
 
 		userID = user.ID
+		phone = user.Phone
 		return nil
 	})
 	if err != nil {
 		return
 	}
 
+	// کد در تراکنش خودش ذخیره و بعد از commit ارسال می‌شود؛ داخل تراکنش بالا صدا زده نمی‌شود تا
+	// شکست آن تراکنش کدی ارسال‌شده باقی نگذارد و هر ورود دو اتصال دیتابیس نگیرد
+	err = as.vcService.SendVerificationCode(ctx, phone, ipAddress)
+	if err != nil {
+		return 0, err
+	}
+
 	return userID, nil
 }
 func (as *AuthService) GetUserByID(ctx context.Context, userID int64) (*domain.User, error) {
@@ -91,8 +104,291 @@ func (as *AuthService) GetUserByID(ctx context.Context, userID int64) (*domain.U
 	return as.userRepo.GetUserByID(ctx, db, userID)
 }
 
+// CreateSession بعد از گذشتن همهٔ مرحله‌های ورود (کد پیامکی و در صورت نیاز TOTP) دستگاه را ثبت
+// و خانوادهٔ refresh token تازه‌ای برای آن می‌سازد؛ رویداد ورود موفق هم همین‌جا ثبت می‌شود
+func (as *AuthService) CreateSession(ctx context.Context, user *domain.User, device *domain.ActiveDevice) (
+	refreshToken string, familyID uuid.UUID, err error) {
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = as.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err := as.registerDevice(ctx, txSession, user, device)
+		if err != nil {
+			return err
+		}
+
+		// ورود دوباره روی همان دستگاه توکن‌های ورود قبلی آن را بی‌اثر می‌کند
+		err = as.refreshTokenRepo.RevokeDeviceRefreshTokenFamilies(ctx, txSession, device.ID,
+			domain.RefreshTokenRevokedByRelogin)
+		if err != nil {
+			return err
+		}
+
+		familyID, err = uuid.NewRandom()
+		if err != nil {
+			return err
+		}
+
+		err = as.refreshTokenRepo.CreateRefreshTokenFamily(ctx, txSession, &domain.RefreshTokenFamily{
+			ID:             familyID,
+			UserID:         user.ID,
+			ActiveDeviceID: device.ID,
+			CreatedAt:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		refreshToken, err = as.issueRefreshToken(ctx, txSession, user, familyID)
+		if err != nil {
+			return err
+		}
+
+		// پاک شدن دستگاه‌های بی‌استفاده ممکن است نسخهٔ نشست را تغییر داده باشد؛
+		// access token باید با نسخهٔ فعلی ساخته شود وگرنه همان اول رد می‌شود
+		user.SessionVersion, err = as.userRepo.GetUserSessionVersion(ctx, txSession, user.ID)
+		return err
+	})
+	if err != nil {
+		if err.Error() == msg.ErrDeviceLimitReached {
+			as.recordLoginEvent(ctx, user, device, err)
+		}
+		return "", uuid.Nil, err
+	}
+
+	as.recordLoginEvent(ctx, user, device, nil)
+	return refreshToken, familyID, nil
+}
+
+func (as *AuthService) RefreshSession(ctx context.Context, refreshToken string) (
+	user *domain.User, adminAccess *domain.AdminAccess, newRefreshToken string, familyID uuid.UUID, err error) {
+	payload, err := as.tokenService.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, nil, "", uuid.Nil, errors.New(msg.ErrInvalidToken)
+	}
+
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	// باطل شدن خانواده بعد از commit گزارش می‌شود تا rollback نشود
+	var reuseErr error
+	err = as.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		token, family, err := as.lockRefreshToken(ctx, txSession, payload)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if token.UsedAt != nil {
+			err = as.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, txSession, family.ID,
+				domain.RefreshTokenRevokedByReuse)
+			if err != nil {
+				return err
+			}
+
+			reuseErr = errors.New(msg.ErrRefreshTokenReused)
+			return nil
+		}
+
+		if !now.Before(token.ExpiresAt) {
+			return errors.New(msg.ErrExpiredToken)
+		}
+
+		user, err = as.userRepo.GetUserByID(ctx, txSession, payload.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New(msg.ErrUserDoesNotExist)
+		}
+		if user.Role != payload.UserRole {
+			return errors.New(msg.ErrInvalidToken)
+		}
+
+		// دسترسی‌های ادمین در access token تازه هم باید باشند
+		if user.Role == domain.Admin || user.Role == domain.SuperAdmin {
+			adminAccess, err = as.userRepo.GetAdminAccess(ctx, txSession, user.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = as.refreshTokenRepo.MarkRefreshTokenUsed(ctx, txSession, token.JTI, now)
+		if err != nil {
+			return err
+		}
+
+		err = as.refreshTokenRepo.DeleteExpiredRefreshTokens(ctx, txSession, family.ID, now)
+		if err != nil {
+			return err
+		}
+
+		err = as.userRepo.TouchUserDevice(ctx, txSession, family.ActiveDeviceID, now)
+		if err != nil {
+			return err
+		}
+
+		newRefreshToken, err = as.issueRefreshToken(ctx, txSession, user, family.ID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, "", uuid.Nil, err
+	}
+	if reuseErr != nil {
+		return nil, nil, "", uuid.Nil, reuseErr
+	}
+
+	return user, adminAccess, newRefreshToken, payload.FamilyID, nil
+}
+
+func (as *AuthService) Logout(ctx context.Context, refreshToken string) (err error) {
+	payload, err := as.tokenService.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return errors.New(msg.ErrInvalidToken)
+	}
+
+	db, err := as.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return as.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		family, err := as.refreshTokenRepo.GetRefreshTokenFamily(ctx, txSession, payload.FamilyID)
+		if err != nil {
+			return err
+		}
+		// دستگاه قبلا حذف شده و خروج انجام شده است
+		if family == nil || family.UserID != payload.UserID {
+			return nil
+		}
+
+		devices, err := as.userRepo.GetUserActiveDevices(ctx, txSession, family.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, device := range devices {
+			if device.ID == family.ActiveDeviceID {
+				// حذف دستگاه، خانواده‌های توکن آن را هم حذف می‌کند و جای دستگاه آزاد می‌شود
+				return as.userRepo.DeleteUserDevice(ctx, txSession, device.UserID, device.DeviceID)
+			}
+		}
+
+		return nil
+	})
+}
+
+// lockRefreshToken توکن و خانواده‌اش را پیدا می‌کند؛ توکن ناشناخته، خانوادهٔ باطل‌شده یا
+// دستگاه حذف‌شده توکن نامعتبر حساب می‌شود
+func (as *AuthService) lockRefreshToken(ctx context.Context, txSession interface{},
+	payload *domain.RefreshTokenPayload) (*domain.RefreshToken, *domain.RefreshTokenFamily, error) {
+	token, err := as.refreshTokenRepo.LockRefreshToken(ctx, txSession, payload.JTI)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil || token.FamilyID != payload.FamilyID {
+		return nil, nil, errors.New(msg.ErrInvalidToken)
+	}
+
+	family, err := as.refreshTokenRepo.GetRefreshTokenFamily(ctx, txSession, token.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if family == nil || family.RevokedAt != nil || family.UserID != payload.UserID {
+		return nil, nil, errors.New(msg.ErrInvalidToken)
+	}
+
+	return token, family, nil
+}
+
+func (as *AuthService) issueRefreshToken(ctx context.Context, txSession interface{},
+	user *domain.User, familyID uuid.UUID) (string, error) {
+	tokenString, payload, expiresAt, err := as.tokenService.CreateRefreshToken(user, familyID)
+	if err != nil {
+		return "", err
+	}
+
+	err = as.refreshTokenRepo.CreateRefreshToken(ctx, txSession, &domain.RefreshToken{
+		JTI:       payload.JTI,
+		FamilyID:  familyID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+// registerDevice دستگاه ورود را ثبت یا زمان آخرین ورودش را به‌روز می‌کند و شناسهٔ ردیف آن را در device می‌گذارد؛
+// دستگاه جدید فقط تا سقف دستگاه‌های کاربر پذیرفته می‌شود
+func (as *AuthService) registerDevice(ctx context.Context, txSession interface{},
+	user *domain.User, device *domain.ActiveDevice) error {
+	// دستگاه‌هایی که مدت زیادی استفاده نشده‌اند جای دستگاه جدید را نمی‌گیرند
+	if idleExpiry := as.deviceIdleExpiry(); idleExpiry > 0 {
+		_, err := as.userRepo.DeleteIdleDevices(ctx, txSession, user.ID, time.Now().Add(-idleExpiry))
+		if err != nil {
+			return err
+		}
+	}
+
+	activeDevices, err := as.userRepo.GetUserActiveDevices(ctx, txSession, user.ID)
+	if err != nil {
+		return fmt.Errorf("could not check active devices: %w", err)
+	}
+
+	now := time.Now()
+	for _, activeDevice := range activeDevices {
+		if activeDevice.DeviceID != device.DeviceID {
+			continue
+		}
+
+		device.ID = activeDevice.ID
+		activeDevice.LastLoginAt = now
+		activeDevice.IPAddress = device.IPAddress
+		activeDevice.UserAgent = device.UserAgent
+		if err := as.userRepo.UpdateDeviceLastLogin(ctx, txSession, activeDevice); err != nil {
+			// Log this error but don't block login
+			slog.Warn("could not update last login", "userId", user.ID, "error", err)
+		}
+		return nil
+	}
+
+	// User's DeviceLimit can be 0, default to 2 in that case.
+	limit := user.DeviceLimit
+	if limit <= 0 {
+		limit = 2
+	}
+	if len(activeDevices) >= limit {
+		return errors.New(msg.ErrDeviceLimitReached)
+	}
+
+	device.UserID = user.ID
+	device.LastLoginAt = now
+	device.LastSeenAt = now
+	device.CreatedAt = now
+	if err := as.userRepo.RegisterNewDevice(ctx, txSession, device); err != nil {
+		return fmt.Errorf("could not register new device: %w", err)
+	}
+	return nil
+}
+
+func (as *AuthService) recordLoginEvent(ctx context.Context, user *domain.User,
+	device *domain.ActiveDevice, loginErr error) {
+	recordLoginEvent(ctx, as.loginEventService, user, device.DeviceID, device.UserAgent, device.IPAddress, loginErr)
+}
+
+func (as *AuthService) deviceIdleExpiry() time.Duration {
+	return time.Duration(as.appConfig.Device.IdleExpiryDays) * 24 * time.Hour
+}
+
 func validateUserLogin(_ context.Context, phone string) (err error) {
-	match, err := regexp.MatchString(`^(\+98|0)?9\d{9}$`, phone)
+	match, err := regexp.MatchString(`^(\+98|0098|0)?9\d{9}$`, phone)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// fakeRefreshTokenRepo توکن‌ها و خانواده‌ها را در حافظه نگه می‌دارد
+type fakeRefreshTokenRepo struct {
+	port.RefreshTokenRepository
+	families map[uuid.UUID]*domain.RefreshTokenFamily
+	tokens   map[uuid.UUID]*domain.RefreshToken
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{
+		families: map[uuid.UUID]*domain.RefreshTokenFamily{},
+		tokens:   map[uuid.UUID]*domain.RefreshToken{},
+	}
+}
+
+func (r *fakeRefreshTokenRepo) CreateRefreshTokenFamily(_ context.Context, _ interface{},
+	family *domain.RefreshTokenFamily) error {
+	r.families[family.ID] = family
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetRefreshTokenFamily(_ context.Context, _ interface{},
+	familyID uuid.UUID) (*domain.RefreshTokenFamily, error) {
+	return r.families[familyID], nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeRefreshTokenFamily(_ context.Context, _ interface{},
+	familyID uuid.UUID, reason domain.RefreshTokenRevokeReason) error {
+	if family := r.families[familyID]; family != nil && family.RevokedAt == nil {
+		now := time.Now()
+		family.RevokedAt = &now
+		family.RevokeReason = &reason
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeDeviceRefreshTokenFamilies(ctx context.Context, db interface{},
+	activeDeviceID int64, reason domain.RefreshTokenRevokeReason) error {
+	for id, family := range r.families {
+		if family.ActiveDeviceID == activeDeviceID {
+			_ = r.RevokeRefreshTokenFamily(ctx, db, id, reason)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) CreateRefreshToken(_ context.Context, _ interface{},
+	token *domain.RefreshToken) error {
+	r.tokens[token.JTI] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) LockRefreshToken(_ context.Context, _ interface{},
+	jti uuid.UUID) (*domain.RefreshToken, error) {
+	return r.tokens[jti], nil
+}
+
+func (r *fakeRefreshTokenRepo) MarkRefreshTokenUsed(_ context.Context, _ interface{},
+	jti uuid.UUID, usedAt time.Time) error {
+	r.tokens[jti].UsedAt = &usedAt
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteExpiredRefreshTokens(_ context.Context, _ interface{},
+	familyID uuid.UUID, now time.Time) error {
+	for jti, token := range r.tokens {
+		if token.FamilyID == familyID && !now.Before(token.ExpiresAt) {
+			delete(r.tokens, jti)
+		}
+	}
+	return nil
+}
+
+// fakeTokenService رشتهٔ توکن را همان JTI می‌گذارد تا تست‌ها بتوانند توکن قدیمی را دوباره ارائه کنند
+type fakeTokenService struct {
+	port.TokenService
+	payloads map[string]*domain.RefreshTokenPayload
+}
+
+func (ts *fakeTokenService) CreateRefreshToken(user *domain.User, familyID uuid.UUID) (
+	string, *domain.RefreshTokenPayload, time.Time, error) {
+	payload := &domain.RefreshTokenPayload{
+		JTI:      uuid.New(),
+		UserID:   user.ID,
+		UserRole: user.Role,
+		FamilyID: familyID,
+		Type:     "refresh",
+	}
+	tokenString := payload.JTI.String()
+	ts.payloads[tokenString] = payload
+	return tokenString, payload, time.Now().Add(time.Hour), nil
+}
+
+func (ts *fakeTokenService) VerifyRefreshToken(tokenString string) (*domain.RefreshTokenPayload, error) {
+	payload, ok := ts.payloads[tokenString]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return payload, nil
+}
+
+// fakeSessionUserRepo حذف دستگاه را مثل تریگر دیتابیس با بالا بردن نسخهٔ نشست شبیه‌سازی می‌کند
+type fakeSessionUserRepo struct {
+	fakeUserRepo
+	devices        []*domain.ActiveDevice
+	touched        []int64
+	sessionVersion int64
+}
+
+func (r *fakeSessionUserRepo) DeleteIdleDevices(_ context.Context, _ interface{}, _ int64,
+	before time.Time) (int64, error) {
+	var kept []*domain.ActiveDevice
+	for _, device := range r.devices {
+		if device.LastSeenAt.Before(before) {
+			r.sessionVersion++
+			continue
+		}
+		kept = append(kept, device)
+	}
+	count := int64(len(r.devices) - len(kept))
+	r.devices = kept
+	return count, nil
+}
+
+func (r *fakeSessionUserRepo) GetUserActiveDevices(context.Context, interface{}, int64) (
+	[]*domain.ActiveDevice, error) {
+	return r.devices, nil
+}
+
+func (r *fakeSessionUserRepo) RegisterNewDevice(_ context.Context, _ interface{}, device *domain.ActiveDevice) error {
+	device.ID = int64(len(r.devices) + 100)
+	r.devices = append(r.devices, device)
+	return nil
+}
+
+func (r *fakeSessionUserRepo) UpdateDeviceLastLogin(context.Context, interface{}, *domain.ActiveDevice) error {
+	return nil
+}
+
+func (r *fakeSessionUserRepo) GetUserSessionVersion(context.Context, interface{}, int64) (int64, error) {
+	return r.sessionVersion, nil
+}
+
+func (r *fakeSessionUserRepo) TouchUserDevice(_ context.Context, _ interface{}, id int64, _ time.Time) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+type fakeLoginEventService struct {
+	port.LoginEventService
+	events []*domain.LoginEvent
+}
+
+func (s *fakeLoginEventService) RecordLoginEvent(_ context.Context, event *domain.LoginEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func newSessionTestAuthService(t *testing.T) (*AuthService, *fakeRefreshTokenRepo,
+	*fakeSessionUserRepo, *domain.User) {
+	t.Helper()
+
+	user := &domain.User{ID: 7, Role: domain.Wholesaler, State: domain.ApprovedUser}
+	userRepo := &fakeSessionUserRepo{
+		fakeUserRepo: fakeUserRepo{user: user},
+		devices: []*domain.ActiveDevice{
+			{ID: 3, UserID: user.ID, DeviceID: "phone-1", LastSeenAt: time.Now()},
+		},
+	}
+	refreshRepo := newFakeRefreshTokenRepo()
+	tokenService := &fakeTokenService{payloads: map[string]*domain.RefreshTokenPayload{}}
+
+	as := RegisterAuthService(fakeDBMS{}, userRepo, nil, nil, refreshRepo, tokenService,
+		&fakeLoginEventService{}, config.App{})
+	return as, refreshRepo, userRepo, user
+}
+
+func testDevice(deviceID string) *domain.ActiveDevice {
+	return &domain.ActiveDevice{DeviceID: deviceID, UserAgent: "ua", IPAddress: "127.0.0.1"}
+}
+
+func TestRefreshSessionRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	as, refreshRepo, userRepo, user := newSessionTestAuthService(t)
+
+	first, _, err := as.CreateSession(ctx, user, testDevice("phone-1"))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	gotUser, _, second, familyID, err := as.RefreshSession(ctx, first)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	if gotUser.ID != user.ID {
+		t.Errorf("user = %d, want %d", gotUser.ID, user.ID)
+	}
+	if second == "" || second == first {
+		t.Fatalf("new refresh token = %q, want a fresh token", second)
+	}
+
+	old := refreshRepo.tokens[uuid.MustParse(first)]
+	next := refreshRepo.tokens[uuid.MustParse(second)]
+	if old.UsedAt == nil {
+		t.Error("rotated token is not marked used")
+	}
+	if next == nil || next.FamilyID != old.FamilyID || familyID != old.FamilyID {
+		t.Error("new token is not in the same family")
+	}
+	if len(userRepo.touched) != 1 || userRepo.touched[0] != 3 {
+		t.Errorf("touched devices = %v, want [3]", userRepo.touched)
+	}
+
+	if _, _, _, _, err := as.RefreshSession(ctx, second); err != nil {
+		t.Errorf("second rotation: %v", err)
+	}
+}
+
+func TestRefreshSessionReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	as, refreshRepo, _, user := newSessionTestAuthService(t)
+
+	first, _, err := as.CreateSession(ctx, user, testDevice("phone-1"))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	_, _, second, _, err := as.RefreshSession(ctx, first)
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+
+	_, _, _, _, err = as.RefreshSession(ctx, first)
+	if err == nil || err.Error() != msg.ErrRefreshTokenReused {
+		t.Fatalf("reuse err = %v, want %s", err, msg.ErrRefreshTokenReused)
+	}
+
+	family := refreshRepo.families[refreshRepo.tokens[uuid.MustParse(first)].FamilyID]
+	if family.RevokedAt == nil || *family.RevokeReason != domain.RefreshTokenRevokedByReuse {
+		t.Fatalf("family revoke = %v, want %s", family.RevokeReason, domain.RefreshTokenRevokedByReuse)
+	}
+
+	// توکنی که بعد از چرخش صادر شده هم با باطل شدن خانواده بی‌اثر است
+	_, _, _, _, err = as.RefreshSession(ctx, second)
+	if err == nil || err.Error() != msg.ErrInvalidToken {
+		t.Errorf("latest token err = %v, want %s", err, msg.ErrInvalidToken)
+	}
+}
+
+func TestRefreshSessionRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(repo *fakeRefreshTokenRepo, ts *fakeTokenService, token string) string
+		wantErr string
+	}{
+		{
+			name: "expired",
+			mutate: func(repo *fakeRefreshTokenRepo, _ *fakeTokenService, token string) string {
+				repo.tokens[uuid.MustParse(token)].ExpiresAt = time.Now().Add(-time.Minute)
+				return token
+			},
+			wantErr: msg.ErrExpiredToken,
+		},
+		{
+			name: "revoked by relogin",
+			mutate: func(repo *fakeRefreshTokenRepo, _ *fakeTokenService, token string) string {
+				_ = repo.RevokeDeviceRefreshTokenFamilies(context.Background(), nil, 3,
+					domain.RefreshTokenRevokedByRelogin)
+				return token
+			},
+			wantErr: msg.ErrInvalidToken,
+		},
+		{
+			name: "unknown jti",
+			mutate: func(repo *fakeRefreshTokenRepo, _ *fakeTokenService, token string) string {
+				delete(repo.tokens, uuid.MustParse(token))
+				return token
+			},
+			wantErr: msg.ErrInvalidToken,
+		},
+		{
+			name: "family mismatch",
+			mutate: func(_ *fakeRefreshTokenRepo, ts *fakeTokenService, token string) string {
+				ts.payloads[token].FamilyID = uuid.New()
+				return token
+			},
+			wantErr: msg.ErrInvalidToken,
+		},
+		{
+			name: "role changed",
+			mutate: func(_ *fakeRefreshTokenRepo, ts *fakeTokenService, token string) string {
+				ts.payloads[token].UserRole = domain.Admin
+				return token
+			},
+			wantErr: msg.ErrInvalidToken,
+		},
+		{
+			name: "signature rejected",
+			mutate: func(*fakeRefreshTokenRepo, *fakeTokenService, string) string {
+				return "forged"
+			},
+			wantErr: msg.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			as, refreshRepo, _, user := newSessionTestAuthService(t)
+
+			token, _, err := as.CreateSession(ctx, user, testDevice("phone-1"))
+			if err != nil {
+				t.Fatalf("CreateSession: %v", err)
+			}
+			token = tt.mutate(refreshRepo, as.tokenService.(*fakeTokenService), token)
+
+			_, _, _, _, err = as.RefreshSession(ctx, token)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateSessionRevokesPreviousLoginOnDevice(t *testing.T) {
+	ctx := context.Background()
+	as, _, _, user := newSessionTestAuthService(t)
+
+	first, _, err := as.CreateSession(ctx, user, testDevice("phone-1"))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, _, err := as.CreateSession(ctx, user, testDevice("phone-1")); err != nil {
+		t.Fatalf("second CreateSession: %v", err)
+	}
+
+	_, _, _, _, err = as.RefreshSession(ctx, first)
+	if err == nil || err.Error() != msg.ErrInvalidToken {
+		t.Errorf("old login err = %v, want %s", err, msg.ErrInvalidToken)
+	}
+}
+
+func TestCreateSessionRegistersDeviceAndRecordsLogin(t *testing.T) {
+	ctx := context.Background()
+	as, refreshRepo, userRepo, user := newSessionTestAuthService(t)
+	events := as.loginEventService.(*fakeLoginEventService)
+
+	_, familyID, err := as.CreateSession(ctx, user, testDevice("phone-2"))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if len(userRepo.devices) != 2 || userRepo.devices[1].DeviceID != "phone-2" {
+		t.Fatalf("devices = %v, want phone-2 registered", userRepo.devices)
+	}
+	if family := refreshRepo.families[familyID]; family == nil || family.ActiveDeviceID != userRepo.devices[1].ID {
+		t.Error("refresh token family is not bound to the new device")
+	}
+	if len(events.events) != 1 || events.events[0].Outcome != domain.LoginSucceeded ||
+		events.events[0].DeviceID != "phone-2" {
+		t.Fatalf("events = %v, want one successful login on phone-2", events.events)
+	}
+
+	// سقف پیش‌فرض دو دستگاه است
+	_, _, err = as.CreateSession(ctx, user, testDevice("phone-3"))
+	if err == nil || err.Error() != msg.ErrDeviceLimitReached {
+		t.Fatalf("third device err = %v, want %s", err, msg.ErrDeviceLimitReached)
+	}
+	if len(events.events) != 2 || events.events[1].Outcome != domain.LoginFailed {
+		t.Fatalf("events = %v, want the rejected device recorded as failed", events.events)
+	}
+}
+
+func TestCreateSessionReturnsSessionVersionAfterIdlePurge(t *testing.T) {
+	ctx := context.Background()
+	as, _, userRepo, user := newSessionTestAuthService(t)
+	as.appConfig.Device.IdleExpiryDays = 30
+	user.SessionVersion = 3
+	userRepo.sessionVersion = 3
+	userRepo.devices[0].LastSeenAt = time.Now().AddDate(0, 0, -90)
+
+	if _, _, err := as.CreateSession(ctx, user, testDevice("new-phone")); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if user.SessionVersion != 4 {
+		t.Fatalf("session version = %d, want 4 after the idle device was purged", user.SessionVersion)
+	}
+	if len(userRepo.devices) != 1 || userRepo.devices[0].DeviceID != "new-phone" {
+		t.Fatalf("devices = %v, want only the new device", userRepo.devices)
+	}
+}
+
+// fakeSendCodeService نشان می‌دهد کد داخل تراکنش Login فرستاده شده یا بعد از commit
+type fakeSendCodeService struct {
+	port.VerificationCodeService
+	dbms     *txTrackingDBMS
+	sentInTx []bool
+}
+
+func (s *fakeSendCodeService) SendVerificationCode(context.Context, string, string) error {
+	s.sentInTx = append(s.sentInTx, s.dbms.inTx)
+	return nil
+}
+
+func TestLoginSendsCodeAfterCommit(t *testing.T) {
+	dbms := &txTrackingDBMS{}
+	vcService := &fakeSendCodeService{dbms: dbms}
+	user := &domain.User{ID: 7, Phone: "09120000000", Role: domain.Wholesaler, State: domain.ApprovedUser}
+	as := RegisterAuthService(dbms, &fakeUserRepo{user: user}, vcService, nil, nil, nil, nil, config.App{})
+
+	userID, err := as.Login(context.Background(), user.Phone, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if userID != user.ID {
+		t.Errorf("user = %d, want %d", userID, user.ID)
+	}
+	if len(vcService.sentInTx) != 1 || vcService.sentInTx[0] {
+		t.Fatalf("sent in tx = %v, want one send after commit", vcService.sentInTx)
+	}
+}
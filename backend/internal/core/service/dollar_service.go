@@ -6,15 +6,19 @@ import (
 	"errors"
 
 	"io"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/nerkhin/internal/adapter/storage/dbms/repository"
-	"github.com/nerkhin/internal/adapter/storage/util/gormutil"
 	"github.com/nerkhin/internal/core/domain"
 	"github.com/nerkhin/internal/core/port"
 	"github.com/shopspring/decimal"
 )
 
+// تعداد کاربرانی که در هر تراکنش محاسبهٔ مجدد قیمت دلاری پردازش می‌شوند
+const dollarRecomputeBatchSize = 100
+
 type DollarService struct {
 	dbms        port.DBMS
 	repo        *repository.DollarLogRepository
@@ -37,83 +41,145 @@ func RegisterDollarService(
 }
 
 // FetchAndUpdateDollar دریافت قیمت دلار از وب‌سرویس، ثبت لاگ و بروزرسانی قیمت‌ها
-func (s *DollarService) FetchAndUpdateDollar(ctx context.Context) error {
+func (s *DollarService) FetchAndUpdateDollar(ctx context.Context) (*domain.DollarRecomputeRun, error) {
 	resp, err := http.Get("https://webservice.tgnsrv.ir/Pr/Get/nerrkhin1224/n09122751224n")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var payload map[string]interface{}
 	if err := json.Unmarshal(body, &payload); err != nil {
-		return err
+		return nil, err
 	}
 
 	priceVal, ok := payload["Dollar"]
 	if !ok {
-		return errors.New("price not found in response")
+		return nil, errors.New("price not found in response")
 	}
 
 	priceFloat, ok := priceVal.(float64)
 	if !ok {
-		return errors.New("invalid price format")
+		return nil, errors.New("invalid price format")
 	}
 
+	return s.recomputeDollarPrices(ctx, decimal.NewFromFloat(priceFloat))
+}
+
+// recomputeDollarPrices نرخ جدید را ثبت و قیمت محصولات دلاری را دسته‌دسته (هر دسته با تراکنش
+// جداگانه) دوباره حساب می‌کند تا جدول user_product در کل اجرا قفل نماند.
+// خطای یک دسته بقیه را متوقف نمی‌کند؛ کاربران آن دسته تک‌تک تکرار و خطاها ثبت می‌شوند.
+func (s *DollarService) recomputeDollarPrices(ctx context.Context, dollarPrice decimal.Decimal) (
+	run *domain.DollarRecomputeRun, err error) {
+	startedAt := time.Now()
+
 	db, err := s.dbms.NewDB(ctx)
 	if err != nil {
-		return err
+		return
+	}
+
+	run = &domain.DollarRecomputeRun{
+		DollarPrice: dollarPrice,
+		StartedAt:   startedAt,
 	}
 
-	// همه در یک تراکنش
-	return s.dbms.BeginTransaction(ctx, db, func(tx interface{}) error {
+	var userIDs []int64
+	err = s.dbms.BeginTransaction(ctx, db, func(tx interface{}) error {
+		priceFloat, _ := dollarPrice.Float64()
 		if err := s.repo.Insert(ctx, tx, priceFloat, "tgnsrv.ir"); err != nil {
 			return err
 		}
-		if err := s.updateUsersDollar(ctx, tx, priceFloat); err != nil {
+		if err := s.userRepo.UpdateUsersDollarPrice(ctx, tx, dollarPrice); err != nil {
 			return err
 		}
-
-		var users []*domain.User
-		db, err := gormutil.CastToGORM(ctx, tx)
-		if err != nil {
+		if _, err := s.repo.CreateRecomputeRun(ctx, tx, run); err != nil {
 			return err
 		}
-		err = db.Table("user_t").
-			Where("dollar_update = TRUE").
-			Find(&users).Error
-		if err != nil {
-			return err
+
+		userIDs, err = s.userRepo.GetDollarUpdateUserIDs(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	run.UsersCount = len(userIDs)
+	for start := 0; start < len(userIDs); start += dollarRecomputeBatchSize {
+		end := min(start+dollarRecomputeBatchSize, len(userIDs))
+		batch := userIDs[start:end]
+		run.BatchesCount++
+
+		rowsChanged, batchErr := s.recomputeBatch(ctx, db, batch)
+		if batchErr == nil {
+			run.RowsChanged += rowsChanged
+			continue
 		}
-		for _, user := range users {
-			if !user.DollarPrice.Valid {
-				continue
-			}
 
-			// آپدیت نرخ دلار در حافظه
-			user.DollarPrice.Decimal = decimal.NewFromFloat(priceFloat)
+		run.FailedBatches++
+		slog.Error("dollar recompute batch failed", "runId", run.ID,
+			"fromUserId", batch[0], "toUserId", batch[len(batch)-1], "error", batchErr)
 
-			// بروزرسانی قیمت محصولات دلاری کاربر
-			if err := s.userRepo.UpdateDollarPrice(ctx, db, user); err != nil {
+		// تکرار تک‌به‌تک برای جدا کردن کاربر(ان) مشکل‌دار
+		for _, userID := range batch {
+			rowsChanged, userErr := s.recomputeBatch(ctx, db, []int64{userID})
+			if userErr == nil {
+				run.RowsChanged += rowsChanged
 				continue
 			}
+
+			run.FailedUsers++
+			s.recordRecomputeFailure(ctx, db, run.ID, userID, userErr)
 		}
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(startedAt).Milliseconds()
+
+	err = s.dbms.BeginTransaction(ctx, db, func(tx interface{}) error {
+		return s.repo.FinishRecomputeRun(ctx, tx, run)
+	})
+	if err != nil {
+		return run, err
+	}
+
+	slog.Info("dollar recompute finished", "runId", run.ID, "dollarPrice", dollarPrice.String(),
+		"users", run.UsersCount, "batches", run.BatchesCount, "failedBatches", run.FailedBatches,
+		"failedUsers", run.FailedUsers, "rowsChanged", run.RowsChanged, "durationMs", run.DurationMs)
 
-		return nil
+	return run, nil
+}
+
+func (s *DollarService) recomputeBatch(ctx context.Context, db interface{}, userIDs []int64) (
+	rowsChanged int64, err error) {
+	err = s.dbms.BeginTransaction(ctx, db, func(tx interface{}) error {
+		rowsChanged, err = s.userRepo.RecomputeDollarPrices(ctx, tx, userIDs)
+		return err
 	})
+	if err != nil {
+		return 0, err
+	}
+	return rowsChanged, nil
 }
 
-// --- private helper ---
-func (s *DollarService) updateUsersDollar(ctx context.Context, tx interface{}, price float64) error {
-	db, err := gormutil.CastToGORM(ctx, tx)
+func (s *DollarService) recordRecomputeFailure(ctx context.Context, db interface{},
+	runID, userID int64, cause error) {
+	err := s.dbms.BeginTransaction(ctx, db, func(tx interface{}) error {
+		return s.repo.InsertRecomputeFailure(ctx, tx, &domain.DollarRecomputeFailure{
+			RunID:     runID,
+			UserID:    &userID,
+			Error:     cause.Error(),
+			CreatedAt: time.Now(),
+		})
+	})
 	if err != nil {
-		return err
+		slog.Error("failed to record dollar recompute failure", "runId", runID,
+			"userId", userID, "error", err)
 	}
-	// فقط کاربرانی که is_dollar=true هستند
-	return db.Exec(`UPDATE user_t SET dollar_price=?, updated_at=NOW() WHERE dollar_update=TRUE`, price).Error
 }
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// fakeDBMS بدون دیتابیس تراکنش را مستقیم اجرا می‌کند؛ ریپازیتوری‌های جعلی session را نادیده می‌گیرند
+type fakeDBMS struct {
+	port.DBMS
+}
+
+func (fakeDBMS) NewDB(context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+func (fakeDBMS) BeginTransaction(_ context.Context, _ interface{},
+	fn func(txSession interface{}) error) error {
+	return fn(nil)
+}
+
+type fakeUserRepo struct {
+	port.UserRepository
+	user   *domain.User
+	locked []int64
+}
+
+func (r *fakeUserRepo) GetUserByID(context.Context, interface{}, int64) (*domain.User, error) {
+	return r.user, nil
+}
+
+func (r *fakeUserRepo) GetUserByPhone(context.Context, interface{}, string) (*domain.User, error) {
+	if r.user == nil {
+		return nil, errors.New("record not found")
+	}
+	return r.user, nil
+}
+
+func (r *fakeUserRepo) LockUser(_ context.Context, _ interface{}, id int64) error {
+	r.locked = append(r.locked, id)
+	return nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type ImpersonationService struct {
+	dbms             port.DBMS
+	repo             port.ImpersonationRepository
+	userRepo         port.UserRepository
+	notificationRepo port.NotificationRepository
+	tokenService     port.TokenService
+	appConfig        config.App
+}
+
+func RegisterImpersonationService(dbms port.DBMS, repo port.ImpersonationRepository,
+	userRepo port.UserRepository, notificationRepo port.NotificationRepository,
+	tokenService port.TokenService, appConfig config.App) *ImpersonationService {
+	return &ImpersonationService{
+		dbms,
+		repo,
+		userRepo,
+		notificationRepo,
+		tokenService,
+		appConfig,
+	}
+}
+
+// StartImpersonation نشست محدودی برای ورود ادمین به جای کاربر باز می‌کند و توکن آن را برمی‌گرداند.
+// توکن تا پایان نشست یا بستن آن معتبر است؛ ورود به جای ادمین‌ها مجاز نیست.
+func (is *ImpersonationService) StartImpersonation(ctx context.Context, adminID int64,
+	req *domain.ImpersonationRequest) (token string, session *domain.ImpersonationSession,
+	targetUser *domain.User, err error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > domain.ImpersonationReasonMaxLength {
+		return "", nil, nil, errors.New(msg.ErrImpersonationReasonIsNotValid)
+	}
+
+	if req.UserID == adminID {
+		return "", nil, nil, errors.New(msg.ErrOperationNotAllowedForThisUser)
+	}
+
+	db, err := is.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = is.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		user, err := is.userRepo.GetUserByID(ctx, txSession, req.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New(msg.ErrRecordNotFound)
+		}
+		if user.Role == domain.Admin || user.Role == domain.SuperAdmin {
+			return errors.New(msg.ErrCannotImpersonateAdmin)
+		}
+
+		now := time.Now()
+		newSession := &domain.ImpersonationSession{
+			ID:        uuid.New(),
+			AdminID:   adminID,
+			UserID:    user.ID,
+			Reason:    reason,
+			ReadOnly:  req.ReadOnly,
+			ExpiresAt: now.Add(is.sessionDuration(req.DurationMinutes)),
+			CreatedAt: now,
+		}
+		err = is.repo.CreateImpersonationSession(ctx, txSession, newSession)
+		if err != nil {
+			return err
+		}
+
+		token, _, _, err = is.tokenService.CreateImpersonationToken(user, newSession)
+		if err != nil {
+			return err
+		}
+
+		session = newSession
+		targetUser = user
+		return nil
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return token, session, targetUser, nil
+}
+
+// EndImpersonation نشستی را که توکن درخواست برای آن ساخته شده می‌بندد و به کاربر اطلاع می‌دهد
+func (is *ImpersonationService) EndImpersonation(ctx context.Context,
+	payload *domain.TokenPayload) (err error) {
+	if payload.ImpersonationSessionID == nil {
+		return errors.New(msg.ErrNotImpersonating)
+	}
+
+	db, err := is.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return is.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		session, err := is.repo.GetImpersonationSession(ctx, txSession, *payload.ImpersonationSessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return errors.New(msg.ErrNotImpersonating)
+		}
+
+		return is.endSession(ctx, txSession, session, time.Now(), domain.ImpersonationEndedByAdmin)
+	})
+}
+
+// CloseExpiredImpersonations نشست‌هایی که زمانشان تمام شده را می‌بندد تا به کاربرانشان اطلاع داده شود
+func (is *ImpersonationService) CloseExpiredImpersonations(ctx context.Context) (count int, err error) {
+	db, err := is.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = is.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		sessions, err := is.repo.GetExpiredImpersonationSessions(ctx, txSession, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			err = is.endSession(ctx, txSession, session, session.ExpiresAt, domain.ImpersonationExpired)
+			if err != nil {
+				return err
+			}
+		}
+
+		count = len(sessions)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// endSession نشست باز را می‌بندد و به کاربری که ادمین به جای او وارد شده بود اطلاع می‌دهد
+func (is *ImpersonationService) endSession(ctx context.Context, txSession interface{},
+	session *domain.ImpersonationSession, endedAt time.Time, reason domain.ImpersonationEndReason) error {
+	if endedAt.After(session.ExpiresAt) {
+		endedAt = session.ExpiresAt
+	}
+
+	ended, err := is.repo.EndImpersonationSession(ctx, txSession, session.ID, endedAt, reason)
+	if err != nil || !ended {
+		return err
+	}
+
+	minutes := int(math.Ceil(endedAt.Sub(session.CreatedAt).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	mode := "با امکان انجام تغییرات"
+	if session.ReadOnly {
+		mode = "فقط برای مشاهده"
+	}
+
+	_, err = is.notificationRepo.CreateNotification(ctx, txSession, &domain.Notification{
+		UserID: session.UserID,
+		Type:   domain.NotificationImpersonation,
+		Title:  "ورود پشتیبانی به حساب شما",
+		Body: fmt.Sprintf("پشتیبانی نرخین به مدت %d دقیقه و %s به جای شما وارد حساب شد. دلیل: %s",
+			minutes, mode, session.Reason),
+	})
+	return err
+}
+
+// sessionDuration مدت درخواستی را به سقف تنظیمات محدود می‌کند؛ صفر یعنی مدت پیش‌فرض
+func (is *ImpersonationService) sessionDuration(minutes int) time.Duration {
+	if minutes <= 0 {
+		minutes = is.appConfig.Impersonation.DefaultMinutes
+	}
+	if maxMinutes := is.appConfig.Impersonation.MaxMinutes; maxMinutes > 0 && minutes > maxMinutes {
+		minutes = maxMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kavenegar/kavenegar-go"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+const loginEventsFetchLimit = 100
+
+type LoginEventService struct {
+	dbms             port.DBMS
+	repo             port.LoginEventRepository
+	userRepo         port.UserRepository
+	notificationRepo port.NotificationRepository
+	appConfig        config.App
+}
+
+func RegisterLoginEventService(dbms port.DBMS, repo port.LoginEventRepository,
+	userRepo port.UserRepository, notificationRepo port.NotificationRepository,
+	appConfig config.App) *LoginEventService {
+	return &LoginEventService{
+		dbms,
+		repo,
+		userRepo,
+		notificationRepo,
+		appConfig,
+	}
+}
+
+// RecordLoginEvent تلاش ورود را ثبت می‌کند. ورود موفق از دستگاه یا IP ناآشنا برای صاحب حساب
+// اعلان و در صورت فعال بودن پیامک می‌شود؛ اولین ورود کاربر مشکوک حساب نمی‌شود.
+func (les *LoginEventService) RecordLoginEvent(ctx context.Context, event *domain.LoginEvent) (err error) {
+	db, err := les.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var user *domain.User
+	err = les.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		if event.Outcome == domain.LoginSucceeded {
+			since := time.Now().AddDate(0, 0, -les.appConfig.LoginAlert.LookbackDays)
+			stats, err := les.repo.GetLoginHistoryStats(ctx, txSession, event.UserID,
+				event.DeviceID, event.IPAddress, since)
+			if err != nil {
+				return err
+			}
+
+			if stats.Total > 0 {
+				event.NewDevice = stats.DeviceCount == 0
+				event.NewIP = stats.IPCount == 0
+			}
+		}
+
+		err := les.repo.CreateLoginEvent(ctx, txSession, event)
+		if err != nil {
+			return err
+		}
+
+		if !event.NewDevice && !event.NewIP {
+			return nil
+		}
+
+		_, err = les.notificationRepo.CreateNotification(ctx, txSession, &domain.Notification{
+			UserID: event.UserID,
+			Type:   domain.NotificationSuspiciousLogin,
+			Title:  "ورود جدید به حساب شما",
+			Body: fmt.Sprintf("ورود از %s با آدرس IP %s انجام شد. اگر این ورود کار شما نبوده، "+
+				"از بخش دستگاه‌ها آن را خارج کنید.", loginEventDeviceLabel(event), event.IPAddress),
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err = les.userRepo.GetUserByID(ctx, txSession, event.UserID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	if user == nil || !les.appConfig.LoginAlert.SmsEnabled || user.Phone == "" {
+		return nil
+	}
+
+	if errSend := les.sendLoginAlertSms(user.Phone, event); errSend != nil {
+		slog.Error("failed to send login alert sms", "userId", user.ID, "error", errSend)
+	}
+
+	return nil
+}
+
+func (les *LoginEventService) GetLoginEvents(ctx context.Context, userID int64) (
+	events []*domain.LoginEvent, err error) {
+	db, err := les.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = les.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		events, err = les.repo.GetLoginEvents(ctx, txSession, userID, loginEventsFetchLimit)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return events, nil
+}
+
+func (les *LoginEventService) sendLoginAlertSms(phone string, event *domain.LoginEvent) error {
+	api := kavenegar.New(les.appConfig.SmsApiKey)
+	params := &kavenegar.VerifyLookupParam{}
+
+	_, err := api.Verify.Lookup(phone, les.appConfig.LoginAlert.SmsTemplate, event.IPAddress, params)
+	return err
+}
+
+func loginEventDeviceLabel(event *domain.LoginEvent) string {
+	if event.NewDevice {
+		if event.UserAgent != "" {
+			return "دستگاه جدید (" + event.UserAgent + ")"
+		}
+		return "دستگاه جدید"
+	}
+	return "یکی از دستگاه‌های شما"
+}
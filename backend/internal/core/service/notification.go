@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/port"
+)
+
+const notificationsFetchLimit = 50
+
+type NotificationService struct {
+	dbms port.DBMS
+	repo port.NotificationRepository
+}
+
+func RegisterNotificationService(dbms port.DBMS,
+	repo port.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		dbms,
+		repo,
+	}
+}
+
+func (ns *NotificationService) GetNotifications(ctx context.Context, userID int64) (
+	notifications []*domain.Notification, err error) {
+	db, err := ns.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ns.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		notifications, err = ns.repo.GetNotifications(ctx, txSession, userID, notificationsFetchLimit)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return notifications, nil
+}
+
+func (ns *NotificationService) MarkNotificationRead(ctx context.Context, userID, id int64) (err error) {
+	db, err := ns.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ns.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		return ns.repo.MarkNotificationRead(ctx, txSession, userID, id)
+	})
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	priceListShareMaxPinAttempts = 5
+	priceListSharePinLockTime    = 15 * time.Minute
+	priceListShareAutoLinkTitle  = "لینک QR فایل لیست قیمت"
+)
+
+var priceListSharePinPattern = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+type PriceListShareService struct {
+	dbms               port.DBMS
+	repo               port.PriceListShareRepository
+	priceListTplRepo   port.PriceListTemplateRepository
+	userRepo           port.UserRepository
+	userSubRepo        port.UserSubscriptionRepository
+	userProductService port.UserProductService
+	appConfig          config.App
+	signingKey         []byte
+}
+
+func RegisterPriceListShareService(dbms port.DBMS, repo port.PriceListShareRepository,
+	priceListTplRepo port.PriceListTemplateRepository, userRepo port.UserRepository,
+	userSubRepo port.UserSubscriptionRepository, userProductService port.UserProductService,
+	appConfig config.App) (*PriceListShareService, error) {
+	signingKey, err := config.DecodeKeyHex("PRICE_LIST_SHARE_SIGNING_KEY",
+		appConfig.PriceListShare.SigningKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceListShareService{
+		dbms,
+		repo,
+		priceListTplRepo,
+		userRepo,
+		userSubRepo,
+		userProductService,
+		appConfig,
+		signingKey,
+	}, nil
+}
+
+func (pss *PriceListShareService) GetPriceListShareLinks(ctx context.Context, userID int64) (
+	links []*domain.PriceListShareLink, err error) {
+	db, err := pss.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = pss.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		links, err = pss.repo.GetPriceListShareLinks(ctx, txSession, userID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	for _, link := range links {
+		pss.fillShareLink(link)
+	}
+
+	return links, nil
+}
+
+func (pss *PriceListShareService) CreatePriceListShareLink(ctx context.Context,
+	link *domain.PriceListShareLink, ttlHours int, pin string) (
+	created *domain.PriceListShareLink, err error) {
+	db, err := pss.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	if ttlHours == 0 {
+		ttlHours = pss.appConfig.PriceListShare.DefaultTTLHours
+	}
+	if ttlHours < 1 || ttlHours > pss.appConfig.PriceListShare.MaxTTLHours {
+		return nil, errors.New(msg.ErrPriceListShareLinkTTLIsNotValid)
+	}
+
+	link.Title = strings.TrimSpace(link.Title)
+	link.IsAuto = false
+	link.ExpiresAt = time.Now().Add(time.Duration(ttlHours) * time.Hour).Truncate(time.Second)
+
+	pin = strings.TrimSpace(pin)
+	if pin != "" {
+		if !priceListSharePinPattern.MatchString(pin) {
+			return nil, errors.New(msg.ErrPriceListSharePinIsNotValid)
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		pinHash := string(hash)
+		link.PinHash = &pinHash
+	}
+
+	err = pss.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err := pss.checkTemplateOwnership(ctx, txSession, link.UserID, link.TemplateID)
+		if err != nil {
+			return err
+		}
+
+		_, err = pss.repo.CreatePriceListShareLink(ctx, txSession, link)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	pss.fillShareLink(link)
+	return link, nil
+}
+
+func (pss *PriceListShareService) RevokePriceListShareLink(ctx context.Context, userID, id int64) (
+	err error) {
+	db, err := pss.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return pss.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		link, err := pss.repo.GetPriceListShareLinkByID(ctx, txSession, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if link == nil {
+			return errors.New(msg.ErrPriceListShareLinkDoesNotExist)
+		}
+
+		return pss.repo.RevokePriceListShareLink(ctx, txSession, userID, id)
+	})
+}
+
+// GetPriceListShareURL لینکی که QR فایل‌های لیست قیمت به آن اشاره می‌کند.
+// با linkID صفر یک لینک خودکار و بدون رمز برای همان قالب استفاده می‌شود و اگر تا نیمهٔ
+// مدت اعتبار پیش‌فرض منقضی شود لینک تازه‌ای ساخته می‌شود تا QR فایل‌های چاپی زود از کار نیفتد.
+func (pss *PriceListShareService) GetPriceListShareURL(ctx context.Context,
+	userID, templateID, linkID int64) (url string, err error) {
+	db, err := pss.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var link *domain.PriceListShareLink
+	err = pss.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		if linkID > 0 {
+			link, err = pss.repo.GetPriceListShareLinkByID(ctx, txSession, userID, linkID)
+			if err != nil {
+				return err
+			}
+
+			if link == nil {
+				return errors.New(msg.ErrPriceListShareLinkDoesNotExist)
+			}
+
+			if !link.IsActive(time.Now()) {
+				return errors.New(msg.ErrPriceListShareLinkIsNotValid)
+			}
+
+			return nil
+		}
+
+		var tplID *int64
+		if templateID > 0 {
+			tplID = &templateID
+		}
+
+		err := pss.checkTemplateOwnership(ctx, txSession, userID, tplID)
+		if err != nil {
+			return err
+		}
+
+		ttl := time.Duration(pss.appConfig.PriceListShare.DefaultTTLHours) * time.Hour
+		now := time.Now()
+		link, err = pss.repo.GetAutoPriceListShareLink(ctx, txSession, userID, tplID, now.Add(ttl/2))
+		if err != nil || link != nil {
+			return err
+		}
+
+		link = &domain.PriceListShareLink{
+			UserID:     userID,
+			TemplateID: tplID,
+			Title:      priceListShareAutoLinkTitle,
+			IsAuto:     true,
+			ExpiresAt:  now.Add(ttl).Truncate(time.Second),
+		}
+		_, err = pss.repo.CreatePriceListShareLink(ctx, txSession, link)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	pss.fillShareLink(link)
+	return link.URL, nil
+}
+
+// OpenSharedPriceList لیست قیمت فقط‌خواندنی را برای بازدیدکنندهٔ بدون ورود برمی‌گرداند.
+// لینک نامعتبر، منقضی و لغوشده پیام یکسانی دارند تا وجود لینک لو نرود.
+// قفل رمز به ازای لینک و IP است تا دارندهٔ آدرس نتواند با رمزهای اشتباه گیرندهٔ اصلی را بیرون نگه دارد.
+func (pss *PriceListShareService) OpenSharedPriceList(ctx context.Context, token, pin, ipAddress string) (
+	priceList *domain.ShopViewModel, err error) {
+	linkID, expiresAt, ok := pss.parseShareToken(token)
+	if !ok || !time.Now().Before(expiresAt) {
+		return nil, errors.New(msg.ErrPriceListShareLinkIsNotValid)
+	}
+
+	db, err := pss.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var link *domain.PriceListShareLink
+	// خطای رمز بعد از commit برگردانده می‌شود تا شمارش تلاش‌های ناموفق rollback نشود
+	var pinErr error
+	err = pss.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		link, err = pss.repo.LockPriceListShareLink(ctx, txSession, linkID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if link == nil || !link.IsActive(now) {
+			return errors.New(msg.ErrPriceListShareLinkIsNotValid)
+		}
+
+		err = pss.checkShopIsPublishing(ctx, txSession, link.UserID)
+		if err != nil {
+			return err
+		}
+
+		if link.PinHash != nil {
+			pinErr = pss.checkPin(ctx, txSession, link, strings.TrimSpace(pin), ipAddress, now)
+			if pinErr != nil {
+				return nil
+			}
+		}
+
+		return pss.repo.RecordPriceListShareView(ctx, txSession, link.ID)
+	})
+	if err != nil {
+		return
+	}
+	if pinErr != nil {
+		return nil, pinErr
+	}
+
+	var templateID int64
+	if link.TemplateID != nil {
+		templateID = *link.TemplateID
+	}
+
+	priceList, err = pss.userProductService.GetPriceList(ctx, link.UserID, templateID)
+	if err != nil {
+		return
+	}
+
+	pss.fillShareLink(link)
+	priceList.ShareURL = link.URL
+
+	return publicPriceList(priceList), nil
+}
+
+func (pss *PriceListShareService) checkPin(ctx context.Context, txSession interface{},
+	link *domain.PriceListShareLink, pin, ipAddress string, now time.Time) error {
+	attempt, err := pss.repo.GetPriceListSharePinAttempt(ctx, txSession, link.ID, ipAddress)
+	if err != nil {
+		return err
+	}
+	if attempt != nil && attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return errors.New(msg.ErrPriceListSharePinLocked)
+	}
+
+	if pin == "" {
+		return errors.New(msg.ErrPriceListSharePinRequired)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(*link.PinHash), []byte(pin)) == nil {
+		if attempt == nil {
+			return nil
+		}
+		return pss.repo.DeletePriceListSharePinAttempt(ctx, txSession, link.ID, ipAddress)
+	}
+
+	if attempt == nil {
+		attempt = &domain.PriceListSharePinAttempt{LinkID: link.ID, IPAddress: ipAddress}
+	}
+	attempt.FailedAttempts++
+	attempt.LockedUntil = nil
+	attempt.UpdatedAt = now
+	if attempt.FailedAttempts >= priceListShareMaxPinAttempts {
+		until := now.Add(priceListSharePinLockTime)
+		attempt.LockedUntil = &until
+		attempt.FailedAttempts = 0
+	}
+
+	err = pss.repo.SavePriceListSharePinAttempt(ctx, txSession, attempt)
+	if err != nil {
+		return err
+	}
+
+	return errors.New(msg.ErrPriceListSharePinIsWrong)
+}
+
+// checkShopIsPublishing لینک فروشگاهی که تاییدش را از دست داده یا اشتراک فعال ندارد، مثل لینک نامعتبر رفتار می‌کند
+func (pss *PriceListShareService) checkShopIsPublishing(ctx context.Context, txSession interface{},
+	shopID int64) error {
+	shop, err := pss.userRepo.GetUserByID(ctx, txSession, shopID)
+	if err != nil {
+		return err
+	}
+	if shop.State != domain.ApprovedUser {
+		return errors.New(msg.ErrPriceListShareLinkIsNotValid)
+	}
+
+	cityIDs, err := pss.userSubRepo.GetAllowedCities(ctx, txSession, shopID)
+	if err != nil {
+		return err
+	}
+	if len(cityIDs) == 0 {
+		return errors.New(msg.ErrPriceListShareLinkIsNotValid)
+	}
+
+	return nil
+}
+
+func (pss *PriceListShareService) checkTemplateOwnership(ctx context.Context, txSession interface{},
+	userID int64, templateID *int64) error {
+	if templateID == nil {
+		return nil
+	}
+
+	template, err := pss.priceListTplRepo.GetPriceListTemplateByID(ctx, txSession, userID, *templateID)
+	if err != nil {
+		return err
+	}
+
+	if template == nil {
+		return errors.New(msg.ErrPriceListTemplateDoesNotExist)
+	}
+
+	return nil
+}
+
+func (pss *PriceListShareService) fillShareLink(link *domain.PriceListShareLink) {
+	link.HasPin = link.PinHash != nil
+	link.Token = pss.shareToken(link.ID, link.ExpiresAt)
+	link.URL = pss.appConfig.PriceListShare.BaseURL + link.Token
+}
+
+// shareToken توکن به شکل «شناسه.انقضا.امضا»؛ امضا HMAC-SHA256 کوتاه‌شده است
+func (pss *PriceListShareService) shareToken(id int64, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", id, expiresAt.Unix())
+	return payload + "." + pss.signShareToken(payload)
+}
+
+func (pss *PriceListShareService) parseShareToken(token string) (
+	id int64, expiresAt time.Time, ok bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(pss.signShareToken(payload))) {
+		return 0, time.Time{}, false
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	return id, time.Unix(unix, 0), true
+}
+
+func (pss *PriceListShareService) signShareToken(payload string) string {
+	mac := hmac.New(sha256.New, pss.signingKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:18])
+}
+
+// publicPriceList اطلاعات داخلی فروشگاه (شماره ورود، نقش، هزینه‌ها و ...) را از خروجی عمومی حذف می‌کند
+func publicPriceList(priceList *domain.ShopViewModel) *domain.ShopViewModel {
+	if shop := priceList.ShopInfo; shop != nil {
+		priceList.ShopInfo = &domain.User{
+			ID:           shop.ID,
+			ShopName:     shop.ShopName,
+			ShopAddress:  shop.ShopAddress,
+			ShopPhone1:   shop.ShopPhone1,
+			ShopPhone2:   shop.ShopPhone2,
+			ShopPhone3:   shop.ShopPhone3,
+			InstagramUrl: shop.InstagramUrl,
+			TelegramUrl:  shop.TelegramUrl,
+			WhatsappUrl:  shop.WhatsappUrl,
+			WebsiteUrl:   shop.WebsiteUrl,
+			ImageUrl:     shop.ImageUrl,
+		}
+	}
+
+	showDollar := priceList.Template != nil && priceList.Template.ShowDollarPrice
+	for _, product := range priceList.Products {
+		product.OtherCosts = decimal.NullDecimal{}
+		if !showDollar {
+			product.DollarPrice = decimal.NullDecimal{}
+		}
+	}
+
+	return priceList
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testShareSigningKeyHex = "8f0c2b1d4e6a7f90123456789abcdef08f0c2b1d4e6a7f90123456789abcdef0"
+
+type fakePriceListShareRepo struct {
+	port.PriceListShareRepository
+	link     *domain.PriceListShareLink
+	views    int
+	attempts map[string]*domain.PriceListSharePinAttempt
+}
+
+func (r *fakePriceListShareRepo) LockPriceListShareLink(context.Context, interface{}, int64) (
+	*domain.PriceListShareLink, error) {
+	return r.link, nil
+}
+
+func (r *fakePriceListShareRepo) RecordPriceListShareView(context.Context, interface{}, int64) error {
+	r.views++
+	return nil
+}
+
+func (r *fakePriceListShareRepo) GetPriceListSharePinAttempt(_ context.Context, _ interface{}, _ int64,
+	ipAddress string) (*domain.PriceListSharePinAttempt, error) {
+	return r.attempts[ipAddress], nil
+}
+
+func (r *fakePriceListShareRepo) SavePriceListSharePinAttempt(_ context.Context, _ interface{},
+	attempt *domain.PriceListSharePinAttempt) error {
+	r.attempts[attempt.IPAddress] = attempt
+	return nil
+}
+
+func (r *fakePriceListShareRepo) DeletePriceListSharePinAttempt(_ context.Context, _ interface{}, _ int64,
+	ipAddress string) error {
+	delete(r.attempts, ipAddress)
+	return nil
+}
+
+type fakeUserSubscriptionRepo struct {
+	port.UserSubscriptionRepository
+	cityIDs []int64
+}
+
+func (r *fakeUserSubscriptionRepo) GetAllowedCities(context.Context, interface{}, int64) (
+	[]int64, error) {
+	return r.cityIDs, nil
+}
+
+type fakeUserProductService struct {
+	port.UserProductService
+}
+
+func (fakeUserProductService) GetPriceList(context.Context, int64, int64) (
+	*domain.ShopViewModel, error) {
+	return &domain.ShopViewModel{ShopInfo: &domain.User{ID: 7}}, nil
+}
+
+func newPriceListShareTestService(t *testing.T, link *domain.PriceListShareLink, shop *domain.User,
+	cityIDs []int64) (*PriceListShareService, *fakePriceListShareRepo) {
+	t.Helper()
+	repo := &fakePriceListShareRepo{link: link, attempts: map[string]*domain.PriceListSharePinAttempt{}}
+	appConfig := config.App{PriceListShare: config.PriceListShareConfig{
+		BaseURL:       "https://example.com/price-list/",
+		SigningKeyHex: testShareSigningKeyHex,
+	}}
+
+	pss, err := RegisterPriceListShareService(fakeDBMS{}, repo, nil, &fakeUserRepo{user: shop},
+		&fakeUserSubscriptionRepo{cityIDs: cityIDs}, fakeUserProductService{}, appConfig)
+	if err != nil {
+		t.Fatalf("RegisterPriceListShareService: %v", err)
+	}
+	return pss, repo
+}
+
+func TestRegisterPriceListShareServiceRequiresSigningKey(t *testing.T) {
+	for _, keyHex := range []string{"", "not-hex", "abcd"} {
+		appConfig := config.App{
+			Token:          config.TokenConfig{SymmetricKeyHex: testShareSigningKeyHex},
+			PriceListShare: config.PriceListShareConfig{SigningKeyHex: keyHex},
+		}
+		_, err := RegisterPriceListShareService(fakeDBMS{}, nil, nil, nil, nil, nil, appConfig)
+		if err == nil {
+			t.Fatalf("signing key %q: want error", keyHex)
+		}
+	}
+}
+
+func TestPriceListShareToken(t *testing.T) {
+	pss, _ := newPriceListShareTestService(t, nil, nil, nil)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := pss.shareToken(42, expiresAt)
+
+	id, gotExpiresAt, ok := pss.parseShareToken(token)
+	if !ok || id != 42 || !gotExpiresAt.Equal(expiresAt) {
+		t.Fatalf("parseShareToken = %d, %v, %v", id, gotExpiresAt, ok)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := []string{
+		"43." + parts[1] + "." + parts[2],
+		parts[0] + "." + parts[1] + "9." + parts[2],
+		parts[0] + "." + parts[1],
+		"",
+	}
+	for _, token := range tampered {
+		if _, _, ok := pss.parseShareToken(token); ok {
+			t.Fatalf("tampered token %q was accepted", token)
+		}
+	}
+}
+
+func TestOpenSharedPriceListChecksShopStatus(t *testing.T) {
+	approved := &domain.User{ID: 7, State: domain.ApprovedUser}
+	tests := []struct {
+		name    string
+		shop    *domain.User
+		cityIDs []int64
+		wantErr string
+	}{
+		{name: "approved shop with subscription", shop: approved, cityIDs: []int64{1}},
+		{
+			name:    "shop lost approval",
+			shop:    &domain.User{ID: 7, State: domain.InactiveShop},
+			cityIDs: []int64{1},
+			wantErr: msg.ErrPriceListShareLinkIsNotValid,
+		},
+		{
+			name:    "shop subscription expired",
+			shop:    approved,
+			wantErr: msg.ErrPriceListShareLinkIsNotValid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &domain.PriceListShareLink{
+				ID:        3,
+				UserID:    7,
+				ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+			}
+			pss, repo := newPriceListShareTestService(t, link, tt.shop, tt.cityIDs)
+
+			_, err := pss.OpenSharedPriceList(context.Background(),
+				pss.shareToken(link.ID, link.ExpiresAt), "", "127.0.0.1")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("OpenSharedPriceList: %v", err)
+				}
+				if repo.views != 1 {
+					t.Fatalf("views = %d, want 1", repo.views)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if repo.views != 0 {
+				t.Fatalf("view recorded for an unpublished shop")
+			}
+		})
+	}
+}
+
+func TestOpenSharedPriceListLocksPinPerClient(t *testing.T) {
+	pinHash, err := bcrypt.GenerateFromPassword([]byte("1234"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	hash := string(pinHash)
+	link := &domain.PriceListShareLink{
+		ID:        3,
+		UserID:    7,
+		PinHash:   &hash,
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	pss, repo := newPriceListShareTestService(t, link,
+		&domain.User{ID: 7, State: domain.ApprovedUser}, []int64{1})
+	token := pss.shareToken(link.ID, link.ExpiresAt)
+	ctx := context.Background()
+
+	for attempt := 1; attempt <= priceListShareMaxPinAttempts; attempt++ {
+		_, err := pss.OpenSharedPriceList(ctx, token, "0000", "10.0.0.1")
+		if err == nil || err.Error() != msg.ErrPriceListSharePinIsWrong {
+			t.Fatalf("attempt %d error = %v, want %q", attempt, err, msg.ErrPriceListSharePinIsWrong)
+		}
+	}
+
+	_, err = pss.OpenSharedPriceList(ctx, token, "1234", "10.0.0.1")
+	if err == nil || err.Error() != msg.ErrPriceListSharePinLocked {
+		t.Fatalf("locked client error = %v, want %q", err, msg.ErrPriceListSharePinLocked)
+	}
+
+	// قفل یک IP گیرندهٔ اصلی را بیرون نگه نمی‌دارد
+	if _, err := pss.OpenSharedPriceList(ctx, token, "1234", "10.0.0.2"); err != nil {
+		t.Fatalf("other client: %v", err)
+	}
+	if repo.views != 1 {
+		t.Errorf("views = %d, want 1", repo.views)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+)
+
+var maxPriceListMarkupPercent = decimal.NewFromInt(1000)
+
+type PriceListTemplateService struct {
+	dbms port.DBMS
+	repo port.PriceListTemplateRepository
+}
+
+func RegisterPriceListTemplateService(dbms port.DBMS,
+	repo port.PriceListTemplateRepository) *PriceListTemplateService {
+	return &PriceListTemplateService{
+		dbms,
+		repo,
+	}
+}
+
+func (pts *PriceListTemplateService) GetPriceListTemplates(ctx context.Context, userID int64) (
+	templates []*domain.PriceListTemplate, err error) {
+	db, err := pts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = pts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		templates, err = pts.repo.GetPriceListTemplates(ctx, txSession, userID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return templates, nil
+}
+
+func (pts *PriceListTemplateService) GetPriceListTemplate(ctx context.Context, userID, id int64) (
+	template *domain.PriceListTemplate, err error) {
+	db, err := pts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = pts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		template, err = pts.repo.GetPriceListTemplateByID(ctx, txSession, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if template == nil {
+			return errors.New(msg.ErrPriceListTemplateDoesNotExist)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	return template, nil
+}
+
+func (pts *PriceListTemplateService) CreatePriceListTemplate(ctx context.Context,
+	template *domain.PriceListTemplate) (id int64, err error) {
+	db, err := pts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = pts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err = validatePriceListTemplate(ctx, template)
+		if err != nil {
+			return err
+		}
+
+		if template.IsDefault {
+			err = pts.repo.ClearDefaultPriceListTemplate(ctx, txSession, template.UserID, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		id, err = pts.repo.CreatePriceListTemplate(ctx, txSession, template)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return id, nil
+}
+
+func (pts *PriceListTemplateService) UpdatePriceListTemplate(ctx context.Context,
+	template *domain.PriceListTemplate) (err error) {
+	db, err := pts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return pts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err = validatePriceListTemplate(ctx, template)
+		if err != nil {
+			return err
+		}
+
+		existing, err := pts.repo.GetPriceListTemplateByID(ctx, txSession, template.UserID, template.ID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			return errors.New(msg.ErrPriceListTemplateDoesNotExist)
+		}
+
+		if template.IsDefault {
+			err = pts.repo.ClearDefaultPriceListTemplate(ctx, txSession, template.UserID, template.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return pts.repo.UpdatePriceListTemplate(ctx, txSession, template)
+	})
+}
+
+func (pts *PriceListTemplateService) DeletePriceListTemplate(ctx context.Context,
+	userID, id int64) (err error) {
+	db, err := pts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return pts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		return pts.repo.DeletePriceListTemplate(ctx, txSession, userID, id)
+	})
+}
+
+// validatePriceListTemplate مقادیر خالی را با مقدار پیش‌فرض پر و بقیه را اعتبارسنجی می‌کند
+func validatePriceListTemplate(_ context.Context, template *domain.PriceListTemplate) error {
+	if template == nil || template.UserID < 1 {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	template.Title = strings.TrimSpace(template.Title)
+	if template.Title == "" {
+		return errors.New(msg.ErrPriceListTemplateTitleIsNotValid)
+	}
+
+	defaults := domain.DefaultPriceListTemplate()
+	if template.GroupBy == "" {
+		template.GroupBy = defaults.GroupBy
+	}
+	if template.SortBy == "" {
+		template.SortBy = defaults.SortBy
+	}
+	if template.SortDir == "" {
+		template.SortDir = defaults.SortDir
+	}
+	if template.PaperSize == "" {
+		template.PaperSize = defaults.PaperSize
+	}
+
+	if !domain.IsPriceListGroupByValid(template.GroupBy) {
+		return errors.New(msg.ErrPriceListGroupByIsNotValid)
+	}
+
+	if !domain.IsPriceListSortValid(template.SortBy) ||
+		(template.SortDir != domain.SortAsc && template.SortDir != domain.SortDesc) {
+		return errors.New(msg.ErrPriceListSortIsNotValid)
+	}
+
+	if !domain.IsPaperSizeValid(template.PaperSize) {
+		return errors.New(msg.ErrPaperSizeIsNotValid)
+	}
+
+	if template.MarkupPercent.Valid && (template.MarkupPercent.Decimal.IsNegative() ||
+		template.MarkupPercent.Decimal.GreaterThan(maxPriceListMarkupPercent)) {
+		return errors.New(msg.ErrPriceListMarkupIsNotValid)
+	}
+
+	return nil
+}
@@ -91,11 +91,15 @@ func (pfs *ProductFilterService) CreateProductFilterOption(ctx context.Context,
 		return
 	}
 
-	id, err := pfs.repo.CreateProductFilterOption(ctx, db, filterOption)
+	// داخل تراکنش تا تغییر در سابقهٔ ادمین (audit_change) ثبت شود
+	err = pfs.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		ID, err = pfs.repo.CreateProductFilterOption(ctx, txSession, filterOption)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
-	return id, nil
+	return ID, nil
 }
 func (pfs *ProductFilterService) UpdateProductFilter(ctx context.Context,
 	updatedFilterData *domain.ProductFilterData) (err error) {
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type RoundingRuleService struct {
+	dbms port.DBMS
+	repo port.RoundingRuleRepository
+}
+
+func RegisterRoundingRuleService(dbms port.DBMS,
+	repo port.RoundingRuleRepository) *RoundingRuleService {
+	return &RoundingRuleService{
+		dbms,
+		repo,
+	}
+}
+
+func (rrs *RoundingRuleService) GetRoundingRules(ctx context.Context, userID int64) (
+	rules []*domain.RoundingRule, err error) {
+	db, err := rrs.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = rrs.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		rules, err = rrs.repo.GetRoundingRules(ctx, txSession, userID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return rules, nil
+}
+
+func (rrs *RoundingRuleService) SaveRoundingRule(ctx context.Context,
+	rule *domain.RoundingRule) (id int64, err error) {
+	db, err := rrs.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = rrs.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err = validateRoundingRule(ctx, rule)
+		if err != nil {
+			return err
+		}
+
+		id, err = rrs.repo.SaveRoundingRule(ctx, txSession, rule)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return id, nil
+}
+
+func (rrs *RoundingRuleService) DeleteRoundingRule(ctx context.Context, userID, id int64) (err error) {
+	db, err := rrs.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return rrs.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		return rrs.repo.DeleteRoundingRule(ctx, txSession, userID, id)
+	})
+}
+
+func validateRoundingRule(_ context.Context, rule *domain.RoundingRule) error {
+	if rule == nil || rule.UserID < 1 {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	if rule.CategoryID != nil && *rule.CategoryID < 1 {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	if !rule.Step.IsPositive() {
+		return errors.New(msg.ErrRoundingStepIsNotValid)
+	}
+
+	if !domain.IsRoundingDirectionValid(rule.Direction) {
+		return errors.New(msg.ErrRoundingDirectionIsNotValid)
+	}
+
+	// پایانهٔ روانی باید کوچکتر از گام باشد؛ مثلا گام ۱۰۰۰ و پایانهٔ ۹۰۰
+	if rule.Ending.IsNegative() || rule.Ending.GreaterThanOrEqual(rule.Step) {
+		return errors.New(msg.ErrRoundingEndingIsNotValid)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// SessionService در هر درخواست نسخهٔ نشست access token را با user_t.session_version مقایسه می‌کند.
+// تریگرهای دیتابیس نسخه را با تغییر نقش، وضعیت یا دسترسی ادمین بالا می‌برند، پس کلاینت باید با
+// refresh token، access token تازه بگیرد. خروج یا حذف یک دستگاه فقط خانوادهٔ refresh token همان
+// دستگاه را از بین می‌برد و access tokenهای دستگاه‌های دیگر معتبر می‌مانند.
+type SessionService struct {
+	dbms              port.DBMS
+	userRepo          port.UserRepository
+	refreshTokenRepo  port.RefreshTokenRepository
+	impersonationRepo port.ImpersonationRepository
+}
+
+func RegisterSessionService(dbms port.DBMS, userRepo port.UserRepository,
+	refreshTokenRepo port.RefreshTokenRepository,
+	impersonationRepo port.ImpersonationRepository) *SessionService {
+	return &SessionService{
+		dbms:              dbms,
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		impersonationRepo: impersonationRepo,
+	}
+}
+
+func (ss *SessionService) ValidateSession(ctx context.Context, payload *domain.TokenPayload) error {
+	db, err := ss.dbms.NewDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	version, err := ss.userRepo.GetUserSessionVersion(ctx, db, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 || version != payload.SessionVersion {
+		return errors.New(msg.ErrSessionIsRevoked)
+	}
+
+	// توکن جعل هویت فقط تا وقتی نشستش باز است معتبر است
+	if payload.ImpersonatorAdminID != nil {
+		if payload.ImpersonationSessionID == nil {
+			return errors.New(msg.ErrSessionIsRevoked)
+		}
+
+		session, err := ss.impersonationRepo.GetImpersonationSession(ctx, db, *payload.ImpersonationSessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil || !session.IsActive(time.Now()) {
+			return errors.New(msg.ErrSessionIsRevoked)
+		}
+		return nil
+	}
+
+	// خانوادهٔ حذف‌شده (خروج یا حذف دستگاه) یا باطل‌شده (ورود دوباره یا استفادهٔ دوباره از توکن)
+	family, err := ss.refreshTokenRepo.GetRefreshTokenFamily(ctx, db, payload.FamilyID)
+	if err != nil {
+		return err
+	}
+	if family == nil || family.RevokedAt != nil || family.UserID != payload.UserID {
+		return errors.New(msg.ErrSessionIsRevoked)
+	}
+
+	return nil
+}
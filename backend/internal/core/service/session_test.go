@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type fakeSessionVersionRepo struct {
+	port.UserRepository
+	version int64
+}
+
+func (r *fakeSessionVersionRepo) GetUserSessionVersion(context.Context, interface{}, int64) (int64, error) {
+	return r.version, nil
+}
+
+type fakeImpersonationRepo struct {
+	port.ImpersonationRepository
+	sessions map[uuid.UUID]*domain.ImpersonationSession
+}
+
+func (r *fakeImpersonationRepo) GetImpersonationSession(_ context.Context, _ interface{},
+	id uuid.UUID) (*domain.ImpersonationSession, error) {
+	return r.sessions[id], nil
+}
+
+func TestValidateSession(t *testing.T) {
+	const userID = 7
+	activeFamily := uuid.New()
+	revokedFamily := uuid.New()
+	otherUserFamily := uuid.New()
+	openSession := uuid.New()
+	endedSession := uuid.New()
+	adminID := int64(1)
+
+	newService := func() *SessionService {
+		refreshRepo := newFakeRefreshTokenRepo()
+		refreshRepo.families[activeFamily] = &domain.RefreshTokenFamily{
+			ID: activeFamily, UserID: userID, ActiveDeviceID: 3}
+		refreshRepo.families[revokedFamily] = &domain.RefreshTokenFamily{
+			ID: revokedFamily, UserID: userID, ActiveDeviceID: 4}
+		_ = refreshRepo.RevokeRefreshTokenFamily(context.Background(), nil, revokedFamily,
+			domain.RefreshTokenRevokedByReuse)
+		refreshRepo.families[otherUserFamily] = &domain.RefreshTokenFamily{
+			ID: otherUserFamily, UserID: userID + 1, ActiveDeviceID: 5}
+
+		now := time.Now()
+		ended := now.Add(-time.Minute)
+		impersonationRepo := &fakeImpersonationRepo{sessions: map[uuid.UUID]*domain.ImpersonationSession{
+			openSession:  {ID: openSession, ExpiresAt: now.Add(time.Hour)},
+			endedSession: {ID: endedSession, ExpiresAt: now.Add(time.Hour), EndedAt: &ended},
+		}}
+
+		return RegisterSessionService(fakeDBMS{}, &fakeSessionVersionRepo{version: 2},
+			refreshRepo, impersonationRepo)
+	}
+
+	tests := []struct {
+		name    string
+		payload domain.TokenPayload
+		wantErr bool
+	}{
+		{
+			name:    "current version and live family",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2, FamilyID: activeFamily},
+		},
+		{
+			name:    "stale version",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 1, FamilyID: activeFamily},
+			wantErr: true,
+		},
+		{
+			name:    "family deleted with its device",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2, FamilyID: uuid.New()},
+			wantErr: true,
+		},
+		{
+			name:    "family revoked",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2, FamilyID: revokedFamily},
+			wantErr: true,
+		},
+		{
+			name:    "family of another user",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2, FamilyID: otherUserFamily},
+			wantErr: true,
+		},
+		{
+			name:    "token without family",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2},
+			wantErr: true,
+		},
+		{
+			name: "open impersonation session",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2,
+				ImpersonatorAdminID: &adminID, ImpersonationSessionID: &openSession},
+		},
+		{
+			name: "ended impersonation session",
+			payload: domain.TokenPayload{UserID: userID, SessionVersion: 2,
+				ImpersonatorAdminID: &adminID, ImpersonationSessionID: &endedSession},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newService().ValidateSession(context.Background(), &tt.payload)
+			if tt.wantErr {
+				if err == nil || err.Error() != msg.ErrSessionIsRevoked {
+					t.Errorf("err = %v, want %s", err, msg.ErrSessionIsRevoked)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// حذف یک دستگاه فقط توکن‌های همان دستگاه را بی‌اعتبار می‌کند
+func TestValidateSessionDeviceRemovalKeepsOtherDevices(t *testing.T) {
+	ctx := context.Background()
+	refreshRepo := newFakeRefreshTokenRepo()
+	phone := &domain.RefreshTokenFamily{ID: uuid.New(), UserID: 7, ActiveDeviceID: 3}
+	laptop := &domain.RefreshTokenFamily{ID: uuid.New(), UserID: 7, ActiveDeviceID: 4}
+	refreshRepo.families[phone.ID] = phone
+	refreshRepo.families[laptop.ID] = laptop
+
+	ss := RegisterSessionService(fakeDBMS{}, &fakeSessionVersionRepo{version: 1}, refreshRepo, nil)
+
+	// ON DELETE CASCADE دستگاه، خانواده‌اش را حذف می‌کند
+	delete(refreshRepo.families, phone.ID)
+
+	err := ss.ValidateSession(ctx, &domain.TokenPayload{UserID: 7, SessionVersion: 1, FamilyID: phone.ID})
+	if err == nil {
+		t.Error("token of the removed device is still accepted")
+	}
+	err = ss.ValidateSession(ctx, &domain.TokenPayload{UserID: 7, SessionVersion: 1, FamilyID: laptop.ID})
+	if err != nil {
+		t.Errorf("token of the remaining device rejected: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/kavenegar/kavenegar-go"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type StalenessPolicyService struct {
+	dbms             port.DBMS
+	repo             port.StalenessPolicyRepository
+	notificationRepo port.NotificationRepository
+	appConfig        config.App
+}
+
+func RegisterStalenessPolicyService(dbms port.DBMS, repo port.StalenessPolicyRepository,
+	notificationRepo port.NotificationRepository, appConfig config.App) *StalenessPolicyService {
+	return &StalenessPolicyService{
+		dbms,
+		repo,
+		notificationRepo,
+		appConfig,
+	}
+}
+
+func (sps *StalenessPolicyService) GetStalenessPolicies(ctx context.Context) (
+	policies []*domain.StalenessPolicy, err error) {
+	db, err := sps.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = sps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		policies, err = sps.repo.GetStalenessPolicies(ctx, txSession)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return policies, nil
+}
+
+func (sps *StalenessPolicyService) SaveStalenessPolicy(ctx context.Context,
+	policy *domain.StalenessPolicy) (id int64, err error) {
+	db, err := sps.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = sps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err = validateStalenessPolicy(ctx, policy)
+		if err != nil {
+			return err
+		}
+
+		id, err = sps.repo.SaveStalenessPolicy(ctx, txSession, policy)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return id, nil
+}
+
+func (sps *StalenessPolicyService) DeleteStalenessPolicy(ctx context.Context, id int64) (err error) {
+	db, err := sps.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return sps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		return sps.repo.DeleteStalenessPolicy(ctx, txSession, id)
+	})
+}
+
+// SendStalePriceReminders به هر فروشگاهی که قیمت قدیمی دارد یک اعلان درون‌برنامه‌ای و در صورت فعال بودن
+// پیامک می‌فرستد. خطای ارسال پیامک یک فروشگاه مانع یادآوری بقیه نمی‌شود.
+func (sps *StalenessPolicyService) SendStalePriceReminders(ctx context.Context) (
+	result *domain.StalePriceReminderResult, err error) {
+	db, err := sps.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var shops []*domain.StalePriceShop
+	err = sps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		shops, err = sps.repo.GetShopsWithStalePrices(ctx, txSession,
+			sps.appConfig.StalePrice.ReminderIntervalDays)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	result = &domain.StalePriceReminderResult{ShopsCount: len(shops)}
+	for _, shop := range shops {
+		err = sps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+			_, err := sps.notificationRepo.CreateNotification(ctx, txSession, &domain.Notification{
+				UserID: shop.UserID,
+				Type:   domain.NotificationStalePrices,
+				Title:  "قیمت‌های شما قدیمی شده است",
+				Body: fmt.Sprintf("%d قیمت از محصولات شما مدتی است به‌روز نشده و در بازار پایین‌تر "+
+					"نمایش داده می‌شود. قیمت‌ها را به‌روز کنید یا اعتبار آن‌ها را تایید کنید.", shop.StaleCount),
+			})
+			if err != nil {
+				return err
+			}
+
+			return sps.repo.MarkStalePriceReminderSent(ctx, txSession, shop)
+		})
+		if err != nil {
+			slog.Error("failed to notify shop about stale prices", "userId", shop.UserID, "error", err)
+			continue
+		}
+		result.NotifiedCount++
+
+		if !sps.appConfig.StalePrice.SmsEnabled || shop.Phone == "" {
+			continue
+		}
+
+		if errSend := sps.sendStalePriceSms(shop); errSend != nil {
+			slog.Error("failed to send stale price sms", "userId", shop.UserID, "error", errSend)
+			result.SmsFailCount++
+			continue
+		}
+		result.SmsSentCount++
+	}
+
+	return result, nil
+}
+
+func (sps *StalenessPolicyService) sendStalePriceSms(shop *domain.StalePriceShop) error {
+	api := kavenegar.New(sps.appConfig.SmsApiKey)
+	params := &kavenegar.VerifyLookupParam{}
+
+	_, err := api.Verify.Lookup(shop.Phone, sps.appConfig.StalePrice.SmsTemplate,
+		strconv.Itoa(shop.StaleCount), params)
+	return err
+}
+
+func validateStalenessPolicy(_ context.Context, policy *domain.StalenessPolicy) error {
+	if policy == nil {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	if policy.CategoryID != nil && *policy.CategoryID < 1 {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	if policy.MaxAgeDays < 1 {
+		return errors.New(msg.ErrStalenessMaxAgeIsNotValid)
+	}
+
+	return nil
+}
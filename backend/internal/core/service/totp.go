@@ -0,0 +1,646 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// طول هر کد بازیابی بدون خط تیره؛ ده کاراکتر base32 یعنی پنجاه بیت
+const recoveryCodeLength = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTPService struct {
+	dbms              port.DBMS
+	repo              port.TOTPRepository
+	userRepo          port.UserRepository
+	authenticator     port.TOTPAuthenticator
+	loginEventService port.LoginEventService
+	notificationRepo  port.NotificationRepository
+	appConfig         config.App
+}
+
+func RegisterTOTPService(dbms port.DBMS, repo port.TOTPRepository, userRepo port.UserRepository,
+	authenticator port.TOTPAuthenticator, loginEventService port.LoginEventService,
+	notificationRepo port.NotificationRepository, appConfig config.App) *TOTPService {
+	return &TOTPService{
+		dbms,
+		repo,
+		userRepo,
+		authenticator,
+		loginEventService,
+		notificationRepo,
+		appConfig,
+	}
+}
+
+// GetLoginChallenge بعد از کد پیامکی مشخص می‌کند آیا کد TOTP هم لازم است؛ nil یعنی ورود کامل است.
+// کاربری که TOTP را فعال کرده همیشه کد می‌دهد. رمز تازه هیچ‌وقت با کد پیامکی تنها ساخته نمی‌شود:
+// اگر سیاست TOTP را الزامی کند، ادمین باید رمزی داشته باشد که از نشست قبلی خودش یا توسط ادمین
+// بالاتری ساخته شده، وگرنه ورود رد می‌شود. رمزی که ادمین ساخته فقط همین‌جا و فقط یک بار برگردانده می‌شود.
+func (ts *TOTPService) GetLoginChallenge(ctx context.Context, user *domain.User) (
+	challenge *domain.TOTPLoginChallenge, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		totp, err := ts.repo.GetUserTOTP(ctx, txSession, user.ID)
+		if err != nil {
+			return err
+		}
+		if totp != nil && totp.ConfirmedAt != nil {
+			challenge = &domain.TOTPLoginChallenge{}
+			return nil
+		}
+
+		if totp != nil && totp.DeliverAtLogin {
+			secret, err := ts.authenticator.DecryptSecret(totp.SecretEncrypted)
+			if err != nil {
+				return err
+			}
+			enrollment, err := ts.enrollmentInfo(user.Phone, secret)
+			if err != nil {
+				return err
+			}
+
+			challenge = &domain.TOTPLoginChallenge{PendingEnrollment: true, Enrollment: enrollment}
+			return ts.repo.MarkUserTOTPDelivered(ctx, txSession, user.ID)
+		}
+
+		policy, err := ts.repo.GetTOTPPolicy(ctx, txSession)
+		if err != nil {
+			return err
+		}
+		if !policy.RequiresTOTP(user.Role) {
+			return nil
+		}
+		if totp == nil {
+			return errors.New(msg.ErrTOTPEnrollmentRequired)
+		}
+
+		challenge = &domain.TOTPLoginChallenge{PendingEnrollment: true}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// VerifyLoginCode مرحلهٔ دوم ورود را با کد TOTP یا کد بازیابی کامل می‌کند. اگر ثبت‌نام در همین ورود
+// شروع شده باشد اولین کد درست آن را تایید می‌کند و کدهای بازیابی برگردانده می‌شوند.
+// کد اشتباه مثل کد پیامکی اشتباه در سابقهٔ ورود کاربر ثبت می‌شود.
+func (ts *TOTPService) VerifyLoginCode(ctx context.Context, userID int64, code, deviceID, userAgent,
+	ipAddress string) (
+	user *domain.User, adminAccess *domain.AdminAccess, recoveryCodes []string, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var codeErr error
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		user, txErr = ts.userRepo.GetUserByID(ctx, txSession, userID)
+		if txErr != nil {
+			return txErr
+		}
+		if user == nil {
+			return errors.New(msg.ErrUserDoesNotExist)
+		}
+
+		totp, txErr := ts.repo.GetUserTOTP(ctx, txSession, userID)
+		if txErr != nil {
+			return txErr
+		}
+		if totp == nil {
+			return errors.New(msg.ErrTOTPIsNotEnabled)
+		}
+
+		codeErr, txErr = ts.checkCode(ctx, txSession, totp, code, totp.ConfirmedAt != nil)
+		if txErr != nil || codeErr != nil {
+			return txErr
+		}
+
+		if totp.ConfirmedAt == nil {
+			recoveryCodes, txErr = ts.confirmEnrollment(ctx, txSession, userID)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		if user.Role == domain.Admin || user.Role == domain.SuperAdmin {
+			adminAccess, txErr = ts.userRepo.GetAdminAccess(ctx, txSession, user.ID)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if codeErr != nil {
+		recordLoginEvent(ctx, ts.loginEventService, user, deviceID, userAgent, ipAddress, codeErr)
+		return nil, nil, nil, codeErr
+	}
+
+	return user, adminAccess, recoveryCodes, nil
+}
+
+// BeginEnrollment رمز تازه‌ای می‌سازد که تا تایید اولین کد فعال نیست؛ ثبت‌نام نیمه‌کارهٔ قبلی جایگزین می‌شود.
+// فقط با نشست واردشدهٔ خود کاربر صدا زده می‌شود.
+func (ts *TOTPService) BeginEnrollment(ctx context.Context, userID int64) (
+	enrollment *domain.TOTPEnrollment, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		user, err := ts.userRepo.GetUserByID(ctx, txSession, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New(msg.ErrUserDoesNotExist)
+		}
+
+		secret, err := ts.saveNewSecret(ctx, txSession, userID, false)
+		if err != nil {
+			return err
+		}
+
+		enrollment, err = ts.enrollmentInfo(user.Phone, secret)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// IssueEnrollment برای کاربری با نقش پایین‌تر رمز TOTP می‌سازد؛ راه ورود ادمینی که سیاست TOTP را
+// برایش الزامی کرده و هنوز نشستی ندارد. رمز به ادمین برگردانده نمی‌شود: فقط یک بار بعد از کد پیامکی
+// خود کاربر در ورود بعدی‌اش نشان داده می‌شود و اولین کد درست آن را فعال می‌کند.
+func (ts *TOTPService) IssueEnrollment(ctx context.Context, adminID, userID int64) (err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err := ts.checkManagedUser(ctx, txSession, adminID, userID)
+		if err != nil {
+			return err
+		}
+
+		_, err = ts.saveNewSecret(ctx, txSession, userID, true)
+		if err != nil {
+			return err
+		}
+
+		return ts.notifyAdminChange(ctx, txSession, userID, "راه‌اندازی ورود دومرحله‌ای",
+			"مدیر سامانه ورود دومرحله‌ای حساب شما را راه‌اندازی کرد. در ورود بعدی، بعد از کد پیامکی، "+
+				"رمز آن یک بار نشان داده می‌شود. اگر این درخواست از طرف شما نبوده با پشتیبانی تماس بگیرید.")
+	})
+}
+
+// ConfirmEnrollment ثبت‌نام را با اولین کد درست برنامهٔ احراز هویت کامل می‌کند و کدهای بازیابی را برمی‌گرداند
+func (ts *TOTPService) ConfirmEnrollment(ctx context.Context, userID int64, code string) (
+	recoveryCodes []string, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var codeErr error
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		totp, txErr := ts.repo.GetUserTOTP(ctx, txSession, userID)
+		if txErr != nil {
+			return txErr
+		}
+		if totp == nil {
+			return errors.New(msg.ErrTOTPIsNotEnabled)
+		}
+		if totp.ConfirmedAt != nil {
+			return errors.New(msg.ErrTOTPAlreadyEnabled)
+		}
+
+		codeErr, txErr = ts.checkCode(ctx, txSession, totp, code, false)
+		if txErr != nil || codeErr != nil {
+			return txErr
+		}
+
+		recoveryCodes, txErr = ts.confirmEnrollment(ctx, txSession, userID)
+		return txErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	if codeErr != nil {
+		return nil, codeErr
+	}
+
+	return recoveryCodes, nil
+}
+
+func (ts *TOTPService) GetStatus(ctx context.Context, userID int64, role domain.UserRole) (
+	status *domain.TOTPStatus, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	policy, err := ts.repo.GetTOTPPolicy(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	status = &domain.TOTPStatus{
+		Required: policy.RequiresTOTP(role),
+	}
+
+	totp, err := ts.repo.GetUserTOTP(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.ConfirmedAt = totp.ConfirmedAt
+	status.RecoveryCodesLeft, err = ts.repo.CountRecoveryCodes(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// RegenerateRecoveryCodes با یک کد TOTP درست کدهای بازیابی قبلی را باطل و کدهای جدید می‌سازد
+func (ts *TOTPService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (
+	recoveryCodes []string, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var codeErr error
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		totp, txErr := ts.getConfirmedTOTP(ctx, txSession, userID)
+		if txErr != nil {
+			return txErr
+		}
+
+		codeErr, txErr = ts.checkCode(ctx, txSession, totp, code, false)
+		if txErr != nil || codeErr != nil {
+			return txErr
+		}
+
+		recoveryCodes, txErr = ts.replaceRecoveryCodes(ctx, txSession, userID)
+		return txErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	if codeErr != nil {
+		return nil, codeErr
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTOTP ورود دومرحله‌ای کاربر را با یک کد درست غیرفعال می‌کند؛ اگر سیاست آن را الزامی کرده باشد مجاز نیست
+func (ts *TOTPService) DisableTOTP(ctx context.Context, userID int64, role domain.UserRole,
+	code string) (err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var codeErr error
+	err = ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		policy, txErr := ts.repo.GetTOTPPolicy(ctx, txSession)
+		if txErr != nil {
+			return txErr
+		}
+		if policy.RequiresTOTP(role) {
+			return errors.New(msg.ErrTOTPIsRequired)
+		}
+
+		totp, txErr := ts.getConfirmedTOTP(ctx, txSession, userID)
+		if txErr != nil {
+			return txErr
+		}
+
+		codeErr, txErr = ts.checkCode(ctx, txSession, totp, code, true)
+		if txErr != nil || codeErr != nil {
+			return txErr
+		}
+
+		return ts.repo.DeleteUserTOTP(ctx, txSession, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return codeErr
+}
+
+// ResetUserTOTP ورود دومرحله‌ای کاربری با نقش پایین‌تر را که دسترسی به برنامه و کدهای بازیابی‌اش را
+// از دست داده پاک می‌کند. اگر TOTP برای او الزامی باشد، ادمین باید دوباره برایش رمز بسازد.
+func (ts *TOTPService) ResetUserTOTP(ctx context.Context, adminID, userID int64) (err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		err := ts.checkManagedUser(ctx, txSession, adminID, userID)
+		if err != nil {
+			return err
+		}
+
+		totp, err := ts.repo.GetUserTOTP(ctx, txSession, userID)
+		if err != nil {
+			return err
+		}
+		if totp == nil {
+			return errors.New(msg.ErrTOTPIsNotEnabled)
+		}
+
+		err = ts.repo.DeleteUserTOTP(ctx, txSession, userID)
+		if err != nil {
+			return err
+		}
+
+		return ts.notifyAdminChange(ctx, txSession, userID, "بازنشانی ورود دومرحله‌ای",
+			"مدیر سامانه ورود دومرحله‌ای حساب شما را پاک کرد و همهٔ نشست‌های شما بسته شد. "+
+				"اگر این درخواست از طرف شما نبوده با پشتیبانی تماس بگیرید.")
+	})
+}
+
+func (ts *TOTPService) GetTOTPPolicy(ctx context.Context) (policy *domain.TOTPPolicy, err error) {
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ts.repo.GetTOTPPolicy(ctx, db)
+}
+
+// UpdateTOTPPolicy سیاست سراسری را تغییر می‌دهد. الزام از ورود بعدی اعمال می‌شود و ادمینی که آن را
+// الزامی می‌کند باید خودش TOTP فعال داشته باشد تا خودش را بیرون نگذارد.
+func (ts *TOTPService) UpdateTOTPPolicy(ctx context.Context, adminID int64,
+	enforcement domain.TOTPEnforcement) (err error) {
+	if !enforcement.IsValid() {
+		return errors.New(msg.ErrTOTPEnforcementIsNotValid)
+	}
+
+	db, err := ts.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ts.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		if enforcement == domain.TOTPRequiredForAdmins {
+			totp, err := ts.repo.GetUserTOTP(ctx, txSession, adminID)
+			if err != nil {
+				return err
+			}
+			if totp == nil || totp.ConfirmedAt == nil {
+				return errors.New(msg.ErrTOTPEnableBeforeEnforcing)
+			}
+		}
+
+		return ts.repo.UpdateTOTPPolicy(ctx, txSession, &domain.TOTPPolicy{
+			Enforcement: enforcement,
+			UpdatedBy:   &adminID,
+		})
+	})
+}
+
+// saveNewSecret رمز تازه را رمزنگاری‌شده جایگزین ثبت‌نام نیمه‌کارهٔ قبلی می‌کند
+func (ts *TOTPService) saveNewSecret(ctx context.Context, txSession interface{}, userID int64,
+	deliverAtLogin bool) (secret string, err error) {
+	current, err := ts.repo.GetUserTOTP(ctx, txSession, userID)
+	if err != nil {
+		return "", err
+	}
+	if current != nil && current.ConfirmedAt != nil {
+		return "", errors.New(msg.ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err = ts.authenticator.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := ts.authenticator.EncryptSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = ts.repo.SaveUserTOTP(ctx, txSession, &domain.UserTOTP{
+		UserID:          userID,
+		SecretEncrypted: encrypted,
+		DeliverAtLogin:  deliverAtLogin,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// enrollmentInfo اطلاعات افزودن رمز به برنامهٔ احراز هویت را می‌سازد
+func (ts *TOTPService) enrollmentInfo(phone, secret string) (*domain.TOTPEnrollment, error) {
+	keyURI := ts.authenticator.KeyURI(phone, secret)
+	qrCode, err := ts.authenticator.QRCode(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret:     secret,
+		OtpauthURL: keyURI,
+		QRCode:     qrCode,
+	}, nil
+}
+
+// checkManagedUser ادمین فقط تنظیمات کاربری با نقش پایین‌تر از خودش را تغییر می‌دهد، نه حساب خودش را
+func (ts *TOTPService) checkManagedUser(ctx context.Context, txSession interface{},
+	adminID, userID int64) error {
+	if adminID == userID {
+		return errors.New(msg.ErrOperationNotAllowedForThisUser)
+	}
+
+	admin, err := ts.userRepo.GetUserByID(ctx, txSession, adminID)
+	if err != nil {
+		return err
+	}
+	if admin == nil {
+		return errors.New(msg.ErrUserDoesNotExist)
+	}
+
+	user, err := ts.userRepo.GetUserByID(ctx, txSession, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New(msg.ErrUserDoesNotExist)
+	}
+
+	if !admin.Role.CanManage(user.Role) {
+		return errors.New(msg.ErrTOTPTargetRoleIsHigher)
+	}
+	return nil
+}
+
+// notifyAdminChange کاربر را از تغییری که ادمین در ورود دومرحله‌ای‌اش داده باخبر و نشست‌هایش را باطل می‌کند
+func (ts *TOTPService) notifyAdminChange(ctx context.Context, txSession interface{}, userID int64,
+	title, body string) error {
+	_, err := ts.notificationRepo.CreateNotification(ctx, txSession, &domain.Notification{
+		UserID: userID,
+		Type:   domain.NotificationTOTP,
+		Title:  title,
+		Body:   body,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ts.userRepo.BumpUserSessionVersion(ctx, txSession, userID)
+}
+
+func (ts *TOTPService) getConfirmedTOTP(ctx context.Context, txSession interface{},
+	userID int64) (*domain.UserTOTP, error) {
+	totp, err := ts.repo.GetUserTOTP(ctx, txSession, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil || totp.ConfirmedAt == nil {
+		return nil, errors.New(msg.ErrTOTPIsNotEnabled)
+	}
+	return totp, nil
+}
+
+// checkCode کد TOTP یا در صورت اجازه کد بازیابی را بررسی می‌کند. codeErr خطای کد اشتباه یا قفل است و
+// تراکنش باید با آن commit شود تا شمارش تلاش‌ها از بین نرود؛ err خطای داخلی است.
+func (ts *TOTPService) checkCode(ctx context.Context, txSession interface{}, totp *domain.UserTOTP,
+	code string, allowRecoveryCode bool) (codeErr error, err error) {
+	now := time.Now()
+	if totp.LockedUntil != nil && now.Before(*totp.LockedUntil) {
+		return verificationCodeThrottled(msg.ErrTOTPIsLocked, totp.LockedUntil.Sub(now)), nil
+	}
+
+	secret, err := ts.authenticator.DecryptSecret(totp.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if step, ok := ts.authenticator.ValidateCode(secret, code, totp.LastUsedStep, now); ok {
+		return nil, ts.repo.MarkUserTOTPUsed(ctx, txSession, totp.UserID, step)
+	}
+
+	if allowRecoveryCode {
+		used, err := ts.repo.UseRecoveryCode(ctx, txSession, totp.UserID,
+			hashRecoveryCode(totp.UserID, code))
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, ts.repo.MarkUserTOTPUsed(ctx, txSession, totp.UserID, 0)
+		}
+	}
+
+	attempts := totp.FailedAttempts + 1
+	var lockedUntil *time.Time
+	if attempts >= ts.maxAttempts() {
+		until := now.Add(configSeconds(ts.appConfig.TOTP.LockMinutes*60, 15*60))
+		lockedUntil = &until
+		attempts = 0
+	}
+
+	err = ts.repo.RecordUserTOTPFailure(ctx, txSession, totp.UserID, attempts, lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil != nil {
+		return verificationCodeThrottled(msg.ErrTOTPIsLocked, lockedUntil.Sub(now)), nil
+	}
+
+	return errors.New(msg.ErrTOTPCodeIsWrong), nil
+}
+
+func (ts *TOTPService) confirmEnrollment(ctx context.Context, txSession interface{},
+	userID int64) (recoveryCodes []string, err error) {
+	err = ts.repo.ConfirmUserTOTP(ctx, txSession, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.replaceRecoveryCodes(ctx, txSession, userID)
+}
+
+// replaceRecoveryCodes کدهای بازیابی تازه می‌سازد؛ فقط همین یک بار به کاربر نشان داده می‌شوند
+func (ts *TOTPService) replaceRecoveryCodes(ctx context.Context, txSession interface{},
+	userID int64) (recoveryCodes []string, err error) {
+	count := ts.appConfig.TOTP.RecoveryCodeCount
+	if count <= 0 {
+		count = 10
+	}
+	recoveryCodes = make([]string, 0, count)
+	hashes := make([]string, 0, count)
+
+	for range count {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:recoveryCodeLength]
+		recoveryCodes = append(recoveryCodes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, hashRecoveryCode(userID, code))
+	}
+
+	err = ts.repo.ReplaceRecoveryCodes(ctx, txSession, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (ts *TOTPService) maxAttempts() int {
+	if ts.appConfig.TOTP.MaxAttempts <= 0 {
+		return 5
+	}
+	return ts.appConfig.TOTP.MaxAttempts
+}
+
+// hashRecoveryCode کد بازیابی را بدون خط تیره و فاصله و بدون حساسیت به حروف hash می‌کند
+func hashRecoveryCode(userID int64, code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(strconv.FormatInt(userID, 10) + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+// کدی که fakeTOTPAuthenticator برای بازهٔ testTOTPStep می‌پذیرد
+const (
+	testTOTPCode = "111111"
+	testTOTPStep = 5
+)
+
+type fakeTOTPRepo struct {
+	port.TOTPRepository
+	totps    map[int64]*domain.UserTOTP
+	recovery map[string]bool // hash کد بازیابی به مصرف‌شده بودن
+	policy   *domain.TOTPPolicy
+}
+
+func newFakeTOTPRepo(enforcement domain.TOTPEnforcement) *fakeTOTPRepo {
+	return &fakeTOTPRepo{
+		totps:    map[int64]*domain.UserTOTP{},
+		recovery: map[string]bool{},
+		policy:   &domain.TOTPPolicy{Enforcement: enforcement},
+	}
+}
+
+func (r *fakeTOTPRepo) GetUserTOTP(_ context.Context, _ interface{}, userID int64) (*domain.UserTOTP, error) {
+	return r.totps[userID], nil
+}
+
+func (r *fakeTOTPRepo) SaveUserTOTP(_ context.Context, _ interface{}, totp *domain.UserTOTP) error {
+	r.totps[totp.UserID] = totp
+	return nil
+}
+
+func (r *fakeTOTPRepo) DeleteUserTOTP(_ context.Context, _ interface{}, userID int64) error {
+	delete(r.totps, userID)
+	return nil
+}
+
+func (r *fakeTOTPRepo) MarkUserTOTPDelivered(_ context.Context, _ interface{}, userID int64) error {
+	r.totps[userID].DeliverAtLogin = false
+	return nil
+}
+
+func (r *fakeTOTPRepo) ConfirmUserTOTP(_ context.Context, _ interface{}, userID int64,
+	confirmedAt time.Time) error {
+	r.totps[userID].ConfirmedAt = &confirmedAt
+	return nil
+}
+
+func (r *fakeTOTPRepo) MarkUserTOTPUsed(_ context.Context, _ interface{}, userID int64, step int64) error {
+	totp := r.totps[userID]
+	if step > 0 {
+		totp.LastUsedStep = step
+	}
+	totp.FailedAttempts = 0
+	totp.LockedUntil = nil
+	return nil
+}
+
+func (r *fakeTOTPRepo) RecordUserTOTPFailure(_ context.Context, _ interface{}, userID int64,
+	attempts int, lockedUntil *time.Time) error {
+	r.totps[userID].FailedAttempts = attempts
+	r.totps[userID].LockedUntil = lockedUntil
+	return nil
+}
+
+func (r *fakeTOTPRepo) ReplaceRecoveryCodes(_ context.Context, _ interface{}, _ int64,
+	codeHashes []string) error {
+	r.recovery = map[string]bool{}
+	for _, hash := range codeHashes {
+		r.recovery[hash] = false
+	}
+	return nil
+}
+
+func (r *fakeTOTPRepo) UseRecoveryCode(_ context.Context, _ interface{}, _ int64,
+	codeHash string) (bool, error) {
+	used, ok := r.recovery[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recovery[codeHash] = true
+	return true, nil
+}
+
+func (r *fakeTOTPRepo) GetTOTPPolicy(context.Context, interface{}) (*domain.TOTPPolicy, error) {
+	return r.policy, nil
+}
+
+// fakeTOTPUserRepo کاربران را با شناسه برمی‌گرداند تا نقش ادمین و کاربر هدف جدا باشد
+type fakeTOTPUserRepo struct {
+	port.UserRepository
+	users  map[int64]*domain.User
+	bumped []int64
+}
+
+func (r *fakeTOTPUserRepo) GetUserByID(_ context.Context, _ interface{}, id int64) (*domain.User, error) {
+	return r.users[id], nil
+}
+
+func (r *fakeTOTPUserRepo) BumpUserSessionVersion(_ context.Context, _ interface{}, userID int64) error {
+	r.bumped = append(r.bumped, userID)
+	return nil
+}
+
+type fakeNotificationRepo struct {
+	port.NotificationRepository
+	notifications []*domain.Notification
+}
+
+func (r *fakeNotificationRepo) CreateNotification(_ context.Context, _ interface{},
+	notification *domain.Notification) (int64, error) {
+	r.notifications = append(r.notifications, notification)
+	return int64(len(r.notifications)), nil
+}
+
+// fakeTOTPAuthenticator رمز را بدون تغییر «رمزنگاری» می‌کند و فقط testTOTPCode را می‌پذیرد
+type fakeTOTPAuthenticator struct {
+	port.TOTPAuthenticator
+}
+
+func (fakeTOTPAuthenticator) GenerateSecret() (string, error) {
+	return "JBSWY3DPEHPK3PXP", nil
+}
+
+func (fakeTOTPAuthenticator) ValidateCode(_, code string, afterStep int64, _ time.Time) (int64, bool) {
+	if code != testTOTPCode || afterStep >= testTOTPStep {
+		return 0, false
+	}
+	return testTOTPStep, true
+}
+
+func (fakeTOTPAuthenticator) KeyURI(accountName, secret string) string {
+	return "otpauth://totp/Nerkhin:" + accountName + "?secret=" + secret
+}
+
+func (fakeTOTPAuthenticator) QRCode(string) ([]byte, error) {
+	return []byte("png"), nil
+}
+
+func (fakeTOTPAuthenticator) EncryptSecret(secret string) (string, error) {
+	return "enc:" + secret, nil
+}
+
+func (fakeTOTPAuthenticator) DecryptSecret(encrypted string) (string, error) {
+	return strings.TrimPrefix(encrypted, "enc:"), nil
+}
+
+func newTestTOTPService(user *domain.User, enforcement domain.TOTPEnforcement) (*TOTPService, *fakeTOTPRepo) {
+	repo := newFakeTOTPRepo(enforcement)
+	appConfig := config.App{TOTP: config.TOTPConfig{MaxAttempts: 3, RecoveryCodeCount: 4}}
+	userRepo := &fakeTOTPUserRepo{users: map[int64]*domain.User{user.ID: user}}
+	return RegisterTOTPService(fakeDBMS{}, repo, userRepo, fakeTOTPAuthenticator{},
+		&fakeLoginEventService{}, &fakeNotificationRepo{}, appConfig), repo
+}
+
+func confirmedTOTP(userID int64) *domain.UserTOTP {
+	confirmedAt := time.Now().Add(-time.Hour)
+	return &domain.UserTOTP{UserID: userID, SecretEncrypted: "enc:JBSWY3DPEHPK3PXP", ConfirmedAt: &confirmedAt}
+}
+
+func TestGetLoginChallenge(t *testing.T) {
+	pending := &domain.UserTOTP{UserID: 7, SecretEncrypted: "enc:JBSWY3DPEHPK3PXP"}
+
+	tests := []struct {
+		name          string
+		role          domain.UserRole
+		enforcement   domain.TOTPEnforcement
+		totp          *domain.UserTOTP
+		wantChallenge bool
+		wantPending   bool
+		wantErr       string
+	}{
+		{name: "confirmed totp is always asked", role: domain.Wholesaler, enforcement: domain.TOTPOptional,
+			totp: confirmedTOTP(7), wantChallenge: true},
+		{name: "optional without totp", role: domain.Admin, enforcement: domain.TOTPOptional},
+		{name: "required for admins only", role: domain.Wholesaler, enforcement: domain.TOTPRequiredForAdmins},
+		{name: "pending row is ignored when optional", role: domain.Admin, enforcement: domain.TOTPOptional,
+			totp: pending},
+		{name: "required without totp", role: domain.Admin, enforcement: domain.TOTPRequiredForAdmins,
+			wantErr: msg.ErrTOTPEnrollmentRequired},
+		{name: "required with issued secret", role: domain.SuperAdmin, enforcement: domain.TOTPRequiredForAdmins,
+			totp: pending, wantChallenge: true, wantPending: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{ID: 7, Role: tt.role}
+			ts, repo := newTestTOTPService(user, tt.enforcement)
+			if tt.totp != nil {
+				repo.totps[user.ID] = tt.totp
+			}
+
+			challenge, err := ts.GetLoginChallenge(context.Background(), user)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLoginChallenge: %v", err)
+			}
+			if (challenge != nil) != tt.wantChallenge {
+				t.Fatalf("challenge = %v, want challenge %v", challenge, tt.wantChallenge)
+			}
+			if challenge != nil && challenge.PendingEnrollment != tt.wantPending {
+				t.Errorf("pending enrollment = %v, want %v", challenge.PendingEnrollment, tt.wantPending)
+			}
+		})
+	}
+}
+
+// verifyLoginCode مرحلهٔ دوم ورود را از یک دستگاه ثابت صدا می‌زند
+func verifyLoginCode(ts *TOTPService, userID int64, code string) (*domain.User, []string, error) {
+	user, _, recoveryCodes, err := ts.VerifyLoginCode(context.Background(), userID, code,
+		"phone-1", "ua", "127.0.0.1")
+	return user, recoveryCodes, err
+}
+
+func TestVerifyLoginCodeConfirmsPendingEnrollment(t *testing.T) {
+	user := &domain.User{ID: 7, Role: domain.Wholesaler}
+	ts, repo := newTestTOTPService(user, domain.TOTPOptional)
+	repo.totps[user.ID] = &domain.UserTOTP{UserID: user.ID, SecretEncrypted: "enc:JBSWY3DPEHPK3PXP"}
+
+	// تا ثبت‌نام تایید نشده کد بازیابی پذیرفته نمی‌شود
+	repo.recovery[hashRecoveryCode(user.ID, "abcde-fghij")] = false
+	if _, _, err := verifyLoginCode(ts, user.ID, "abcde-fghij"); err == nil ||
+		err.Error() != msg.ErrTOTPCodeIsWrong {
+		t.Fatalf("recovery code before confirmation err = %v, want %s", err, msg.ErrTOTPCodeIsWrong)
+	}
+
+	_, recoveryCodes, err := verifyLoginCode(ts, user.ID, testTOTPCode)
+	if err != nil {
+		t.Fatalf("VerifyLoginCode: %v", err)
+	}
+	if repo.totps[user.ID].ConfirmedAt == nil {
+		t.Fatal("enrollment is not confirmed")
+	}
+	if len(recoveryCodes) != 4 {
+		t.Fatalf("recovery codes = %d, want 4", len(recoveryCodes))
+	}
+	for _, code := range recoveryCodes {
+		if _, ok := repo.recovery[hashRecoveryCode(user.ID, code)]; !ok {
+			t.Errorf("recovery code %q is not stored", code)
+		}
+	}
+	if _, ok := repo.recovery[hashRecoveryCode(user.ID, "abcde-fghij")]; ok {
+		t.Error("old recovery codes were not replaced")
+	}
+
+	// همان کد در همان بازه دوباره پذیرفته نمی‌شود
+	if _, _, err := verifyLoginCode(ts, user.ID, testTOTPCode); err == nil {
+		t.Error("replayed code was accepted")
+	}
+}
+
+func TestVerifyLoginCodeRecoveryCodeIsSingleUse(t *testing.T) {
+	user := &domain.User{ID: 7, Role: domain.Wholesaler}
+	ts, repo := newTestTOTPService(user, domain.TOTPOptional)
+	repo.totps[user.ID] = confirmedTOTP(user.ID)
+	repo.recovery[hashRecoveryCode(user.ID, "abcde-fghij")] = false
+
+	got, recoveryCodes, err := verifyLoginCode(ts, user.ID, "ABCDE FGHIJ")
+	if err != nil {
+		t.Fatalf("VerifyLoginCode: %v", err)
+	}
+	if got.ID != user.ID || recoveryCodes != nil {
+		t.Errorf("user = %d, recovery codes = %v; want user %d and no new codes", got.ID, recoveryCodes, user.ID)
+	}
+
+	_, _, err = verifyLoginCode(ts, user.ID, "abcde-fghij")
+	if err == nil || err.Error() != msg.ErrTOTPCodeIsWrong {
+		t.Fatalf("reused recovery code err = %v, want %s", err, msg.ErrTOTPCodeIsWrong)
+	}
+	if repo.totps[user.ID].FailedAttempts != 1 {
+		t.Errorf("failed attempts = %d, want 1", repo.totps[user.ID].FailedAttempts)
+	}
+}
+
+func TestVerifyLoginCodeLocksAfterMaxAttempts(t *testing.T) {
+	user := &domain.User{ID: 7, Role: domain.Wholesaler}
+	ts, repo := newTestTOTPService(user, domain.TOTPOptional)
+	repo.totps[user.ID] = confirmedTOTP(user.ID)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		_, _, err := verifyLoginCode(ts, user.ID, "000000")
+		want := msg.ErrTOTPCodeIsWrong
+		if attempt == 3 {
+			want = msg.ErrTOTPIsLocked
+		}
+		if err == nil || err.Error() != want {
+			t.Fatalf("attempt %d err = %v, want %s", attempt, err, want)
+		}
+	}
+
+	_, _, err := verifyLoginCode(ts, user.ID, testTOTPCode)
+	if err == nil || err.Error() != msg.ErrTOTPIsLocked {
+		t.Fatalf("correct code while locked err = %v, want %s", err, msg.ErrTOTPIsLocked)
+	}
+	if repo.totps[user.ID].LastUsedStep != 0 {
+		t.Error("code was accepted while locked")
+	}
+
+	// کدهای اشتباه بعد از کد پیامکی درست هم در سابقهٔ ورود دیده می‌شوند
+	events := ts.loginEventService.(*fakeLoginEventService).events
+	if len(events) != 4 {
+		t.Fatalf("login events = %d, want 4 failures", len(events))
+	}
+	for _, event := range events {
+		if event.Outcome != domain.LoginFailed || event.DeviceID != "phone-1" || event.FailureReason == nil {
+			t.Errorf("event = %+v, want a failed login on phone-1", event)
+		}
+	}
+}
+
+func TestIssueEnrollment(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: 7, Role: domain.Admin, Phone: "09120000000"}
+	ts, repo := newTestTOTPService(user, domain.TOTPOptional)
+	userRepo := ts.userRepo.(*fakeTOTPUserRepo)
+	userRepo.users[1] = &domain.User{ID: 1, Role: domain.SuperAdmin}
+
+	if err := ts.IssueEnrollment(ctx, 1, user.ID); err != nil {
+		t.Fatalf("IssueEnrollment: %v", err)
+	}
+	saved := repo.totps[user.ID]
+	if saved == nil || saved.ConfirmedAt != nil || !saved.DeliverAtLogin {
+		t.Fatalf("saved totp = %+v, want a pending secret waiting for the owner's login", saved)
+	}
+
+	notifications := ts.notificationRepo.(*fakeNotificationRepo).notifications
+	if len(notifications) != 1 || notifications[0].UserID != user.ID ||
+		notifications[0].Type != domain.NotificationTOTP {
+		t.Errorf("notifications = %+v, want one totp notification for the user", notifications)
+	}
+	if len(userRepo.bumped) != 1 || userRepo.bumped[0] != user.ID {
+		t.Errorf("bumped sessions = %v, want [%d]", userRepo.bumped, user.ID)
+	}
+
+	// رمز فقط یک بار و بعد از کد پیامکی خود کاربر نشان داده می‌شود، حتی اگر سیاست اختیاری باشد
+	challenge, err := ts.GetLoginChallenge(ctx, user)
+	if err != nil || challenge == nil || !challenge.PendingEnrollment || challenge.Enrollment == nil {
+		t.Fatalf("challenge = %+v, err = %v; want the issued secret", challenge, err)
+	}
+	if saved.SecretEncrypted != "enc:"+challenge.Enrollment.Secret {
+		t.Errorf("delivered secret %q does not match the stored one", challenge.Enrollment.Secret)
+	}
+
+	challenge, err = ts.GetLoginChallenge(ctx, user)
+	if err != nil || challenge != nil {
+		t.Fatalf("second challenge = %+v, err = %v; want the secret delivered only once", challenge, err)
+	}
+
+	repo.totps[user.ID] = confirmedTOTP(user.ID)
+	if err := ts.IssueEnrollment(ctx, 1, user.ID); err == nil || err.Error() != msg.ErrTOTPAlreadyEnabled {
+		t.Errorf("confirmed user err = %v, want %s", err, msg.ErrTOTPAlreadyEnabled)
+	}
+}
+
+func TestManageUserTOTPRequiresLowerRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminRole  domain.UserRole
+		targetRole domain.UserRole
+		self       bool
+		wantErr    string
+	}{
+		{name: "admin on wholesaler", adminRole: domain.Admin, targetRole: domain.Wholesaler},
+		{name: "super admin on admin", adminRole: domain.SuperAdmin, targetRole: domain.Admin},
+		{name: "super admin on super admin", adminRole: domain.SuperAdmin, targetRole: domain.SuperAdmin},
+		{name: "admin on admin", adminRole: domain.Admin, targetRole: domain.Admin,
+			wantErr: msg.ErrTOTPTargetRoleIsHigher},
+		{name: "admin on super admin", adminRole: domain.Admin, targetRole: domain.SuperAdmin,
+			wantErr: msg.ErrTOTPTargetRoleIsHigher},
+		{name: "self", adminRole: domain.SuperAdmin, targetRole: domain.SuperAdmin, self: true,
+			wantErr: msg.ErrOperationNotAllowedForThisUser},
+	}
+
+	actions := map[string]func(ts *TOTPService, adminID, userID int64) error{
+		"issue": func(ts *TOTPService, adminID, userID int64) error {
+			return ts.IssueEnrollment(context.Background(), adminID, userID)
+		},
+		"reset": func(ts *TOTPService, adminID, userID int64) error {
+			return ts.ResetUserTOTP(context.Background(), adminID, userID)
+		},
+	}
+
+	for _, tt := range tests {
+		for action, run := range actions {
+			t.Run(tt.name+"/"+action, func(t *testing.T) {
+				target := &domain.User{ID: 7, Role: tt.targetRole}
+				ts, repo := newTestTOTPService(target, domain.TOTPOptional)
+				userRepo := ts.userRepo.(*fakeTOTPUserRepo)
+				adminID := int64(1)
+				if tt.self {
+					adminID = target.ID
+				} else {
+					userRepo.users[adminID] = &domain.User{ID: adminID, Role: tt.adminRole}
+				}
+				if action == "reset" {
+					repo.totps[target.ID] = confirmedTOTP(target.ID)
+				}
+
+				err := run(ts, adminID, target.ID)
+				notifications := ts.notificationRepo.(*fakeNotificationRepo).notifications
+				if tt.wantErr != "" {
+					if err == nil || err.Error() != tt.wantErr {
+						t.Fatalf("err = %v, want %s", err, tt.wantErr)
+					}
+					if len(notifications) != 0 || len(userRepo.bumped) != 0 {
+						t.Error("refused change notified the user or ended their sessions")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", action, err)
+				}
+				if len(notifications) != 1 || notifications[0].UserID != target.ID {
+					t.Errorf("notifications = %+v, want one for the user", notifications)
+				}
+				if len(userRepo.bumped) != 1 || userRepo.bumped[0] != target.ID {
+					t.Errorf("bumped sessions = %v, want [%d]", userRepo.bumped, target.ID)
+				}
+			})
+		}
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	want := hashRecoveryCode(7, "abcdefghij")
+	for _, code := range []string{"abcde-fghij", "ABCDE-FGHIJ", " abcde fghij "} {
+		if got := hashRecoveryCode(7, code); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the plain code", code)
+		}
+	}
+	if hashRecoveryCode(8, "abcdefghij") == want {
+		t.Error("recovery code hash is not bound to the user")
+	}
+}
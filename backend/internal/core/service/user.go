@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/core/domain"
 	"github.com/nerkhin/internal/core/domain/msg"
@@ -28,11 +31,14 @@ type UserService struct {
 	verificationCodeService port.VerificationCodeService
 	verificationCodeRepo    port.VerificationCodeRepository
 	tokenService            port.TokenService
+	adminRoleRepo           port.AdminRoleRepository
+	refreshTokenRepo        port.RefreshTokenRepository
 }
 
 func RegisterUserService(dbms port.DBMS, repo port.UserRepository,
 	vcService port.VerificationCodeService, verificationCodeRepo port.VerificationCodeRepository,
-	appConfig config.App, tokenService port.TokenService) *UserService {
+	appConfig config.App, tokenService port.TokenService,
+	adminRoleRepo port.AdminRoleRepository, refreshTokenRepo port.RefreshTokenRepository) *UserService {
 	return &UserService{
 		dbms,
 		repo,
@@ -40,6 +46,8 @@ func RegisterUserService(dbms port.DBMS, repo port.UserRepository,
 		vcService,
 		verificationCodeRepo,
 		tokenService,
+		adminRoleRepo,
+		refreshTokenRepo,
 	}
 }
 
@@ -507,7 +515,32 @@ func (us *UserService) UpdateAdminAccess(ctx context.Context, adminAccess *domai
 			return errors.New(msg.ErrDataIsNotValid)
 		}
 
-		err := us.repo.UpdateAdminAccess(ctx, txSession, adminAccess)
+		// دسترسی سوپرادمین ثابت است و کاربران عادی دسترسی ادمینی ندارند
+		admin, err := us.repo.GetUserByID(ctx, txSession, adminAccess.UserID)
+		if err != nil {
+			return err
+		}
+		if admin.Role != domain.Admin {
+			return errors.New(msg.ErrDataIsNotValid)
+		}
+
+		permissions, err := normalizePermissions(adminAccess.Permissions)
+		if err != nil {
+			return err
+		}
+		adminAccess.Permissions = permissions
+
+		if adminAccess.RoleID != nil {
+			role, err := us.adminRoleRepo.GetAdminRole(ctx, txSession, *adminAccess.RoleID)
+			if err != nil {
+				return err
+			}
+			if role == nil {
+				return errors.New(msg.ErrAdminRoleDoesNotExist)
+			}
+		}
+
+		err = us.repo.UpdateAdminAccess(ctx, txSession, adminAccess)
 		if err != nil {
 			return err
 		}
@@ -706,37 +739,145 @@ func (us *UserService) FetchAdminUserList(ctx context.Context,
 	return users, nil
 }
 
-// in service/user_service.go (or auth_service.go)
+// GetOwnDevices دستگاه‌های خود کاربر را برمی‌گرداند؛ دستگاهی که درخواست از آن آمده علامت می‌خورد
+func (us *UserService) GetOwnDevices(ctx context.Context, userID int64, currentDeviceID string) (
+	devices []*domain.ActiveDevice, err error) {
+	devices, err = us.GetUserActiveDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		device.IsCurrent = currentDeviceID != "" && device.DeviceID == currentDeviceID
+	}
+
+	return devices, nil
+}
+
+func (us *UserService) RenameOwnDevice(ctx context.Context, userID, id int64, name string) (err error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > domain.ActiveDeviceNameMaxLength {
+		return errors.New(msg.ErrDeviceNameIsNotValid)
+	}
+
+	var deviceName *string
+	if name != "" {
+		deviceName = &name
+	}
 
-func (us *UserService) ImpersonateUser(ctx context.Context, targetUserID, adminID int64) (string, *domain.User, error) {
 	db, err := us.dbms.NewDB(ctx)
 	if err != nil {
-		return "", nil, err
+		return
 	}
 
-	var tokenString string
-	var targetUser *domain.User
-	err = us.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
-		localTargetUser, txErr := us.repo.GetUserByID(ctx, txSession, targetUserID)
-		if txErr != nil {
-			fmt.Println("err:", err)
-			return txErr
+	return us.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		device, err := us.findOwnDevice(ctx, txSession, userID, id)
+		if err != nil {
+			return err
+		}
+
+		return us.repo.UpdateUserDeviceName(ctx, txSession, device.ID, deviceName)
+	})
+}
+
+// SignOutOwnDevice دستگاه را حذف می‌کند؛ توکن‌های تمدید آن هم با حذف دستگاه باطل می‌شوند
+func (us *UserService) SignOutOwnDevice(ctx context.Context, userID, id int64) (err error) {
+	db, err := us.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return us.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		device, err := us.findOwnDevice(ctx, txSession, userID, id)
+		if err != nil {
+			return err
+		}
+
+		return us.repo.DeleteUserDevice(ctx, txSession, userID, device.DeviceID)
+	})
+}
+
+// SendSignOutOtherDevicesCode کد تایید خروج از بقیهٔ دستگاه‌ها را به شمارهٔ خود کاربر می‌فرستد
+func (us *UserService) SendSignOutOtherDevicesCode(ctx context.Context, userID int64, ipAddress string) (err error) {
+	user, err := us.GetUserByID(ctx, userID)
+	if err != nil {
+		return
+	}
+	if user == nil {
+		return errors.New(msg.ErrUserDoesNotExist)
+	}
+
+	return us.verificationCodeService.SendVerificationCode(ctx, user.Phone, ipAddress)
+}
+
+// SignOutOtherDevices بعد از تایید دوبارهٔ کد، همهٔ دستگاه‌های کاربر به جز دستگاه فعلی را حذف می‌کند.
+// دستگاه فعلی از خانوادهٔ refresh token توکن درخواست پیدا می‌شود، نه از ورودی کلاینت.
+func (us *UserService) SignOutOtherDevices(ctx context.Context, userID int64,
+	familyID uuid.UUID, code string) (err error) {
+	if familyID == uuid.Nil {
+		return errors.New(msg.ErrDeviceIDIsNotSet)
+	}
+
+	err = us.verificationCodeService.ConfirmCode(ctx, userID, code)
+	if err != nil {
+		return
+	}
+
+	db, err := us.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return us.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		family, err := us.refreshTokenRepo.GetRefreshTokenFamily(ctx, txSession, familyID)
+		if err != nil {
+			return err
 		}
-		if localTargetUser == nil {
-			fmt.Println("not found user")
-			return errors.New("UserNotFound")
+		if family == nil || family.RevokedAt != nil || family.UserID != userID {
+			return errors.New(msg.ErrDeviceDoesNotExist)
 		}
-		targetUser = localTargetUser
 
-		// ساخت توکن تقلیدی
-		tokenStr, _, _, txErr := us.tokenService.CreateImpersonationToken(targetUser, adminID)
-		if txErr != nil {
-			fmt.Println("error in create token:", err)
-			return txErr
+		current, err := us.findOwnDevice(ctx, txSession, userID, family.ActiveDeviceID)
+		if err != nil {
+			return err
 		}
-		tokenString = tokenStr
-		return nil
+
+		return us.repo.DeleteOtherUserDevices(ctx, txSession, userID, current.DeviceID)
 	})
+}
+
+// ExpireIdleDevices دستگاه‌هایی که بیش از DEVICE_IDLE_EXPIRY_DAYS روز استفاده نشده‌اند را حذف می‌کند
+func (us *UserService) ExpireIdleDevices(ctx context.Context) (count int64, err error) {
+	days := us.appConfig.Device.IdleExpiryDays
+	if days <= 0 {
+		return 0, nil
+	}
+
+	db, err := us.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -days)
+	err = us.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		count, err = us.repo.DeleteIdleDevices(ctx, txSession, 0, before)
+		return err
+	})
+	return count, err
+}
+
+func (us *UserService) findOwnDevice(ctx context.Context, txSession interface{},
+	userID, id int64) (*domain.ActiveDevice, error) {
+	devices, err := us.repo.GetUserActiveDevices(ctx, txSession, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		if device.ID == id {
+			return device, nil
+		}
+	}
 
-	return tokenString, targetUser, err
+	return nil, errors.New(msg.ErrDeviceDoesNotExist)
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+type fakeConfirmCodeService struct {
+	port.VerificationCodeService
+	confirmed []string
+}
+
+func (s *fakeConfirmCodeService) ConfirmCode(_ context.Context, _ int64, code string) error {
+	s.confirmed = append(s.confirmed, code)
+	return nil
+}
+
+type fakeOwnDevicesRepo struct {
+	fakeUserRepo
+	devices []*domain.ActiveDevice
+	kept    []string
+}
+
+func (r *fakeOwnDevicesRepo) GetUserActiveDevices(context.Context, interface{}, int64) (
+	[]*domain.ActiveDevice, error) {
+	return r.devices, nil
+}
+
+func (r *fakeOwnDevicesRepo) DeleteOtherUserDevices(_ context.Context, _ interface{}, _ int64,
+	keepDeviceID string) error {
+	r.kept = append(r.kept, keepDeviceID)
+	return nil
+}
+
+func TestSignOutOtherDevicesKeepsTokenDevice(t *testing.T) {
+	const userID = 7
+	phoneFamily := uuid.New()
+	strangerFamily := uuid.New()
+
+	tests := []struct {
+		name     string
+		familyID uuid.UUID
+		wantKept string
+		wantErr  string
+	}{
+		{name: "device of the token", familyID: phoneFamily, wantKept: "phone"},
+		{name: "token without family", familyID: uuid.Nil, wantErr: msg.ErrDeviceIDIsNotSet},
+		{name: "family of another user", familyID: strangerFamily, wantErr: msg.ErrDeviceDoesNotExist},
+		{name: "family removed", familyID: uuid.New(), wantErr: msg.ErrDeviceDoesNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := &fakeOwnDevicesRepo{devices: []*domain.ActiveDevice{
+				{ID: 3, UserID: userID, DeviceID: "phone"},
+				{ID: 4, UserID: userID, DeviceID: "laptop"},
+			}}
+			refreshRepo := newFakeRefreshTokenRepo()
+			refreshRepo.families[phoneFamily] = &domain.RefreshTokenFamily{
+				ID: phoneFamily, UserID: userID, ActiveDeviceID: 3}
+			refreshRepo.families[strangerFamily] = &domain.RefreshTokenFamily{
+				ID: strangerFamily, UserID: userID + 1, ActiveDeviceID: 9}
+
+			us := RegisterUserService(fakeDBMS{}, userRepo, &fakeConfirmCodeService{}, nil,
+				config.App{}, nil, nil, refreshRepo)
+
+			err := us.SignOutOtherDevices(context.Background(), userID, tt.familyID, "123456")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				if len(userRepo.kept) != 0 {
+					t.Fatal("devices were deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SignOutOtherDevices: %v", err)
+			}
+			if len(userRepo.kept) != 1 || userRepo.kept[0] != tt.wantKept {
+				t.Fatalf("kept = %v, want [%s]", userRepo.kept, tt.wantKept)
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
+	"unicode/utf8"
 
 	"math"
 
+	"github.com/nerkhin/internal/adapter/config"
 	"github.com/nerkhin/internal/core/domain"
 	"github.com/nerkhin/internal/core/domain/msg"
 	"github.com/nerkhin/internal/core/port"
+	"github.com/nerkhin/internal/pkg/pagination"
 	"github.com/shopspring/decimal"
 	"golang.org/x/sync/errgroup"
 )
@@ -24,6 +29,9 @@ type UserProductService struct {
 	favoriteProductRepo port.FavoriteProductRepository
 	favoriteAccountRepo port.FavoriteAccountRepository
 	userSubRepo         port.UserSubscriptionRepository
+	roundingRuleRepo    port.RoundingRuleRepository
+	priceListTplRepo    port.PriceListTemplateRepository
+	appConfig           config.App
 }
 
 func RegisterUserProductService(dbms port.DBMS, repo port.UserProductRepository,
@@ -33,7 +41,10 @@ func RegisterUserProductService(dbms port.DBMS, repo port.UserProductRepository,
 	productModelRepo port.ProductModelRepository,
 	favoriteProductRepo port.FavoriteProductRepository,
 	favoriteAccountRepo port.FavoriteAccountRepository,
-	userSubRepo port.UserSubscriptionRepository) *UserProductService {
+	userSubRepo port.UserSubscriptionRepository,
+	roundingRuleRepo port.RoundingRuleRepository,
+	priceListTplRepo port.PriceListTemplateRepository,
+	appConfig config.App) *UserProductService {
 	return &UserProductService{
 		dbms,
 		repo,
@@ -45,6 +56,9 @@ func RegisterUserProductService(dbms port.DBMS, repo port.UserProductRepository,
 		favoriteProductRepo,
 		favoriteAccountRepo,
 		userSubRepo,
+		roundingRuleRepo,
+		priceListTplRepo,
+		appConfig,
 	}
 }
 
@@ -63,47 +77,59 @@ func (ups *UserProductService) CreateUserProduct(ctx context.Context,
 		if err := validateNewUserProduct(ctx, userProduct); err != nil {
 			return err
 		}
-
-		// 2. (اختیاری) اعتبارسنجی قیمت دلاری، اگر محصول دلاری است
-		if userProduct.IsDollar {
-			shopInfo, err := ups.userRepo.GetUserByID(ctx, txSession, userProduct.UserID)
-			if err != nil {
-				return err
-			}
-			if !shopInfo.DollarPrice.Valid {
-				return errors.New(msg.ErrShopDollarPriceIsNotSet)
-			}
-			if err = validateUserProductPrices(ctx, userProduct, shopInfo.DollarPrice.Decimal); err != nil {
-				return err
-			}
-		}
-
-		// 3. واکشی محصول اصلی (Master Product) برای اطمینان از وجود آن
-		// این همان بخشی است که در کد شما جا افتاده بود
-		targetProduct, err := ups.productRepo.GetProductByID(ctx, txSession, userProduct.ProductID)
-		if err != nil {
+		if err := ups.normalizeUserProductSKU(ctx, txSession, userProduct); err != nil {
 			return err
 		}
 
-		// 4. افزایش تعداد فروشگاه‌های ارائه‌دهنده این محصول
-		targetProduct.ShopsCount++
-		if err = ups.productRepo.UpdateProduct(ctx, txSession, &targetProduct.Product); err != nil {
+		// 2. اعتبارسنجی قیمت دلاری (اگر محصول دلاری است)، گرد کردن قیمت نهایی و مقایسه با بازار
+		deviation, err := ups.prepareUserProductPrices(ctx, txSession, userProduct.UserID,
+			userProduct.ProductID, userProduct, nil)
+		if err != nil {
 			return err
 		}
 
-		// 5. تعیین ترتیب نمایش محصول برای این کاربر
-		maxOrder, err := ups.repo.GetMaxOrder(ctx, txSession, userProduct.UserID)
+		// 3 تا 6. ثبت محصول کاربر در انتهای لیست فروشگاه
+		id, err = ups.insertUserProduct(ctx, txSession, userProduct)
 		if err != nil {
 			return err
 		}
-		userProduct.Order = maxOrder + 1
 
-		// 6. ایجاد نهایی محصول کاربر (UserProduct)
-		id, err = ups.repo.CreateUserProduct(ctx, txSession, userProduct)
-		return err
+		return ups.flagPriceDeviation(ctx, txSession, userProduct, deviation)
 	})
 }
 
+// insertUserProduct محصول کاربر را با قیمت‌های آماده‌شده در انتهای لیست فروشگاه ثبت می‌کند
+func (ups *UserProductService) insertUserProduct(ctx context.Context, txSession interface{},
+	userProduct *domain.UserProduct) (id int64, err error) {
+	// 3. واکشی محصول اصلی (Master Product) برای اطمینان از وجود آن
+	targetProduct, err := ups.productRepo.GetProductByID(ctx, txSession, userProduct.ProductID)
+	if err != nil {
+		return
+	}
+
+	// 4. افزایش تعداد فروشگاه‌های ارائه‌دهنده این محصول
+	targetProduct.ShopsCount++
+	if err = ups.productRepo.UpdateProduct(ctx, txSession, &targetProduct.Product); err != nil {
+		return
+	}
+
+	// 5. تعیین ترتیب نمایش محصول برای این کاربر
+	maxOrder, err := ups.repo.GetMaxOrder(ctx, txSession, userProduct.UserID)
+	if err != nil {
+		return
+	}
+	userProduct.Order = maxOrder + 1
+
+	// 6. ایجاد نهایی محصول کاربر (UserProduct)
+	id, err = ups.repo.CreateUserProduct(ctx, txSession, userProduct)
+	if err != nil {
+		return
+	}
+
+	userProduct.ID = id
+	return id, nil
+}
+
 // تابع کمکی برای اعتبارسنجی UserProduct جدید
 func validateNewUserProduct(_ context.Context, product *domain.UserProduct) (err error) {
 	if product == nil {
@@ -644,49 +670,56 @@ func (ups *UserProductService) FetchShops(ctx context.Context, productId int64,
 	return shops, nil
 }
 
-func (ps *UserProductService) GetPriceList(ctx context.Context, currentUserID int64) (
+func (ps *UserProductService) GetPriceList(ctx context.Context, currentUserID, templateID int64) (
 	priceList *domain.ShopViewModel, err error) {
 	db, err := ps.dbms.NewDB(ctx)
 	if err != nil {
 		return
 	}
 
-	priceList = &domain.ShopViewModel{
-		Products: []*domain.UserProductView{},
-	}
 	err = ps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
 		user, err := ps.userRepo.GetUserByID(ctx, txSession, currentUserID)
 		if err != nil {
 			return err
 		}
-		priceList.ShopInfo = user
 
-		prices, err := ps.repo.GetPriceList(ctx, txSession, user.ID)
-		if err != nil {
-			return err
-		}
+		priceList, err = ps.buildPriceList(ctx, txSession, user, templateID, nil)
+		return err
+	})
 
-		productIDs := []int64{}
-		for _, prod := range prices {
-			productIDs = append(productIDs, prod.ProductID)
-		}
+	if err != nil {
+		return
+	}
 
-		defaultFilterRelationsMap, err := ps.productFilterRepo.
-			GetProductFilterRelationsMapByProductIDs(ctx, txSession, productIDs)
+	return priceList, nil
+}
+
+// GetShopPriceList دسترسی را مثل FetchShopProductsFiltered به شهرهای اشتراک کاربر محدود می‌کند
+func (ps *UserProductService) GetShopPriceList(ctx context.Context, currentUserID, shopID int64,
+	query *domain.UserProductQuery) (priceList *domain.ShopViewModel, err error) {
+	db, err := ps.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ps.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		shop, err := ps.userRepo.GetUserByID(ctx, txSession, shopID)
 		if err != nil {
 			return err
 		}
 
-		for _, price := range prices {
-			defaultFilter, ok := defaultFilterRelationsMap[price.ProductID]
-			if ok {
-				price.DefaultFilter = defaultFilter
+		if currentUserID != shop.ID {
+			has, err := ps.userSubRepo.CheckUserAccessToCity(ctx, txSession, currentUserID, shop.CityID)
+			if err != nil {
+				return err
+			}
+			if !has {
+				return errors.New(msg.ErrYouDoNotAccessToThisShop)
 			}
 		}
 
-		priceList.Products = prices
-
-		return nil
+		priceList, err = ps.buildPriceList(ctx, txSession, shop, 0, query)
+		return err
 	})
 
 	if err != nil {
@@ -696,6 +729,75 @@ func (ps *UserProductService) GetPriceList(ctx context.Context, currentUserID in
 	return priceList, nil
 }
 
+func (ps *UserProductService) buildPriceList(ctx context.Context, txSession interface{},
+	shop *domain.User, templateID int64, query *domain.UserProductQuery) (
+	priceList *domain.ShopViewModel, err error) {
+	priceList = &domain.ShopViewModel{
+		ShopInfo: shop,
+		Products: []*domain.UserProductView{},
+	}
+
+	priceList.Template, err = ps.resolvePriceListTemplate(ctx, txSession, shop.ID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := ps.repo.GetPriceList(ctx, txSession, shop.ID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := []int64{}
+	for _, prod := range prices {
+		productIDs = append(productIDs, prod.ProductID)
+	}
+
+	defaultFilterRelationsMap, err := ps.productFilterRepo.
+		GetProductFilterRelationsMapByProductIDs(ctx, txSession, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, price := range prices {
+		defaultFilter, ok := defaultFilterRelationsMap[price.ProductID]
+		if ok {
+			price.DefaultFilter = defaultFilter
+		}
+	}
+
+	priceList.Products = prices
+
+	return priceList, nil
+}
+
+// resolvePriceListTemplate قالب انتخاب‌شده، در غیر این صورت قالب پیش‌فرض فروشگاه و در نهایت چیدمان ثابت قبلی
+func (ps *UserProductService) resolvePriceListTemplate(ctx context.Context, txSession interface{},
+	userID, templateID int64) (template *domain.PriceListTemplate, err error) {
+	if templateID > 0 {
+		template, err = ps.priceListTplRepo.GetPriceListTemplateByID(ctx, txSession, userID, templateID)
+		if err != nil {
+			return nil, err
+		}
+
+		if template == nil {
+			return nil, errors.New(msg.ErrPriceListTemplateDoesNotExist)
+		}
+
+		return template, nil
+	}
+
+	template, err = ps.priceListTplRepo.GetDefaultPriceListTemplate(ctx, txSession, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if template == nil {
+		template = domain.DefaultPriceListTemplate()
+	}
+
+	return template, nil
+}
+
 func (ups *UserProductService) UpdateUserProduct(ctx context.Context,
 	userProduct *domain.UserProduct) (err error) {
 	db, err := ups.dbms.NewDB(ctx)
@@ -708,22 +810,44 @@ func (ups *UserProductService) UpdateUserProduct(ctx context.Context,
 			return errors.New(msg.ErrDataIsNotValid)
 		}
 
-		if userProduct.IsDollar {
-			shopInfo, err := ups.userRepo.GetUserByID(ctx, txSession, userProduct.UserID)
-			if err != nil {
+		current, err := ups.repo.GetUserProductByID(ctx, txSession, userProduct.ID)
+		if err != nil {
+			return err
+		}
+		// ردیف باید مال همین فروشگاه باشد؛ قیمت، کد کالا و تنظیمات گرد کردن فروشگاه دیگری تغییر نمی‌کنند
+		if current == nil || current.UserID != userProduct.UserID {
+			return errors.New(msg.ErrOperationNotAllowedForThisUser)
+		}
+
+		// کد کالای خالی (nil) یعنی بدون تغییر؛ رشتهٔ خالی کد را پاک می‌کند
+		skuChanged := userProduct.SKU != nil
+		if skuChanged {
+			if err = ups.normalizeUserProductSKU(ctx, txSession, userProduct); err != nil {
 				return err
 			}
-			if !shopInfo.DollarPrice.Valid {
-				return errors.New(msg.ErrShopDollarPriceIsNotSet)
-			}
+		}
+
+		deviation, err := ups.prepareUserProductPrices(ctx, txSession, userProduct.UserID,
+			current.ProductID, userProduct, current)
+		if err != nil {
+			return err
+		}
 
-			err = validateUserProductPrices(ctx, userProduct, shopInfo.DollarPrice.Decimal)
+		err = ups.repo.UpdateUserProduct(ctx, txSession, userProduct)
+		if err != nil {
+			return err
+		}
+
+		if skuChanged {
+			err = ups.repo.UpdateUserProductSKU(ctx, txSession, userProduct.UserID, userProduct.ID,
+				userProduct.SKU)
 			if err != nil {
 				return err
 			}
 		}
 
-		err = ups.repo.UpdateUserProduct(ctx, txSession, userProduct)
+		userProduct.ProductID = current.ProductID
+		err = ups.flagPriceDeviation(ctx, txSession, userProduct, deviation)
 		if err != nil {
 			return err
 		}
@@ -737,6 +861,53 @@ func (ups *UserProductService) UpdateUserProduct(ctx context.Context,
 	return nil
 }
 
+// normalizeUserProductSKU کد کالا را یکدست می‌کند و یکتا بودنش در فروشگاه را می‌سنجد؛
+// کد خالی به nil (بدون کد) تبدیل می‌شود
+func (ups *UserProductService) normalizeUserProductSKU(ctx context.Context, txSession interface{},
+	userProduct *domain.UserProduct) error {
+	if userProduct.SKU == nil {
+		return nil
+	}
+
+	sku := domain.NormalizeSKU(*userProduct.SKU)
+	if sku == "" {
+		userProduct.SKU = nil
+		return nil
+	}
+	if utf8.RuneCountInString(sku) > domain.UserProductSKUMaxLength {
+		return errors.New(msg.ErrUserProductSKUIsNotValid)
+	}
+
+	owner, err := ups.repo.GetUserProductBySKU(ctx, txSession, userProduct.UserID, sku)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.ID != userProduct.ID {
+		return errors.New(msg.ErrUserProductSKUIsTaken)
+	}
+
+	userProduct.SKU = &sku
+	return nil
+}
+
+func (ups *UserProductService) FetchUserProductBySKU(ctx context.Context, userID int64, sku string) (
+	userProduct *domain.UserProductView, err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	owner, err := ups.repo.GetUserProductBySKU(ctx, db, userID, domain.NormalizeSKU(sku))
+	if err != nil {
+		return
+	}
+	if owner == nil {
+		return nil, errors.New(msg.ErrUserProductSKUDoesNotExist)
+	}
+
+	return ups.repo.FetchUserProductById(ctx, db, owner.ID)
+}
+
 func (ups *UserProductService) FetchUserProductById(ctx context.Context, upId int64) (
 	userProduct *domain.UserProductView, err error) {
 	db, err := ups.dbms.NewDB(ctx)
@@ -826,40 +997,463 @@ func (ups *UserProductService) ChangeVisibilityStatus(ctx context.Context, userP
 	return nil
 }
 
-func validateUserProductPrices(_ context.Context, userProduct *domain.UserProduct,
-	dollarPrice decimal.Decimal) (err error) {
-	if !userProduct.IsDollar {
+// prepareUserProductPrices قیمت‌ها را اعتبارسنجی و قیمت محاسبه‌شدهٔ دلاری را طبق قانون گرد کردن
+// فروشگاه تنظیم می‌کند تا ویرایش دستی با محاسبهٔ دلاری و تغییر گروهی هم‌خوان باشد.
+// current قیمت ذخیره‌شدهٔ فعلی است و برای محصول جدید nil است.
+func (ups *UserProductService) prepareUserProductPrices(ctx context.Context, txSession interface{},
+	shopID, productID int64, userProduct, current *domain.UserProduct) (
+	deviation *domain.PriceDeviation, err error) {
+	shopInfo, err := ups.userRepo.GetUserByID(ctx, txSession, shopID)
+	if err != nil {
+		return
+	}
+
+	rule, err := ups.roundingRuleRepo.ResolveRoundingRule(ctx, txSession, shopID, productID)
+	if err != nil {
 		return
 	}
 
+	deviation, err = ups.validateUserProductPrices(ctx, txSession, shopInfo, rule, productID,
+		userProduct, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if userProduct.IsDollar {
+		// فقط قیمت محاسبه‌شده از دلار و سایر هزینه‌ها گرد می‌شود؛ قیمتی که کاربر دستی وارد کرده دست نمی‌خورد
+		userProduct.FinalPrice = domain.RoundPrice(rule, shopInfo.Rounded, userProduct.FinalPrice)
+	}
+
+	return deviation, nil
+}
+
+// flagPriceDeviation قیمتی را که با وجود هشدار تایید شده برای بررسی ادمین ثبت می‌کند
+func (ups *UserProductService) flagPriceDeviation(ctx context.Context, txSession interface{},
+	userProduct *domain.UserProduct, deviation *domain.PriceDeviation) error {
+	if deviation == nil {
+		return nil
+	}
+
+	_, err := ups.repo.CreatePriceDeviationFlag(ctx, txSession, &domain.PriceDeviationFlag{
+		UserID:           userProduct.UserID,
+		ProductID:        userProduct.ProductID,
+		UserProductID:    userProduct.ID,
+		Price:            deviation.Price,
+		MarketMedian:     deviation.MarketMedian,
+		DeviationPercent: deviation.DeviationPercent,
+		CreatedAt:        time.Now(),
+	})
+	return err
+}
+
+// validateMarketDeviation قیمت را با میانهٔ بازار مقایسه می‌کند. خارج از بازه و بدون تایید
+// کاربر خطای PriceDeviationError برمی‌گرداند؛ با تایید کاربر فقط جزئیات انحراف را برمی‌گرداند.
+func validateMarketDeviation(_ context.Context, price decimal.Decimal, confirmed bool,
+	stats *domain.MarketPriceStats, guard config.PriceGuardConfig) (
+	deviation *domain.PriceDeviation, err error) {
+	if guard.BandPercent <= 0 || stats == nil || !stats.Median.Valid ||
+		!stats.Median.Decimal.IsPositive() || stats.SampleSize < guard.MinSamples {
+		return nil, nil
+	}
+
+	hundred := decimal.NewFromInt(100)
+	median := stats.Median.Decimal.Round(0)
+	band := median.Mul(decimal.NewFromInt(int64(guard.BandPercent))).Div(hundred)
+	minAllowed := decimal.Max(median.Sub(band), decimal.Zero)
+	maxAllowed := median.Add(band)
+
+	if price.GreaterThanOrEqual(minAllowed) && price.LessThanOrEqual(maxAllowed) {
+		return nil, nil
+	}
+
+	deviation = &domain.PriceDeviation{
+		Price:            price,
+		MarketMedian:     median,
+		MinAllowed:       minAllowed,
+		MaxAllowed:       maxAllowed,
+		DeviationPercent: price.Sub(median).Div(median).Mul(hundred).Round(2),
+		SampleSize:       stats.SampleSize,
+	}
+
+	if !confirmed {
+		return nil, &domain.PriceDeviationError{
+			Message:        msg.ErrPriceDeviatesFromMarket,
+			PriceDeviation: *deviation,
+		}
+	}
+
+	return deviation, nil
+}
+
+// validateUserProductPrices قیمت دلاری را با نرخ دلار فروشگاه می‌سنجد و قیمتی را که نسبت به
+// قیمت ذخیره‌شده تغییر کرده با میانهٔ بازار مقایسه می‌کند؛ قیمت بدون تغییر دوباره تایید نمی‌خواهد.
+func (ups *UserProductService) validateUserProductPrices(ctx context.Context, txSession interface{},
+	shop *domain.User, rule *domain.RoundingRule, productID int64,
+	userProduct, current *domain.UserProduct) (deviation *domain.PriceDeviation, err error) {
+	finalPrice := userProduct.FinalPrice
+	if userProduct.IsDollar {
+		if !shop.DollarPrice.Valid {
+			return nil, errors.New(msg.ErrShopDollarPriceIsNotSet)
+		}
+
+		err = validateDollarPrices(ctx, userProduct, shop.DollarPrice.Decimal, rule, shop.Rounded)
+		if err != nil {
+			return nil, err
+		}
+		finalPrice = domain.RoundPrice(rule, shop.Rounded, finalPrice)
+	}
+
+	if current != nil && current.FinalPrice.Equal(finalPrice) {
+		return nil, nil
+	}
+
+	marketStats, err := ups.repo.GetMarketPriceStats(ctx, txSession, productID, shop.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateMarketDeviation(ctx, finalPrice, userProduct.ConfirmPriceDeviation, marketStats,
+		ups.appConfig.PriceGuard)
+}
+
+func validateDollarPrices(_ context.Context, userProduct *domain.UserProduct,
+	dollarPrice decimal.Decimal, rule *domain.RoundingRule, legacyRounded bool) (err error) {
 	priceInToman := userProduct.DollarPrice.Decimal.Mul(dollarPrice)
 	otherCosts := userProduct.OtherCosts.Decimal
 	totalPrice := priceInToman.Add(otherCosts)
 
-	if !totalPrice.Equal(userProduct.FinalPrice) {
+	// قیمت خام یا قیمت گرد شده هر دو پذیرفته می‌شوند
+	roundedPrice := domain.RoundPrice(rule, legacyRounded, totalPrice)
+	if !totalPrice.Equal(userProduct.FinalPrice) && !roundedPrice.Equal(userProduct.FinalPrice) {
 		return errors.New(msg.ErrPricesDoNotMatch)
 	}
 
 	return nil
 }
 
-func (ups *UserProductService) AdjustUserFinalPricesByPercent(
-	ctx context.Context, userID int64, percent decimal.Decimal,
-) error {
+func (ups *UserProductService) PreviewPriceAdjustment(ctx context.Context,
+	adjustment *domain.PriceAdjustment) (preview *domain.PriceAdjustmentPreview, err error) {
+	err = validatePriceAdjustment(ctx, adjustment)
+	if err != nil {
+		return
+	}
+
 	db, err := ups.dbms.NewDB(ctx)
 	if err != nil {
-		return err
+		return
+	}
+
+	items, err := ups.calculatePriceAdjustment(ctx, db, adjustment)
+	if err != nil {
+		return
+	}
+
+	return &domain.PriceAdjustmentPreview{
+		Items:         items,
+		AffectedCount: len(items),
+	}, nil
+}
+
+func (ups *UserProductService) AdjustPrices(ctx context.Context,
+	adjustment *domain.PriceAdjustment) (result *domain.PriceAdjustmentResult, err error) {
+	err = validatePriceAdjustment(ctx, adjustment)
+	if err != nil {
+		return
+	}
+
+	scope, err := json.Marshal(adjustment.Scope)
+	if err != nil {
+		return
+	}
+
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		items, err := ups.calculatePriceAdjustment(ctx, txSession, adjustment)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return errors.New(msg.ErrNoProductsMatchPriceAdjustment)
+		}
+
+		batch := &domain.PriceAdjustmentBatch{
+			UserID:        adjustment.UserID,
+			Mode:          adjustment.Mode,
+			Value:         adjustment.Value,
+			Scope:         string(scope),
+			AffectedCount: len(items),
+			CreatedAt:     time.Now(),
+		}
+
+		batchID, err := ups.repo.CreatePriceAdjustmentBatch(ctx, txSession, batch, items)
+		if err != nil {
+			return err
+		}
+
+		affected, err := ups.repo.ApplyPriceAdjustmentBatch(ctx, txSession, batchID)
+		if err != nil {
+			return err
+		}
+
+		result = &domain.PriceAdjustmentResult{
+			BatchID:       batchID,
+			AffectedCount: int(affected),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (ups *UserProductService) UndoLastPriceAdjustment(ctx context.Context, userID int64) (
+	result *domain.PriceAdjustmentUndoResult, err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		batch, err := ups.repo.GetLastPriceAdjustmentBatch(ctx, txSession, userID)
+		if err != nil {
+			if err.Error() == msg.ErrRecordNotFound {
+				return errors.New(msg.ErrNoPriceAdjustmentToUndo)
+			}
+			return err
+		}
+		// فقط آخرین عملیات بازگرداندنی است؛ بعد از بازگردانی آن، عملیات قدیمی‌تر دست نمی‌خورند
+		if batch.RevertedAt != nil {
+			return errors.New(msg.ErrNoPriceAdjustmentToUndo)
+		}
+
+		restored, err := ups.repo.RevertPriceAdjustmentBatch(ctx, txSession, batch.ID)
+		if err != nil {
+			return err
+		}
+
+		result = &domain.PriceAdjustmentUndoResult{
+			BatchID:       batch.ID,
+			RestoredCount: int(restored),
+			SkippedCount:  batch.AffectedCount - int(restored),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// calculatePriceAdjustment قیمت جدید هر محصول داخل محدوده را حساب می‌کند؛
+// ردیف‌هایی که قیمتشان تغییری نمی‌کند کنار گذاشته می‌شوند و اگر قیمت یکی منفی شود کل عملیات رد می‌شود.
+func (ups *UserProductService) calculatePriceAdjustment(ctx context.Context, dbSession interface{},
+	adjustment *domain.PriceAdjustment) (items []*domain.PriceAdjustmentItem, err error) {
+	user, err := ups.userRepo.GetUserByID(ctx, dbSession, adjustment.UserID)
+	if err != nil {
+		return
+	}
+
+	rules, err := ups.roundingRuleRepo.GetRoundingRules(ctx, dbSession, adjustment.UserID)
+	if err != nil {
+		return
+	}
+	roundingRules := domain.NewRoundingRules(rules, user.Rounded)
+
+	candidates, err := ups.repo.GetAdjustablePrices(ctx, dbSession, adjustment.UserID, adjustment.Scope)
+	if err != nil {
+		return
+	}
+
+	items = make([]*domain.PriceAdjustmentItem, 0, len(candidates))
+	for _, item := range candidates {
+		newPrice := adjustPrice(item.OldPrice, adjustment)
+		if newPrice.IsNegative() {
+			return nil, errors.New(msg.ErrPriceAdjustmentMakesPriceNegative)
+		}
+
+		item.NewPrice = roundingRules.Apply(item.CategoryID, newPrice)
+		if item.NewPrice.Equal(item.OldPrice) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func adjustPrice(price decimal.Decimal, adjustment *domain.PriceAdjustment) decimal.Decimal {
+	switch adjustment.Mode {
+	case domain.PriceAdjustmentAmount:
+		return price.Add(adjustment.Value)
+	default:
+		// 10 → 1.10 ،  -5 → 0.95
+		factor := decimal.NewFromInt(1).Add(adjustment.Value.Div(decimal.NewFromInt(100)))
+		return price.Mul(factor)
+	}
+}
+
+func validatePriceAdjustment(_ context.Context, adjustment *domain.PriceAdjustment) error {
+	if !domain.IsPriceAdjustmentModeValid(adjustment.Mode) {
+		return errors.New(msg.ErrPriceAdjustmentModeIsNotValid)
 	}
 
-	// 10 → 0.10 ،  -5 → -0.05
-	rate := percent.Div(decimal.NewFromInt(100))
+	if adjustment.Value.IsZero() {
+		return errors.New(msg.ErrPriceAdjustmentValueIsNotValid)
+	}
 
 	// جلوگیری از فاکتور ≤ 0 (یعنی درصد ≤ -100)
-	if rate.LessThanOrEqual(decimal.NewFromInt(-1)) {
-		return errors.New("invalid percent: must be greater than -100")
+	if adjustment.Mode == domain.PriceAdjustmentPercent &&
+		adjustment.Value.LessThanOrEqual(decimal.NewFromInt(-100)) {
+		return errors.New(msg.ErrPriceAdjustmentPercentIsNotValid)
+	}
+
+	return nil
+}
+
+func (ups *UserProductService) GetPriceDeviationFlags(ctx context.Context,
+	filter domain.PriceDeviationFlagFilter, pag pagination.Pagination) (
+	result pagination.PaginatedResult[*domain.PriceDeviationFlagView], err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	if pag.Page < 1 {
+		pag.Page = 1
+	}
+	if pag.PageSize < 1 || pag.PageSize > 100 {
+		pag.PageSize = 20
+	}
+
+	var (
+		flags []*domain.PriceDeviationFlagView
+		total int64
+	)
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		flags, total, err = ups.repo.GetPriceDeviationFlags(ctx, txSession, filter, pag)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return pagination.NewPaginatedResult(flags, total, pag), nil
+}
+
+func (ups *UserProductService) ReviewPriceDeviationFlag(ctx context.Context, id, adminID int64) (
+	err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
 	}
 
 	return ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
-		return ups.repo.AdjustUserFinalPricesByRate(ctx, txSession, userID, rate)
+		return ups.repo.ReviewPriceDeviationFlag(ctx, txSession, id, adminID)
+	})
+}
+
+// ConfirmPricesStillValid تایید یک‌ضربه‌ای «قیمت‌ها هنوز معتبرند» برای همهٔ محصولات یا محصولات انتخابی
+func (ups *UserProductService) ConfirmPricesStillValid(ctx context.Context, userID int64,
+	ids []int64) (confirmedCount int64, err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		confirmedCount, err = ups.repo.TouchUserProducts(ctx, txSession, userID, ids)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return confirmedCount, nil
+}
+
+func (ups *UserProductService) GetCompetitivePositions(ctx context.Context,
+	filter *domain.CompetitivePositionFilter, pag pagination.Pagination) (
+	result pagination.PaginatedResult[*domain.CompetitivePosition], err error) {
+	if pag.Page < 1 {
+		pag.Page = 1
+	}
+	if pag.PageSize < 1 || pag.PageSize > 100 {
+		pag.PageSize = 20
+	}
+
+	filter.Limit = pag.PageSize
+	filter.Offset = (pag.Page - 1) * pag.PageSize
+
+	positions, total, err := ups.fetchCompetitivePositions(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	return pagination.NewPaginatedResult(positions, total, pag), nil
+}
+
+// ExportCompetitivePositions همهٔ ردیف‌های گزارش بدون صفحه‌بندی
+func (ups *UserProductService) ExportCompetitivePositions(ctx context.Context,
+	filter *domain.CompetitivePositionFilter) (positions []*domain.CompetitivePosition, err error) {
+	filter.Limit = 0
+	filter.Offset = 0
+
+	positions, _, err = ups.fetchCompetitivePositions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+func (ups *UserProductService) fetchCompetitivePositions(ctx context.Context,
+	filter *domain.CompetitivePositionFilter) (positions []*domain.CompetitivePosition,
+	total int64, err error) {
+	err = validateCompetitivePositionFilter(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		positions, total, err = ups.repo.GetCompetitivePositions(ctx, txSession, filter)
+		return err
 	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return positions, total, nil
+}
+
+func validateCompetitivePositionFilter(_ context.Context,
+	filter *domain.CompetitivePositionFilter) error {
+	if filter == nil || filter.UserID < 1 {
+		return errors.New(msg.ErrDataIsNotValid)
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = domain.CompetitivePositionSortModel
+	}
+	if !domain.IsCompetitivePositionSortValid(filter.SortBy) {
+		return errors.New(msg.ErrCompetitivePositionSortIsNotValid)
+	}
+
+	if filter.SortDir != "" && filter.SortDir != domain.SortAsc && filter.SortDir != domain.SortDesc {
+		return errors.New(msg.ErrCompetitivePositionSortIsNotValid)
+	}
+
+	return nil
 }
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type fakeUserProductRepo struct {
+	port.UserProductRepository
+	adjustable []*domain.PriceAdjustmentItem
+	lastBatch  *domain.PriceAdjustmentBatch
+	reverted   []int64
+	market     *domain.MarketPriceStats
+}
+
+func (r *fakeUserProductRepo) GetAdjustablePrices(context.Context, interface{}, int64,
+	domain.PriceAdjustmentScope) ([]*domain.PriceAdjustmentItem, error) {
+	return r.adjustable, nil
+}
+
+func (r *fakeUserProductRepo) GetLastPriceAdjustmentBatch(context.Context, interface{}, int64) (
+	*domain.PriceAdjustmentBatch, error) {
+	if r.lastBatch == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.lastBatch, nil
+}
+
+func (r *fakeUserProductRepo) RevertPriceAdjustmentBatch(_ context.Context, _ interface{},
+	batchID int64) (int64, error) {
+	r.reverted = append(r.reverted, batchID)
+	return 2, nil
+}
+
+type fakeRoundingRuleRepo struct {
+	port.RoundingRuleRepository
+	rules []*domain.RoundingRule
+}
+
+func (r *fakeRoundingRuleRepo) GetRoundingRules(context.Context, interface{}, int64) (
+	[]*domain.RoundingRule, error) {
+	return r.rules, nil
+}
+
+func newPriceAdjustmentTestService(repo *fakeUserProductRepo, rules []*domain.RoundingRule) *UserProductService {
+	return &UserProductService{
+		dbms:             fakeDBMS{},
+		repo:             repo,
+		userRepo:         &fakeUserRepo{user: &domain.User{ID: 1}},
+		roundingRuleRepo: &fakeRoundingRuleRepo{rules: rules},
+	}
+}
+
+func TestAdjustPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  domain.PriceAdjustmentMode
+		value int64
+		price int64
+		want  int64
+	}{
+		{"percent increase", domain.PriceAdjustmentPercent, 10, 200000, 220000},
+		{"percent decrease", domain.PriceAdjustmentPercent, -5, 200000, 190000},
+		{"amount increase", domain.PriceAdjustmentAmount, 15000, 200000, 215000},
+		{"amount below zero is kept negative", domain.PriceAdjustmentAmount, -250000, 200000, -50000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adjustPrice(decimal.NewFromInt(tt.price), &domain.PriceAdjustment{
+				Mode:  tt.mode,
+				Value: decimal.NewFromInt(tt.value),
+			})
+			if !got.Equal(decimal.NewFromInt(tt.want)) {
+				t.Fatalf("adjustPrice = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePriceAdjustment(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    domain.PriceAdjustmentMode
+		value   int64
+		wantErr string
+	}{
+		{"valid percent", domain.PriceAdjustmentPercent, 10, ""},
+		{"valid negative amount", domain.PriceAdjustmentAmount, -1000, ""},
+		{"unknown mode", "ratio", 10, msg.ErrPriceAdjustmentModeIsNotValid},
+		{"zero value", domain.PriceAdjustmentAmount, 0, msg.ErrPriceAdjustmentValueIsNotValid},
+		{"percent of -100", domain.PriceAdjustmentPercent, -100, msg.ErrPriceAdjustmentPercentIsNotValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePriceAdjustment(context.Background(), &domain.PriceAdjustment{
+				Mode:  tt.mode,
+				Value: decimal.NewFromInt(tt.value),
+			})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewPriceAdjustmentRoundsAndSkipsUnchanged(t *testing.T) {
+	categoryID := int64(7)
+	repo := &fakeUserProductRepo{adjustable: []*domain.PriceAdjustmentItem{
+		{UserProductID: 1, CategoryID: 7, OldPrice: decimal.NewFromInt(101000)},
+		{UserProductID: 2, CategoryID: 8, OldPrice: decimal.NewFromInt(100000)},
+	}}
+	rules := []*domain.RoundingRule{{
+		CategoryID: &categoryID,
+		Step:       decimal.NewFromInt(10000),
+		Direction:  domain.RoundingUp,
+	}}
+	ups := newPriceAdjustmentTestService(repo, rules)
+
+	preview, err := ups.PreviewPriceAdjustment(context.Background(), &domain.PriceAdjustment{
+		UserID: 1,
+		Mode:   domain.PriceAdjustmentPercent,
+		Value:  decimal.NewFromInt(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.AffectedCount != 2 {
+		t.Fatalf("affected = %d, want 2", preview.AffectedCount)
+	}
+	if !preview.Items[0].NewPrice.Equal(decimal.NewFromInt(110000)) {
+		t.Fatalf("category rule not applied: %s", preview.Items[0].NewPrice)
+	}
+	if !preview.Items[1].NewPrice.Equal(decimal.NewFromInt(105000)) {
+		t.Fatalf("unexpected price without rule: %s", preview.Items[1].NewPrice)
+	}
+}
+
+func TestPreviewPriceAdjustmentRejectsNegativePrices(t *testing.T) {
+	repo := &fakeUserProductRepo{adjustable: []*domain.PriceAdjustmentItem{
+		{UserProductID: 1, OldPrice: decimal.NewFromInt(500000)},
+		{UserProductID: 2, OldPrice: decimal.NewFromInt(20000)},
+	}}
+	ups := newPriceAdjustmentTestService(repo, nil)
+
+	_, err := ups.PreviewPriceAdjustment(context.Background(), &domain.PriceAdjustment{
+		UserID: 1,
+		Mode:   domain.PriceAdjustmentAmount,
+		Value:  decimal.NewFromInt(-30000),
+	})
+	if err == nil || err.Error() != msg.ErrPriceAdjustmentMakesPriceNegative {
+		t.Fatalf("error = %v, want %q", err, msg.ErrPriceAdjustmentMakesPriceNegative)
+	}
+}
+
+func TestUndoLastPriceAdjustment(t *testing.T) {
+	revertedAt := time.Now()
+	tests := []struct {
+		name         string
+		batch        *domain.PriceAdjustmentBatch
+		wantErr      string
+		wantReverted bool
+	}{
+		{"latest batch", &domain.PriceAdjustmentBatch{ID: 3, AffectedCount: 3}, "", true},
+		{"latest batch already undone", &domain.PriceAdjustmentBatch{ID: 3, RevertedAt: &revertedAt},
+			msg.ErrNoPriceAdjustmentToUndo, false},
+		{"no batch", nil, msg.ErrNoPriceAdjustmentToUndo, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserProductRepo{lastBatch: tt.batch}
+			ups := newPriceAdjustmentTestService(repo, nil)
+
+			result, err := ups.UndoLastPriceAdjustment(context.Background(), 1)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantReverted != (len(repo.reverted) == 1) {
+				t.Fatalf("reverted batches = %v", repo.reverted)
+			}
+			if tt.wantReverted && result.SkippedCount != 1 {
+				t.Fatalf("skipped = %d, want 1", result.SkippedCount)
+			}
+		})
+	}
+}
+
+func (r *fakeUserProductRepo) GetMarketPriceStats(context.Context, interface{}, int64, int64) (
+	*domain.MarketPriceStats, error) {
+	if r.market == nil {
+		return &domain.MarketPriceStats{}, nil
+	}
+	return r.market, nil
+}
+
+func (r *fakeRoundingRuleRepo) ResolveRoundingRule(context.Context, interface{}, int64, int64) (
+	*domain.RoundingRule, error) {
+	if len(r.rules) == 0 {
+		return nil, nil
+	}
+	return r.rules[0], nil
+}
+
+func TestPrepareUserProductPricesRoundsOnlyComputedPrices(t *testing.T) {
+	shop := &domain.User{
+		ID:          1,
+		Rounded:     true,
+		DollarPrice: decimal.NullDecimal{Decimal: decimal.NewFromInt(100000), Valid: true},
+	}
+
+	tests := []struct {
+		name        string
+		userProduct *domain.UserProduct
+		want        int64
+	}{
+		{
+			name:        "manual price is kept as typed",
+			userProduct: &domain.UserProduct{FinalPrice: decimal.NewFromInt(1234567)},
+			want:        1234567,
+		},
+		{
+			name: "dollar price is rounded",
+			userProduct: &domain.UserProduct{
+				IsDollar:    true,
+				DollarPrice: decimal.NullDecimal{Decimal: decimal.NewFromInt(12), Valid: true},
+				OtherCosts:  decimal.NullDecimal{Decimal: decimal.NewFromInt(70000), Valid: true},
+				FinalPrice:  decimal.NewFromInt(1270000),
+			},
+			want: 1300000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ups := &UserProductService{
+				repo:             &fakeUserProductRepo{},
+				userRepo:         &fakeUserRepo{user: shop},
+				roundingRuleRepo: &fakeRoundingRuleRepo{},
+			}
+
+			_, err := ups.prepareUserProductPrices(context.Background(), nil, 1, 1, tt.userProduct, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.userProduct.FinalPrice.Equal(decimal.NewFromInt(tt.want)) {
+				t.Fatalf("final price = %s, want %d", tt.userProduct.FinalPrice, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUserProductPricesMarketDeviation(t *testing.T) {
+	market := &domain.MarketPriceStats{
+		Median:     decimal.NullDecimal{Decimal: decimal.NewFromInt(1000000), Valid: true},
+		SampleSize: 5,
+	}
+	stored := &domain.UserProduct{FinalPrice: decimal.NewFromInt(3000000)}
+
+	tests := []struct {
+		name          string
+		price         int64
+		confirmed     bool
+		current       *domain.UserProduct
+		wantErr       bool
+		wantDeviation bool
+	}{
+		{"inside the band", 1200000, false, nil, false, false},
+		{"new price outside the band", 3000000, false, nil, true, false},
+		{"confirmed price outside the band", 3000000, true, nil, false, true},
+		{"unchanged price is not checked again", 3000000, false, stored, false, false},
+		{"changed price is checked", 3100000, false, stored, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ups := &UserProductService{
+				repo:      &fakeUserProductRepo{market: market},
+				appConfig: config.App{PriceGuard: config.PriceGuardConfig{BandPercent: 50, MinSamples: 3}},
+			}
+			userProduct := &domain.UserProduct{
+				FinalPrice:            decimal.NewFromInt(tt.price),
+				ConfirmPriceDeviation: tt.confirmed,
+			}
+
+			deviation, err := ups.validateUserProductPrices(context.Background(), nil,
+				&domain.User{ID: 1}, nil, 1, userProduct, tt.current)
+
+			var deviationErr *domain.PriceDeviationError
+			if tt.wantErr != errors.As(err, &deviationErr) {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantDeviation != (deviation != nil) {
+				t.Fatalf("deviation = %+v, want %v", deviation, tt.wantDeviation)
+			}
+		})
+	}
+}
+
+type fakeOwnedUserProductRepo struct {
+	fakeUserProductRepo
+	current *domain.UserProduct
+	updated bool
+}
+
+func (r *fakeOwnedUserProductRepo) GetUserProductByID(context.Context, interface{}, int64) (
+	*domain.UserProduct, error) {
+	return r.current, nil
+}
+
+func (r *fakeOwnedUserProductRepo) UpdateUserProduct(context.Context, interface{}, *domain.UserProduct) error {
+	r.updated = true
+	return nil
+}
+
+func TestUpdateUserProductRejectsOtherShopsRow(t *testing.T) {
+	repo := &fakeOwnedUserProductRepo{current: &domain.UserProduct{ID: 5, UserID: 2, ProductID: 9}}
+	ups := &UserProductService{dbms: fakeDBMS{}, repo: repo}
+
+	sku := "A-1"
+	err := ups.UpdateUserProduct(context.Background(), &domain.UserProduct{
+		ID:         5,
+		UserID:     1,
+		FinalPrice: decimal.NewFromInt(1000),
+		SKU:        &sku,
+	})
+	if err == nil || err.Error() != msg.ErrOperationNotAllowedForThisUser {
+		t.Fatalf("err = %v, want %s", err, msg.ErrOperationNotAllowedForThisUser)
+	}
+	if repo.updated {
+		t.Error("another shop's row was updated")
+	}
+}
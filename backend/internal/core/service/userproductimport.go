@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/shopspring/decimal"
+)
+
+// userProductImportMaxRows سقف ردیف‌های یک فایل؛ هر ردیف چند کوئری قیمت و بازار دارد
+const userProductImportMaxRows = 5000
+
+// ImportUserProducts ردیف‌های فایل قیمت فروشگاه را به محصولات کاتالوگ تطبیق داده و قیمت‌ها را
+// با همان قواعد ویرایش دستی (گرد کردن، قیمت دلاری و انحراف از بازار) اعمال می‌کند.
+// فقط خانه‌هایی که با مقدار فعلی فرق دارند اعمال می‌شوند و ردیفی که بعد از خروجی گرفتن روی
+// سرور تغییر کرده به‌جای بازنویسی، تداخل گزارش می‌شود.
+// در حالت آزمایشی همهٔ بررسی‌ها انجام می‌شود ولی چیزی ذخیره نمی‌شود.
+func (ups *UserProductService) ImportUserProducts(ctx context.Context, userID int64,
+	rows []*domain.UserProductImportRow, options domain.UserProductImportOptions) (
+	result *domain.UserProductImportResult, err error) {
+	if len(rows) == 0 {
+		return nil, errors.New(msg.ErrUserProductImportIsEmpty)
+	}
+	if len(rows) > userProductImportMaxRows {
+		return nil, errors.New(msg.ErrUserProductImportHasTooManyRows)
+	}
+
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	result = &domain.UserProductImportResult{DryRun: options.DryRun}
+	err = ups.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+		shop, err := ups.userRepo.GetUserByID(ctx, txSession, userID)
+		if err != nil {
+			return err
+		}
+
+		productIDs, err := ups.resolveImportProductIDs(ctx, txSession, userID, rows)
+		if err != nil {
+			return err
+		}
+
+		current, err := ups.repo.GetUserProductsByProductIDs(ctx, txSession, userID,
+			uniqueInt64s(productIDs))
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[int64]bool, len(rows))
+		skuOwners := make(map[string]int64) // کدهای کالای همین فایل تا دو محصول یک کد نگیرند
+		touchedIDs := make([]int64, 0, len(rows))
+		orders := make(map[int64]int64)
+		for i, row := range rows {
+			rowResult := &domain.UserProductImportRowResult{Row: row.Row, ProductID: productIDs[i]}
+
+			rowErr := row.ParseError
+			if rowErr == "" && productIDs[i] < 1 {
+				switch {
+				case row.UserProductID > 0:
+					rowErr = msg.ErrUserProductImportProductIsNotInShop
+				case row.ProductID < 1 && (row.Brand == "" || row.Model == "") && row.SKU != "":
+					rowErr = msg.ErrUserProductSKUDoesNotExist
+				case row.ProductID < 1 && (row.Brand == "" || row.Model == ""):
+					rowErr = msg.ErrUserProductImportProductIsNotSet
+				case productIDs[i] < 0:
+					rowErr = msg.ErrUserProductImportProductIsAmbiguous
+				default:
+					rowErr = msg.ErrUserProductImportProductNotFound
+				}
+			}
+			if rowErr == "" && seen[productIDs[i]] {
+				rowErr = msg.ErrUserProductImportProductIsRepeated
+			}
+			if rowErr == "" && row.SKU != "" && skuOwners[row.SKU] > 0 && skuOwners[row.SKU] != productIDs[i] {
+				rowErr = msg.ErrUserProductSKUIsTaken
+			}
+			if rowErr != "" {
+				rowResult.Status = domain.UserProductImportFailed
+				rowResult.Error = rowErr
+				result.Add(rowResult)
+				continue
+			}
+			seen[productIDs[i]] = true
+			if row.SKU != "" {
+				skuOwners[row.SKU] = productIDs[i]
+			}
+
+			userProduct, err := ups.importUserProductRow(ctx, txSession, shop, row, productIDs[i],
+				current[productIDs[i]], options, rowResult)
+			if err != nil {
+				if !isUserProductImportRowError(err) {
+					return err
+				}
+				rowResult.Status = domain.UserProductImportFailed
+				rowResult.Error = err.Error()
+			}
+			if userProduct != nil && rowResult.Status == domain.UserProductImportUpdated {
+				touchedIDs = append(touchedIDs, userProduct.ID)
+				if userProduct.Order != current[productIDs[i]].Order {
+					orders[userProduct.ID] = userProduct.Order
+				}
+			}
+
+			result.Add(rowResult)
+		}
+
+		if options.DryRun || len(touchedIDs) == 0 {
+			return nil
+		}
+
+		if err = ups.repo.ReorderUserProducts(ctx, txSession, userID, orders); err != nil {
+			return err
+		}
+
+		_, err = ups.repo.TouchUserProducts(ctx, txSession, userID, touchedIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExportUserProducts همهٔ محصولات فروشگاه را با شناسه و نسخهٔ ردیف برای ورود دوباره برمی‌گرداند
+func (ups *UserProductService) ExportUserProducts(ctx context.Context, userID int64) (
+	products []*domain.UserProductView, err error) {
+	db, err := ups.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	return ups.repo.FetchShopProducts(ctx, db, userID)
+}
+
+// resolveImportProductIDs شناسهٔ محصول کاتالوگ هر ردیف را پیدا می‌کند: صفر یعنی پیدا نشد
+// و ۱- یعنی برند و مدل با چند محصول مطابقت دارد. ترتیب کلیدها: شناسهٔ ردیف فروشگاه، کد کالا،
+// شناسهٔ محصول و در آخر برند و مدل.
+func (ups *UserProductService) resolveImportProductIDs(ctx context.Context, txSession interface{},
+	userID int64, rows []*domain.UserProductImportRow) (productIDs []int64, err error) {
+	requestedIDs := make([]int64, 0, len(rows))
+	userProductIDs := make([]int64, 0, len(rows))
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.SKU != "" {
+			skus = append(skus, row.SKU)
+		}
+		switch {
+		case row.UserProductID > 0:
+			userProductIDs = append(userProductIDs, row.UserProductID)
+		case row.ProductID > 0:
+			requestedIDs = append(requestedIDs, row.ProductID)
+		}
+	}
+
+	existing := make(map[int64]bool, len(requestedIDs))
+	if len(requestedIDs) > 0 {
+		products, err := ups.productRepo.GetProductsByIDs(ctx, txSession, uniqueInt64s(requestedIDs))
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			existing[product.ID] = true
+		}
+	}
+
+	shopProducts, err := ups.repo.GetUserProductsByIDs(ctx, txSession, userID,
+		uniqueInt64s(userProductIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	bySKU, err := ups.repo.GetUserProductsBySKUs(ctx, txSession, userID, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	byBrandModel := make(map[string]int64)
+	productIDs = make([]int64, len(rows))
+	for i, row := range rows {
+		if row.ParseError != "" {
+			continue
+		}
+
+		if row.UserProductID > 0 {
+			if shopProduct, ok := shopProducts[row.UserProductID]; ok {
+				productIDs[i] = shopProduct.ProductID
+			}
+			continue
+		}
+
+		// کد کالایی که در فروشگاه ثبت نشده کلید تطبیق نیست و روی محصول پیداشده نشانده می‌شود
+		if shopProduct, ok := bySKU[row.SKU]; ok && row.SKU != "" {
+			productIDs[i] = shopProduct.ProductID
+			continue
+		}
+
+		if row.ProductID > 0 {
+			if existing[row.ProductID] {
+				productIDs[i] = row.ProductID
+			}
+			continue
+		}
+
+		if row.Brand == "" || row.Model == "" {
+			continue
+		}
+
+		key := strings.ToLower(row.Brand) + "\x00" + strings.ToLower(row.Model)
+		id, ok := byBrandModel[key]
+		if !ok {
+			ids, err := ups.productRepo.FindProductIDsByBrandAndModel(ctx, txSession, row.Brand, row.Model)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case len(ids) == 1:
+				id = ids[0]
+			case len(ids) > 1:
+				id = -1
+			}
+			byBrandModel[key] = id
+		}
+		productIDs[i] = id
+	}
+
+	return productIDs, nil
+}
+
+// importUserProductRow مقادیر ردیف را روی محصول فعلی فروشگاه (یا محصول جدید) می‌نشاند.
+// خانه‌های خالی یا برابر با مقدار فعلی نادیده گرفته می‌شوند و نوع قیمت از ستون «دلاری» یا پر
+// بودن قیمت دلاری تشخیص داده می‌شود؛ قیمت نهایی خالی برای محصول دلاری از نرخ دلار فروشگاه
+// محاسبه می‌شود. ترتیب فقط برای محصولات موجود اعمال می‌شود.
+func (ups *UserProductService) importUserProductRow(ctx context.Context, txSession interface{},
+	shop *domain.User, row *domain.UserProductImportRow, productID int64,
+	current *domain.UserProduct, options domain.UserProductImportOptions,
+	rowResult *domain.UserProductImportRowResult) (userProduct *domain.UserProduct, err error) {
+	if current == nil && !options.CreateMissing {
+		return nil, newImportRowError(msg.ErrUserProductImportProductIsNotInShop)
+	}
+
+	userProduct = &domain.UserProduct{
+		UserID:    shop.ID,
+		ProductID: productID,
+		IsHidden:  options.CreateHidden,
+	}
+	if current != nil {
+		*userProduct = *current
+		rowResult.UserProductID = current.ID
+		row = changedImportCells(row, current)
+	}
+	userProduct.ConfirmPriceDeviation = options.ConfirmPriceDeviation
+
+	switch {
+	case row.IsDollar != nil:
+		userProduct.IsDollar = *row.IsDollar
+	case row.DollarPrice.Valid:
+		userProduct.IsDollar = true
+	case row.FinalPrice.Valid:
+		userProduct.IsDollar = false
+	}
+	if row.DollarPrice.Valid {
+		userProduct.DollarPrice = row.DollarPrice
+	}
+	if row.OtherCosts.Valid {
+		userProduct.OtherCosts = row.OtherCosts
+	}
+	if row.IsHidden != nil {
+		userProduct.IsHidden = *row.IsHidden
+	}
+	if row.Order != nil && current != nil && *row.Order > 0 {
+		userProduct.Order = *row.Order
+	}
+	if row.SKU != "" {
+		if utf8.RuneCountInString(row.SKU) > domain.UserProductSKUMaxLength {
+			return nil, newImportRowError(msg.ErrUserProductSKUIsNotValid)
+		}
+		owner, err := ups.repo.GetUserProductBySKU(ctx, txSession, shop.ID, row.SKU)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil && (current == nil || owner.ID != current.ID) {
+			return nil, newImportRowError(msg.ErrUserProductSKUIsTaken)
+		}
+		sku := row.SKU
+		userProduct.SKU = &sku
+	}
+
+	if userProduct.IsDollar {
+		if !userProduct.DollarPrice.Valid || !userProduct.DollarPrice.Decimal.IsPositive() {
+			return nil, newImportRowError(msg.ErrDollarPriceIsNotSet)
+		}
+		if !shop.DollarPrice.Valid {
+			return nil, newImportRowError(msg.ErrShopDollarPriceIsNotSet)
+		}
+	}
+
+	switch {
+	case row.FinalPrice.Valid:
+		userProduct.FinalPrice = row.FinalPrice.Decimal
+	case userProduct.IsDollar && (current == nil || row.DollarPrice.Valid ||
+		row.OtherCosts.Valid || !current.IsDollar):
+		userProduct.FinalPrice = userProduct.DollarPrice.Decimal.Mul(shop.DollarPrice.Decimal).
+			Add(userProduct.OtherCosts.Decimal)
+	case current == nil:
+		return nil, newImportRowError(msg.ErrFinalPriceIsNotSet)
+	}
+	if !userProduct.FinalPrice.IsPositive() {
+		return nil, newImportRowError(msg.ErrFinalPriceIsNotSet)
+	}
+
+	pricesChanged := current == nil || !userProductImportPricesEqual(current, userProduct)
+	otherChanged := current != nil && (current.IsHidden != userProduct.IsHidden ||
+		current.Order != userProduct.Order || current.SKUValue() != userProduct.SKUValue())
+	if current != nil && !pricesChanged && !otherChanged {
+		rowResult.Status = domain.UserProductImportUnchanged
+		rowResult.FinalPrice = &current.FinalPrice
+		return current, nil
+	}
+
+	if current != nil && row.Version > 0 && row.Version != current.Version {
+		rowResult.Status = domain.UserProductImportConflict
+		rowResult.Error = msg.ErrUserProductImportRowChangedOnServer
+		rowResult.FinalPrice = &current.FinalPrice
+		return nil, nil
+	}
+
+	var deviation *domain.PriceDeviation
+	if pricesChanged {
+		deviation, err = ups.prepareUserProductPrices(ctx, txSession, shop.ID, productID, userProduct,
+			current)
+		if err != nil {
+			return nil, priceValidationRowError(err)
+		}
+		pricesChanged = current == nil || !userProductImportPricesEqual(current, userProduct)
+		if !pricesChanged {
+			deviation = nil
+		}
+	}
+	rowResult.FinalPrice = &userProduct.FinalPrice
+
+	switch {
+	case current == nil:
+		rowResult.Status = domain.UserProductImportCreated
+	case !pricesChanged && !otherChanged:
+		rowResult.Status = domain.UserProductImportUnchanged
+		return current, nil
+	default:
+		rowResult.Status = domain.UserProductImportUpdated
+	}
+
+	if options.DryRun {
+		return userProduct, nil
+	}
+
+	if current == nil {
+		rowResult.UserProductID, err = ups.insertUserProduct(ctx, txSession, userProduct)
+	} else {
+		err = ups.repo.UpdateUserProduct(ctx, txSession, userProduct)
+		if err == nil && current.SKUValue() != userProduct.SKUValue() {
+			err = ups.repo.UpdateUserProductSKU(ctx, txSession, userProduct.UserID, userProduct.ID,
+				userProduct.SKU)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return userProduct, ups.flagPriceDeviation(ctx, txSession, userProduct, deviation)
+}
+
+// changedImportCells نسخه‌ای از ردیف برمی‌گرداند که خانه‌های برابر با مقدار فعلی در آن خالی
+// شده‌اند؛ این‌طور فایل خروجی که فقط چند خانه‌اش ویرایش شده، بقیهٔ مقادیر را دوباره نمی‌نویسد
+// و مثلا تغییر قیمت دلاری باعث محاسبهٔ دوبارهٔ قیمت نهایی قدیمی فایل می‌شود.
+func changedImportCells(row *domain.UserProductImportRow, current *domain.UserProduct) *domain.UserProductImportRow {
+	changed := *row
+	if changed.IsDollar != nil && *changed.IsDollar == current.IsDollar {
+		changed.IsDollar = nil
+	}
+	if changed.DollarPrice.Valid && nullDecimalEqual(changed.DollarPrice, current.DollarPrice) {
+		changed.DollarPrice = decimal.NullDecimal{}
+	}
+	if changed.OtherCosts.Valid && nullDecimalEqual(changed.OtherCosts, current.OtherCosts) {
+		changed.OtherCosts = decimal.NullDecimal{}
+	}
+	if changed.FinalPrice.Valid && changed.FinalPrice.Decimal.Equal(current.FinalPrice) {
+		changed.FinalPrice = decimal.NullDecimal{}
+	}
+	if changed.IsHidden != nil && *changed.IsHidden == current.IsHidden {
+		changed.IsHidden = nil
+	}
+	if changed.Order != nil && *changed.Order == current.Order {
+		changed.Order = nil
+	}
+	if changed.SKU == current.SKUValue() {
+		changed.SKU = ""
+	}
+	return &changed
+}
+
+func userProductImportPricesEqual(current, next *domain.UserProduct) bool {
+	return current.IsDollar == next.IsDollar &&
+		current.FinalPrice.Equal(next.FinalPrice) &&
+		nullDecimalEqual(current.DollarPrice, next.DollarPrice) &&
+		nullDecimalEqual(current.OtherCosts, next.OtherCosts)
+}
+
+func uniqueInt64s(ids []int64) []int64 {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func nullDecimalEqual(a, b decimal.NullDecimal) bool {
+	if a.Valid != b.Valid {
+		return false
+	}
+	return !a.Valid || a.Decimal.Equal(b.Decimal)
+}
+
+// userProductImportRowError خطای اعتبارسنجی یک ردیف؛ در گزارش همان ردیف می‌نشیند و بقیهٔ
+// ردیف‌ها ادامه پیدا می‌کنند. هر خطای دیگری (مثلا پایگاه داده) کل ورود را متوقف می‌کند.
+type userProductImportRowError struct {
+	err error
+}
+
+func (e *userProductImportRowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *userProductImportRowError) Unwrap() error {
+	return e.err
+}
+
+func newImportRowError(message string) error {
+	return &userProductImportRowError{err: errors.New(message)}
+}
+
+// priceValidationRowError خطاهای اعتبارسنجی قیمت در prepareUserProductPrices را خطای ردیف
+// می‌کند و بقیهٔ خطاها را دست‌نخورده برمی‌گرداند
+func priceValidationRowError(err error) error {
+	var deviationErr *domain.PriceDeviationError
+	if errors.As(err, &deviationErr) {
+		return &userProductImportRowError{err: err}
+	}
+
+	switch err.Error() {
+	case msg.ErrPricesDoNotMatch, msg.ErrShopDollarPriceIsNotSet:
+		return &userProductImportRowError{err: err}
+	}
+	return err
+}
+
+func isUserProductImportRowError(err error) bool {
+	var rowErr *userProductImportRowError
+	return errors.As(err, &rowErr)
+}
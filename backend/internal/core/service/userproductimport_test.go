@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+	"github.com/shopspring/decimal"
+)
+
+// fakeImportRepo محصولات فروشگاه را بر اساس شناسهٔ محصول کاتالوگ نگه می‌دارد
+type fakeImportRepo struct {
+	fakeUserProductRepo
+	products  map[int64]*domain.UserProduct
+	updated   []*domain.UserProduct
+	updateErr error
+}
+
+func (r *fakeImportRepo) GetUserProductsByProductIDs(_ context.Context, _ interface{}, _ int64,
+	productIDs []int64) (map[int64]*domain.UserProduct, error) {
+	found := make(map[int64]*domain.UserProduct)
+	for _, id := range productIDs {
+		if up, ok := r.products[id]; ok {
+			found[id] = up
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeImportRepo) GetUserProductsByIDs(_ context.Context, _ interface{}, _ int64,
+	ids []int64) (map[int64]*domain.UserProduct, error) {
+	found := make(map[int64]*domain.UserProduct)
+	for _, up := range r.products {
+		for _, id := range ids {
+			if up.ID == id {
+				found[id] = up
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeImportRepo) GetUserProductsBySKUs(_ context.Context, _ interface{}, _ int64,
+	skus []string) (map[string]*domain.UserProduct, error) {
+	found := make(map[string]*domain.UserProduct)
+	for _, sku := range skus {
+		if up, _ := r.GetUserProductBySKU(context.Background(), nil, 0, sku); up != nil {
+			found[sku] = up
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeImportRepo) GetUserProductBySKU(_ context.Context, _ interface{}, _ int64,
+	sku string) (*domain.UserProduct, error) {
+	for _, up := range r.products {
+		if up.SKUValue() == sku {
+			return up, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeImportRepo) UpdateUserProduct(_ context.Context, _ interface{},
+	userProduct *domain.UserProduct) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	r.updated = append(r.updated, userProduct)
+	return nil
+}
+
+func (r *fakeImportRepo) ReorderUserProducts(context.Context, interface{}, int64,
+	map[int64]int64) error {
+	return nil
+}
+
+func (r *fakeImportRepo) TouchUserProducts(_ context.Context, _ interface{}, _ int64,
+	ids []int64) (int64, error) {
+	return int64(len(ids)), nil
+}
+
+type fakeProductRepo struct {
+	port.ProductRepository
+	catalog    []int64
+	brandModel map[string][]int64
+}
+
+func (r *fakeProductRepo) GetProductsByIDs(_ context.Context, _ interface{}, ids []int64) (
+	[]*domain.Product, error) {
+	var products []*domain.Product
+	for _, id := range ids {
+		for _, catalogID := range r.catalog {
+			if id == catalogID {
+				products = append(products, &domain.Product{ID: id})
+			}
+		}
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepo) FindProductIDsByBrandAndModel(_ context.Context, _ interface{},
+	brandTitle, modelName string) ([]int64, error) {
+	return r.brandModel[strings.ToLower(brandTitle)+"/"+strings.ToLower(modelName)], nil
+}
+
+func importPrice(v int64) decimal.NullDecimal {
+	return decimal.NullDecimal{Decimal: decimal.NewFromInt(v), Valid: true}
+}
+
+func newImportTestService(repo *fakeImportRepo, guard config.PriceGuardConfig) *UserProductService {
+	sku := "C-3"
+	repo.products = map[int64]*domain.UserProduct{
+		101: {ID: 11, UserID: 1, ProductID: 101, FinalPrice: decimal.NewFromInt(1000), Version: 1},
+		102: {ID: 12, UserID: 1, ProductID: 102, FinalPrice: decimal.NewFromInt(2000), Version: 2},
+		103: {ID: 13, UserID: 1, ProductID: 103, FinalPrice: decimal.NewFromInt(3000), Version: 1, SKU: &sku},
+		105: {ID: 14, UserID: 1, ProductID: 105, FinalPrice: decimal.NewFromInt(5000), Version: 1},
+	}
+
+	return &UserProductService{
+		dbms:     fakeDBMS{},
+		repo:     repo,
+		userRepo: &fakeUserRepo{user: &domain.User{ID: 1}},
+		productRepo: &fakeProductRepo{
+			catalog: []int64{101, 102, 103, 104, 105},
+			brandModel: map[string][]int64{
+				"samsung/a55": {201, 202},
+			},
+		},
+		roundingRuleRepo: &fakeRoundingRuleRepo{},
+		appConfig:        config.App{PriceGuard: guard},
+	}
+}
+
+func TestImportUserProductsMatchesRowsAndReportsConflicts(t *testing.T) {
+	repo := &fakeImportRepo{}
+	ups := newImportTestService(repo, config.PriceGuardConfig{})
+
+	rows := []*domain.UserProductImportRow{
+		{Row: 2, UserProductID: 11, FinalPrice: importPrice(1100)},
+		{Row: 3, SKU: "C-3", FinalPrice: importPrice(3300)},
+		{Row: 4, Brand: "Samsung", Model: "A55", FinalPrice: importPrice(100)},
+		{Row: 5, Brand: "Nokia", Model: "X", FinalPrice: importPrice(100)},
+		{Row: 6, ProductID: 104, FinalPrice: importPrice(100)},
+		{Row: 7, UserProductID: 12, Version: 1, FinalPrice: importPrice(2500)},
+		{Row: 8, UserProductID: 14, Version: 1, FinalPrice: importPrice(5000)},
+		{Row: 9, ProductID: 101, FinalPrice: importPrice(1200)},
+		{Row: 10, ParseError: msg.ErrFinalPriceIsNotSet},
+	}
+
+	result, err := ups.ImportUserProducts(context.Background(), 1, rows,
+		domain.UserProductImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportUserProducts: %v", err)
+	}
+
+	want := []struct {
+		status domain.UserProductImportStatus
+		err    string
+	}{
+		{status: domain.UserProductImportUpdated},
+		{status: domain.UserProductImportUpdated},
+		{status: domain.UserProductImportFailed, err: msg.ErrUserProductImportProductIsAmbiguous},
+		{status: domain.UserProductImportFailed, err: msg.ErrUserProductImportProductNotFound},
+		{status: domain.UserProductImportFailed, err: msg.ErrUserProductImportProductIsNotInShop},
+		{status: domain.UserProductImportConflict, err: msg.ErrUserProductImportRowChangedOnServer},
+		{status: domain.UserProductImportUnchanged},
+		{status: domain.UserProductImportFailed, err: msg.ErrUserProductImportProductIsRepeated},
+		{status: domain.UserProductImportFailed, err: msg.ErrFinalPriceIsNotSet},
+	}
+	if len(result.Rows) != len(want) {
+		t.Fatalf("got %d row results, want %d", len(result.Rows), len(want))
+	}
+	for i, w := range want {
+		got := result.Rows[i]
+		if got.Status != w.status || got.Error != w.err {
+			t.Errorf("row %d = %s %q, want %s %q", got.Row, got.Status, got.Error, w.status, w.err)
+		}
+	}
+
+	if len(repo.updated) != 2 {
+		t.Fatalf("updated %d products, want 2", len(repo.updated))
+	}
+	if result.Updated != 2 || result.Conflicts != 1 || result.Unchanged != 1 || result.Failed != 5 {
+		t.Fatalf("unexpected totals: %+v", result)
+	}
+}
+
+func TestImportUserProductsReportsPriceDeviationPerRow(t *testing.T) {
+	repo := &fakeImportRepo{}
+	repo.market = &domain.MarketPriceStats{Median: importPrice(1000), SampleSize: 5}
+	ups := newImportTestService(repo, config.PriceGuardConfig{BandPercent: 50, MinSamples: 3})
+
+	rows := []*domain.UserProductImportRow{
+		{Row: 2, UserProductID: 11, FinalPrice: importPrice(9000)},
+		{Row: 3, UserProductID: 12, FinalPrice: importPrice(1200)},
+	}
+
+	result, err := ups.ImportUserProducts(context.Background(), 1, rows,
+		domain.UserProductImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportUserProducts: %v", err)
+	}
+	if result.Rows[0].Status != domain.UserProductImportFailed ||
+		result.Rows[0].Error != msg.ErrPriceDeviatesFromMarket {
+		t.Fatalf("row 2 = %+v, want price deviation failure", result.Rows[0])
+	}
+	if result.Rows[1].Status != domain.UserProductImportUpdated {
+		t.Fatalf("row 3 = %+v, want updated", result.Rows[1])
+	}
+}
+
+func TestImportUserProductsAbortsOnStorageErrors(t *testing.T) {
+	storageErr := errors.New("connection reset")
+	repo := &fakeImportRepo{updateErr: storageErr}
+	ups := newImportTestService(repo, config.PriceGuardConfig{})
+
+	rows := []*domain.UserProductImportRow{
+		{Row: 2, UserProductID: 11, FinalPrice: importPrice(1100)},
+	}
+
+	_, err := ups.ImportUserProducts(context.Background(), 1, rows, domain.UserProductImportOptions{})
+	if !errors.Is(err, storageErr) {
+		t.Fatalf("error = %v, want storage error", err)
+	}
+}
+
+func TestIsUserProductImportRowError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"row validation", newImportRowError(msg.ErrUserProductSKUIsTaken), true},
+		{"price mismatch", priceValidationRowError(errors.New(msg.ErrPricesDoNotMatch)), true},
+		{
+			"market deviation",
+			priceValidationRowError(&domain.PriceDeviationError{Message: msg.ErrPriceDeviatesFromMarket}),
+			true,
+		},
+		// خطای ترجمه‌شده‌ای که از پایگاه داده یا جای دیگری می‌آید نباید ردیفی حساب شود
+		{"translated storage error", priceValidationRowError(errors.New(msg.ErrRecordNotFound)), false},
+		{"storage error", priceValidationRowError(errors.New("connection reset")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUserProductImportRowError(tt.err); got != tt.want {
+				t.Fatalf("isUserProductImportRowError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
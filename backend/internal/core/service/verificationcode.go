@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/kavenegar/kavenegar-go"
@@ -15,54 +20,139 @@ import (
 
 var CODE_LENGTH = 6
 
+// verificationCodeSendWindow بازهٔ شمارش سقف ارسال پیامک برای هر شماره و IP
+const verificationCodeSendWindow = time.Hour
+
 type VerificationCodeService struct {
-	dbms      port.DBMS
-	repo      port.VerificationCodeRepository
-	userRepo  port.UserRepository // CHANGED: userRepo is needed
-	appConfig config.App
+	dbms              port.DBMS
+	repo              port.VerificationCodeRepository
+	userRepo          port.UserRepository // CHANGED: userRepo is needed
+	loginEventService port.LoginEventService
+	smsSender         verificationCodeSender
+	appConfig         config.App
+	codeHashKey       []byte
 }
 
+// verificationCodeSender پیامک کد را می‌فرستد؛ پیش‌فرض Kavenegar است
+type verificationCodeSender func(phone, code string) error
+
 func RegisterVerificationCodeService(
 	dbms port.DBMS,
 	repo port.VerificationCodeRepository,
 	userRepo port.UserRepository, // CHANGED
-	appConfig config.App) port.VerificationCodeService {
+	loginEventService port.LoginEventService,
+	appConfig config.App) (port.VerificationCodeService, error) {
+	codeHashKey, err := config.DecodeKeyHex("OTP_HASH_KEY", appConfig.OTP.HashKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
 	return &VerificationCodeService{
-		dbms:      dbms,
-		repo:      repo,
-		userRepo:  userRepo,
-		appConfig: appConfig,
+		dbms:              dbms,
+		repo:              repo,
+		userRepo:          userRepo,
+		loginEventService: loginEventService,
+		smsSender:         kavenegarVerificationCodeSender(appConfig.SmsApiKey),
+		appConfig:         appConfig,
+		codeHashKey:       codeHashKey,
+	}, nil
+}
+
+func kavenegarVerificationCodeSender(apiKey string) verificationCodeSender {
+	return func(phone, code string) error {
+		api := kavenegar.New(apiKey)
+		_, err := api.Verify.Lookup(phone, "otp-code", code, &kavenegar.VerifyLookupParam{})
+		return err
 	}
 }
 
-func (vc *VerificationCodeService) SendVerificationCode(ctx context.Context, phone string) (codeGenerated string, err error) {
+// SendVerificationCode کد تازه می‌سازد، hash آن را با زمان انقضا ذخیره و پیامک می‌کند.
+// فاصلهٔ ارسال برای هر شماره و سقف ساعتی هر شماره و IP رعایت می‌شود. پیامک بعد از commit
+// فرستاده می‌شود تا قفل ردیف‌ها در مدت تماس با سرویس پیامک نگه داشته نشود و اگر ارسال ناموفق
+// باشد کد ذخیره‌شده پاک می‌شود.
+func (vc *VerificationCodeService) SendVerificationCode(ctx context.Context, phone, ipAddress string) (err error) {
 	db, err := vc.dbms.NewDB(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get DB session for sending code: %w", err)
+		return fmt.Errorf("failed to get DB session for sending code: %w", err)
 	}
 
-	user, err := vc.userRepo.GetUserByPhone(ctx, db, phone)
-	if err != nil {
-		// This part is correct, if user does not exist, they cannot log in.
-		return "", errors.New(msg.ErrUserDoesNotExist)
-	}
+	var user *domain.User
+	var code, codeHash string
+	err = vc.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		user, txErr = vc.userRepo.GetUserByPhone(ctx, txSession, phone)
+		if txErr != nil {
+			// This part is correct, if user does not exist, they cannot log in.
+			return errors.New(msg.ErrUserDoesNotExist)
+		}
+
+		// اولین ارسال هنوز ردیف کدی برای قفل ندارد؛ قفل ردیف کاربر درخواست‌های هم‌زمان را پشت سر هم می‌کند
+		if txErr = vc.userRepo.LockUser(ctx, txSession, user.ID); txErr != nil {
+			return txErr
+		}
+
+		now := time.Now()
+		current, txErr := vc.repo.GetVerificationCode(ctx, txSession, user.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		if current != nil {
+			if current.LockedUntil != nil && now.Before(*current.LockedUntil) {
+				return verificationCodeThrottled(msg.ErrVerificationCodeIsLocked, current.LockedUntil.Sub(now))
+			}
+
+			resendAt := current.CreatedAt.Add(vc.resendCooldown())
+			if now.Before(resendAt) {
+				return verificationCodeThrottled(msg.ErrVerificationCodeResendTooSoon, resendAt.Sub(now))
+			}
+		}
+
+		txErr = vc.checkSendLimits(ctx, txSession, user.Phone, ipAddress, now)
+		if txErr != nil {
+			return txErr
+		}
+
+		code = GenerateRandomCode(CODE_LENGTH)
+		codeHash = vc.hashCode(user.ID, code)
+		txErr = vc.repo.SaveVerificationCode(ctx, txSession, &domain.VerificationCode{
+			UserID:    user.ID,
+			CodeHash:  &codeHash,
+			ExpiresAt: now.Add(vc.codeTTL()),
+			CreatedAt: now,
+		})
+		if txErr != nil {
+			return fmt.Errorf("failed to save verification code: %w", txErr)
+		}
 
-	codeGenerated = GenerateRandomCode(CODE_LENGTH)
+		txErr = vc.repo.CreateVerificationCodeSend(ctx, txSession, &domain.VerificationCodeSend{
+			Phone:     user.Phone,
+			IPAddress: ipAddress,
+			CreatedAt: now,
+		})
+		if txErr != nil {
+			return txErr
+		}
 
-	err = vc.repo.SaveVerificationCode(ctx, db, user.ID, codeGenerated)
+		return vc.repo.DeleteVerificationCodeSendsBefore(ctx, txSession, now.Add(-verificationCodeSendWindow))
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to save verification code: %w", err)
+		return
 	}
-	api := kavenegar.New(vc.appConfig.SmsApiKey)
-	receptor := phone
-	template := "otp-code"
-	params := &kavenegar.VerifyLookupParam{}
 
-	if _, errSend := api.Verify.Lookup(receptor, template, codeGenerated, params); errSend != nil {
-		return "", fmt.Errorf("failed to send SMS via Kavenegar: %w", errSend)
+	if sendErr := vc.smsSender(phone, code); sendErr != nil {
+		slog.Error("failed to send verification code SMS", "userId", user.ID, "error", sendErr)
+
+		// ثبت ارسال برای سقف ساعتی می‌ماند ولی کد نرسیده پاک می‌شود تا کاربر بی‌درنگ دوباره درخواست دهد
+		err = vc.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
+			return vc.repo.DeleteVerificationCode(ctx, txSession, user.ID, codeHash)
+		})
+		if err != nil {
+			slog.Error("failed to delete undelivered verification code", "userId", user.ID, "error", err)
+		}
+		return errors.New(msg.ErrSendingVerificationCodeFailed)
 	}
 
-	return codeGenerated, nil
+	return nil
 }
 
 // CHANGED: Function signature and logic
@@ -74,78 +164,26 @@ func (vc *VerificationCodeService) VerifyCode(ctx context.Context, phone, code,
 		return nil, nil, fmt.Errorf("failed to get DB session for verifying code: %w", err)
 	}
 
+	// خطای کد بعد از commit برگردانده می‌شود تا شمارش تلاش‌های اشتباه rollback نشود
+	var codeErr error
 	err = vc.dbms.BeginTransaction(ctx, db, func(txSession interface{}) error {
 		if len(code) != CODE_LENGTH {
 			return errors.New(msg.ErrVerificationCodeLengthIsNotValid)
 		}
 
-		// 1. Get user and verify code (existing logic)
+		// 1. Get user and verify code
 		localUser, txErr := vc.userRepo.GetUserByPhone(ctx, txSession, phone)
 		if txErr != nil {
 			return errors.New(msg.ErrUserDoesNotExist)
 		}
 		user = localUser
 
-		savedCode, txErr := vc.repo.GetVerificationCode(ctx, txSession, user.ID)
-		if txErr != nil {
-			return errors.New(msg.ErrVerificationCodeLengthIsNotValid)
-		}
-
-		if code != savedCode {
-			return errors.New(msg.ErrCodeIsWrong)
-		}
-
-		// 2. NEW LOGIC: Device verification
-		activeDevices, txErr := vc.userRepo.GetUserActiveDevices(ctx, txSession, user.ID)
-		if txErr != nil {
-			return fmt.Errorf("could not check active devices: %w", txErr)
-		}
-
-		isDeviceRegistered := false
-		var currentDevice *domain.ActiveDevice
-		for _, device := range activeDevices {
-			if device.DeviceID == deviceID {
-				isDeviceRegistered = true
-				currentDevice = device
-				break
-			}
+		codeErr, txErr = vc.consumeCode(ctx, txSession, user.ID, code)
+		if txErr != nil || codeErr != nil {
+			return txErr
 		}
 
-		if isDeviceRegistered {
-			// Device is known, just update its last login time
-			currentDevice.LastLoginAt = time.Now()
-			currentDevice.IPAddress = ipAddress
-			currentDevice.UserAgent = userAgent
-			if txErr := vc.userRepo.UpdateDeviceLastLogin(ctx, txSession, currentDevice); txErr != nil {
-				// Log this error but don't block login
-				fmt.Printf("Warning: could not update last login for user %d: %v\n", user.ID, txErr)
-			}
-		} else {
-			// This is a new device, check the limit
-			// User's DeviceLimit can be 0, default to 2 in that case.
-			limit := user.DeviceLimit
-			if limit <= 0 {
-				limit = 2
-			}
-
-			if len(activeDevices) >= limit {
-				// Use a specific error message for this
-				return errors.New("شما به حداکثر تعداد دستگاه‌های مجاز برای ورود رسیده‌اید")
-			}
-
-			// There's room, register the new device
-			newDevice := &domain.ActiveDevice{
-				UserID:      user.ID,
-				DeviceID:    deviceID,
-				UserAgent:   userAgent,
-				IPAddress:   ipAddress,
-				LastLoginAt: time.Now(),
-				CreatedAt:   time.Now(),
-			}
-			if txErr := vc.userRepo.RegisterNewDevice(ctx, txSession, newDevice); txErr != nil {
-				return fmt.Errorf("could not register new device: %w", txErr)
-			}
-		}
+		// دستگاه و رویداد ورود موفق بعد از مرحلهٔ TOTP در AuthService.CreateSession ثبت می‌شوند
 
 		// 3. Get admin access (existing logic)
 		if user.Role == domain.Admin || user.Role == domain.SuperAdmin {
@@ -163,6 +201,188 @@ func (vc *VerificationCodeService) VerifyCode(ctx context.Context, phone, code,
 	if err != nil {
 		return nil, nil, err
 	}
+	if codeErr != nil {
+		recordLoginEvent(ctx, vc.loginEventService, user, deviceID, userAgent, ipAddress, codeErr)
+		return nil, nil, codeErr
+	}
 
 	return user, adminAccess, nil
 }
+
+// recordLoginEvent نتیجهٔ ورود را در سابقهٔ ورود کاربر ثبت می‌کند؛ خطای ثبت سابقه جلوی ورود را نمی‌گیرد
+func recordLoginEvent(ctx context.Context, loginEventService port.LoginEventService, user *domain.User,
+	deviceID, userAgent, ipAddress string, loginErr error) {
+	if user == nil {
+		return
+	}
+
+	event := &domain.LoginEvent{
+		UserID:    user.ID,
+		DeviceID:  deviceID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Outcome:   domain.LoginSucceeded,
+	}
+	if loginErr != nil {
+		reason := loginErr.Error()
+		event.Outcome = domain.LoginFailed
+		event.FailureReason = &reason
+	}
+
+	if err := loginEventService.RecordLoginEvent(ctx, event); err != nil {
+		slog.Error("failed to record login event", "userId", user.ID, "error", err)
+	}
+}
+
+// ConfirmCode کد تایید کاربر واردشده را برای عملیات حساس (مثل خروج بقیهٔ دستگاه‌ها) بررسی و مصرف می‌کند
+func (vc *VerificationCodeService) ConfirmCode(ctx context.Context, userID int64, code string) (err error) {
+	if len(code) != CODE_LENGTH {
+		return errors.New(msg.ErrVerificationCodeLengthIsNotValid)
+	}
+
+	db, err := vc.dbms.NewDB(ctx)
+	if err != nil {
+		return
+	}
+
+	var codeErr error
+	err = vc.dbms.BeginTransaction(ctx, db, func(txSession interface{}) (txErr error) {
+		codeErr, txErr = vc.consumeCode(ctx, txSession, userID, code)
+		return txErr
+	})
+	if err != nil {
+		return
+	}
+
+	return codeErr
+}
+
+// consumeCode کد کاربر را بررسی و در صورت درستی باطل می‌کند. codeErr خطای کد است و باید بعد از commit
+// برگردانده شود؛ err خطای دیتابیس است و تراکنش را برمی‌گرداند.
+func (vc *VerificationCodeService) consumeCode(ctx context.Context, txSession interface{},
+	userID int64, code string) (codeErr error, err error) {
+	savedCode, err := vc.repo.GetVerificationCode(ctx, txSession, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codeErr = vc.checkCode(ctx, txSession, savedCode, userID, code, time.Now())
+	if codeErr != nil {
+		return codeErr, nil
+	}
+
+	// کد یک‌بار مصرف است
+	return nil, vc.repo.InvalidateVerificationCode(ctx, txSession, savedCode.ID)
+}
+
+// checkCode کد واردشده را با hash ذخیره‌شده مقایسه می‌کند؛ هر کد اشتباه شمرده می‌شود و
+// با رسیدن به سقف تلاش، کد باطل و شماره برای مدتی قفل می‌شود.
+func (vc *VerificationCodeService) checkCode(ctx context.Context, txSession interface{},
+	savedCode *domain.VerificationCode, userID int64, code string, now time.Time) error {
+	if savedCode != nil && savedCode.LockedUntil != nil && now.Before(*savedCode.LockedUntil) {
+		return verificationCodeThrottled(msg.ErrVerificationCodeIsLocked, savedCode.LockedUntil.Sub(now))
+	}
+
+	if savedCode == nil || savedCode.CodeHash == nil || !now.Before(savedCode.ExpiresAt) {
+		return errors.New(msg.ErrVerificationCodeIsExpired)
+	}
+
+	if hmac.Equal([]byte(vc.hashCode(userID, code)), []byte(*savedCode.CodeHash)) {
+		return nil
+	}
+
+	attempts := savedCode.Attempts + 1
+	var lockedUntil *time.Time
+	if attempts >= vc.maxAttempts() {
+		until := now.Add(vc.lockTime())
+		lockedUntil = &until
+		attempts = 0
+	}
+
+	err := vc.repo.RecordVerificationCodeFailure(ctx, txSession, savedCode.ID, attempts, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	if lockedUntil != nil {
+		return verificationCodeThrottled(msg.ErrVerificationCodeIsLocked, lockedUntil.Sub(now))
+	}
+	return errors.New(msg.ErrCodeIsWrong)
+}
+
+// checkSendLimits سقف ارسال پیامک در یک ساعت را برای شماره و IP بررسی می‌کند
+func (vc *VerificationCodeService) checkSendLimits(ctx context.Context, txSession interface{},
+	phone, ipAddress string, now time.Time) error {
+	since := now.Add(-verificationCodeSendWindow)
+
+	count, oldest, err := vc.repo.CountVerificationCodeSendsByPhone(ctx, txSession, phone, since)
+	if err != nil {
+		return err
+	}
+	if limit := vc.appConfig.OTP.MaxSendsPerPhone; limit > 0 && count >= int64(limit) {
+		return verificationCodeWindowThrottled(oldest, now)
+	}
+
+	if ipAddress == "" {
+		return nil
+	}
+
+	count, oldest, err = vc.repo.CountVerificationCodeSendsByIP(ctx, txSession, ipAddress, since)
+	if err != nil {
+		return err
+	}
+	if limit := vc.appConfig.OTP.MaxSendsPerIP; limit > 0 && count >= int64(limit) {
+		return verificationCodeWindowThrottled(oldest, now)
+	}
+
+	return nil
+}
+
+// hashCode کد را با کلید سرور و شناسهٔ کاربر hash می‌کند؛ کد شش‌رقمی بدون کلید با جست‌وجوی کامل پیدا می‌شود
+func (vc *VerificationCodeService) hashCode(userID int64, code string) string {
+	mac := hmac.New(sha256.New, vc.codeHashKey)
+	mac.Write([]byte(strconv.FormatInt(userID, 10) + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (vc *VerificationCodeService) codeTTL() time.Duration {
+	return configSeconds(vc.appConfig.OTP.TTLSeconds, 120)
+}
+
+func (vc *VerificationCodeService) resendCooldown() time.Duration {
+	return configSeconds(vc.appConfig.OTP.ResendCooldownSeconds, 60)
+}
+
+func (vc *VerificationCodeService) lockTime() time.Duration {
+	return configSeconds(vc.appConfig.OTP.LockMinutes*60, 15*60)
+}
+
+func (vc *VerificationCodeService) maxAttempts() int {
+	if vc.appConfig.OTP.MaxAttempts <= 0 {
+		return 5
+	}
+	return vc.appConfig.OTP.MaxAttempts
+}
+
+func configSeconds(seconds, defaultSeconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func verificationCodeThrottled(message string, retryAfter time.Duration) error {
+	return &domain.VerificationCodeThrottleError{
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
+
+// verificationCodeWindowThrottled تا خارج شدن قدیمی‌ترین ارسال از بازهٔ یک‌ساعته صبر می‌خواهد
+func verificationCodeWindowThrottled(oldest *time.Time, now time.Time) error {
+	retryAfter := verificationCodeSendWindow
+	if oldest != nil {
+		retryAfter = oldest.Add(verificationCodeSendWindow).Sub(now)
+	}
+	return verificationCodeThrottled(msg.ErrVerificationCodeTooManyRequests, retryAfter)
+}
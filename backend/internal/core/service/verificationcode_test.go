@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nerkhin/internal/adapter/config"
+	"github.com/nerkhin/internal/core/domain"
+	"github.com/nerkhin/internal/core/domain/msg"
+	"github.com/nerkhin/internal/core/port"
+)
+
+const testOTPHashKeyHex = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+// txTrackingDBMS نشان می‌دهد کدی که اجرا می‌شود داخل تراکنش است یا بعد از commit
+type txTrackingDBMS struct {
+	fakeDBMS
+	inTx bool
+}
+
+func (d *txTrackingDBMS) BeginTransaction(_ context.Context, _ interface{},
+	fn func(txSession interface{}) error) error {
+	d.inTx = true
+	defer func() { d.inTx = false }()
+	return fn(nil)
+}
+
+type fakeVerificationCodeRepo struct {
+	port.VerificationCodeRepository
+	current  *domain.VerificationCode
+	saved    *domain.VerificationCode
+	sends    int
+	deleted  []string
+	failures []int
+}
+
+func (r *fakeVerificationCodeRepo) GetVerificationCode(context.Context, interface{}, int64) (
+	*domain.VerificationCode, error) {
+	return r.current, nil
+}
+
+func (r *fakeVerificationCodeRepo) SaveVerificationCode(_ context.Context, _ interface{},
+	vc *domain.VerificationCode) error {
+	r.saved = vc
+	return nil
+}
+
+func (r *fakeVerificationCodeRepo) DeleteVerificationCode(_ context.Context, _ interface{}, _ int64,
+	codeHash string) error {
+	r.deleted = append(r.deleted, codeHash)
+	return nil
+}
+
+func (r *fakeVerificationCodeRepo) RecordVerificationCodeFailure(_ context.Context, _ interface{},
+	_ int64, attempts int, _ *time.Time) error {
+	r.failures = append(r.failures, attempts)
+	return nil
+}
+
+func (r *fakeVerificationCodeRepo) CreateVerificationCodeSend(context.Context, interface{},
+	*domain.VerificationCodeSend) error {
+	r.sends++
+	return nil
+}
+
+func (r *fakeVerificationCodeRepo) CountVerificationCodeSendsByPhone(context.Context, interface{},
+	string, time.Time) (int64, *time.Time, error) {
+	return int64(r.sends), nil, nil
+}
+
+func (r *fakeVerificationCodeRepo) CountVerificationCodeSendsByIP(context.Context, interface{},
+	string, time.Time) (int64, *time.Time, error) {
+	return int64(r.sends), nil, nil
+}
+
+func (r *fakeVerificationCodeRepo) DeleteVerificationCodeSendsBefore(context.Context, interface{},
+	time.Time) error {
+	return nil
+}
+
+func newVerificationCodeTestService(t *testing.T, repo *fakeVerificationCodeRepo,
+	userRepo *fakeUserRepo) *VerificationCodeService {
+	t.Helper()
+	appConfig := config.App{OTP: config.OTPConfig{HashKeyHex: testOTPHashKeyHex, MaxAttempts: 3}}
+	service, err := RegisterVerificationCodeService(&txTrackingDBMS{}, repo, userRepo, nil, appConfig)
+	if err != nil {
+		t.Fatalf("RegisterVerificationCodeService: %v", err)
+	}
+	return service.(*VerificationCodeService)
+}
+
+func TestRegisterVerificationCodeServiceRequiresPepper(t *testing.T) {
+	appConfig := config.App{Token: config.TokenConfig{SymmetricKeyHex: testOTPHashKeyHex}}
+	if _, err := RegisterVerificationCodeService(fakeDBMS{}, nil, nil, nil, appConfig); err == nil {
+		t.Fatal("want error without OTP_HASH_KEY even when the Paseto key is set")
+	}
+}
+
+func TestVerificationCodeHash(t *testing.T) {
+	vc := newVerificationCodeTestService(t, &fakeVerificationCodeRepo{}, &fakeUserRepo{})
+
+	hash := vc.hashCode(1, "123456")
+	if hash != vc.hashCode(1, "123456") {
+		t.Fatal("hash is not deterministic")
+	}
+	if hash == vc.hashCode(2, "123456") {
+		t.Fatal("hash does not depend on the user")
+	}
+	if hash == vc.hashCode(1, "123457") {
+		t.Fatal("hash does not depend on the code")
+	}
+
+	other := *vc
+	other.codeHashKey = []byte("another pepper of thirty-two bytes")
+	if hash == other.hashCode(1, "123456") {
+		t.Fatal("hash does not depend on the pepper")
+	}
+}
+
+func TestSendVerificationCode(t *testing.T) {
+	user := &domain.User{ID: 7, Phone: "09120000000"}
+	lockedUntil := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name       string
+		current    *domain.VerificationCode
+		sendErr    error
+		wantErr    string
+		wantSent   bool
+		wantDelete bool
+	}{
+		{name: "first send", wantSent: true},
+		{
+			name:     "cooldown passed",
+			current:  &domain.VerificationCode{ID: 1, UserID: 7, CreatedAt: time.Now().Add(-2 * time.Minute)},
+			wantSent: true,
+		},
+		{
+			name:    "cooldown",
+			current: &domain.VerificationCode{ID: 1, UserID: 7, CreatedAt: time.Now()},
+			wantErr: msg.ErrVerificationCodeResendTooSoon,
+		},
+		{
+			name: "locked",
+			current: &domain.VerificationCode{ID: 1, UserID: 7, LockedUntil: &lockedUntil,
+				CreatedAt: time.Now().Add(-time.Hour)},
+			wantErr: msg.ErrVerificationCodeIsLocked,
+		},
+		{
+			name:       "sms provider fails",
+			sendErr:    errors.New("provider unavailable"),
+			wantErr:    msg.ErrSendingVerificationCodeFailed,
+			wantSent:   true,
+			wantDelete: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeVerificationCodeRepo{current: tt.current}
+			userRepo := &fakeUserRepo{user: user}
+			vc := newVerificationCodeTestService(t, repo, userRepo)
+			dbms := vc.dbms.(*txTrackingDBMS)
+
+			var sentCode string
+			vc.smsSender = func(phone, code string) error {
+				if dbms.inTx {
+					t.Fatal("sms sent inside the transaction")
+				}
+				sentCode = code
+				return tt.sendErr
+			}
+
+			err := vc.SendVerificationCode(context.Background(), user.Phone, "10.0.0.1")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("SendVerificationCode: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			if (sentCode != "") != tt.wantSent {
+				t.Fatalf("sms sent = %v, want %v", sentCode != "", tt.wantSent)
+			}
+			if len(userRepo.locked) != 1 || userRepo.locked[0] != user.ID {
+				t.Fatalf("user row locks = %v, want [%d]", userRepo.locked, user.ID)
+			}
+			if !tt.wantSent {
+				return
+			}
+
+			wantHash := vc.hashCode(user.ID, sentCode)
+			if repo.saved == nil || *repo.saved.CodeHash != wantHash {
+				t.Fatal("saved hash does not match the sent code")
+			}
+			if tt.wantDelete && (len(repo.deleted) != 1 || repo.deleted[0] != wantHash) {
+				t.Fatalf("deleted = %v, want the undelivered code", repo.deleted)
+			}
+			if !tt.wantDelete && len(repo.deleted) != 0 {
+				t.Fatalf("deleted a delivered code")
+			}
+		})
+	}
+}
+
+func TestCheckCodeCountsAttemptsAndLocks(t *testing.T) {
+	repo := &fakeVerificationCodeRepo{}
+	vc := newVerificationCodeTestService(t, repo, &fakeUserRepo{})
+	now := time.Now()
+	hash := vc.hashCode(7, "123456")
+	saved := &domain.VerificationCode{ID: 1, UserID: 7, CodeHash: &hash, ExpiresAt: now.Add(time.Minute)}
+
+	if err := vc.checkCode(context.Background(), nil, saved, 7, "123456", now); err != nil {
+		t.Fatalf("correct code: %v", err)
+	}
+
+	err := vc.checkCode(context.Background(), nil, saved, 7, "000000", now)
+	if err == nil || err.Error() != msg.ErrCodeIsWrong {
+		t.Fatalf("wrong code error = %v", err)
+	}
+
+	saved.Attempts = 2
+	err = vc.checkCode(context.Background(), nil, saved, 7, "000000", now)
+	var throttleErr *domain.VerificationCodeThrottleError
+	if !errors.As(err, &throttleErr) || throttleErr.Message != msg.ErrVerificationCodeIsLocked {
+		t.Fatalf("third wrong code error = %v, want lock", err)
+	}
+	if len(repo.failures) != 2 || repo.failures[0] != 1 || repo.failures[1] != 0 {
+		t.Fatalf("recorded attempts = %v, want [1 0]", repo.failures)
+	}
+
+	expired := &domain.VerificationCode{ID: 1, UserID: 7, CodeHash: &hash, ExpiresAt: now}
+	err = vc.checkCode(context.Background(), nil, expired, 7, "123456", now)
+	if err == nil || err.Error() != msg.ErrVerificationCodeIsExpired {
+		t.Fatalf("expired code error = %v", err)
+	}
+}
+
+func (r *fakeVerificationCodeRepo) InvalidateVerificationCode(context.Context, interface{}, int64) error {
+	r.current = nil
+	return nil
+}
+
+// TestVerifyCodeLeavesDeviceToSession دستگاه و رویداد ورود موفق بعد از مرحلهٔ TOTP در CreateSession ثبت می‌شوند؛
+// fakeUserRepo متدهای دستگاه را ندارد و هر فراخوانی آن‌ها panic می‌کند
+func TestVerifyCodeLeavesDeviceToSession(t *testing.T) {
+	user := &domain.User{ID: 7, Phone: "09120000000", State: domain.ApprovedUser}
+	repo := &fakeVerificationCodeRepo{}
+	events := &fakeLoginEventService{}
+
+	appConfig := config.App{OTP: config.OTPConfig{HashKeyHex: testOTPHashKeyHex, MaxAttempts: 3}}
+	service, err := RegisterVerificationCodeService(&txTrackingDBMS{}, repo, &fakeUserRepo{user: user},
+		events, appConfig)
+	if err != nil {
+		t.Fatalf("RegisterVerificationCodeService: %v", err)
+	}
+	vc := service.(*VerificationCodeService)
+
+	hash := vc.hashCode(user.ID, "123456")
+	repo.current = &domain.VerificationCode{ID: 1, UserID: user.ID, CodeHash: &hash,
+		ExpiresAt: time.Now().Add(time.Minute)}
+
+	_, _, err = vc.VerifyCode(context.Background(), user.Phone, "654321", "new-phone", "ua", "127.0.0.1")
+	if err == nil || err.Error() != msg.ErrCodeIsWrong {
+		t.Fatalf("wrong code err = %v, want %s", err, msg.ErrCodeIsWrong)
+	}
+	if len(events.events) != 1 || events.events[0].Outcome != domain.LoginFailed {
+		t.Fatalf("events = %v, want one failed login", events.events)
+	}
+
+	got, _, err := vc.VerifyCode(context.Background(), user.Phone, "123456", "new-phone", "ua", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("user = %d, want %d", got.ID, user.ID)
+	}
+	if len(events.events) != 1 {
+		t.Errorf("events = %d, want the success to be left to CreateSession", len(events.events))
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS price_adjustment_item;
+DROP TABLE IF EXISTS price_adjustment_batch;
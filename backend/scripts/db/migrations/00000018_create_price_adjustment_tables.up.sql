@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS price_adjustment_batch (
+  id              BIGSERIAL       NOT NULL PRIMARY KEY,
+  user_id         BIGINT          NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  mode_c          VARCHAR(16)     NOT NULL,
+  value_c         DECIMAL(28, 6)  NOT NULL,
+  scope           JSONB           NOT NULL DEFAULT '{}'::jsonb,
+  affected_count  INT             NOT NULL DEFAULT 0,
+  reverted_at     TIMESTAMP,
+  created_at      TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_adjustment_batch_user
+  ON price_adjustment_batch (user_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS price_adjustment_item (
+  id               BIGSERIAL       NOT NULL PRIMARY KEY,
+  batch_id         BIGINT          NOT NULL REFERENCES price_adjustment_batch (id) ON DELETE CASCADE,
+  user_product_id  BIGINT          NOT NULL REFERENCES user_product (id) ON DELETE CASCADE,
+  old_price        DECIMAL(28, 6)  NOT NULL,
+  new_price        DECIMAL(28, 6)  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_adjustment_item_batch
+  ON price_adjustment_item (batch_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS rounding_rule (
+  id           BIGSERIAL       NOT NULL PRIMARY KEY,
+  user_id      BIGINT          NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  category_id  BIGINT          NULL     REFERENCES product_category (id) ON DELETE CASCADE,
+  step_c       DECIMAL(28, 6)  NOT NULL,
+  direction    VARCHAR(16)     NOT NULL,
+  ending       DECIMAL(28, 6)  NOT NULL DEFAULT 0,
+  created_at   TIMESTAMP       NOT NULL DEFAULT NOW(),
+  updated_at   TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+
+-- یک قانون پیش‌فرض برای هر فروشگاه و حداکثر یک قانون برای هر دسته
+CREATE UNIQUE INDEX IF NOT EXISTS uq_rounding_rule_user_category
+  ON rounding_rule (user_id, COALESCE(category_id, 0));
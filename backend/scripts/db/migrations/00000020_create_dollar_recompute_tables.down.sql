@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dollar_recompute_failure;
+DROP TABLE IF EXISTS dollar_recompute_run;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS dollar_recompute_run (
+  id              BIGSERIAL       NOT NULL PRIMARY KEY,
+  dollar_price    DECIMAL(28, 6)  NOT NULL,
+  users_count     INT             NOT NULL DEFAULT 0,
+  batches_count   INT             NOT NULL DEFAULT 0,
+  failed_batches  INT             NOT NULL DEFAULT 0,
+  failed_users    INT             NOT NULL DEFAULT 0,
+  rows_changed    BIGINT          NOT NULL DEFAULT 0,
+  duration_ms     BIGINT          NOT NULL DEFAULT 0,
+  started_at      TIMESTAMP       NOT NULL,
+  finished_at     TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dollar_recompute_failure (
+  id          BIGSERIAL   NOT NULL PRIMARY KEY,
+  run_id      BIGINT      NOT NULL REFERENCES dollar_recompute_run (id) ON DELETE CASCADE,
+  user_id     BIGINT      NULL     REFERENCES user_t (id) ON DELETE CASCADE,
+  error_c     TEXT        NOT NULL,
+  created_at  TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dollar_recompute_failure_run
+  ON dollar_recompute_failure (run_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS price_deviation_flag (
+  id                 BIGSERIAL       NOT NULL PRIMARY KEY,
+  user_id            BIGINT          NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  product_id         BIGINT          NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+  user_product_id    BIGINT          NOT NULL REFERENCES user_product (id) ON DELETE CASCADE,
+  price              DECIMAL(28, 6)  NOT NULL,
+  market_median      DECIMAL(28, 6)  NOT NULL,
+  deviation_percent  DECIMAL(28, 6)  NOT NULL,
+  reviewed_at        TIMESTAMP,
+  reviewed_by        BIGINT          REFERENCES user_t (id) ON DELETE SET NULL,
+  created_at         TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_deviation_flag_unreviewed
+  ON price_deviation_flag (created_at DESC) WHERE reviewed_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stale_price_reminder;
+DROP TABLE IF EXISTS price_staleness_policy;
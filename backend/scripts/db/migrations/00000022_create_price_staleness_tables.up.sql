@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS price_staleness_policy (
+  id            BIGSERIAL   NOT NULL PRIMARY KEY,
+  category_id   BIGINT      NULL     REFERENCES product_category (id) ON DELETE CASCADE,
+  max_age_days  INT         NOT NULL,
+  auto_hide     BOOLEAN     NOT NULL DEFAULT FALSE,
+  created_at    TIMESTAMP   NOT NULL DEFAULT NOW(),
+  updated_at    TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+-- یک سیاست پیش‌فرض (category_id خالی) و حداکثر یک سیاست برای هر دسته
+CREATE UNIQUE INDEX IF NOT EXISTS uq_price_staleness_policy_category
+  ON price_staleness_policy (COALESCE(category_id, 0));
+
+INSERT INTO price_staleness_policy (category_id, max_age_days, auto_hide)
+VALUES (NULL, 30, FALSE)
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS stale_price_reminder (
+  user_id       BIGINT      NOT NULL PRIMARY KEY REFERENCES user_t (id) ON DELETE CASCADE,
+  stale_count   INT         NOT NULL,
+  last_sent_at  TIMESTAMP   NOT NULL
+);
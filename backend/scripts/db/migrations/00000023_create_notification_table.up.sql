@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notification (
+  id          BIGSERIAL     NOT NULL PRIMARY KEY,
+  user_id     BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  type_c      VARCHAR(50)   NOT NULL,
+  title       VARCHAR(200)  NOT NULL,
+  body        TEXT          NOT NULL,
+  read_at     TIMESTAMP,
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_user
+  ON notification (user_id, id DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS price_list_template;
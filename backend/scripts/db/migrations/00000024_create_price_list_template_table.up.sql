@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS price_list_template (
+  id                 BIGSERIAL       NOT NULL PRIMARY KEY,
+  user_id            BIGINT          NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  title              VARCHAR(100)    NOT NULL,
+  is_default         BOOLEAN         NOT NULL DEFAULT FALSE,
+  show_dollar_price  BOOLEAN         NOT NULL DEFAULT FALSE,
+  show_image         BOOLEAN         NOT NULL DEFAULT FALSE,
+  show_description   BOOLEAN         NOT NULL DEFAULT FALSE,
+  group_by           VARCHAR(16)     NOT NULL DEFAULT 'none',
+  sort_by            VARCHAR(16)     NOT NULL DEFAULT 'default',
+  sort_dir           VARCHAR(4)      NOT NULL DEFAULT 'asc',
+  header_text        TEXT            NOT NULL DEFAULT '',
+  footer_text        TEXT            NOT NULL DEFAULT '',
+  markup_percent     DECIMAL(10, 2)  NULL,
+  paper_size         VARCHAR(16)     NOT NULL DEFAULT 'A4',
+  created_at         TIMESTAMP       NOT NULL DEFAULT NOW(),
+  updated_at         TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_template_user
+  ON price_list_template (user_id);
+
+-- هر فروشگاه حداکثر یک قالب پیش‌فرض دارد
+CREATE UNIQUE INDEX IF NOT EXISTS uq_price_list_template_default
+  ON price_list_template (user_id) WHERE is_default;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS price_list_share_pin_attempt;
+DROP TABLE IF EXISTS price_list_share_link;
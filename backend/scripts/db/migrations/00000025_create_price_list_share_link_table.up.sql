@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS price_list_share_link (
+  id                   BIGSERIAL     NOT NULL PRIMARY KEY,
+  user_id              BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  template_id          BIGINT        NULL REFERENCES price_list_template (id) ON DELETE SET NULL,
+  title                VARCHAR(100)  NOT NULL DEFAULT '',
+  pin_hash             VARCHAR(100)  NULL,
+  is_auto              BOOLEAN       NOT NULL DEFAULT FALSE,
+  expires_at           TIMESTAMP     NOT NULL,
+  revoked_at           TIMESTAMP     NULL,
+  view_count           BIGINT        NOT NULL DEFAULT 0,
+  last_viewed_at       TIMESTAMP     NULL,
+  created_at           TIMESTAMP     NOT NULL DEFAULT NOW(),
+  updated_at           TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_share_link_user
+  ON price_list_share_link (user_id);
+
+-- تلاش‌های اشتباه رمز به ازای لینک و IP شمرده می‌شوند؛ قفل مشترک روی خود لینک به هر کسی که آدرس را
+-- دارد اجازه می‌داد گیرندهٔ اصلی را با چند رمز اشتباه برای همیشه بیرون نگه دارد
+CREATE TABLE IF NOT EXISTS price_list_share_pin_attempt (
+  link_id          BIGINT       NOT NULL REFERENCES price_list_share_link (id) ON DELETE CASCADE,
+  ip_address       VARCHAR(45)  NOT NULL,
+  failed_attempts  INT          NOT NULL DEFAULT 0,
+  locked_until     TIMESTAMP    NULL,
+  updated_at       TIMESTAMP    NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (link_id, ip_address)
+);
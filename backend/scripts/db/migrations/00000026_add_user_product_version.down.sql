@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS user_product_version ON user_product;
+DROP FUNCTION IF EXISTS bump_user_product_version();
+ALTER TABLE user_product DROP COLUMN IF EXISTS version;
@@ -0,0 +1,20 @@
+ALTER TABLE user_product ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- نسخهٔ ردیف با هر تغییر قیمت یا وضعیت نمایش بالا می‌رود تا فایل‌های خروجی قدیمی هنگام
+-- ورود دوباره تشخیص داده شوند؛ تغییر ترتیب نسخه را عوض نمی‌کند چون حذف یک محصول ترتیب
+-- بقیه را جابه‌جا می‌کند
+CREATE OR REPLACE FUNCTION bump_user_product_version() RETURNS TRIGGER AS $$
+BEGIN
+  IF (NEW.is_dollar, NEW.dollar_price, NEW.other_costs, NEW.final_price, NEW.is_hidden)
+     IS DISTINCT FROM
+     (OLD.is_dollar, OLD.dollar_price, OLD.other_costs, OLD.final_price, OLD.is_hidden) THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_product_version ON user_product;
+CREATE TRIGGER user_product_version
+  BEFORE UPDATE ON user_product
+  FOR EACH ROW EXECUTE FUNCTION bump_user_product_version();
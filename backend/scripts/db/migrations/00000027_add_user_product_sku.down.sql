@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION bump_user_product_version() RETURNS TRIGGER AS $$
+BEGIN
+  IF (NEW.is_dollar, NEW.dollar_price, NEW.other_costs, NEW.final_price, NEW.is_hidden)
+     IS DISTINCT FROM
+     (OLD.is_dollar, OLD.dollar_price, OLD.other_costs, OLD.final_price, OLD.is_hidden) THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE price_list_template DROP COLUMN IF EXISTS show_sku;
+DROP INDEX IF EXISTS uq_user_product_sku;
+ALTER TABLE user_product DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,19 @@
+ALTER TABLE user_product ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NULL;
+
+-- کد کالای فروشگاه اختیاری است ولی در هر فروشگاه یکتاست
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_product_sku
+  ON user_product (user_id, sku) WHERE sku IS NOT NULL;
+
+ALTER TABLE price_list_template ADD COLUMN IF NOT EXISTS show_sku BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- تغییر کد کالا هم نسخهٔ ردیف را بالا می‌برد
+CREATE OR REPLACE FUNCTION bump_user_product_version() RETURNS TRIGGER AS $$
+BEGIN
+  IF (NEW.is_dollar, NEW.dollar_price, NEW.other_costs, NEW.final_price, NEW.is_hidden, NEW.sku)
+     IS DISTINCT FROM
+     (OLD.is_dollar, OLD.dollar_price, OLD.other_costs, OLD.final_price, OLD.is_hidden, OLD.sku) THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
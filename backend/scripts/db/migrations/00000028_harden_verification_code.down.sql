@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS verification_code_send;
+
+DELETE FROM verification_code;
+
+ALTER TABLE verification_code
+  DROP COLUMN IF EXISTS code_hash,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS expires_at,
+  DROP COLUMN IF EXISTS locked_until,
+  DROP COLUMN IF EXISTS created_at;
+ALTER TABLE verification_code ADD COLUMN IF NOT EXISTS code VARCHAR(6) NOT NULL;
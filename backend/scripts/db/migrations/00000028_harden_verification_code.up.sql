@@ -0,0 +1,23 @@
+-- کدهای قبلی به‌صورت متن ساده و بدون انقضا ذخیره شده‌اند و کنار گذاشته می‌شوند
+DELETE FROM verification_code;
+
+ALTER TABLE verification_code DROP COLUMN IF EXISTS code;
+ALTER TABLE verification_code
+  ADD COLUMN IF NOT EXISTS code_hash     VARCHAR(64)  NULL,
+  ADD COLUMN IF NOT EXISTS attempts      INT          NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS expires_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS locked_until  TIMESTAMP    NULL,
+  ADD COLUMN IF NOT EXISTS created_at    TIMESTAMP    NOT NULL DEFAULT NOW();
+
+-- هر پیامک ارسال‌شده برای محدودیت ارسال به ازای شماره و IP ثبت می‌شود
+CREATE TABLE IF NOT EXISTS verification_code_send (
+  id          BIGSERIAL     NOT NULL PRIMARY KEY,
+  phone       VARCHAR(20)   NOT NULL,
+  ip_address  VARCHAR(64)   NOT NULL DEFAULT '',
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_code_send_phone
+  ON verification_code_send (phone, created_at);
+CREATE INDEX IF NOT EXISTS idx_verification_code_send_ip
+  ON verification_code_send (ip_address, created_at);
@@ -0,0 +1,25 @@
+-- هر ورود با کد تایید یک خانوادهٔ توکن می‌سازد که به دستگاه فعال گره خورده است؛
+-- حذف دستگاه، خانواده و همهٔ توکن‌هایش را هم حذف می‌کند.
+CREATE TABLE IF NOT EXISTS refresh_token_family (
+  id                UUID          NOT NULL PRIMARY KEY,
+  user_id           BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  active_device_id  BIGINT        NOT NULL REFERENCES active_devices (id) ON DELETE CASCADE,
+  revoked_at        TIMESTAMP     NULL,
+  revoke_reason     VARCHAR(20)   NULL,
+  created_at        TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_family_device
+  ON refresh_token_family (active_device_id);
+
+-- فقط jti توکن ذخیره می‌شود؛ used_at یعنی توکن یک بار چرخیده و استفادهٔ دوباره از آن نشانهٔ سرقت است
+CREATE TABLE IF NOT EXISTS refresh_token (
+  jti         UUID          NOT NULL PRIMARY KEY,
+  family_id   UUID          NOT NULL REFERENCES refresh_token_family (id) ON DELETE CASCADE,
+  expires_at  TIMESTAMP     NOT NULL,
+  used_at     TIMESTAMP     NULL,
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_family
+  ON refresh_token (family_id);
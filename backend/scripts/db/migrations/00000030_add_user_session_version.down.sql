@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS admin_access_delete_session_version ON admin_access;
+DROP TRIGGER IF EXISTS admin_access_session_version ON admin_access;
+DROP FUNCTION IF EXISTS bump_owner_session_version();
+
+DROP TRIGGER IF EXISTS user_t_session_version ON user_t;
+DROP FUNCTION IF EXISTS bump_user_session_version();
+
+ALTER TABLE user_t DROP COLUMN IF EXISTS session_version;
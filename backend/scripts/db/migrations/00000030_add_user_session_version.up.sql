@@ -0,0 +1,38 @@
+-- نسخهٔ نشست در access token ثبت و در هر درخواست با این ستون مقایسه می‌شود؛
+-- بالا رفتن آن همهٔ access tokenهای قبلی کاربر را فورا بی‌اعتبار می‌کند.
+ALTER TABLE user_t ADD COLUMN IF NOT EXISTS session_version BIGINT NOT NULL DEFAULT 1;
+
+-- تغییر نقش یا وضعیت کاربر
+CREATE OR REPLACE FUNCTION bump_user_session_version() RETURNS TRIGGER AS $$
+BEGIN
+  IF (NEW.role, NEW.state_c) IS DISTINCT FROM (OLD.role, OLD.state_c) THEN
+    NEW.session_version := OLD.session_version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_t_session_version ON user_t;
+CREATE TRIGGER user_t_session_version
+  BEFORE UPDATE ON user_t
+  FOR EACH ROW EXECUTE FUNCTION bump_user_session_version();
+
+-- تغییر دسترسی ادمین نسخهٔ صاحب ردیف را بالا می‌برد. حذف دستگاه یا باطل شدن خانوادهٔ refresh token
+-- فقط access tokenهای همان خانواده را بی‌اعتبار می‌کند و به نسخهٔ کل کاربر دست نمی‌زند.
+CREATE OR REPLACE FUNCTION bump_owner_session_version() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE user_t SET session_version = session_version + 1 WHERE id = OLD.user_id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_access_session_version ON admin_access;
+CREATE TRIGGER admin_access_session_version
+  AFTER UPDATE ON admin_access
+  FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+  EXECUTE FUNCTION bump_owner_session_version();
+
+DROP TRIGGER IF EXISTS admin_access_delete_session_version ON admin_access;
+CREATE TRIGGER admin_access_delete_session_version
+  AFTER DELETE ON admin_access
+  FOR EACH ROW EXECUTE FUNCTION bump_owner_session_version();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_active_devices_last_seen;
+
+ALTER TABLE active_devices DROP COLUMN IF EXISTS last_seen_at;
+ALTER TABLE active_devices DROP COLUMN IF EXISTS name;
@@ -0,0 +1,10 @@
+ALTER TABLE active_devices ADD COLUMN IF NOT EXISTS name VARCHAR(50) NULL;
+
+-- آخرین تمدید توکن دستگاه؛ دستگاه‌هایی که مدتی استفاده نشده‌اند خودکار حذف می‌شوند
+ALTER TABLE active_devices ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NULL;
+UPDATE active_devices SET last_seen_at = last_login_at WHERE last_seen_at IS NULL;
+ALTER TABLE active_devices ALTER COLUMN last_seen_at SET NOT NULL;
+ALTER TABLE active_devices ALTER COLUMN last_seen_at SET DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_active_devices_last_seen
+  ON active_devices (last_seen_at);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS login_event_append_only ON login_event;
+DROP FUNCTION IF EXISTS login_event_append_only();
+DROP TABLE IF EXISTS login_event;
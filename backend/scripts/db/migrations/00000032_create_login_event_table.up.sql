@@ -0,0 +1,27 @@
+-- سابقهٔ ورودها فقط اضافه می‌شود؛ active_devices فقط آخرین ورود هر دستگاه را نگه می‌دارد
+CREATE TABLE IF NOT EXISTS login_event (
+  id              BIGSERIAL     NOT NULL PRIMARY KEY,
+  user_id         BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  device_id       VARCHAR(255)  NOT NULL DEFAULT '',
+  ip_address      VARCHAR(45)   NOT NULL DEFAULT '',
+  user_agent      TEXT          NOT NULL DEFAULT '',
+  outcome         VARCHAR(20)   NOT NULL,
+  failure_reason  VARCHAR(100)  NULL,
+  new_device      BOOLEAN       NOT NULL DEFAULT FALSE,
+  new_ip          BOOLEAN       NOT NULL DEFAULT FALSE,
+  created_at      TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_event_user
+  ON login_event (user_id, id DESC);
+
+CREATE OR REPLACE FUNCTION login_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'login_event is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS login_event_append_only ON login_event;
+CREATE TRIGGER login_event_append_only
+  BEFORE UPDATE ON login_event
+  FOR EACH ROW EXECUTE FUNCTION login_event_append_only();
@@ -0,0 +1,27 @@
+DROP TRIGGER IF EXISTS admin_role_permission_session_version ON admin_role_permission;
+DROP FUNCTION IF EXISTS bump_admin_role_session_version();
+DROP TRIGGER IF EXISTS admin_access_permission_session_version ON admin_access_permission;
+DROP FUNCTION IF EXISTS bump_admin_permission_session_version();
+
+ALTER TABLE admin_access
+  ADD COLUMN IF NOT EXISTS save_product         BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS change_user_state    BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS change_shop_state    BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS change_account_state BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE admin_access a SET
+  save_product = EXISTS (
+    SELECT 1 FROM admin_access_permission p
+    WHERE p.user_id = a.user_id AND p.permission = 'catalog'),
+  change_user_state = EXISTS (
+    SELECT 1 FROM admin_access_permission p
+    WHERE p.user_id = a.user_id AND p.permission = 'users');
+
+UPDATE admin_access SET
+  change_shop_state = change_user_state,
+  change_account_state = change_user_state;
+
+DROP TABLE IF EXISTS admin_access_permission;
+ALTER TABLE admin_access DROP COLUMN IF EXISTS role_id;
+DROP TABLE IF EXISTS admin_role_permission;
+DROP TABLE IF EXISTS admin_role;
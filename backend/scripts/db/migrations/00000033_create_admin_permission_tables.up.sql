@@ -0,0 +1,72 @@
+-- نقش‌های قابل تخصیص به ادمین‌ها؛ هر نقش مجموعه‌ای از دسترسی‌های نام‌دار است
+CREATE TABLE IF NOT EXISTS admin_role (
+  id          BIGSERIAL     NOT NULL PRIMARY KEY,
+  name        VARCHAR(100)  NOT NULL UNIQUE,
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS admin_role_permission (
+  role_id     BIGINT        NOT NULL REFERENCES admin_role (id) ON DELETE CASCADE,
+  permission  VARCHAR(50)   NOT NULL,
+  PRIMARY KEY (role_id, permission)
+);
+
+-- هر ادمین حداکثر یک نقش دارد و می‌تواند دسترسی‌های مستقیم هم داشته باشد
+ALTER TABLE admin_access
+  ADD COLUMN IF NOT EXISTS role_id BIGINT NULL REFERENCES admin_role (id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS admin_access_permission (
+  user_id     BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  permission  VARCHAR(50)   NOT NULL,
+  PRIMARY KEY (user_id, permission)
+);
+
+-- دسترسی‌های بولی قبلی به دسترسی‌های نام‌دار تبدیل می‌شوند
+INSERT INTO admin_access_permission (user_id, permission)
+  SELECT user_id, 'catalog' FROM admin_access WHERE save_product
+ON CONFLICT DO NOTHING;
+
+INSERT INTO admin_access_permission (user_id, permission)
+  SELECT user_id, 'users' FROM admin_access
+  WHERE change_user_state OR change_shop_state OR change_account_state
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE admin_access
+  DROP COLUMN IF EXISTS save_product,
+  DROP COLUMN IF EXISTS change_user_state,
+  DROP COLUMN IF EXISTS change_shop_state,
+  DROP COLUMN IF EXISTS change_account_state;
+
+-- دسترسی‌ها در access token هستند؛ تغییر آن‌ها نسخهٔ نشست ادمین‌های مربوط را بالا می‌برد
+CREATE OR REPLACE FUNCTION bump_admin_permission_session_version() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE user_t SET session_version = session_version + 1 WHERE id = OLD.user_id;
+  ELSE
+    UPDATE user_t SET session_version = session_version + 1 WHERE id = NEW.user_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_access_permission_session_version ON admin_access_permission;
+CREATE TRIGGER admin_access_permission_session_version
+  AFTER INSERT OR DELETE ON admin_access_permission
+  FOR EACH ROW EXECUTE FUNCTION bump_admin_permission_session_version();
+
+CREATE OR REPLACE FUNCTION bump_admin_role_session_version() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE user_t SET session_version = session_version + 1
+  WHERE id IN (
+    SELECT user_id FROM admin_access
+    WHERE role_id = CASE WHEN TG_OP = 'DELETE' THEN OLD.role_id ELSE NEW.role_id END
+  );
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_role_permission_session_version ON admin_role_permission;
+CREATE TRIGGER admin_role_permission_session_version
+  AFTER INSERT OR DELETE ON admin_role_permission
+  FOR EACH ROW EXECUTE FUNCTION bump_admin_role_session_version();
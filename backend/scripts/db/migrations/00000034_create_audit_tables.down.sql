@@ -0,0 +1,25 @@
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'active_devices', 'admin_access', 'admin_access_permission', 'admin_role',
+    'admin_role_permission', 'city', 'favorite_account', 'favorite_product',
+    'price_adjustment_batch', 'price_adjustment_item', 'price_deviation_flag',
+    'price_list_share_link', 'price_list_template', 'price_staleness_policy',
+    'product', 'product_brand', 'product_category', 'product_filter',
+    'product_filter_option', 'product_filter_relation', 'product_image',
+    'product_model', 'product_request', 'product_tag', 'report', 'rounding_rule',
+    'subscription', 'temp_authority', 'user_payment_transaction_history',
+    'user_product', 'user_subscription', 'user_t'
+  ] LOOP
+    IF to_regclass(t) IS NOT NULL THEN
+      EXECUTE format('DROP TRIGGER IF EXISTS audit_row_change ON %I', t);
+    END IF;
+  END LOOP;
+END;
+$$;
+
+DROP FUNCTION IF EXISTS audit_row_change();
+DROP TABLE IF EXISTS audit_change;
+DROP TABLE IF EXISTS audit_log;
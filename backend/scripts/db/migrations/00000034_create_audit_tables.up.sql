@@ -0,0 +1,115 @@
+-- هر درخواست تغییردهندهٔ ادمین یا درخواستی که با توکن جعل هویت آمده یک ردیف دارد.
+-- actor_id کلید خارجی نیست تا با حذف کاربر سابقه‌اش از بین نرود.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id                    BIGSERIAL     NOT NULL PRIMARY KEY,
+  request_id            UUID          NOT NULL UNIQUE,
+  actor_id              BIGINT        NOT NULL,
+  impersonated_user_id  BIGINT        NULL,
+  method                VARCHAR(10)   NOT NULL,
+  route                 VARCHAR(255)  NOT NULL,
+  path                  TEXT          NOT NULL,
+  status_code           INT           NOT NULL,
+  entity_type           VARCHAR(100)  NOT NULL DEFAULT '',
+  entity_id             VARCHAR(100)  NULL,
+  ip_address            VARCHAR(45)   NOT NULL DEFAULT '',
+  user_agent            TEXT          NOT NULL DEFAULT '',
+  request_body          JSONB         NULL,
+  created_at            TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor
+  ON audit_log (actor_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_impersonated_user
+  ON audit_log (impersonated_user_id, id DESC) WHERE impersonated_user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity
+  ON audit_log (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at
+  ON audit_log (created_at);
+
+-- تغییر ردیف‌ها با تریگر ثبت می‌شود؛ برای UPDATE فقط ستون‌های تغییرکرده نگه داشته می‌شوند
+CREATE TABLE IF NOT EXISTS audit_change (
+  id           BIGSERIAL     NOT NULL PRIMARY KEY,
+  request_id   UUID          NOT NULL,
+  table_name   VARCHAR(100)  NOT NULL,
+  operation    VARCHAR(10)   NOT NULL,
+  row_id       VARCHAR(100)  NULL,
+  before_data  JSONB         NULL,
+  after_data   JSONB         NULL,
+  created_at   TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_change_request
+  ON audit_change (request_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_change_created_at
+  ON audit_change (created_at);
+
+-- شناسهٔ درخواست را برنامه در ابتدای تراکنش با set_config('nerkhin.audit_request_id', ..., true) می‌گذارد؛
+-- تغییرات بدون آن (کاربران عادی، کران‌جاب‌ها) ثبت نمی‌شوند
+CREATE OR REPLACE FUNCTION audit_row_change() RETURNS TRIGGER AS $$
+DECLARE
+  v_request_id TEXT := current_setting('nerkhin.audit_request_id', true);
+  v_before JSONB;
+  v_after JSONB;
+BEGIN
+  IF v_request_id IS NULL OR v_request_id = '' THEN
+    RETURN NULL;
+  END IF;
+
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    v_before := to_jsonb(OLD);
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    v_after := to_jsonb(NEW);
+  END IF;
+
+  IF TG_OP = 'UPDATE' THEN
+    SELECT
+      COALESCE(jsonb_object_agg(o.key, o.value), '{}'::jsonb),
+      COALESCE(jsonb_object_agg(o.key, v_after -> o.key), '{}'::jsonb)
+    INTO v_before, v_after
+    FROM jsonb_each(v_before) AS o
+    WHERE o.value IS DISTINCT FROM v_after -> o.key;
+
+    IF v_before = '{}'::jsonb THEN
+      RETURN NULL;
+    END IF;
+  END IF;
+
+  INSERT INTO audit_change (request_id, table_name, operation, row_id, before_data, after_data)
+  VALUES (
+    v_request_id::uuid,
+    TG_TABLE_NAME,
+    TG_OP,
+    COALESCE(to_jsonb(NEW) ->> 'id', to_jsonb(OLD) ->> 'id'),
+    v_before,
+    v_after
+  );
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- product_model در همهٔ محیط‌ها با مایگریشن ساخته نشده است؛ جدول‌های ناموجود رد می‌شوند
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'active_devices', 'admin_access', 'admin_access_permission', 'admin_role',
+    'admin_role_permission', 'city', 'favorite_account', 'favorite_product',
+    'price_adjustment_batch', 'price_adjustment_item', 'price_deviation_flag',
+    'price_list_share_link', 'price_list_template', 'price_staleness_policy',
+    'product', 'product_brand', 'product_category', 'product_filter',
+    'product_filter_option', 'product_filter_relation', 'product_image',
+    'product_model', 'product_request', 'product_tag', 'report', 'rounding_rule',
+    'subscription', 'temp_authority', 'user_payment_transaction_history',
+    'user_product', 'user_subscription', 'user_t'
+  ] LOOP
+    IF to_regclass(t) IS NOT NULL THEN
+      EXECUTE format('DROP TRIGGER IF EXISTS audit_row_change ON %I', t);
+      EXECUTE format('CREATE TRIGGER audit_row_change AFTER INSERT OR UPDATE OR DELETE ON %I '
+        'FOR EACH ROW EXECUTE FUNCTION audit_row_change()', t);
+    END IF;
+  END LOOP;
+END;
+$$;
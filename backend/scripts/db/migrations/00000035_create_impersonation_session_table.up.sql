@@ -0,0 +1,17 @@
+-- هر ورود ادمین به جای کاربر یک نشست محدود است؛ توکن جعل هویت فقط تا وقتی این نشست باز است پذیرفته می‌شود
+CREATE TABLE IF NOT EXISTS impersonation_session (
+  id          UUID          NOT NULL PRIMARY KEY,
+  admin_id    BIGINT        NOT NULL,
+  user_id     BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  reason      TEXT          NOT NULL,
+  read_only   BOOLEAN       NOT NULL DEFAULT FALSE,
+  expires_at  TIMESTAMP     NOT NULL,
+  ended_at    TIMESTAMP     NULL,
+  end_reason  VARCHAR(20)   NULL,
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_session_user
+  ON impersonation_session (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_impersonation_session_open
+  ON impersonation_session (expires_at) WHERE ended_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS audit_row_change ON totp_policy;
+DROP TABLE IF EXISTS totp_policy;
+DROP TABLE IF EXISTS user_totp_recovery_code;
+DROP TABLE IF EXISTS user_totp;
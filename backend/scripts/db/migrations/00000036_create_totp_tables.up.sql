@@ -0,0 +1,42 @@
+-- عامل دوم TOTP؛ رمز با کلید سرور رمزنگاری می‌شود و تا تایید اولین کد فعال نیست
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id           BIGINT        NOT NULL PRIMARY KEY REFERENCES user_t (id) ON DELETE CASCADE,
+  secret_encrypted  TEXT          NOT NULL,
+  confirmed_at      TIMESTAMP     NULL,
+  last_used_step    BIGINT        NOT NULL DEFAULT 0,
+  failed_attempts   INT           NOT NULL DEFAULT 0,
+  locked_until      TIMESTAMP     NULL,
+  deliver_at_login  BOOLEAN       NOT NULL DEFAULT FALSE,
+  created_at        TIMESTAMP     NOT NULL DEFAULT NOW(),
+  updated_at        TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+-- کدهای بازیابی یک‌بارمصرف؛ فقط hash آن‌ها نگه داشته می‌شود
+CREATE TABLE IF NOT EXISTS user_totp_recovery_code (
+  id          BIGSERIAL     NOT NULL PRIMARY KEY,
+  user_id     BIGINT        NOT NULL REFERENCES user_t (id) ON DELETE CASCADE,
+  code_hash   VARCHAR(64)   NOT NULL,
+  used_at     TIMESTAMP     NULL,
+  created_at  TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_totp_recovery_code_user
+  ON user_totp_recovery_code (user_id);
+
+-- سیاست سراسری که ادمین‌ها تغییر می‌دهند؛ همیشه یک ردیف دارد. پیش‌فرض، الزام برای ادمین‌هاست:
+-- ادمینی که هنوز TOTP ندارد آن را از نشست فعلی خود در /totp/enroll راه می‌اندازد، یا ادمین بالاتری
+-- با /totp/users/:userId/enroll برایش رمز می‌سازد که فقط یک بار بعد از کد پیامکی خودش به او نشان
+-- داده می‌شود؛ ورود فقط با کد پیامکی پذیرفته نمی‌شود.
+CREATE TABLE IF NOT EXISTS totp_policy (
+  id           SMALLINT      NOT NULL PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  enforcement  VARCHAR(20)   NOT NULL DEFAULT 'admins',
+  updated_by   BIGINT        NULL,
+  updated_at   TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO totp_policy (id, enforcement) VALUES (1, 'admins') ON CONFLICT DO NOTHING;
+
+DROP TRIGGER IF EXISTS audit_row_change ON totp_policy;
+CREATE TRIGGER audit_row_change
+  AFTER INSERT OR UPDATE OR DELETE ON totp_policy
+  FOR EACH ROW EXECUTE FUNCTION audit_row_change();